@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/config"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/infra/repository"
+	"guestbook-example/internal/service"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// importActor is the actor of the creations of the import subcommand in the
+// audit log.
+const importActor = "cli"
+
+// runImport runs the import subcommand, which imports a CSV or JSON Lines
+// file of messages into a guestbook like POST /api/v1/messages/import, and
+// writes the same report to stdout:
+//
+//	guestbook-example import [-guestbook slug] [-format csv|jsonl] [-dry-run] FILE
+//
+// FILE is - for stdin.
+func runImport(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	slug := flags.String("guestbook", domain.DefaultGuestbookSlug, "slug of the guestbook to import the messages into")
+	format := flags.String("format", "", "csv or jsonl, guessed from the file extension when empty")
+	dryRun := flags.Bool("dry-run", false, "validate the file without creating messages")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: guestbook-example import [-guestbook slug] [-format csv|jsonl] [-dry-run] FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single file")
+	}
+
+	path := flags.Arg(0)
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if *format == "" {
+		*format = importFormat(path)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	db, err := initDB()
+	if err != nil {
+		return err
+	}
+	if err := migrateDB(db); err != nil {
+		return err
+	}
+
+	// Logs go to stderr, leaving stdout to the report.
+	db.Logger = gormlogger.New(log.New(stderr, "\r\n", log.LstdFlags), gormlogger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      gormlogger.Warn,
+	})
+	logger := slog.New(slog.NewJSONHandler(stderr, nil))
+	ctx := context.Background()
+	guestbookRepo := repository.NewGuestbookRepo(logger, db)
+	if _, err := guestbookRepo.EnsureDefault(ctx, cfg.Title); err != nil {
+		return err
+	}
+	g, err := guestbookRepo.GetBySlug(ctx, *slug)
+	if err != nil {
+		return fmt.Errorf("failed to get guestbook %q: %w", *slug, err)
+	}
+
+	messageService := service.NewMessageService(logger, repository.NewMessageRepo(logger, db),
+		service.WithUnitOfWork(repository.NewUnitOfWork(logger, db)),
+		service.WithAuditRepo(repository.NewAuditRepo(logger, db)),
+	)
+	ctx = domain.ContextWithActor(domain.ContextWithGuestbook(ctx, g), importActor)
+	report, err := messageService.Import(ctx, in, domain.MessageImportOptions{
+		Format: domain.MessageImportFormat(*format),
+		DryRun: *dryRun,
+	})
+	if report != nil {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(model.NewImportMessagesResponse(report)); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "%d accepted, %d rejected\n", report.Accepted, report.Rejected)
+	}
+
+	return err
+}
+
+// importFormat guesses the format of an import file from its extension.
+func importFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return string(domain.MessageImportCSV)
+	default:
+		return string(domain.MessageImportJSONL)
+	}
+}
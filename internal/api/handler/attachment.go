@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentService interface {
+	Open(context.Context, string) (io.ReadCloser, string, error)
+}
+
+// AttachmentHandler is the handler for the images attached to messages
+type AttachmentHandler struct {
+	logger            *slog.Logger
+	attachmentService AttachmentService
+}
+
+// NewAttachmentHandler returns a new AttachmentHandler
+func NewAttachmentHandler(logger *slog.Logger, attachmentService AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		logger:            logger,
+		attachmentService: attachmentService,
+	}
+}
+
+// Get serves an image or thumbnail. Their keys are never reused, so they are
+// cached for good.
+func (h *AttachmentHandler) Get(c *gin.Context) {
+	r, contentType, err := h.attachmentService.Open(c, c.Param("key"))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+
+		h.logger.Error("failed to open attachment", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, -1, contentType, r, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAttachmentHandler_Get(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	const key = "0123456789abcdef0123456789abcdef.png"
+	tests := []struct {
+		name            string
+		service         AttachmentService
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "success",
+			service: func() AttachmentService {
+				mockService := new(mocks.AttachmentService)
+				mockService.On("Open", mock.Anything, key).Return(io.NopCloser(strings.NewReader("png")), "image/png", nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "png",
+			expectedHeaders: map[string]string{
+				"Content-Type":           "image/png",
+				"Cache-Control":          "public, max-age=31536000, immutable",
+				"X-Content-Type-Options": "nosniff",
+			},
+		},
+		{
+			name: "not found",
+			service: func() AttachmentService {
+				mockService := new(mocks.AttachmentService)
+				mockService.On("Open", mock.Anything, key).Return(nil, "", domain.ErrNotFound)
+				return mockService
+			}(),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"attachment not found"}`,
+		},
+		{
+			name: "failed to open attachment",
+			service: func() AttachmentService {
+				mockService := new(mocks.AttachmentService)
+				mockService.On("Open", mock.Anything, key).Return(nil, "", errors.New("boom"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAttachmentHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/attachments/:key", handler.Get)
+
+			req, _ := http.NewRequest(http.MethodGet, "/attachments/"+key, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, tt.expectedBody, resp.Body.String())
+			for name, value := range tt.expectedHeaders {
+				assert.Equal(t, value, resp.Header().Get(name), name)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditService interface {
+	GetAll(context.Context, domain.AuditQuery) ([]*domain.AuditEntry, error)
+	Verify(context.Context) (*domain.AuditVerification, error)
+}
+
+// AuditHandler is the handler for the audit log of the message changes
+type AuditHandler struct {
+	logger       *slog.Logger
+	auditService AuditService
+}
+
+// NewAuditHandler returns a new AuditHandler
+func NewAuditHandler(logger *slog.Logger, auditService AuditService) *AuditHandler {
+	return &AuditHandler{
+		logger:       logger,
+		auditService: auditService,
+	}
+}
+
+// GetAll returns the audit entries matching the query, oldest first
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	var query model.ListAuditEntriesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("failed to bind query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+	q, err := query.ToEntity()
+	if err != nil {
+		h.logger.Error("failed to parse query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entities, err := h.auditService.GetAll(c, q)
+	if err != nil {
+		h.logger.Error("failed to get all audit entries", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action, time range or limit"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListAuditEntriesResponse(entities))
+}
+
+// Verify checks the hash chain of the audit log
+func (h *AuditHandler) Verify(c *gin.Context) {
+	entity, err := h.auditService.Verify(c)
+	if err != nil {
+		h.logger.Error("failed to verify audit log", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewVerifyAuditLogResponse(entity))
+}
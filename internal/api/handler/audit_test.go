@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditHandler_GetAll(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		service        AuditService
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("GetAll", mock.Anything, domain.AuditQuery{
+					Actor:     "admin",
+					Action:    domain.AuditActionUpdate,
+					MessageID: 1,
+					Since:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+					Limit:     10,
+				}).Return([]*domain.AuditEntry{
+					{
+						ID:          1,
+						GuestbookID: 1,
+						Actor:       "admin",
+						Action:      domain.AuditActionUpdate,
+						MessageID:   1,
+						Before:      &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey"},
+						After:       &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hello"},
+						RequestID:   "abc",
+						CreatedAt:   createdAt,
+						PrevHash:    "",
+						Hash:        "0f",
+					},
+				}, nil)
+				return mockService
+			}(),
+			query:          "?actor=admin&action=update&message_id=1&since=2024-06-01&limit=10",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"entries":[{"id":1,"guestbook_id":1,"actor":"admin","action":"update","message_id":1,` +
+				`"before":{"author":"Arthur Morgan","content":"Hey"},"after":{"author":"Arthur Morgan","content":"Hello"},` +
+				`"request_id":"abc","created_at":"2024-06-01T12:00:00Z","prev_hash":"","hash":"0f"}]}`,
+		},
+		{
+			name: "empty",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("GetAll", mock.Anything, domain.AuditQuery{}).Return([]*domain.AuditEntry{}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"entries":[]}`,
+		},
+		{
+			name:           "invalid query",
+			service:        new(mocks.AuditService),
+			query:          "?message_id=one",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid query"}`,
+		},
+		{
+			name:           "invalid since",
+			service:        new(mocks.AuditService),
+			query:          "?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid since: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""}`,
+		},
+		{
+			name: "invalid action",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("GetAll", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: invalid action", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			query:          "?action=read",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid action, time range or limit"}`,
+		},
+		{
+			name: "failed to get all audit entries",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("GetAll", mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuditHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/audit-log", handler.GetAll)
+
+			req, _ := http.NewRequest(http.MethodGet, "/audit-log"+tt.query, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestAuditHandler_Verify(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		service        AuditService
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "valid",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("Verify", mock.Anything).Return(&domain.AuditVerification{Entries: 3}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"valid":true,"entries":3}`,
+		},
+		{
+			name: "broken",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("Verify", mock.Anything).Return(&domain.AuditVerification{Entries: 3, BrokenAt: 2}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"valid":false,"entries":3,"broken_at":2}`,
+		},
+		{
+			name: "failed to verify audit log",
+			service: func() AuditService {
+				mockService := new(mocks.AuditService)
+				mockService.On("Verify", mock.Anything).Return(nil, errors.New("db down"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuditHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/audit-log/verify", handler.Verify)
+
+			req, _ := http.NewRequest(http.MethodGet, "/audit-log/verify", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
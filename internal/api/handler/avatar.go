@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AvatarService interface {
+	Get(context.Context, string, int) ([]byte, error)
+}
+
+// AvatarHandler is the handler for the avatars of message authors
+type AvatarHandler struct {
+	logger        *slog.Logger
+	avatarService AvatarService
+}
+
+// NewAvatarHandler returns a new AvatarHandler
+func NewAvatarHandler(logger *slog.Logger, avatarService AvatarService) *AvatarHandler {
+	return &AvatarHandler{
+		logger:        logger,
+		avatarService: avatarService,
+	}
+}
+
+// Get serves the avatar of an ID, at the size in pixels given by the s query
+// parameter like Gravatar. Avatars never change, so they are cached for good.
+func (h *AvatarHandler) Get(c *gin.Context) {
+	id, ok := strings.CutSuffix(c.Param("file"), ".png")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "avatar not found"})
+		return
+	}
+
+	var size int
+	if s := c.Query("s"); s != "" {
+		var err error
+		if size, err = strconv.Atoi(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size"})
+			return
+		}
+	}
+
+	etag := `"` + id + "-" + strconv.Itoa(size) + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	png, err := h.avatarService.Get(c, id, size)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "avatar not found"})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size"})
+			return
+		}
+
+		h.logger.Error("failed to get avatar", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Data(http.StatusOK, "image/png", png)
+}
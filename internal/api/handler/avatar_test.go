@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAvatarHandler_Get(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	const hash = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name            string
+		service         AvatarService
+		path            string
+		ifNoneMatch     string
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "success",
+			service: func() AvatarService {
+				mockService := new(mocks.AvatarService)
+				mockService.On("Get", mock.Anything, hash, 0).Return([]byte("png"), nil)
+				return mockService
+			}(),
+			path:           "/avatars/" + hash + ".png",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "png",
+			expectedHeaders: map[string]string{
+				"Content-Type":  "image/png",
+				"Cache-Control": "public, max-age=31536000, immutable",
+				"ETag":          `"` + hash + `-0"`,
+			},
+		},
+		{
+			name: "success with size",
+			service: func() AvatarService {
+				mockService := new(mocks.AvatarService)
+				mockService.On("Get", mock.Anything, hash, 40).Return([]byte("png"), nil)
+				return mockService
+			}(),
+			path:           "/avatars/" + hash + ".png?s=40",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "png",
+		},
+		{
+			name:           "not modified",
+			service:        new(mocks.AvatarService),
+			path:           "/avatars/" + hash + ".png?s=40",
+			ifNoneMatch:    `"` + hash + `-40"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "not a PNG",
+			service:        new(mocks.AvatarService),
+			path:           "/avatars/" + hash + ".jpg",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"avatar not found"}`,
+		},
+		{
+			name:           "invalid size",
+			service:        new(mocks.AvatarService),
+			path:           "/avatars/" + hash + ".png?s=big",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid size"}`,
+		},
+		{
+			name: "size out of range",
+			service: func() AvatarService {
+				mockService := new(mocks.AvatarService)
+				mockService.On("Get", mock.Anything, hash, 4096).Return(nil, domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			path:           "/avatars/" + hash + ".png?s=4096",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid size"}`,
+		},
+		{
+			name: "invalid hash",
+			service: func() AvatarService {
+				mockService := new(mocks.AvatarService)
+				mockService.On("Get", mock.Anything, "nope", 0).Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			path:           "/avatars/nope.png",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"avatar not found"}`,
+		},
+		{
+			name: "failed to get avatar",
+			service: func() AvatarService {
+				mockService := new(mocks.AvatarService)
+				mockService.On("Get", mock.Anything, hash, 0).Return(nil, errors.New("boom"))
+				return mockService
+			}(),
+			path:           "/avatars/" + hash + ".png",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAvatarHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/avatars/:file", handler.Get)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, tt.expectedBody, resp.Body.String())
+			for name, value := range tt.expectedHeaders {
+				assert.Equal(t, value, resp.Header().Get(name), name)
+			}
+		})
+	}
+}
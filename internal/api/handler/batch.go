@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageBatchService interface {
+	Batch(context.Context, domain.MessageBatch) ([]domain.MessageOperationResult, error)
+}
+
+// BatchHandler is the handler for batches of message operations
+type BatchHandler struct {
+	logger         *slog.Logger
+	messageService MessageBatchService
+}
+
+// NewBatchHandler returns a new BatchHandler
+func NewBatchHandler(logger *slog.Logger, messageService MessageBatchService) *BatchHandler {
+	return &BatchHandler{
+		logger:         logger,
+		messageService: messageService,
+	}
+}
+
+// Batch applies a list of create, update and delete operations, and returns
+// the result of each
+func (h *BatchHandler) Batch(c *gin.Context) {
+	var req model.BatchMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	batch, err := req.ToEntity()
+	if err != nil {
+		h.logger.Error("failed to parse batch", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := h.messageService.Batch(c, batch)
+	if err != nil {
+		h.logger.Error("failed to apply batch", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a batch must have 1 to %d operations", domain.MaxBatchOperations)})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	res := make([]model.MessageOperationResponse, len(results))
+	for i, result := range results {
+		typ := batch.Operations[i].Type
+		if result.Err == nil {
+			status := http.StatusOK
+			if typ == domain.MessageOperationCreate {
+				status = http.StatusCreated
+			}
+			res[i] = model.MessageOperationResponse{Status: status, ID: result.ID}
+			continue
+		}
+
+		status, message := batchOperationError(typ, result.Err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("failed to apply batch operation", slog.Int("operation", i), slog.String("error", result.Err.Error()))
+		}
+		res[i] = model.MessageOperationResponse{Status: status, Error: message}
+	}
+
+	c.JSON(http.StatusOK, model.NewBatchMessagesResponse(res))
+}
+
+// batchOperationError returns the status and error message of an operation
+// of a batch that failed, matching those of the single message endpoints.
+func batchOperationError(typ domain.MessageOperationType, err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrAborted):
+		return http.StatusFailedDependency, "aborted by another operation of the batch"
+	case typ == domain.MessageOperationCreate:
+		return createMessageError(err)
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return http.StatusBadRequest, "author or content is empty after sanitization"
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "message not found"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
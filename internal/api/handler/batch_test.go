@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchHandler_Batch(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	parentID := int64(1)
+
+	tests := []struct {
+		name           string
+		service        MessageBatchService
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() MessageBatchService {
+				mockService := new(mocks.MessageBatchService)
+				mockService.On("Batch", mock.Anything, domain.MessageBatch{
+					BestEffort: true,
+					Operations: []domain.MessageOperation{
+						{Type: domain.MessageOperationCreate, Message: &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+						{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 2, Author: "Dutch van der Linde", Message: "I have a plan!"}},
+						{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 3}},
+						{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 4}},
+						{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Micah Bell", Message: "Spam"}},
+					},
+				}).Return([]domain.MessageOperationResult{
+					{ID: 5},
+					{ID: 2},
+					{ID: 3},
+					{Err: domain.ErrNotFound},
+					{Err: fmt.Errorf("failed to create message: %w", domain.ErrForbidden)},
+				}, nil)
+				return mockService
+			}(),
+			requestBody: `{"best_effort":true,"operations":[
+				{"op":"create","parent_id":1,"author":"Arthur Morgan","content":"Hey, Dutch!"},
+				{"op":"update","id":2,"author":"Dutch van der Linde","content":"I have a plan!"},
+				{"op":"delete","id":3},
+				{"op":"delete","id":4},
+				{"op":"create","author":"Micah Bell","content":"Spam"}
+			]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"succeeded":3,"failed":2,"results":[
+				{"status":201,"id":5},
+				{"status":200,"id":2},
+				{"status":200,"id":3},
+				{"status":404,"error":"message not found"},
+				{"status":403,"error":"posting is disabled for this guestbook"}
+			]}`,
+		},
+		{
+			name: "aborted",
+			service: func() MessageBatchService {
+				mockService := new(mocks.MessageBatchService)
+				mockService.On("Batch", mock.Anything, mock.Anything).Return([]domain.MessageOperationResult{
+					{Err: domain.ErrAborted},
+					{Err: fmt.Errorf("failed to update message: %w", domain.ErrInvalidArgument)},
+					{Err: errors.New("db down")},
+				}, nil)
+				return mockService
+			}(),
+			requestBody:    `{"operations":[{"op":"delete","id":1},{"op":"update","id":2,"author":"<b></b>","content":"Hey"},{"op":"delete","id":3}]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"succeeded":0,"failed":3,"results":[
+				{"status":424,"error":"aborted by another operation of the batch"},
+				{"status":400,"error":"author or content is empty after sanitization"},
+				{"status":500,"error":"internal server error"}
+			]}`,
+		},
+		{
+			name:           "invalid request",
+			service:        new(mocks.MessageBatchService),
+			requestBody:    `{"operations":{}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid request"}`,
+		},
+		{
+			name:           "invalid op",
+			service:        new(mocks.MessageBatchService),
+			requestBody:    `{"operations":[{"op":"delete","id":1},{"op":"purge"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"operation 1: invalid op \"purge\""}`,
+		},
+		{
+			name: "too many operations",
+			service: func() MessageBatchService {
+				mockService := new(mocks.MessageBatchService)
+				mockService.On("Batch", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("failed to apply batch: %w", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			requestBody:    `{"operations":[]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"a batch must have 1 to 500 operations"}`,
+		},
+		{
+			name: "failed to apply batch",
+			service: func() MessageBatchService {
+				mockService := new(mocks.MessageBatchService)
+				mockService.On("Batch", mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+				return mockService
+			}(),
+			requestBody:    `{"operations":[{"op":"delete","id":1}]}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBatchHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.POST("/messages:batch", handler.Batch)
+
+			req, _ := http.NewRequest(http.MethodPost, "/messages:batch", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
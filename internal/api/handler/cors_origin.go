@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CORSOriginService interface {
+	GetAll(context.Context) ([]*domain.CORSOrigin, error)
+	Create(context.Context, *domain.CORSOrigin) (int64, error)
+	Delete(context.Context, int64) error
+}
+
+// CORSOriginHandler is the handler for the origins allowed to call the API
+type CORSOriginHandler struct {
+	logger            *slog.Logger
+	corsOriginService CORSOriginService
+}
+
+// NewCORSOriginHandler returns a new CORSOriginHandler
+func NewCORSOriginHandler(logger *slog.Logger, corsOriginService CORSOriginService) *CORSOriginHandler {
+	return &CORSOriginHandler{
+		logger:            logger,
+		corsOriginService: corsOriginService,
+	}
+}
+
+// GetAll returns all allowed origins
+func (h *CORSOriginHandler) GetAll(c *gin.Context) {
+	entities, err := h.corsOriginService.GetAll(c)
+	if err != nil {
+		h.logger.Error("failed to get all cors origins", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListCORSOriginsResponse(entities))
+}
+
+// Create allows an origin
+func (h *CORSOriginHandler) Create(c *gin.Context) {
+	var req model.CreateCORSOriginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	id, err := h.corsOriginService.Create(c, req.ToEntity())
+	if err != nil {
+		h.logger.Error("failed to create cors origin", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid origin"})
+		case errors.Is(err, domain.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "origin already allowed"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// Delete removes an allowed origin
+func (h *CORSOriginHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	err = h.corsOriginService.Delete(c, id)
+	if err != nil {
+		h.logger.Error("failed to delete cors origin", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "origin not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
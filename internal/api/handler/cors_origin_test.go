@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCORSOriginHandler_GetAll(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name              string
+		corsOriginService CORSOriginService
+		expectedStatus    int
+	}{
+		{
+			name: "success",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{
+					{ID: 1, Origin: "https://partner.example"},
+				}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failed to get all cors origins",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("GetAll", mock.Anything).Return(nil, fmt.Errorf("failed to get all cors origins"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCORSOriginHandler(logger, tt.corsOriginService)
+
+			router := gin.Default()
+			router.GET("/cors-origins", handler.GetAll)
+
+			req, _ := http.NewRequest(http.MethodGet, "/cors-origins", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestCORSOriginHandler_Create(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name              string
+		corsOriginService CORSOriginService
+		requestBody       string
+		expectedStatus    int
+	}{
+		{
+			name: "success",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Create", mock.Anything, &domain.CORSOrigin{Origin: "https://partner.example"}).Return(int64(1), nil)
+				return mockService
+			}(),
+			requestBody:    `{"origin":"https://partner.example"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "failed to bind json",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				return mockService
+			}(),
+			requestBody:    `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid origin",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.CORSOrigin")).Return(int64(0), domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			requestBody:    `{"origin":"javascript:alert(1)"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "origin already allowed",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.CORSOrigin")).Return(int64(0), domain.ErrAlreadyExists)
+				return mockService
+			}(),
+			requestBody:    `{"origin":"https://partner.example"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failed to create cors origin",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.CORSOrigin")).Return(int64(0), fmt.Errorf("failed to create cors origin"))
+				return mockService
+			}(),
+			requestBody:    `{"origin":"https://partner.example"}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCORSOriginHandler(logger, tt.corsOriginService)
+
+			router := gin.Default()
+			router.POST("/cors-origins", handler.Create)
+
+			req, _ := http.NewRequest(http.MethodPost, "/cors-origins", strings.NewReader(tt.requestBody))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestCORSOriginHandler_Delete(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name              string
+		corsOriginService CORSOriginService
+		id                string
+		expectedStatus    int
+	}{
+		{
+			name: "success",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(nil)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failed to parse id",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				return mockService
+			}(),
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "origin not found",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to delete cors origin",
+			corsOriginService: func() CORSOriginService {
+				mockService := new(mocks.CORSOriginService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(fmt.Errorf("failed to delete cors origin"))
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCORSOriginHandler(logger, tt.corsOriginService)
+
+			router := gin.Default()
+			router.DELETE("/cors-origins/:id", handler.Delete)
+
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/cors-origins/%s", tt.id), nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCSPReportSize limits the size of a CSP violation report body.
+const maxCSPReportSize = 64 << 10
+
+// cspViolation holds the fields of a CSP violation report that are logged.
+// Both the report-uri format (kebab-case) and the Reporting API format
+// (camelCase) are accepted.
+type cspViolation struct {
+	DocumentURI        string `json:"document-uri"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	Disposition        string `json:"disposition"`
+
+	DocumentURL                string `json:"documentURL"`
+	BlockedURL                 string `json:"blockedURL"`
+	EffectiveDirectiveReported string `json:"effectiveDirective"`
+	SourceFileReported         string `json:"sourceFile"`
+	LineNumberReported         int    `json:"lineNumber"`
+}
+
+// CSPReportHandler is the handler for CSP violation reports
+type CSPReportHandler struct {
+	logger *slog.Logger
+}
+
+// NewCSPReportHandler returns a new CSPReportHandler
+func NewCSPReportHandler(logger *slog.Logger) *CSPReportHandler {
+	return &CSPReportHandler{
+		logger: logger,
+	}
+}
+
+// Create logs the CSP violation reports sent by browsers
+func (h *CSPReportHandler) Create(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCSPReportSize))
+	if err != nil {
+		h.logger.Error("failed to read csp report", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	violations, err := parseCSPReport(body)
+	if err != nil {
+		h.logger.Error("failed to parse csp report", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	for _, v := range violations {
+		h.logger.Warn("csp violation",
+			slog.String("document_uri", first(v.DocumentURI, v.DocumentURL)),
+			slog.String("blocked_uri", first(v.BlockedURI, v.BlockedURL)),
+			slog.String("violated_directive", first(v.ViolatedDirective, v.EffectiveDirective, v.EffectiveDirectiveReported)),
+			slog.String("source_file", first(v.SourceFile, v.SourceFileReported)),
+			slog.Int("line_number", max(v.LineNumber, v.LineNumberReported)),
+			slog.String("disposition", v.Disposition),
+			slog.String("user_agent", c.Request.UserAgent()),
+		)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseCSPReport parses a report sent either to a report-uri endpoint
+// (application/csp-report) or by the Reporting API (application/reports+json).
+func parseCSPReport(body []byte) ([]cspViolation, error) {
+	var legacy struct {
+		Report *cspViolation `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &legacy); err == nil && legacy.Report != nil {
+		return []cspViolation{*legacy.Report}, nil
+	}
+
+	var reports []struct {
+		Type string       `json:"type"`
+		Body cspViolation `json:"body"`
+	}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, err
+	}
+
+	violations := make([]cspViolation, 0, len(reports))
+	for _, r := range reports {
+		if r.Type == "csp-violation" {
+			violations = append(violations, r.Body)
+		}
+	}
+	return violations, nil
+}
+
+// first returns the first non-empty string.
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
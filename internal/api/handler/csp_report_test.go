@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSPReportHandler_Create(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{
+			name:        "report-uri format",
+			contentType: "application/csp-report",
+			body: `{"csp-report":{"document-uri":"http://localhost:8080/","blocked-uri":"inline",` +
+				`"violated-directive":"script-src-elem","line-number":12}}`,
+			expectedStatus: http.StatusNoContent,
+			expectedLog:    `"blocked_uri":"inline"`,
+		},
+		{
+			name:        "reporting api format",
+			contentType: "application/reports+json",
+			body: `[{"type":"csp-violation","body":{"documentURL":"http://localhost:8080/",` +
+				`"blockedURL":"https://evil.example/x.js","effectiveDirective":"script-src-elem"}}]`,
+			expectedStatus: http.StatusNoContent,
+			expectedLog:    `"blocked_uri":"https://evil.example/x.js"`,
+		},
+		{
+			name:           "invalid body",
+			contentType:    "application/csp-report",
+			body:           `not json`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "body too large",
+			contentType:    "application/csp-report",
+			body:           `{"csp-report":{"document-uri":"` + strings.Repeat("a", maxCSPReportSize) + `"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			handler := NewCSPReportHandler(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+			router := gin.Default()
+			router.POST("/csp-reports", handler.Create)
+
+			req, _ := http.NewRequest(http.MethodPost, "/csp-reports", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedLog != "" {
+				assert.Contains(t, logs.String(), `"msg":"csp violation"`)
+				assert.Contains(t, logs.String(), tt.expectedLog)
+			}
+		})
+	}
+}
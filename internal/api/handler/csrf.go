@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"guestbook-example/internal/api/middleware"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFHandler is the handler for CSRF tokens
+type CSRFHandler struct {
+	logger *slog.Logger
+}
+
+// NewCSRFHandler returns a new CSRFHandler
+func NewCSRFHandler(logger *slog.Logger) *CSRFHandler {
+	return &CSRFHandler{
+		logger: logger,
+	}
+}
+
+// Get returns the CSRF token to echo in state-changing requests
+func (h *CSRFHandler) Get(c *gin.Context) {
+	token := middleware.CSRFToken(c)
+	if token == "" {
+		h.logger.Error("failed to get csrf token", slog.String("error", "csrf middleware is not in use"))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"csrf_token": token})
+}
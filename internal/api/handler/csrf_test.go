@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"guestbook-example/internal/api/middleware"
+)
+
+func TestCSRFHandler_Get(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		middlewares    []gin.HandlerFunc
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			middlewares:    []gin.HandlerFunc{middleware.CSRF(slog.New(slog.NewJSONHandler(io.Discard, nil)), middleware.DefaultCSRFConfig(), nil)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "csrf middleware not in use",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCSRFHandler(logger)
+
+			router := gin.Default()
+			router.Use(tt.middlewares...)
+			router.GET("/csrf-token", handler.Get)
+
+			req, _ := http.NewRequest(http.MethodGet, "/csrf-token", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.expectedStatus, resp.Code)
+			if resp.Code != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				CSRFToken string `json:"csrf_token"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+			cookies := resp.Result().Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, cookies[0].Value, body.CSRFToken)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmbedService interface {
+	GetConfig(context.Context, string) (*domain.EmbedConfig, error)
+}
+
+// EmbedHandler is the handler for embedded guestbook widgets
+type EmbedHandler struct {
+	logger       *slog.Logger
+	embedService EmbedService
+}
+
+// NewEmbedHandler returns a new EmbedHandler
+func NewEmbedHandler(logger *slog.Logger, embedService EmbedService) *EmbedHandler {
+	return &EmbedHandler{
+		logger:       logger,
+		embedService: embedService,
+	}
+}
+
+// GetConfig returns the widget configuration of a guestbook
+func (h *EmbedHandler) GetConfig(c *gin.Context) {
+	entity, err := h.embedService.GetConfig(c, c.Param("guestbook"))
+	if err != nil {
+		h.logger.Error("failed to get embed config", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "guestbook not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, model.NewGetEmbedConfigResponse(entity))
+}
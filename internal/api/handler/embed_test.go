@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedHandler_GetConfig(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		embedService   EmbedService
+		guestbook      string
+		expectedStatus int
+		expectedBody   *model.GetEmbedConfigResponse
+	}{
+		{
+			name: "success",
+			embedService: func() EmbedService {
+				mockService := new(mocks.EmbedService)
+				mockService.On("GetConfig", mock.Anything, "default").Return(&domain.EmbedConfig{
+					GuestbookSlug: "default",
+					Title:         "Guestbook",
+					AllowPosting:  true,
+				}, nil)
+				return mockService
+			}(),
+			guestbook:      "default",
+			expectedStatus: http.StatusOK,
+			expectedBody: &model.GetEmbedConfigResponse{
+				Guestbook:    "default",
+				Title:        "Guestbook",
+				AllowPosting: true,
+				MessagesURL:  "/api/v1/guestbooks/default/messages",
+			},
+		},
+		{
+			name: "guestbook not found",
+			embedService: func() EmbedService {
+				mockService := new(mocks.EmbedService)
+				mockService.On("GetConfig", mock.Anything, "unknown").Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			guestbook:      "unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to get embed config",
+			embedService: func() EmbedService {
+				mockService := new(mocks.EmbedService)
+				mockService.On("GetConfig", mock.Anything, "default").Return(nil, fmt.Errorf("failed to get embed config"))
+				return mockService
+			}(),
+			guestbook:      "default",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewEmbedHandler(logger, tt.embedService)
+
+			router := gin.Default()
+			router.GET("/embed/:guestbook/config", handler.GetConfig)
+
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/embed/%s/config", tt.guestbook), nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != nil {
+				var got model.GetEmbedConfigResponse
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+				assert.Equal(t, *tt.expectedBody, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageExportService interface {
+	Export(context.Context, domain.MessageExportQuery, func(*domain.ExportedMessage) error) error
+}
+
+// ExportHandler is the handler for the message export
+type ExportHandler struct {
+	logger         *slog.Logger
+	messageService MessageExportService
+}
+
+// NewExportHandler returns a new ExportHandler
+func NewExportHandler(logger *slog.Logger, messageService MessageExportService) *ExportHandler {
+	return &ExportHandler{
+		logger:         logger,
+		messageService: messageService,
+	}
+}
+
+// Export streams the messages of the guestbook as a CSV or JSON Lines file
+// download, as the format query parameter asks. Messages are written as they
+// are read from the database, so an export failing midway is cut short.
+func (h *ExportHandler) Export(c *gin.Context) {
+	var req model.ExportMessagesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	var (
+		contentType, ext string
+		write            func(*domain.ExportedMessage) error
+		flush            func() error
+	)
+	switch req.Format {
+	case model.ExportFormatCSV:
+		contentType, ext = "text/csv; charset=utf-8", "csv"
+		// The csv.Writer buffers the header, so nothing is sent before the
+		// first messages are read and a failing export can still be answered
+		// with an error.
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write(model.ExportedMessagesCSVHeader(req))
+		write = func(m *domain.ExportedMessage) error {
+			return w.Write(model.NewExportedMessage(m, req).CSVRecord(req))
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	case "", model.ExportFormatJSONL, model.ExportFormatNDJSON:
+		contentType, ext = "application/x-ndjson", "jsonl"
+		w := bufio.NewWriter(c.Writer)
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		write = func(m *domain.ExportedMessage) error {
+			return enc.Encode(model.NewExportedMessage(m, req))
+		}
+		flush = w.Flush
+	default:
+		h.logger.Error("failed to export messages", slog.String("error", "unknown format "+req.Format))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return
+	}
+
+	slug := domain.DefaultGuestbookSlug
+	if g, ok := domain.GuestbookFromContext(c); ok {
+		slug = g.Slug
+	}
+	filename := slug + "-messages-" + time.Now().UTC().Format("20060102") + "." + ext
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Status(http.StatusOK)
+
+	err := h.messageService.Export(c, req.ToEntity(), write)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		h.logger.Error("failed to export messages", slog.String("error", err.Error()))
+
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+}
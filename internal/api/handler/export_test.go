@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportHandler_Export(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	parentID := int64(1)
+	messages := []*domain.ExportedMessage{
+		{ID: 1, GuestbookID: 2, Author: "Arthur Morgan", Message: "Hey, Dutch!", CreatedAt: at, UpdatedAt: at},
+		{ID: 2, GuestbookID: 2, ParentID: &parentID, Author: "Dutch van der Linde", Message: "I have a plan,\n\"a good one\"", CreatedAt: at, UpdatedAt: at, DeletedAt: &at},
+	}
+	exporting := func(q domain.MessageExportQuery, ms []*domain.ExportedMessage, err error) MessageExportService {
+		mockService := new(mocks.MessageExportService)
+		mockService.On("Export", mock.Anything, q, mock.Anything).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*domain.ExportedMessage) error)
+				for _, m := range ms {
+					if fn(m) != nil {
+						return
+					}
+				}
+			}).
+			Return(err)
+		return mockService
+	}
+
+	tests := []struct {
+		name                string
+		service             MessageExportService
+		query               string
+		expectedStatus      int
+		expectedContentType string
+		expectedFilename    string
+		expectedBody        string
+	}{
+		{
+			name:                "jsonl",
+			service:             exporting(domain.MessageExportQuery{}, messages[:1], nil),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedFilename:    "wedding-messages-",
+			expectedBody:        `{"id":1,"guestbook_id":2,"parent_id":null,"author":"Arthur Morgan","content":"Hey, Dutch!"}` + "\n",
+		},
+		{
+			name:                "ndjson with timestamps and deleted messages",
+			service:             exporting(domain.MessageExportQuery{IncludeDeleted: true}, messages, nil),
+			query:               "?format=ndjson&timestamps=true&deleted=true",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: `{"id":1,"guestbook_id":2,"parent_id":null,"author":"Arthur Morgan","content":"Hey, Dutch!","created_at":"1899-04-01T12:00:00Z","updated_at":"1899-04-01T12:00:00Z"}` + "\n" +
+				`{"id":2,"guestbook_id":2,"parent_id":1,"author":"Dutch van der Linde","content":"I have a plan,\n\"a good one\"","created_at":"1899-04-01T12:00:00Z","updated_at":"1899-04-01T12:00:00Z","deleted_at":"1899-04-01T12:00:00Z"}` + "\n",
+		},
+		{
+			name:                "csv",
+			service:             exporting(domain.MessageExportQuery{IncludeDeleted: true}, messages, nil),
+			query:               "?format=csv&deleted=1",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedFilename:    ".csv",
+			expectedBody: "id,guestbook_id,parent_id,author,content,deleted_at\n" +
+				"1,2,,Arthur Morgan,\"Hey, Dutch!\",\n" +
+				"2,2,1,Dutch van der Linde,\"I have a plan,\n\"\"a good one\"\"\",1899-04-01T12:00:00Z\n",
+		},
+		{
+			name:                "empty csv",
+			service:             exporting(domain.MessageExportQuery{}, nil, nil),
+			query:               "?format=csv",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,guestbook_id,parent_id,author,content\n",
+		},
+		{
+			name:           "unknown format",
+			service:        new(mocks.MessageExportService),
+			query:          "?format=xml",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid format"}`,
+		},
+		{
+			name:           "invalid query",
+			service:        new(mocks.MessageExportService),
+			query:          "?deleted=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid query"}`,
+		},
+		{
+			name:                "failed to export messages",
+			service:             exporting(domain.MessageExportQuery{}, nil, errors.New("db down")),
+			query:               "?format=csv",
+			expectedStatus:      http.StatusInternalServerError,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewExportHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/messages/export", func(c *gin.Context) {
+				ctx := domain.ContextWithGuestbook(c.Request.Context(), &domain.Guestbook{ID: 2, Slug: "wedding"})
+				c.Request = c.Request.WithContext(ctx)
+			}, handler.Export)
+			router.ContextWithFallback = true
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/messages/export"+tt.query, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, tt.expectedBody, resp.Body.String())
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, resp.Header().Get("Content-Type"))
+			}
+			if tt.expectedFilename != "" {
+				assert.Contains(t, resp.Header().Get("Content-Disposition"), tt.expectedFilename)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				assert.Empty(t, resp.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
+
+func TestExportHandler_Export_ClientGone(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	// A failure after rows were sent cuts the export short.
+	mockService := new(mocks.MessageExportService)
+	mockService.On("Export", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*domain.ExportedMessage) error)
+			for i := range 1000 {
+				_ = fn(&domain.ExportedMessage{ID: int64(i + 1), Message: strings.Repeat("x", 100)})
+			}
+		}).
+		Return(errors.New("connection reset"))
+	handler := NewExportHandler(logger, mockService)
+
+	router := gin.Default()
+	router.GET("/messages/export", handler.Export)
+
+	req, _ := http.NewRequest(http.MethodGet, "/messages/export", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+	assert.NotContains(t, resp.Body.String(), "internal server error")
+}
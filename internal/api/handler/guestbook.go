@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GuestbookService interface {
+	GetBySlug(context.Context, string) (*domain.Guestbook, error)
+	GetAll(context.Context) ([]*domain.Guestbook, error)
+	Create(context.Context, *domain.Guestbook) (int64, error)
+	Update(context.Context, *domain.Guestbook) error
+}
+
+// GuestbookHandler is the handler for guestbooks
+type GuestbookHandler struct {
+	logger           *slog.Logger
+	guestbookService GuestbookService
+}
+
+// NewGuestbookHandler returns a new GuestbookHandler
+func NewGuestbookHandler(logger *slog.Logger, guestbookService GuestbookService) *GuestbookHandler {
+	return &GuestbookHandler{
+		logger:           logger,
+		guestbookService: guestbookService,
+	}
+}
+
+// Scope is a middleware scoping the request context to the guestbook named
+// by the slug parameter, or to the default guestbook on routes without one.
+func (h *GuestbookHandler) Scope(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		slug = domain.DefaultGuestbookSlug
+	}
+
+	g, err := h.guestbookService.GetBySlug(c, slug)
+	if err != nil {
+		h.logger.Error("failed to get guestbook", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "guestbook not found"})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Request = c.Request.WithContext(domain.ContextWithGuestbook(c.Request.Context(), g))
+	c.Next()
+}
+
+// Get returns a guestbook
+func (h *GuestbookHandler) Get(c *gin.Context) {
+	entity, err := h.guestbookService.GetBySlug(c, c.Param("slug"))
+	if err != nil {
+		h.logger.Error("failed to get guestbook", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "guestbook not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewGetGuestbookResponse(entity))
+}
+
+// GetAll returns all guestbooks
+func (h *GuestbookHandler) GetAll(c *gin.Context) {
+	entities, err := h.guestbookService.GetAll(c)
+	if err != nil {
+		h.logger.Error("failed to get all guestbooks", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListGuestbooksResponse(entities))
+}
+
+// Create creates a guestbook
+func (h *GuestbookHandler) Create(c *gin.Context) {
+	var req model.CreateGuestbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	id, err := h.guestbookService.Create(c, req.ToEntity())
+	if err != nil {
+		h.logger.Error("failed to create guestbook", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid slug or title"})
+		case errors.Is(err, domain.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "guestbook already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// Update updates a guestbook
+func (h *GuestbookHandler) Update(c *gin.Context) {
+	var req model.UpdateGuestbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	req.Slug = c.Param("slug")
+
+	err := h.guestbookService.Update(c, req.ToEntity())
+	if err != nil {
+		h.logger.Error("failed to update guestbook", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid title"})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "guestbook not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slug": req.Slug})
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GuestbookOwnerService interface {
+	GetAll(context.Context) ([]*domain.GuestbookOwner, error)
+	Create(context.Context, *domain.GuestbookOwner) (int64, error)
+	Update(context.Context, *domain.GuestbookOwner) error
+	Delete(context.Context, int64) error
+}
+
+// GuestbookOwnerHandler is the handler for the owners of a guestbook, who
+// are emailed about its new messages
+type GuestbookOwnerHandler struct {
+	logger                *slog.Logger
+	guestbookOwnerService GuestbookOwnerService
+}
+
+// NewGuestbookOwnerHandler returns a new GuestbookOwnerHandler
+func NewGuestbookOwnerHandler(logger *slog.Logger, guestbookOwnerService GuestbookOwnerService) *GuestbookOwnerHandler {
+	return &GuestbookOwnerHandler{
+		logger:                logger,
+		guestbookOwnerService: guestbookOwnerService,
+	}
+}
+
+// GetAll returns the owners of the guestbook
+func (h *GuestbookOwnerHandler) GetAll(c *gin.Context) {
+	entities, err := h.guestbookOwnerService.GetAll(c)
+	if err != nil {
+		h.logger.Error("failed to get all guestbook owners", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListGuestbookOwnersResponse(entities))
+}
+
+// Create adds an owner to the guestbook
+func (h *GuestbookOwnerHandler) Create(c *gin.Context) {
+	var req model.CreateGuestbookOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	id, err := h.guestbookOwnerService.Create(c, req.ToEntity())
+	if err != nil {
+		h.logger.Error("failed to create guestbook owner", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email or frequency"})
+		case errors.Is(err, domain.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "owner already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// Update changes the name, email or notification frequency of an owner
+func (h *GuestbookOwnerHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	var req model.UpdateGuestbookOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	err = h.guestbookOwnerService.Update(c, req.ToEntity(id))
+	if err != nil {
+		h.logger.Error("failed to update guestbook owner", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "owner not found"})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email or frequency"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// Delete removes an owner from the guestbook
+func (h *GuestbookOwnerHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	err = h.guestbookOwnerService.Delete(c, id)
+	if err != nil {
+		h.logger.Error("failed to delete guestbook owner", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "owner not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
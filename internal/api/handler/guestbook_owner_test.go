@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGuestbookOwnerHandler_GetAll(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name                  string
+		guestbookOwnerService GuestbookOwnerService
+		expectedStatus        int
+		expectedBody          string
+	}{
+		{
+			name: "success",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("GetAll", mock.Anything).Return([]*domain.GuestbookOwner{
+					{ID: 1, GuestbookID: 2, Name: "Dutch", Email: "dutch@example.com", Frequency: domain.NotifyDaily, LastMessageID: 3},
+				}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"owners":[{"id":1,"name":"Dutch","email":"dutch@example.com","frequency":"daily","last_notified_at":"0001-01-01T00:00:00Z","created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name: "failed to get all guestbook owners",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("GetAll", mock.Anything).Return(nil, fmt.Errorf("failed to get all guestbook owners"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookOwnerHandler(logger, tt.guestbookOwnerService)
+
+			router := gin.Default()
+			router.GET("/owners", handler.GetAll)
+
+			req, _ := http.NewRequest(http.MethodGet, "/owners", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestGuestbookOwnerHandler_Create(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name                  string
+		guestbookOwnerService GuestbookOwnerService
+		requestBody           string
+		expectedStatus        int
+		expectedBody          string
+	}{
+		{
+			name: "success",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Create", mock.Anything, &domain.GuestbookOwner{
+					Name:      "Dutch",
+					Email:     "dutch@example.com",
+					Frequency: domain.NotifyInstant,
+				}).Return(int64(1), nil)
+				return mockService
+			}(),
+			requestBody:    `{"name":"Dutch","email":"dutch@example.com"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name:                  "failed to bind json",
+			guestbookOwnerService: new(mocks.GuestbookOwnerService),
+			requestBody:           `{"email":`,
+			expectedStatus:        http.StatusBadRequest,
+			expectedBody:          `{"error":"invalid request"}`,
+		},
+		{
+			name: "invalid owner",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("%w: unknown frequency", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			requestBody:    `{"email":"dutch@example.com","frequency":"weekly"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid email or frequency"}`,
+		},
+		{
+			name: "owner already exists",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), domain.ErrAlreadyExists)
+				return mockService
+			}(),
+			requestBody:    `{"email":"dutch@example.com"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":"owner already exists"}`,
+		},
+		{
+			name: "failed to create guestbook owner",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create guestbook owner"))
+				return mockService
+			}(),
+			requestBody:    `{"email":"dutch@example.com"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookOwnerHandler(logger, tt.guestbookOwnerService)
+
+			router := gin.Default()
+			router.POST("/owners", handler.Create)
+
+			req, _ := http.NewRequest(http.MethodPost, "/owners", strings.NewReader(tt.requestBody))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestGuestbookOwnerHandler_Update(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name                  string
+		guestbookOwnerService GuestbookOwnerService
+		id                    string
+		requestBody           string
+		expectedStatus        int
+	}{
+		{
+			name: "success",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Update", mock.Anything, &domain.GuestbookOwner{
+					ID:        1,
+					Email:     "dutch@example.com",
+					Frequency: domain.NotifyOff,
+				}).Return(nil)
+				return mockService
+			}(),
+			id:             "1",
+			requestBody:    `{"email":"dutch@example.com","frequency":"off"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:                  "invalid id",
+			guestbookOwnerService: new(mocks.GuestbookOwnerService),
+			id:                    "abc",
+			requestBody:           `{"email":"dutch@example.com"}`,
+			expectedStatus:        http.StatusBadRequest,
+		},
+		{
+			name: "owner not found",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "42",
+			requestBody:    `{"email":"dutch@example.com"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "invalid owner",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(fmt.Errorf("%w: invalid email address", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			id:             "1",
+			requestBody:    `{"email":"dutch"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookOwnerHandler(logger, tt.guestbookOwnerService)
+
+			router := gin.Default()
+			router.PUT("/owners/:id", handler.Update)
+
+			req, _ := http.NewRequest(http.MethodPut, "/owners/"+tt.id, strings.NewReader(tt.requestBody))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestGuestbookOwnerHandler_Delete(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name                  string
+		guestbookOwnerService GuestbookOwnerService
+		id                    string
+		expectedStatus        int
+	}{
+		{
+			name: "success",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(nil)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "owner not found",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Delete", mock.Anything, int64(42)).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "42",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to delete guestbook owner",
+			guestbookOwnerService: func() GuestbookOwnerService {
+				mockService := new(mocks.GuestbookOwnerService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(fmt.Errorf("failed to delete guestbook owner"))
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookOwnerHandler(logger, tt.guestbookOwnerService)
+
+			router := gin.Default()
+			router.DELETE("/owners/:id", handler.Delete)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/owners/"+tt.id, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGuestbookHandler_Scope(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	wedding := &domain.Guestbook{ID: 2, Slug: "wedding", Title: "Wedding guestbook"}
+	defaultGuestbook := &domain.Guestbook{ID: 1, Slug: domain.DefaultGuestbookSlug, Title: "Guestbook"}
+
+	tests := []struct {
+		name             string
+		guestbookService GuestbookService
+		path             string
+		expectedStatus   int
+		expectedScope    *domain.Guestbook
+	}{
+		{
+			name: "success",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("GetBySlug", mock.Anything, "wedding").Return(wedding, nil)
+				return mockService
+			}(),
+			path:           "/guestbooks/wedding/messages",
+			expectedStatus: http.StatusOK,
+			expectedScope:  wedding,
+		},
+		{
+			name: "success with default guestbook",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("GetBySlug", mock.Anything, domain.DefaultGuestbookSlug).Return(defaultGuestbook, nil)
+				return mockService
+			}(),
+			path:           "/messages",
+			expectedStatus: http.StatusOK,
+			expectedScope:  defaultGuestbook,
+		},
+		{
+			name: "guestbook not found",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("GetBySlug", mock.Anything, "unknown").Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			path:           "/guestbooks/unknown/messages",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to get guestbook",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("GetBySlug", mock.Anything, "wedding").Return(nil, fmt.Errorf("failed to get guestbook"))
+				return mockService
+			}(),
+			path:           "/guestbooks/wedding/messages",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookHandler(logger, tt.guestbookService)
+
+			var scope *domain.Guestbook
+			next := func(c *gin.Context) {
+				scope, _ = domain.GuestbookFromContext(c)
+				c.Status(http.StatusOK)
+			}
+
+			router := gin.Default()
+			router.ContextWithFallback = true
+			router.GET("/messages", handler.Scope, next)
+			router.GET("/guestbooks/:slug/messages", handler.Scope, next)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, tt.expectedScope, scope)
+		})
+	}
+}
+
+func TestGuestbookHandler_Create(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name             string
+		guestbookService GuestbookService
+		body             string
+		expectedStatus   int
+	}{
+		{
+			name: "success",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Create", mock.Anything, &domain.Guestbook{
+					Slug:     "wedding",
+					Title:    "Wedding guestbook",
+					Settings: domain.GuestbookSettings{AllowPosting: true},
+				}).Return(int64(2), nil)
+				return mockService
+			}(),
+			body:           `{"slug":"wedding","title":"Wedding guestbook"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "success with posting disabled",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Create", mock.Anything, &domain.Guestbook{
+					Slug:     "archive",
+					Title:    "Archive",
+					Settings: domain.GuestbookSettings{AllowPosting: false},
+				}).Return(int64(3), nil)
+				return mockService
+			}(),
+			body:           `{"slug":"archive","title":"Archive","settings":{"allow_posting":false}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:             "invalid request",
+			guestbookService: new(mocks.GuestbookService),
+			body:             `{`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name: "invalid slug",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			body:           `{"slug":"Wedding/2024","title":"Wedding guestbook"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "guestbook already exists",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), domain.ErrAlreadyExists)
+				return mockService
+			}(),
+			body:           `{"slug":"wedding","title":"Wedding guestbook"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failed to create guestbook",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create guestbook"))
+				return mockService
+			}(),
+			body:           `{"slug":"wedding","title":"Wedding guestbook"}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookHandler(logger, tt.guestbookService)
+
+			router := gin.Default()
+			router.POST("/guestbooks", handler.Create)
+
+			req, _ := http.NewRequest(http.MethodPost, "/guestbooks", strings.NewReader(tt.body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestGuestbookHandler_Update(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name             string
+		guestbookService GuestbookService
+		body             string
+		expectedStatus   int
+	}{
+		{
+			name: "success",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Update", mock.Anything, &domain.Guestbook{
+					Slug:     "wedding",
+					Title:    "Our wedding",
+					Settings: domain.GuestbookSettings{AllowPosting: false},
+				}).Return(nil)
+				return mockService
+			}(),
+			body:           `{"title":"Our wedding","settings":{"allow_posting":false}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "guestbook not found",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			body:           `{"title":"Our wedding"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "empty title",
+			guestbookService: func() GuestbookService {
+				mockService := new(mocks.GuestbookService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			body:           `{"title":""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGuestbookHandler(logger, tt.guestbookService)
+
+			router := gin.Default()
+			router.PUT("/guestbooks/:slug", handler.Update)
+
+			req, _ := http.NewRequest(http.MethodPut, "/guestbooks/wedding", strings.NewReader(tt.body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
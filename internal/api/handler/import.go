@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the size of an import file.
+const maxImportSize = 32 << 20
+
+type MessageImportService interface {
+	Import(context.Context, io.Reader, domain.MessageImportOptions) (*domain.MessageImportReport, error)
+}
+
+// ImportHandler is the handler for the message import
+type ImportHandler struct {
+	logger         *slog.Logger
+	messageService MessageImportService
+}
+
+// NewImportHandler returns a new ImportHandler
+func NewImportHandler(logger *slog.Logger, messageService MessageImportService) *ImportHandler {
+	return &ImportHandler{
+		logger:         logger,
+		messageService: messageService,
+	}
+}
+
+// Import creates the messages of the CSV or JSON Lines file in the request
+// body, and returns which rows were accepted or rejected
+func (h *ImportHandler) Import(c *gin.Context) {
+	var req model.ImportMessagesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	report, err := h.messageService.Import(c, body, req.ToEntity(c.ContentType()))
+	if err != nil {
+		h.logger.Error("failed to import messages", slog.String("error", err.Error()))
+
+		status, msg := http.StatusInternalServerError, "internal server error"
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			status, msg = http.StatusRequestEntityTooLarge, "import file too large"
+		case errors.Is(err, domain.ErrInvalidArgument):
+			status, msg = http.StatusBadRequest, "invalid format or header"
+		}
+		if report == nil {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		// The rows before the failure may have been created, so the report
+		// goes with the error.
+		resp := model.NewImportMessagesResponse(report)
+		resp.Error = msg
+		c.JSON(status, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewImportMessagesResponse(report))
+}
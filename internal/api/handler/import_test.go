@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportHandler_Import(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		service        MessageImportService
+		query          string
+		contentType    string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() MessageImportService {
+				mockService := new(mocks.MessageImportService)
+				mockService.On("Import", mock.Anything, mock.Anything, domain.MessageImportOptions{Format: domain.MessageImportCSV}).
+					Return(&domain.MessageImportReport{
+						Accepted: 1,
+						Rejected: 1,
+						Rows:     []domain.MessageImportRow{{Line: 2, ID: 1}, {Line: 3, Error: "author is empty"}},
+					}, nil)
+				return mockService
+			}(),
+			contentType:    "text/csv; charset=utf-8",
+			requestBody:    "author,content\nArthur Morgan,Hey\n,Hey\n",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dry_run":false,"accepted":1,"rejected":1,"rows":[{"line":2,"id":1},{"line":3,"error":"author is empty"}]}`,
+		},
+		{
+			name: "dry run",
+			service: func() MessageImportService {
+				mockService := new(mocks.MessageImportService)
+				mockService.On("Import", mock.Anything, mock.Anything, domain.MessageImportOptions{Format: domain.MessageImportJSONL, DryRun: true}).
+					Return(&domain.MessageImportReport{DryRun: true, Accepted: 1, Rows: []domain.MessageImportRow{{Line: 1}}}, nil)
+				return mockService
+			}(),
+			query:          "?format=ndjson&dry_run=true",
+			requestBody:    `{"author":"Arthur Morgan","content":"Hey"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dry_run":true,"accepted":1,"rejected":0,"rows":[{"line":1}]}`,
+		},
+		{
+			name:           "invalid query",
+			service:        new(mocks.MessageImportService),
+			query:          "?dry_run=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid query"}`,
+		},
+		{
+			name: "invalid file",
+			service: func() MessageImportService {
+				mockService := new(mocks.MessageImportService)
+				mockService.On("Import", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: no content column", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			query:          "?format=csv",
+			requestBody:    "author\nArthur Morgan\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid format or header"}`,
+		},
+		{
+			name: "file too large",
+			service: func() MessageImportService {
+				mockService := new(mocks.MessageImportService)
+				mockService.On("Import", mock.Anything, mock.Anything, mock.Anything).
+					Return(&domain.MessageImportReport{Accepted: 1, Rows: []domain.MessageImportRow{{Line: 1, ID: 1}}},
+						fmt.Errorf("failed to import messages: %w", &http.MaxBytesError{Limit: maxImportSize}))
+				return mockService
+			}(),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `{"dry_run":false,"accepted":1,"rejected":0,"rows":[{"line":1,"id":1}],"error":"import file too large"}`,
+		},
+		{
+			name: "failed to import messages",
+			service: func() MessageImportService {
+				mockService := new(mocks.MessageImportService)
+				mockService.On("Import", mock.Anything, mock.Anything, mock.Anything).
+					Return(&domain.MessageImportReport{Accepted: 1, Rows: []domain.MessageImportRow{{Line: 2, ID: 1}}}, errors.New("db down"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"dry_run":false,"accepted":1,"rejected":0,"rows":[{"line":2,"id":1}],"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewImportHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.POST("/messages/import", handler.Import)
+
+			req, _ := http.NewRequest(http.MethodPost, "/messages/import"+tt.query, strings.NewReader(tt.requestBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
@@ -5,17 +5,24 @@ import (
 	"errors"
 	"guestbook-example/internal/api/model"
 	"guestbook-example/internal/domain"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MessageService interface {
 	Get(context.Context, int64) (*domain.Message, error)
-	GetAll(context.Context) ([]*domain.Message, error)
+	GetAll(context.Context, domain.MessageQuery) ([]*domain.Message, error)
+	GetThreads(context.Context, int) ([]*domain.Message, error)
+	GetReplies(context.Context, int64) ([]*domain.Message, error)
+	Search(context.Context, string) ([]*domain.Message, error)
 	Create(context.Context, *domain.Message) (int64, error)
+	CreateWithImage(context.Context, *domain.Message, io.Reader) (int64, error)
 	Update(context.Context, *domain.Message) error
 	Delete(context.Context, int64) error
 }
@@ -56,20 +63,68 @@ func (h *MessageHandler) Get(c *gin.Context) {
 		return
 	}
 
-	message := &model.GetMessageResponse{
-		ID:      entity.ID,
-		Author:  entity.Author,
-		Content: entity.Message,
-	}
-
-	c.JSON(http.StatusOK, message)
+	c.JSON(http.StatusOK, model.NewGetMessageResponse(entity))
 }
 
-// GetAll returns all messages
+// GetAll returns the messages, filtered by the author, since and until query
+// parameters and ordered by sort. With view=tree, it returns the top-level
+// messages with their replies nested down to the depth query parameter.
+// With q, it returns the messages matching the full-text query instead.
 func (h *MessageHandler) GetAll(c *gin.Context) {
-	entities, err := h.messageService.GetAll(c)
+	if q, ok := c.GetQuery("q"); ok {
+		h.search(c, q)
+		return
+	}
+
+	var req model.ListMessagesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	var (
+		entities []*domain.Message
+		err      error
+	)
+	switch view := c.Query("view"); view {
+	case "", "flat":
+		query, parseErr := req.ToEntity()
+		if parseErr != nil {
+			h.logger.Error("failed to parse query", slog.String("error", parseErr.Error()))
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+			return
+		}
+		entities, err = h.messageService.GetAll(c, query)
+	case "tree":
+		if !req.IsZero() {
+			h.logger.Error("failed to get all messages", slog.String("error", "filters with tree view"))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "filters and sort are not supported with view=tree"})
+			return
+		}
+		depth := domain.DefaultThreadDepth
+		if v, ok := c.GetQuery("depth"); ok {
+			depth, err = strconv.Atoi(v)
+			if err != nil {
+				h.logger.Error("failed to parse depth", slog.String("error", err.Error()))
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depth"})
+				return
+			}
+		}
+		entities, err = h.messageService.GetThreads(c, depth)
+	default:
+		h.logger.Error("failed to get all messages", slog.String("error", "unknown view "+view))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid view"})
+		return
+	}
 	if err != nil {
 		h.logger.Error("failed to get all messages", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depth, sort or time range"})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
 	}
@@ -79,36 +134,129 @@ func (h *MessageHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, messages)
 }
 
-// Create creates a message
+// search returns the messages matching a full-text query, best matches first
+func (h *MessageHandler) search(c *gin.Context, q string) {
+	entities, err := h.messageService.Search(c, q)
+	if err != nil {
+		h.logger.Error("failed to search messages", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid search query"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListMessagesResponse(entities))
+}
+
+// GetReplies returns the direct replies to a message
+func (h *MessageHandler) GetReplies(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	entities, err := h.messageService.GetReplies(c, id)
+	if err != nil {
+		h.logger.Error("failed to get replies", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListMessagesResponse(entities))
+}
+
+// maxMultipartMemory is the size of the parts of multipart requests kept in
+// memory, larger parts are stored in temporary files.
+const maxMultipartMemory = 1 << 20
+
+// Create creates a message, from a JSON body or from a multipart form with an
+// image attached to the message.
 func (h *MessageHandler) Create(c *gin.Context) {
+	if c.ContentType() == "multipart/form-data" {
+		h.createWithImage(c)
+		return
+	}
+
 	var req model.CreateMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	if !bindMessageJSON(c, h.logger, &req) {
 		return
 	}
 
-	// Validate request
-	author := req.Author
-	if author == "" {
-		h.logger.Error("failed to create message", slog.String("error", "author is empty"))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "author is empty"})
+	id, err := createMessage(c, h.messageService, &req)
+	if err != nil {
+		h.logger.Error("failed to create message", slog.String("error", err.Error()))
+		status, message := createMessageError(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
-	content := req.Content
-	if content == "" {
-		h.logger.Error("failed to create message", slog.String("error", "content is empty"))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "content is empty"})
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// createWithImage creates a message from a multipart form with the author,
+// content and parent_id fields, and the image to attach in the image field.
+// Without image, the message is created like a JSON one.
+func (h *MessageHandler) createWithImage(c *gin.Context) {
+	// The image may be a little smaller than the body, the rest is the fields
+	// and the multipart framing.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, domain.MaxAttachmentSize+maxMultipartMemory)
+	if err := c.Request.ParseMultipartForm(maxMultipartMemory); err != nil {
+		h.logger.Error("failed to parse multipart form", slog.String("error", err.Error()))
+		status, message := createMessageError(err)
+		if status == http.StatusInternalServerError {
+			status, message = http.StatusBadRequest, "invalid request"
+		}
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
+	defer c.Request.MultipartForm.RemoveAll()
 
-	id, err := h.messageService.Create(c, &domain.Message{
-		Author:  author,
-		Message: content,
-	})
+	req := model.CreateMessageRequest{
+		Author:  c.Request.PostFormValue("author"),
+		Content: c.Request.PostFormValue("content"),
+		Email:   c.Request.PostFormValue("email"),
+	}
+	if v := c.Request.PostFormValue("parent_id"); v != "" {
+		parentID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.logger.Error("failed to parse parent id", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		req.ParentID = &parentID
+	}
+
+	var id int64
+	file, _, err := c.Request.FormFile("image")
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+		id, err = createMessage(c, h.messageService, &req)
+	case err != nil:
+		h.logger.Error("failed to read image", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	default:
+		defer file.Close()
+		if err = validateCreateMessage(&req); err == nil {
+			id, err = h.messageService.CreateWithImage(c, req.ToEntity(), file)
+		}
+	}
 	if err != nil {
 		h.logger.Error("failed to create message", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		status, message := createMessageError(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
@@ -125,9 +273,11 @@ func (h *MessageHandler) Update(c *gin.Context) {
 	}
 
 	var req model.UpdateMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	if !bindMessageJSON(c, h.logger, &req) {
+		return
+	}
+	if utf8.RuneCountInString(req.Content) > domain.MaxContentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errContentTooLong.Error()})
 		return
 	}
 	req.ID = id
@@ -136,6 +286,15 @@ func (h *MessageHandler) Update(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("failed to update message", slog.String("error", err.Error()))
 
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "author or content is empty after sanitization"})
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
 		// TODO: determine error
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
@@ -159,6 +318,11 @@ func (h *MessageHandler) Delete(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("failed to delete message", slog.String("error", err.Error()))
 
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
 		// TODO: determine error
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
@@ -166,3 +330,87 @@ func (h *MessageHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"id": id})
 }
+
+var (
+	errEmptyAuthor  = errors.New("author is empty")
+	errEmptyContent = errors.New("content is empty")
+	// errContentTooLong is returned for content longer than
+	// domain.MaxContentLength characters.
+	errContentTooLong = errors.New("content is too long")
+	errInvalidEmail   = errors.New("email is invalid")
+)
+
+// maxEmailLength is the longest email address that can be delivered to.
+const maxEmailLength = 254
+
+// maxMessageBodySize is the largest JSON body of a message, in bytes. It
+// leaves room for domain.MaxContentLength characters escaped in JSON.
+const maxMessageBodySize = 128 << 10
+
+// bindMessageJSON binds the JSON body of a message request of at most
+// maxMessageBodySize bytes to req. It responds with the error and returns
+// false when the body is invalid or too large.
+func bindMessageJSON(c *gin.Context, logger *slog.Logger, req any) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxMessageBodySize)
+	if err := c.ShouldBindJSON(req); err != nil {
+		logger.Error("failed to bind json", slog.String("error", err.Error()))
+		if errors.As(err, new(*http.MaxBytesError)) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request too large"})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return false
+	}
+	return true
+}
+
+// createMessage validates a request and creates the message. It is shared by
+// the REST and WebSocket APIs, so both accept the same messages.
+func createMessage(ctx context.Context, messageService MessageService, req *model.CreateMessageRequest) (int64, error) {
+	if err := validateCreateMessage(req); err != nil {
+		return 0, err
+	}
+
+	return messageService.Create(ctx, req.ToEntity())
+}
+
+// validateCreateMessage checks the fields required to create a message.
+func validateCreateMessage(req *model.CreateMessageRequest) error {
+	if req.Author == "" {
+		return errEmptyAuthor
+	}
+	if req.Content == "" {
+		return errEmptyContent
+	}
+	if utf8.RuneCountInString(req.Content) > domain.MaxContentLength {
+		return errContentTooLong
+	}
+	if req.Email != "" {
+		addr, err := mail.ParseAddress(req.Email)
+		if err != nil || addr.Address != req.Email || len(req.Email) > maxEmailLength {
+			return errInvalidEmail
+		}
+	}
+	return nil
+}
+
+// createMessageError returns the status and error message to respond with
+// when createMessage fails.
+func createMessageError(err error) (int, string) {
+	switch {
+	case errors.Is(err, errEmptyAuthor), errors.Is(err, errEmptyContent), errors.Is(err, errContentTooLong), errors.Is(err, errInvalidEmail):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return http.StatusBadRequest, "author or content is empty after sanitization"
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, "posting is disabled for this guestbook"
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusBadRequest, "parent message not found"
+	case errors.Is(err, domain.ErrTooLarge), errors.As(err, new(*http.MaxBytesError)):
+		return http.StatusRequestEntityTooLarge, "image too large"
+	case errors.Is(err, domain.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType, "attachment must be a JPEG, PNG or GIF image"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"guestbook-example/internal/domain"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -82,22 +85,132 @@ func TestMessageHandler_GetAll(t *testing.T) {
 	tests := []struct {
 		name           string
 		messageService MessageService
+		query          string
 		expectedStatus int
 	}{
 		{
 			name: "success",
 			messageService: func() MessageService {
 				mockService := new(mocks.MessageService)
-				mockService.On("GetAll", mock.Anything).Return([]*domain.Message{}, nil)
+				mockService.On("GetAll", mock.Anything, domain.MessageQuery{}).Return([]*domain.Message{}, nil)
 				return mockService
 			}(),
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "success with filters and sort",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetAll", mock.Anything, domain.MessageQuery{
+					Author: "Arthur Morgan",
+					Since:  time.Date(1899, 4, 1, 0, 0, 0, 0, time.UTC),
+					Until:  time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+					Sort:   domain.MessageSortCreatedAtDesc,
+				}).Return([]*domain.Message{}, nil)
+				return mockService
+			}(),
+			query:          "?author=Arthur+Morgan&since=1899-04-01&until=1899-04-02T12:00:00Z&sort=-created_at",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failed to parse since",
+			messageService: new(mocks.MessageService),
+			query:          "?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown sort",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetAll", mock.Anything, domain.MessageQuery{Sort: "author"}).Return(nil, domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			query:          "?sort=author",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "filters with tree view",
+			messageService: new(mocks.MessageService),
+			query:          "?view=tree&sort=-created_at",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success with tree view",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetThreads", mock.Anything, domain.DefaultThreadDepth).Return([]*domain.Message{}, nil)
+				return mockService
+			}(),
+			query:          "?view=tree",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success with tree view and depth",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetThreads", mock.Anything, 1).Return([]*domain.Message{}, nil)
+				return mockService
+			}(),
+			query:          "?view=tree&depth=1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failed to parse depth",
+			messageService: new(mocks.MessageService),
+			query:          "?view=tree&depth=deep",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "depth out of range",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetThreads", mock.Anything, 100).Return(nil, domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			query:          "?view=tree&depth=100",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown view",
+			messageService: new(mocks.MessageService),
+			query:          "?view=graph",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success with search query",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Search", mock.Anything, "dutch plan").Return([]*domain.Message{}, nil)
+				return mockService
+			}(),
+			query:          "?q=dutch+plan&view=tree",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid search query",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Search", mock.Anything, "").Return(nil, domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			query:          "?q=",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failed to search messages",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Search", mock.Anything, "dutch").Return(nil, fmt.Errorf("failed to search messages"))
+				return mockService
+			}(),
+			query:          "?q=dutch",
+			expectedStatus: http.StatusInternalServerError,
+		},
 		{
 			name: "failed to get all messages",
 			messageService: func() MessageService {
 				mockService := new(mocks.MessageService)
-				mockService.On("GetAll", mock.Anything).Return(nil, fmt.Errorf("failed to get all messages"))
+				mockService.On("GetAll", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to get all messages"))
 				return mockService
 			}(),
 			expectedStatus: http.StatusInternalServerError,
@@ -111,7 +224,72 @@ func TestMessageHandler_GetAll(t *testing.T) {
 			router := gin.Default()
 			router.GET("/messages", handler.GetAll)
 
-			req, _ := http.NewRequest(http.MethodGet, "/messages", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/messages"+tt.query, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestMessageHandler_GetReplies(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		messageService MessageService
+		id             string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetReplies", mock.Anything, int64(1)).Return([]*domain.Message{}, nil)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failed to parse id",
+			messageService: new(mocks.MessageService),
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "message not found",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetReplies", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to get replies",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("GetReplies", mock.Anything, int64(1)).Return(nil, fmt.Errorf("failed to get replies"))
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewMessageHandler(logger, tt.messageService)
+
+			router := gin.Default()
+			router.GET("/messages/:id/replies", handler.GetReplies)
+
+			req, _ := http.NewRequest(http.MethodGet, "/messages/"+tt.id+"/replies", nil)
 			resp := httptest.NewRecorder()
 
 			router.ServeHTTP(resp, req)
@@ -128,6 +306,7 @@ func TestMessageHandler_Create(t *testing.T) {
 	type requestBody struct {
 		Author  string `json:"author"`
 		Content string `json:"content"`
+		Email   string `json:"email,omitempty"`
 	}
 	tests := []struct {
 		name           string
@@ -148,6 +327,32 @@ func TestMessageHandler_Create(t *testing.T) {
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name: "success with email",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+					return m.EmailHash == domain.HashEmail("john@example.com")
+				})).Return(int64(1), nil)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "Hello everybody!",
+				Email:   "john@example.com",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "failed to create message with invalid email",
+			messageService: new(mocks.MessageService),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "Hello everybody!",
+				Email:   "John <john@example.com>",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "failed to create message with empty request body",
 			messageService: func() MessageService {
@@ -158,6 +363,45 @@ func TestMessageHandler_Create(t *testing.T) {
 			requestBody:    requestBody{},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "failed to create message with content rejected by sanitization",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(int64(0), domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "<script>alert(1)</script>",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failed to create message in guestbook not allowing posting",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(int64(0), domain.ErrForbidden)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "Hello everybody!",
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "failed to create reply to unknown message",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(int64(0), domain.ErrNotFound)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "Hello everybody!",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "failed to create message with empty author",
 			messageService: func() MessageService {
@@ -184,6 +428,24 @@ func TestMessageHandler_Create(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "failed to create message with content too long",
+			messageService: new(mocks.MessageService),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: strings.Repeat("é", domain.MaxContentLength+1),
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failed to create message with body too large",
+			messageService: new(mocks.MessageService),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: strings.Repeat("<", maxMessageBodySize),
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,6 +471,124 @@ func TestMessageHandler_Create(t *testing.T) {
 	}
 }
 
+func TestMessageHandler_Create_Multipart(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	isMessage := mock.MatchedBy(func(m *domain.Message) bool {
+		return m.Author == "John Doe" && m.Message == "Look!" && m.ParentID != nil && *m.ParentID == 3
+	})
+	tests := []struct {
+		name           string
+		messageService MessageService
+		fields         map[string]string
+		image          []byte
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("CreateWithImage", mock.Anything, isMessage, mock.Anything).Return(int64(1), nil)
+				return mockService
+			}(),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!", "parent_id": "3"},
+			image:          []byte("image"),
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name: "success without image",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, isMessage).Return(int64(1), nil)
+				return mockService
+			}(),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!", "parent_id": "3"},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name:           "failed to create message with empty content",
+			messageService: new(mocks.MessageService),
+			fields:         map[string]string{"author": "John Doe"},
+			image:          []byte("image"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"content is empty"}`,
+		},
+		{
+			name:           "failed to create message with invalid parent id",
+			messageService: new(mocks.MessageService),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!", "parent_id": "x"},
+			image:          []byte("image"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid request"}`,
+		},
+		{
+			name: "failed to create message with unsupported image",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("CreateWithImage", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), domain.ErrUnsupportedMediaType)
+				return mockService
+			}(),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!"},
+			image:          []byte("<svg/>"),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"error":"attachment must be a JPEG, PNG or GIF image"}`,
+		},
+		{
+			name: "failed to create message with image too large",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("CreateWithImage", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), domain.ErrTooLarge)
+				return mockService
+			}(),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!"},
+			image:          []byte("image"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `{"error":"image too large"}`,
+		},
+		{
+			name:           "failed to create message with body too large",
+			messageService: new(mocks.MessageService),
+			fields:         map[string]string{"author": "John Doe", "content": "Look!"},
+			image:          make([]byte, domain.MaxAttachmentSize+maxMultipartMemory),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `{"error":"image too large"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewMessageHandler(logger, tt.messageService)
+
+			router := gin.Default()
+			router.POST("/messages", handler.Create)
+
+			var buf bytes.Buffer
+			w := multipart.NewWriter(&buf)
+			for name, value := range tt.fields {
+				w.WriteField(name, value)
+			}
+			if tt.image != nil {
+				part, _ := w.CreateFormFile("image", "photo.jpg")
+				part.Write(tt.image)
+			}
+			w.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, "/messages", &buf)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
 func TestMessageHandler_Update(t *testing.T) {
 	gin.DefaultWriter = io.Discard
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
@@ -236,6 +616,41 @@ func TestMessageHandler_Update(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "failed to update message with content rejected by sanitization",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Update", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "<script>alert(1)</script>",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failed to update message with content too long",
+			messageService: new(mocks.MessageService),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: strings.Repeat("a", domain.MaxContentLength+1),
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failed to update message not found",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Update", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			requestBody: requestBody{
+				Author:  "John Doe",
+				Content: "Hello everybody!",
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name: "failed to update message",
 			messageService: func() MessageService {
@@ -299,6 +714,16 @@ func TestMessageHandler_Delete(t *testing.T) {
 			id:             "abc",
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "failed to delete message not found",
+			messageService: func() MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Delete", mock.Anything, mock.AnythingOfType("int64")).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name: "failed to delete message",
 			messageService: func() MessageService {
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessagePreviewService interface {
+	Preview(context.Context, string) (string, error)
+}
+
+// PreviewHandler is the handler for the live preview of messages being written
+type PreviewHandler struct {
+	logger         *slog.Logger
+	previewService MessagePreviewService
+}
+
+// NewPreviewHandler returns a new PreviewHandler
+func NewPreviewHandler(logger *slog.Logger, previewService MessagePreviewService) *PreviewHandler {
+	return &PreviewHandler{
+		logger:         logger,
+		previewService: previewService,
+	}
+}
+
+// Preview returns the HTML the content of a message would be rendered to
+func (h *PreviewHandler) Preview(c *gin.Context) {
+	var req model.PreviewMessageRequest
+	if !bindMessageJSON(c, h.logger, &req) {
+		return
+	}
+	if req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errEmptyContent.Error()})
+		return
+	}
+	if utf8.RuneCountInString(req.Content) > domain.MaxContentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errContentTooLong.Error()})
+		return
+	}
+
+	html, err := h.previewService.Preview(c, req.Content)
+	if err != nil {
+		h.logger.Error("failed to preview message", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "content is empty after sanitization"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PreviewMessageResponse{ContentHTML: html})
+}
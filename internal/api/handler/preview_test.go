@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPreviewHandler_Preview(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		service        MessagePreviewService
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() MessagePreviewService {
+				mockService := new(mocks.MessagePreviewService)
+				mockService.On("Preview", mock.Anything, "**Hello**").Return("<p><strong>Hello</strong></p>\n", nil)
+				return mockService
+			}(),
+			body:           `{"content":"**Hello**"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"content_html":"<p><strong>Hello</strong></p>\n"}`,
+		},
+		{
+			name:           "invalid request",
+			service:        new(mocks.MessagePreviewService),
+			body:           `{"content":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid request"}`,
+		},
+		{
+			name:           "empty content",
+			service:        new(mocks.MessagePreviewService),
+			body:           `{"content":""}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"content is empty"}`,
+		},
+		{
+			name:           "content too long",
+			service:        new(mocks.MessagePreviewService),
+			body:           `{"content":"` + strings.Repeat("[", domain.MaxContentLength+1) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"content is too long"}`,
+		},
+		{
+			name:           "body too large",
+			service:        new(mocks.MessagePreviewService),
+			body:           `{"content":"` + strings.Repeat("[", maxMessageBodySize) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `{"error":"request too large"}`,
+		},
+		{
+			name: "content empty after sanitization",
+			service: func() MessagePreviewService {
+				mockService := new(mocks.MessagePreviewService)
+				mockService.On("Preview", mock.Anything, "<b></b>").Return("", domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			body:           `{"content":"<b></b>"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"content is empty after sanitization"}`,
+		},
+		{
+			name: "failed to preview message",
+			service: func() MessagePreviewService {
+				mockService := new(mocks.MessagePreviewService)
+				mockService.On("Preview", mock.Anything, "Hello").Return("", errors.New("boom"))
+				return mockService
+			}(),
+			body:           `{"content":"Hello"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPreviewHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.POST("/messages/preview", handler.Preview)
+
+			req, _ := http.NewRequest(http.MethodPost, "/messages/preview", strings.NewReader(tt.body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionService interface {
+	SetReaction(ctx context.Context, messageID int64, emoji string, on bool) ([]domain.ReactionCount, error)
+}
+
+// ReactionHandler is the handler for reactions to messages
+type ReactionHandler struct {
+	logger          *slog.Logger
+	reactionService ReactionService
+}
+
+// NewReactionHandler returns a new ReactionHandler
+func NewReactionHandler(logger *slog.Logger, reactionService ReactionService) *ReactionHandler {
+	return &ReactionHandler{
+		logger:          logger,
+		reactionService: reactionService,
+	}
+}
+
+// Create adds the visitor's reaction to a message
+func (h *ReactionHandler) Create(c *gin.Context) {
+	h.setReaction(c, true)
+}
+
+// Delete removes the visitor's reaction to a message
+func (h *ReactionHandler) Delete(c *gin.Context) {
+	h.setReaction(c, false)
+}
+
+func (h *ReactionHandler) setReaction(c *gin.Context, on bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	counts, err := h.reactionService.SetReaction(c, id, c.Param("emoji"), on)
+	if err != nil {
+		h.logger.Error("failed to set reaction", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported emoji"})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListReactionsResponse(counts))
+}
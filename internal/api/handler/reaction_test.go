@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionHandler(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name            string
+		reactionService ReactionService
+		method          string
+		id              string
+		emoji           string
+		expectedStatus  int
+		expectedBody    *model.ListReactionsResponse
+	}{
+		{
+			name: "add reaction",
+			reactionService: func() ReactionService {
+				mockService := new(mocks.ReactionService)
+				mockService.On("SetReaction", mock.Anything, int64(1), "👍", true).Return([]domain.ReactionCount{
+					{Emoji: "👍", Count: 1, Reacted: true},
+				}, nil)
+				return mockService
+			}(),
+			method:         http.MethodPost,
+			id:             "1",
+			emoji:          "👍",
+			expectedStatus: http.StatusOK,
+			expectedBody: &model.ListReactionsResponse{
+				Reactions: []model.ReactionCount{{Emoji: "👍", Count: 1, Reacted: true}},
+			},
+		},
+		{
+			name: "remove last reaction",
+			reactionService: func() ReactionService {
+				mockService := new(mocks.ReactionService)
+				mockService.On("SetReaction", mock.Anything, int64(1), "👍", false).Return(nil, nil)
+				return mockService
+			}(),
+			method:         http.MethodDelete,
+			id:             "1",
+			emoji:          "👍",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &model.ListReactionsResponse{Reactions: []model.ReactionCount{}},
+		},
+		{
+			name:            "failed to parse id",
+			reactionService: new(mocks.ReactionService),
+			method:          http.MethodPost,
+			id:              "abc",
+			emoji:           "👍",
+			expectedStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "unsupported emoji",
+			reactionService: func() ReactionService {
+				mockService := new(mocks.ReactionService)
+				mockService.On("SetReaction", mock.Anything, int64(1), "x", true).Return(nil, domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			method:         http.MethodPost,
+			id:             "1",
+			emoji:          "x",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "message not found",
+			reactionService: func() ReactionService {
+				mockService := new(mocks.ReactionService)
+				mockService.On("SetReaction", mock.Anything, int64(1), "👍", true).Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			method:         http.MethodPost,
+			id:             "1",
+			emoji:          "👍",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failed to set reaction",
+			reactionService: func() ReactionService {
+				mockService := new(mocks.ReactionService)
+				mockService.On("SetReaction", mock.Anything, int64(1), "👍", true).Return(nil, fmt.Errorf("failed to set reaction"))
+				return mockService
+			}(),
+			method:         http.MethodPost,
+			id:             "1",
+			emoji:          "👍",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewReactionHandler(logger, tt.reactionService)
+
+			router := gin.Default()
+			router.POST("/messages/:id/reactions/:emoji", handler.Create)
+			router.DELETE("/messages/:id/reactions/:emoji", handler.Delete)
+
+			req, _ := http.NewRequest(tt.method, "/messages/"+tt.id+"/reactions/"+url.PathEscape(tt.emoji), nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedBody != nil {
+				var got model.ListReactionsResponse
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+				assert.Equal(t, *tt.expectedBody, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageRevisionService interface {
+	GetRevisions(context.Context, int64) ([]*domain.MessageRevision, error)
+	Revert(context.Context, int64, int) error
+}
+
+// RevisionHandler is the handler for the edit history of messages
+type RevisionHandler struct {
+	logger          *slog.Logger
+	revisionService MessageRevisionService
+}
+
+// NewRevisionHandler returns a new RevisionHandler
+func NewRevisionHandler(logger *slog.Logger, revisionService MessageRevisionService) *RevisionHandler {
+	return &RevisionHandler{
+		logger:          logger,
+		revisionService: revisionService,
+	}
+}
+
+// GetAll returns the versions of a message, oldest first, with their diffs
+func (h *RevisionHandler) GetAll(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	entities, err := h.revisionService.GetRevisions(c, id)
+	if err != nil {
+		h.logger.Error("failed to get message revisions", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListMessageRevisionsResponse(entities))
+}
+
+// Revert updates a message back to a previous revision
+func (h *RevisionHandler) Revert(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		h.logger.Error("failed to parse revision", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	if err := h.revisionService.Revert(c, id, revision); err != nil {
+		h.logger.Error("failed to revert message", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message or revision not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
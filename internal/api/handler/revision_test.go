@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRevisionHandler_GetAll(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	createdAt := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	editedAt := time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		service        MessageRevisionService
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("GetRevisions", mock.Anything, int64(1)).Return([]*domain.MessageRevision{
+					{
+						MessageID: 1,
+						Revision:  1,
+						Author:    "Arthur Morgan",
+						Message:   "Hey, John!",
+						CreatedAt: createdAt,
+						Diff:      []domain.DiffSegment{{Op: domain.DiffInsert, Text: "Hey, John!"}},
+					},
+					{
+						MessageID: 1,
+						Revision:  2,
+						Author:    "Arthur Morgan",
+						Message:   "Hey, Dutch!",
+						CreatedAt: editedAt,
+						Current:   true,
+						Diff: []domain.DiffSegment{
+							{Op: domain.DiffEqual, Text: "Hey, "},
+							{Op: domain.DiffDelete, Text: "John!"},
+							{Op: domain.DiffInsert, Text: "Dutch!"},
+						},
+					},
+				}, nil)
+				return mockService
+			}(),
+			path:           "/messages/1/revisions",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"revisions":[` +
+				`{"revision":1,"author":"Arthur Morgan","content":"Hey, John!","content_type":"text/plain; charset=utf-8","created_at":"1899-04-01T12:00:00Z",` +
+				`"diff":[{"op":"insert","text":"Hey, John!"}]},` +
+				`{"revision":2,"author":"Arthur Morgan","content":"Hey, Dutch!","content_type":"text/plain; charset=utf-8","created_at":"1899-04-02T12:00:00Z","current":true,` +
+				`"diff":[{"op":"equal","text":"Hey, "},{"op":"delete","text":"John!"},{"op":"insert","text":"Dutch!"}]}]}`,
+		},
+		{
+			name:           "invalid id",
+			service:        new(mocks.MessageRevisionService),
+			path:           "/messages/one/revisions",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"empty of invalid id"}`,
+		},
+		{
+			name: "message not found",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("GetRevisions", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			path:           "/messages/1/revisions",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"message not found"}`,
+		},
+		{
+			name: "failed to get message revisions",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("GetRevisions", mock.Anything, int64(1)).Return(nil, errors.New("db down"))
+				return mockService
+			}(),
+			path:           "/messages/1/revisions",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRevisionHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.GET("/messages/:id/revisions", handler.GetAll)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestRevisionHandler_Revert(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		service        MessageRevisionService
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("Revert", mock.Anything, int64(1), 2).Return(nil)
+				return mockService
+			}(),
+			path:           "/messages/1/revisions/2/revert",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name:           "invalid id",
+			service:        new(mocks.MessageRevisionService),
+			path:           "/messages/one/revisions/2/revert",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"empty of invalid id"}`,
+		},
+		{
+			name:           "invalid revision",
+			service:        new(mocks.MessageRevisionService),
+			path:           "/messages/1/revisions/two/revert",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid revision"}`,
+		},
+		{
+			name: "revision not found",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("Revert", mock.Anything, int64(1), 2).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			path:           "/messages/1/revisions/2/revert",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"message or revision not found"}`,
+		},
+		{
+			name: "failed to revert message",
+			service: func() MessageRevisionService {
+				mockService := new(mocks.MessageRevisionService)
+				mockService.On("Revert", mock.Anything, int64(1), 2).Return(errors.New("db down"))
+				return mockService
+			}(),
+			path:           "/messages/1/revisions/2/revert",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRevisionHandler(logger, tt.service)
+
+			router := gin.Default()
+			router.POST("/messages/:id/revisions/:revision/revert", handler.Revert)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
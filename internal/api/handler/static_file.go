@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"bytes"
+	"guestbook-example/internal/api/middleware"
+	"io"
 	"log/slog"
 	"net/http"
 	"time"
@@ -8,6 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	indexFile  = "index.html"
+	widgetFile = "widget.js"
+)
+
 type StaticFileHandler struct {
 	logger   *slog.Logger
 	staticFS http.FileSystem
@@ -25,19 +33,43 @@ func (h *StaticFileHandler) Get(c *gin.Context) {
 	path := c.Request.URL.Path
 
 	// Try to serve the requested file from the static file system
-	if f, err := h.staticFS.Open(path[1:]); err == nil {
-		defer f.Close()
-		http.ServeContent(c.Writer, c.Request, path, time.Now(), f)
-		return
+	if name := path[1:]; name != indexFile {
+		if f, err := h.staticFS.Open(name); err == nil {
+			defer f.Close()
+			if name == widgetFile {
+				// The widget is loaded by other websites, which may require
+				// cross-origin resources to opt in.
+				c.Header("Cross-Origin-Resource-Policy", "cross-origin")
+			}
+			http.ServeContent(c.Writer, c.Request, path, time.Now(), f)
+			return
+		}
 	}
 
 	// fallback to index.html
-	f, err := h.staticFS.Open("index.html")
+	f, err := h.staticFS.Open(indexFile)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "index.html not found")
 		return
 	}
 	defer f.Close()
 
-	http.ServeContent(c.Writer, c.Request, "index.html", time.Now(), f)
+	nonce := middleware.CSPNonce(c)
+	if nonce == "" {
+		http.ServeContent(c.Writer, c.Request, indexFile, time.Now(), f)
+		return
+	}
+
+	// Tag the scripts with the CSP nonce of this request. The page differs
+	// per request from now on, so it must not be cached.
+	content, err := io.ReadAll(f)
+	if err != nil {
+		h.logger.Error("failed to read index.html", slog.String("error", err.Error()))
+		c.String(http.StatusInternalServerError, "index.html not found")
+		return
+	}
+	content = bytes.ReplaceAll(content, []byte("<script"), []byte(`<script nonce="`+nonce+`"`))
+
+	c.Header("Cache-Control", "no-store")
+	http.ServeContent(c.Writer, c.Request, indexFile, time.Now(), bytes.NewReader(content))
 }
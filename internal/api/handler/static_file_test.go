@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/api/middleware"
 )
 
 func TestNewStaticFileHandler(t *testing.T) {
@@ -34,11 +35,12 @@ func TestStaticFileHandler_Get(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name           string
-		path           string
-		setupMock      func(fs *mocks.FileSystem)
-		expectedStatus int
-		expectedBody   string
+		name            string
+		path            string
+		setupMock       func(fs *mocks.FileSystem)
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
 	}{
 		{
 			name: "Successfully serve file",
@@ -52,6 +54,20 @@ func TestStaticFileHandler_Get(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "body { color: red; }",
 		},
+		{
+			name: "Successfully serve widget",
+			path: "/widget.js",
+			setupMock: func(fs *mocks.FileSystem) {
+				mockFile := NewMockFile("(function () {})();")
+				mockFile.On("Close").Return(nil)
+				fs.On("Open", "widget.js").Return(mockFile, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "(function () {})();",
+			expectedHeaders: map[string]string{
+				"Cross-Origin-Resource-Policy": "cross-origin",
+			},
+		},
 		{
 			name: "File not found, fallback to index.html",
 			path: "/not-found.html",
@@ -96,6 +112,47 @@ func TestStaticFileHandler_Get(t *testing.T) {
 			// Assert
 			require.Equal(t, tt.expectedStatus, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			for k, v := range tt.expectedHeaders {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestStaticFileHandler_Get_CSPNonce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "index.html requested", path: "/index.html"},
+		{name: "fallback to index.html", path: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFS := mocks.NewFileSystem(t)
+			mockFile := NewMockFile(`<html><script src="script.js"></script></html>`)
+			mockFile.On("Close").Return(nil)
+			mockFS.On("Open", "index.html").Return(mockFile, nil)
+			if tt.path != "/index.html" {
+				mockFS.On("Open", tt.path[1:]).Return(nil, os.ErrNotExist)
+			}
+
+			router := gin.New()
+			router.Use(middleware.SecurityHeaders(middleware.DefaultSecurityHeadersConfig()))
+			router.NoRoute(NewStaticFileHandler(slog.Default(), mockFS).Get)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			policy := w.Header().Get("Content-Security-Policy")
+			nonce := policy[strings.Index(policy, "'nonce-")+len("'nonce-"):]
+			nonce = nonce[:strings.Index(nonce, "'")]
+			assert.Contains(t, w.Body.String(), `<script nonce="`+nonce+`" src="script.js">`)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
 		})
 	}
 }
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHeartbeatInterval is how often an idle stream sends a comment, so
+// proxies do not close the connection.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+type StreamService interface {
+	Subscribe(ctx context.Context, lastEventID int64) (<-chan domain.MessageEvent, bool, error)
+}
+
+// StreamHandler is the handler for the message stream
+type StreamHandler struct {
+	logger            *slog.Logger
+	streamService     StreamService
+	heartbeatInterval time.Duration
+}
+
+// NewStreamHandler returns a new StreamHandler
+func NewStreamHandler(logger *slog.Logger, streamService StreamService, heartbeatInterval time.Duration) *StreamHandler {
+	return &StreamHandler{
+		logger:            logger,
+		streamService:     streamService,
+		heartbeatInterval: heartbeatInterval,
+	}
+}
+
+// Stream sends the changes to the messages as Server-Sent Events, named after
+// the event type and carrying a model.MessageEventResponse. Clients that
+// reconnect with a Last-Event-ID header receive the events they missed, or a
+// reset event telling them to reload the messages if those are gone.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	var lastEventID int64
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			h.logger.Error("failed to parse last event id", slog.String("last_event_id", v))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return
+		}
+		lastEventID = id
+	}
+
+	// The subscription ends with the request.
+	events, resumed, err := h.streamService.Subscribe(c.Request.Context(), lastEventID)
+	if err != nil {
+		h.logger.Error("failed to subscribe to messages", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	// Ask clients to reconnect after 3s should the connection drop.
+	io.WriteString(c.Writer, "retry: 3000\n\n")
+	if !resumed {
+		c.Render(-1, sse.Event{Event: "reset", Data: gin.H{}})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatInt(ev.ID, 10),
+				Event: string(ev.Type),
+				Data:  model.NewMessageEventResponse(ev),
+			})
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// streamRecorder is a ResponseRecorder that gin can stream to.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r streamRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func events(evs ...domain.MessageEvent) <-chan domain.MessageEvent {
+	ch := make(chan domain.MessageEvent, len(evs))
+	for _, ev := range evs {
+		ch <- ev
+	}
+	close(ch)
+	return ch
+}
+
+func TestStreamHandler_Stream(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		streamService  func() *mocks.StreamService
+		lastEventID    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", mock.Anything, int64(0)).Return(events(
+					domain.MessageEvent{ID: 1, Type: domain.MessageCreated, MessageID: 7, Time: at,
+						Message: &domain.Message{ID: 7, Author: "Arthur Morgan", AvatarID: "576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c", Message: "<b>Hey</b>"}},
+					domain.MessageEvent{ID: 2, Type: domain.MessageDeleted, MessageID: 7, Time: at},
+				), true, nil)
+				return mockService
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "retry: 3000\n\n" +
+				"id:1\nevent:message.created\n" +
+				`data:{"type":"message.created","message_id":7,"message":{"id":7,"author":"Arthur Morgan",` +
+				`"avatar_url":"/api/v1/avatars/576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c.png",` +
+				`"content":"\u003cb\u003eHey\u003c/b\u003e","content_type":"text/plain; charset=utf-8","edited":false},"time":"1899-04-01T12:00:00Z"}` + "\n\n" +
+				"id:2\nevent:message.deleted\n" +
+				`data:{"type":"message.deleted","message_id":7,"time":"1899-04-01T12:00:00Z"}` + "\n\n",
+		},
+		{
+			name: "resumed",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", mock.Anything, int64(41)).Return(events(), true, nil)
+				return mockService
+			},
+			lastEventID:    "41",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\n",
+		},
+		{
+			name: "not resumed",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", mock.Anything, int64(42)).Return(events(), false, nil)
+				return mockService
+			},
+			lastEventID:    "42",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\nevent:reset\ndata:{}\n\n",
+		},
+		{
+			name: "invalid last event id",
+			streamService: func() *mocks.StreamService {
+				return new(mocks.StreamService)
+			},
+			lastEventID:    "forty-two",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid Last-Event-ID"}`,
+		},
+		{
+			name: "failed to subscribe",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", mock.Anything, int64(0)).Return(nil, false, fmt.Errorf("failed to subscribe to messages"))
+				return mockService
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamService := tt.streamService()
+			handler := NewStreamHandler(logger, streamService, DefaultHeartbeatInterval)
+
+			router := gin.Default()
+			router.GET("/messages/stream", handler.Stream)
+
+			req, _ := http.NewRequest(http.MethodGet, "/messages/stream", nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			resp := streamRecorder{httptest.NewRecorder()}
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, tt.expectedBody, resp.Body.String())
+			streamService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStreamHandler_Stream_Heartbeat(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+
+	open := make(chan domain.MessageEvent)
+	mockService := new(mocks.StreamService)
+	mockService.On("Subscribe", mock.Anything, int64(0)).Return((<-chan domain.MessageEvent)(open), true, nil)
+
+	handler := NewStreamHandler(slog.New(slog.NewJSONHandler(io.Discard, nil)), mockService, time.Millisecond)
+	router := gin.Default()
+	router.GET("/messages/stream", handler.Stream)
+
+	// The stream ends when the client goes away.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/messages/stream", nil)
+	resp := streamRecorder{httptest.NewRecorder()}
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, "text/event-stream;charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header().Get("Cache-Control"))
+	assert.Contains(t, resp.Body.String(), ": heartbeat\n\n")
+}
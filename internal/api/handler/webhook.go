@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookService interface {
+	GetAll(context.Context) ([]*domain.Webhook, error)
+	Create(context.Context, *domain.Webhook) (int64, error)
+	Update(context.Context, *domain.Webhook) error
+	Delete(context.Context, int64) error
+	GetDeliveries(ctx context.Context, webhookID int64) ([]*domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID int64) error
+}
+
+// WebhookHandler is the handler for the webhooks message events are
+// delivered to
+type WebhookHandler struct {
+	logger         *slog.Logger
+	webhookService WebhookService
+}
+
+// NewWebhookHandler returns a new WebhookHandler
+func NewWebhookHandler(logger *slog.Logger, webhookService WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		logger:         logger,
+		webhookService: webhookService,
+	}
+}
+
+// GetAll returns all webhooks, without their secrets
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	entities, err := h.webhookService.GetAll(c)
+	if err != nil {
+		h.logger.Error("failed to get all webhooks", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListWebhooksResponse(entities))
+}
+
+// Create subscribes a webhook to message events
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req model.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	id, err := h.webhookService.Create(c, req.ToEntity())
+	if err != nil {
+		h.logger.Error("failed to create webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url, secret or events"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// Update changes the url, secret, events or state of a webhook
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	var req model.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind json", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	err = h.webhookService.Update(c, req.ToEntity(id))
+	if err != nil {
+		h.logger.Error("failed to update webhook", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url, secret or events"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// Delete removes a webhook and its deliveries
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	err = h.webhookService.Delete(c, id)
+	if err != nil {
+		h.logger.Error("failed to delete webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// GetDeliveries returns the delivery log of a webhook, newest first
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+
+	entities, err := h.webhookService.GetDeliveries(c, id)
+	if err != nil {
+		h.logger.Error("failed to get webhook deliveries", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewListWebhookDeliveriesResponse(entities))
+}
+
+// Redeliver queues a delivered or dead-lettered delivery again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid id"})
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		h.logger.Error("failed to parse delivery id", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty of invalid delivery id"})
+		return
+	}
+
+	err = h.webhookService.Redeliver(c, id, deliveryID)
+	if err != nil {
+		h.logger.Error("failed to redeliver webhook delivery", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusConflict, gin.H{"error": "delivery is pending"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": deliveryID})
+}
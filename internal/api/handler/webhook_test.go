@@ -0,0 +1,399 @@
+package handler
+
+import (
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookHandler_GetAll(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("GetAll", mock.Anything).Return([]*domain.Webhook{
+					{ID: 1, URL: "https://hooks.example", Secret: "0123456789abcdef", EventTypes: []domain.MessageEventType{domain.MessageCreated}, Active: true},
+				}, nil)
+				return mockService
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"webhooks":[{"id":1,"url":"https://hooks.example","events":["message.created"],"active":true,"created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name: "failed to get all webhooks",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("GetAll", mock.Anything).Return(nil, fmt.Errorf("failed to get all webhooks"))
+				return mockService
+			}(),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.GET("/webhooks", handler.GetAll)
+
+			req, _ := http.NewRequest(http.MethodGet, "/webhooks", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestWebhookHandler_Create(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		requestBody    string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Create", mock.Anything, &domain.Webhook{
+					URL:        "https://hooks.example",
+					Secret:     "0123456789abcdef",
+					EventTypes: []domain.MessageEventType{domain.MessageCreated, domain.MessageDeleted},
+					Active:     true,
+				}).Return(int64(1), nil)
+				return mockService
+			}(),
+			requestBody:    `{"url":"https://hooks.example","secret":"0123456789abcdef","events":["message.created","message.deleted"]}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "inactive",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Create", mock.Anything, mock.MatchedBy(func(w *domain.Webhook) bool { return !w.Active })).Return(int64(1), nil)
+				return mockService
+			}(),
+			requestBody:    `{"url":"https://hooks.example","secret":"0123456789abcdef","events":["message.created"],"active":false}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "failed to bind json",
+			webhookService: new(mocks.WebhookService),
+			requestBody:    `{"url":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid webhook",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("%w: no event types", domain.ErrInvalidArgument))
+				return mockService
+			}(),
+			requestBody:    `{"url":"https://hooks.example","secret":"0123456789abcdef"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failed to create webhook",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create webhook"))
+				return mockService
+			}(),
+			requestBody:    `{"url":"https://hooks.example","secret":"0123456789abcdef","events":["message.created"]}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.POST("/webhooks", handler.Create)
+
+			req, _ := http.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.requestBody))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestWebhookHandler_Update(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		id             string
+		requestBody    string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Update", mock.Anything, &domain.Webhook{
+					ID:         1,
+					URL:        "https://hooks.example",
+					EventTypes: []domain.MessageEventType{domain.MessageCreated},
+					Active:     true,
+				}).Return(nil)
+				return mockService
+			}(),
+			id:             "1",
+			requestBody:    `{"url":"https://hooks.example","events":["message.created"]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid id",
+			webhookService: new(mocks.WebhookService),
+			id:             "abc",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "webhook not found",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "42",
+			requestBody:    `{"url":"https://hooks.example","events":["message.created"]}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "invalid webhook",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Update", mock.Anything, mock.Anything).Return(domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			id:             "1",
+			requestBody:    `{"url":"ftp://hooks.example","events":["message.created"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.PUT("/webhooks/:id", handler.Update)
+
+			req, _ := http.NewRequest(http.MethodPut, "/webhooks/"+tt.id, strings.NewReader(tt.requestBody))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestWebhookHandler_Delete(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		id             string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Delete", mock.Anything, int64(1)).Return(nil)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid id",
+			webhookService: new(mocks.WebhookService),
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "webhook not found",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Delete", mock.Anything, int64(42)).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "42",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.DELETE("/webhooks/:id", handler.Delete)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/webhooks/"+tt.id, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestWebhookHandler_GetDeliveries(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		id             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("GetDeliveries", mock.Anything, int64(1)).Return([]*domain.WebhookDelivery{
+					{ID: 3, WebhookID: 1, EventID: 5, EventType: domain.MessageDeleted, Payload: `{"id":5}`, Status: domain.WebhookDeliveryDead, Attempts: 8, LastStatusCode: 500, LastError: "unexpected status"},
+				}, nil)
+				return mockService
+			}(),
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deliveries":[{"id":3,"event_id":5,"event":"message.deleted","payload":{"id":5},"status":"dead","attempts":8,"last_status_code":500,"last_error":"unexpected status","created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name: "webhook not found",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("GetDeliveries", mock.Anything, int64(42)).Return(nil, domain.ErrNotFound)
+				return mockService
+			}(),
+			id:             "42",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"webhook not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.GET("/webhooks/:id/deliveries", handler.GetDeliveries)
+
+			req, _ := http.NewRequest(http.MethodGet, "/webhooks/"+tt.id+"/deliveries", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestWebhookHandler_Redeliver(t *testing.T) {
+	gin.DefaultWriter = io.Discard
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		webhookService WebhookService
+		path           string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Redeliver", mock.Anything, int64(1), int64(3)).Return(nil)
+				return mockService
+			}(),
+			path:           "/webhooks/1/deliveries/3/redeliver",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "invalid delivery id",
+			webhookService: new(mocks.WebhookService),
+			path:           "/webhooks/1/deliveries/abc/redeliver",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "delivery not found",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Redeliver", mock.Anything, int64(1), int64(42)).Return(domain.ErrNotFound)
+				return mockService
+			}(),
+			path:           "/webhooks/1/deliveries/42/redeliver",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "delivery pending",
+			webhookService: func() WebhookService {
+				mockService := new(mocks.WebhookService)
+				mockService.On("Redeliver", mock.Anything, int64(1), int64(3)).Return(domain.ErrInvalidArgument)
+				return mockService
+			}(),
+			path:           "/webhooks/1/deliveries/3/redeliver",
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewWebhookHandler(logger, tt.webhookService)
+
+			router := gin.Default()
+			router.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
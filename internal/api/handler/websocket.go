@@ -0,0 +1,376 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"guestbook-example/internal/api/middleware"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketWriteTimeout is how long writing a message to a client may take.
+const webSocketWriteTimeout = 10 * time.Second
+
+// errSlowClient ends connections that do not keep up with their messages.
+var errSlowClient = errors.New("client too slow")
+
+// WebSocketConfig configures the WebSocket API.
+type WebSocketConfig struct {
+	// AllowedOrigins are origins other than the server's own that may open a
+	// connection, e.g. "https://partner.example", in addition to the ones
+	// allowed by the allow list given to NewWebSocketHandler.
+	AllowedOrigins []string
+	// HeartbeatInterval is how often the server pings clients. Clients that
+	// do not answer within two intervals are disconnected.
+	HeartbeatInterval time.Duration
+	// SendBuffer is the number of messages a client can fall behind before it
+	// is disconnected.
+	SendBuffer int
+	// MaxMessageSize is the size limit of the messages sent by clients.
+	MaxMessageSize int64
+	// MaxSubscriptions is the number of guestbooks a connection can subscribe
+	// to.
+	MaxSubscriptions int
+}
+
+// DefaultWebSocketConfig returns the default WebSocket configuration.
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		SendBuffer:        64,
+		MaxMessageSize:    16 << 10,
+		MaxSubscriptions:  16,
+	}
+}
+
+// OriginAllowList decides whether origins that are not configured statically
+// may open a connection.
+type OriginAllowList interface {
+	IsAllowed(ctx context.Context, origin string) (bool, error)
+}
+
+// WebSocketHandler is the handler for the WebSocket API
+type WebSocketHandler struct {
+	logger           *slog.Logger
+	guestbookService GuestbookService
+	messageService   MessageService
+	streamService    StreamService
+	config           WebSocketConfig
+	upgrader         websocket.Upgrader
+	isAllowedOrigin  func(context.Context, string) bool
+}
+
+// NewWebSocketHandler returns a new WebSocketHandler. originAllowList, which
+// may be nil, allows origins besides config.AllowedOrigins.
+func NewWebSocketHandler(logger *slog.Logger, guestbookService GuestbookService, messageService MessageService, streamService StreamService, config WebSocketConfig, originAllowList OriginAllowList) *WebSocketHandler {
+	h := &WebSocketHandler{
+		logger:           logger,
+		guestbookService: guestbookService,
+		messageService:   messageService,
+		streamService:    streamService,
+		config:           config,
+		isAllowedOrigin:  middleware.OriginChecker(logger, config.AllowedOrigins, originAllowList),
+	}
+	h.upgrader = websocket.Upgrader{CheckOrigin: h.checkOrigin}
+	return h
+}
+
+// checkOrigin only lets the server's own and the allowed origins connect. As
+// browsers send cookies along with the handshake, any other site could
+// otherwise post messages on behalf of its visitors.
+func (h *WebSocketHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Not a browser.
+		return true
+	}
+	if h.isAllowedOrigin(r.Context(), origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Serve upgrades the request to a WebSocket connection, over which clients
+// exchange JSON model.WebSocketRequest and model.WebSocketResponse messages:
+// they subscribe to the events of guestbooks and post messages.
+func (h *WebSocketHandler) Serve(c *gin.Context) {
+	// Upgrade responds with an error itself.
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error("failed to upgrade connection", slog.String("error", err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithCancelCause(c.Request.Context())
+	wc := &webSocketConn{
+		h:      h,
+		conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		out:    make(chan model.WebSocketResponse, h.config.SendBuffer),
+		subs:   make(map[string]*webSocketSub),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wc.writeLoop()
+	}()
+	wc.readLoop()
+	<-done
+}
+
+// webSocketConn is a client connection. Its commands are handled in the
+// goroutine reading them, and a single goroutine writes to it.
+type webSocketConn struct {
+	h      *WebSocketHandler
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	out    chan model.WebSocketResponse
+
+	mu   sync.Mutex
+	subs map[string]*webSocketSub
+}
+
+// webSocketSub is the subscription of a connection to a guestbook.
+type webSocketSub struct {
+	cancel context.CancelFunc
+}
+
+// send queues a message for the client without blocking. A client whose
+// queue is full is disconnected. It reports whether the message was queued.
+func (wc *webSocketConn) send(resp model.WebSocketResponse) bool {
+	select {
+	case <-wc.ctx.Done():
+		return false
+	default:
+	}
+
+	select {
+	case wc.out <- resp:
+		return true
+	default:
+		wc.h.logger.Warn("disconnecting slow websocket client")
+		wc.cancel(errSlowClient)
+		return false
+	}
+}
+
+func (wc *webSocketConn) sendError(req model.WebSocketRequest, message string) {
+	wc.send(model.WebSocketResponse{Type: model.WebSocketError, Ref: req.Ref, Guestbook: req.Guestbook, Error: message})
+}
+
+func (wc *webSocketConn) readLoop() {
+	defer wc.cancel(nil)
+
+	timeout := 2 * wc.h.config.HeartbeatInterval
+	wc.conn.SetReadLimit(wc.h.config.MaxMessageSize)
+	wc.conn.SetReadDeadline(time.Now().Add(timeout))
+	wc.conn.SetPongHandler(func(string) error {
+		return wc.conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	for {
+		_, data, err := wc.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && wc.ctx.Err() == nil {
+				wc.h.logger.Error("failed to read websocket message", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		var req model.WebSocketRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			wc.h.logger.Error("failed to unmarshal websocket message", slog.String("error", err.Error()))
+			wc.sendError(req, "invalid request")
+			continue
+		}
+		wc.handle(req)
+	}
+}
+
+func (wc *webSocketConn) writeLoop() {
+	heartbeat := time.NewTicker(wc.h.config.HeartbeatInterval)
+	defer func() {
+		heartbeat.Stop()
+		// Unblocks the reading goroutine.
+		wc.conn.Close()
+	}()
+
+	for {
+		select {
+		case resp := <-wc.out:
+			wc.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+			if err := wc.conn.WriteJSON(resp); err != nil {
+				wc.cancel(err)
+				return
+			}
+		case <-heartbeat.C:
+			if err := wc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteTimeout)); err != nil {
+				wc.cancel(err)
+				return
+			}
+		case <-wc.ctx.Done():
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			if errors.Is(context.Cause(wc.ctx), errSlowClient) {
+				msg = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, errSlowClient.Error())
+			}
+			wc.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(webSocketWriteTimeout))
+			return
+		}
+	}
+}
+
+func (wc *webSocketConn) handle(req model.WebSocketRequest) {
+	switch req.Type {
+	case model.WebSocketSubscribe:
+		wc.subscribe(req)
+	case model.WebSocketUnsubscribe:
+		wc.unsubscribe(req)
+	case model.WebSocketPost:
+		wc.post(req)
+	default:
+		wc.h.logger.Error("failed to handle websocket message", slog.String("error", "unknown type "+req.Type))
+		wc.sendError(req, "invalid request")
+	}
+}
+
+// guestbook returns the guestbook a command applies to, or sends an error and
+// returns nil.
+func (wc *webSocketConn) guestbook(req model.WebSocketRequest) *domain.Guestbook {
+	slug := req.Guestbook
+	if slug == "" {
+		slug = domain.DefaultGuestbookSlug
+	}
+
+	g, err := wc.h.guestbookService.GetBySlug(wc.ctx, slug)
+	if err != nil {
+		wc.h.logger.Error("failed to get guestbook", slog.String("error", err.Error()))
+
+		if errors.Is(err, domain.ErrNotFound) {
+			wc.sendError(req, "guestbook not found")
+			return nil
+		}
+
+		wc.sendError(req, "internal server error")
+		return nil
+	}
+
+	return g
+}
+
+func (wc *webSocketConn) subscribe(req model.WebSocketRequest) {
+	g := wc.guestbook(req)
+	if g == nil {
+		return
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if _, ok := wc.subs[g.Slug]; ok {
+		wc.sendError(req, "already subscribed")
+		return
+	}
+	if len(wc.subs) >= wc.h.config.MaxSubscriptions {
+		wc.sendError(req, "too many subscriptions")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(domain.ContextWithGuestbook(wc.ctx, g))
+	events, resumed, err := wc.h.streamService.Subscribe(ctx, req.LastEventID)
+	if err != nil {
+		cancel()
+		wc.h.logger.Error("failed to subscribe to messages", slog.String("error", err.Error()))
+		wc.sendError(req, "internal server error")
+		return
+	}
+	sub := &webSocketSub{cancel: cancel}
+	wc.subs[g.Slug] = sub
+
+	wc.send(model.WebSocketResponse{Type: model.WebSocketSubscribed, Ref: req.Ref, Guestbook: g.Slug})
+	if !resumed {
+		wc.send(model.WebSocketResponse{Type: model.WebSocketReset, Guestbook: g.Slug})
+	}
+	go wc.forward(ctx, sub, g.Slug, events)
+}
+
+// forward sends the events of a subscription to the client until the
+// subscription is cancelled.
+func (wc *webSocketConn) forward(ctx context.Context, sub *webSocketSub, slug string, events <-chan domain.MessageEvent) {
+	for ev := range events {
+		ok := wc.send(model.WebSocketResponse{
+			Type:      model.WebSocketEvent,
+			Guestbook: slug,
+			EventID:   ev.ID,
+			Event:     model.NewMessageEventResponse(ev),
+		})
+		if !ok {
+			return
+		}
+	}
+
+	// The subscription was dropped while the connection is still open, so
+	// the client may subscribe again.
+	if ctx.Err() == nil {
+		wc.h.logger.Warn("websocket subscription ended", slog.String("guestbook", slug))
+		wc.mu.Lock()
+		if wc.subs[slug] == sub {
+			delete(wc.subs, slug)
+		}
+		wc.mu.Unlock()
+		wc.send(model.WebSocketResponse{Type: model.WebSocketUnsubscribed, Guestbook: slug})
+	}
+}
+
+func (wc *webSocketConn) unsubscribe(req model.WebSocketRequest) {
+	slug := req.Guestbook
+	if slug == "" {
+		slug = domain.DefaultGuestbookSlug
+	}
+
+	wc.mu.Lock()
+	sub, ok := wc.subs[slug]
+	delete(wc.subs, slug)
+	wc.mu.Unlock()
+	if !ok {
+		wc.sendError(req, "not subscribed")
+		return
+	}
+	sub.cancel()
+
+	wc.send(model.WebSocketResponse{Type: model.WebSocketUnsubscribed, Ref: req.Ref, Guestbook: slug})
+}
+
+func (wc *webSocketConn) post(req model.WebSocketRequest) {
+	if req.Message == nil {
+		wc.h.logger.Error("failed to create message", slog.String("error", "no message"))
+		wc.sendError(req, "invalid request")
+		return
+	}
+	g := wc.guestbook(req)
+	if g == nil {
+		return
+	}
+
+	id, err := createMessage(domain.ContextWithGuestbook(wc.ctx, g), wc.h.messageService, req.Message)
+	if err != nil {
+		wc.h.logger.Error("failed to create message", slog.String("error", err.Error()))
+		_, message := createMessageError(err)
+		wc.sendError(req, message)
+		return
+	}
+
+	wc.send(model.WebSocketResponse{Type: model.WebSocketCreated, Ref: req.Ref, Guestbook: g.Slug, MessageID: id})
+}
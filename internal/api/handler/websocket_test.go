@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/api/handler/mocks"
+	"guestbook-example/internal/api/model"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newWebSocketServer serves a WebSocketHandler on /ws.
+func newWebSocketServer(t *testing.T, h *WebSocketHandler) *httptest.Server {
+	t.Helper()
+	gin.DefaultWriter = io.Discard
+	router := gin.Default()
+	router.GET("/ws", h.Serve)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func TestWebSocketHandler_Serve(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	wedding := &domain.Guestbook{ID: 2, Slug: "wedding", Settings: domain.GuestbookSettings{AllowPosting: true}}
+	defaultGuestbook := &domain.Guestbook{ID: 1, Slug: domain.DefaultGuestbookSlug, Settings: domain.GuestbookSettings{AllowPosting: true}}
+	guestbookService := func() *mocks.GuestbookService {
+		mockService := new(mocks.GuestbookService)
+		mockService.On("GetBySlug", mock.Anything, "wedding").Return(wedding, nil)
+		mockService.On("GetBySlug", mock.Anything, domain.DefaultGuestbookSlug).Return(defaultGuestbook, nil)
+		mockService.On("GetBySlug", mock.Anything, "unknown").Return(nil, domain.ErrNotFound)
+		return mockService
+	}
+	scopedTo := func(g *domain.Guestbook) any {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			got, ok := domain.GuestbookFromContext(ctx)
+			return ok && got == g
+		})
+	}
+	// open is a subscription that stays open.
+	open := func(evs ...domain.MessageEvent) <-chan domain.MessageEvent {
+		ch := make(chan domain.MessageEvent, len(evs))
+		for _, ev := range evs {
+			ch <- ev
+		}
+		return ch
+	}
+
+	tests := []struct {
+		name           string
+		messageService func() *mocks.MessageService
+		streamService  func() *mocks.StreamService
+		requests       []string
+		expected       []model.WebSocketResponse
+	}{
+		{
+			name: "subscribe",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", scopedTo(wedding), int64(41)).Return(open(
+					domain.MessageEvent{ID: 42, Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 7, Time: at},
+				), true, nil)
+				return mockService
+			},
+			requests: []string{`{"type":"subscribe","ref":"1","guestbook":"wedding","last_event_id":41}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketSubscribed, Ref: "1", Guestbook: "wedding"},
+				{Type: model.WebSocketEvent, Guestbook: "wedding", EventID: 42, Event: &model.MessageEventResponse{Type: "message.deleted", MessageID: 7, Time: at}},
+			},
+		},
+		{
+			name: "subscribe not resumed",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", scopedTo(defaultGuestbook), int64(42)).Return(open(), false, nil)
+				return mockService
+			},
+			requests: []string{`{"type":"subscribe","last_event_id":42}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketSubscribed, Guestbook: domain.DefaultGuestbookSlug},
+				{Type: model.WebSocketReset, Guestbook: domain.DefaultGuestbookSlug},
+			},
+		},
+		{
+			name: "subscribe twice",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", scopedTo(wedding), int64(0)).Return(open(), true, nil).Once()
+				return mockService
+			},
+			requests: []string{
+				`{"type":"subscribe","ref":"1","guestbook":"wedding"}`,
+				`{"type":"subscribe","ref":"2","guestbook":"wedding"}`,
+			},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketSubscribed, Ref: "1", Guestbook: "wedding"},
+				{Type: model.WebSocketError, Ref: "2", Guestbook: "wedding", Error: "already subscribed"},
+			},
+		},
+		{
+			name: "subscribe to unknown guestbook",
+			requests: []string{
+				`{"type":"subscribe","ref":"1","guestbook":"unknown"}`,
+			},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketError, Ref: "1", Guestbook: "unknown", Error: "guestbook not found"},
+			},
+		},
+		{
+			name: "failed to subscribe",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", mock.Anything, int64(0)).Return(nil, false, fmt.Errorf("failed to subscribe to messages"))
+				return mockService
+			},
+			requests: []string{`{"type":"subscribe","ref":"1","guestbook":"wedding"}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketError, Ref: "1", Guestbook: "wedding", Error: "internal server error"},
+			},
+		},
+		{
+			name: "unsubscribe",
+			streamService: func() *mocks.StreamService {
+				mockService := new(mocks.StreamService)
+				mockService.On("Subscribe", scopedTo(wedding), int64(0)).Return(open(), true, nil)
+				return mockService
+			},
+			requests: []string{
+				`{"type":"subscribe","ref":"1","guestbook":"wedding"}`,
+				`{"type":"unsubscribe","ref":"2","guestbook":"wedding"}`,
+				`{"type":"unsubscribe","ref":"3","guestbook":"wedding"}`,
+			},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketSubscribed, Ref: "1", Guestbook: "wedding"},
+				{Type: model.WebSocketUnsubscribed, Ref: "2", Guestbook: "wedding"},
+				{Type: model.WebSocketError, Ref: "3", Guestbook: "wedding", Error: "not subscribed"},
+			},
+		},
+		{
+			name: "post",
+			messageService: func() *mocks.MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", scopedTo(wedding), &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}).Return(int64(7), nil)
+				return mockService
+			},
+			requests: []string{`{"type":"post","ref":"1","guestbook":"wedding","message":{"author":"Arthur Morgan","content":"Hey, Dutch!"}}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketCreated, Ref: "1", Guestbook: "wedding", MessageID: 7},
+			},
+		},
+		{
+			name: "post with empty author",
+			requests: []string{
+				`{"type":"post","ref":"1","message":{"content":"Hey, Dutch!"}}`,
+				`{"type":"post","ref":"2"}`,
+			},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketError, Ref: "1", Error: "author is empty"},
+				{Type: model.WebSocketError, Ref: "2", Error: "invalid request"},
+			},
+		},
+		{
+			name: "post to guestbook not allowing posting",
+			messageService: func() *mocks.MessageService {
+				mockService := new(mocks.MessageService)
+				mockService.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create message: %w", domain.ErrForbidden))
+				return mockService
+			},
+			requests: []string{`{"type":"post","ref":"1","guestbook":"wedding","message":{"author":"Arthur Morgan","content":"Hey, Dutch!"}}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketError, Ref: "1", Guestbook: "wedding", Error: "posting is disabled for this guestbook"},
+			},
+		},
+		{
+			name:     "invalid request",
+			requests: []string{`{"type":`, `{"type":"dance","ref":"2"}`},
+			expected: []model.WebSocketResponse{
+				{Type: model.WebSocketError, Error: "invalid request"},
+				{Type: model.WebSocketError, Ref: "2", Error: "invalid request"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageService, streamService := new(mocks.MessageService), new(mocks.StreamService)
+			if tt.messageService != nil {
+				messageService = tt.messageService()
+			}
+			if tt.streamService != nil {
+				streamService = tt.streamService()
+			}
+			h := NewWebSocketHandler(logger, guestbookService(), messageService, streamService, DefaultWebSocketConfig(), nil)
+			conn, _, err := dialWebSocket(t, newWebSocketServer(t, h), nil)
+			if err != nil {
+				t.Fatalf("failed to dial, got error: %v", err)
+			}
+
+			for _, req := range tt.requests {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+					t.Fatalf("failed to write request, got error: %v", err)
+				}
+			}
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			for _, expected := range tt.expected {
+				var resp model.WebSocketResponse
+				if err := conn.ReadJSON(&resp); err != nil {
+					t.Fatalf("failed to read response, got error: %v", err)
+				}
+				assert.Equal(t, expected, resp)
+			}
+
+			messageService.AssertExpectations(t)
+			streamService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebSocketHandler_Serve_Origin(t *testing.T) {
+	config := DefaultWebSocketConfig()
+	config.AllowedOrigins = []string{"https://partner.example"}
+	allowList := new(mocks.OriginAllowList)
+	allowList.On("IsAllowed", mock.Anything, "https://db.example").Return(true, nil)
+	allowList.On("IsAllowed", mock.Anything, "https://broken.example").Return(false, errors.New("db error"))
+	allowList.On("IsAllowed", mock.Anything, mock.Anything).Return(false, nil)
+	h := NewWebSocketHandler(slog.New(slog.NewJSONHandler(io.Discard, nil)), new(mocks.GuestbookService), new(mocks.MessageService), new(mocks.StreamService), config, allowList)
+	server := newWebSocketServer(t, h)
+
+	tests := []struct {
+		name    string
+		origin  string
+		wantErr bool
+	}{
+		{name: "no origin"},
+		{name: "same origin", origin: server.URL},
+		{name: "allowed origin", origin: "https://partner.example"},
+		{name: "origin allowed by allow list", origin: "https://db.example"},
+		{name: "allow list error", origin: "https://broken.example", wantErr: true},
+		{name: "other origin", origin: "https://evil.example", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			_, resp, err := dialWebSocket(t, server, header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Dial() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandler_Serve_Heartbeat(t *testing.T) {
+	config := DefaultWebSocketConfig()
+	config.HeartbeatInterval = 10 * time.Millisecond
+	h := NewWebSocketHandler(slog.New(slog.NewJSONHandler(io.Discard, nil)), new(mocks.GuestbookService), new(mocks.MessageService), new(mocks.StreamService), config, nil)
+	conn, _, err := dialWebSocket(t, newWebSocketServer(t, h), nil)
+	if err != nil {
+		t.Fatalf("failed to dial, got error: %v", err)
+	}
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// Control messages are handled while reading.
+	go conn.ReadMessage()
+
+	select {
+	case <-pinged:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no ping received")
+	}
+}
+
+func TestWebSocketConn_SendSlowClient(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	wc := &webSocketConn{
+		h:      &WebSocketHandler{logger: slog.New(slog.NewJSONHandler(io.Discard, nil))},
+		ctx:    ctx,
+		cancel: cancel,
+		out:    make(chan model.WebSocketResponse, 1),
+	}
+
+	if !wc.send(model.WebSocketResponse{Type: model.WebSocketEvent}) {
+		t.Fatalf("send() = false, want true")
+	}
+	// The client has not read the first message yet.
+	if wc.send(model.WebSocketResponse{Type: model.WebSocketEvent}) {
+		t.Fatalf("send() = true, want false")
+	}
+	if !errors.Is(context.Cause(ctx), errSlowClient) {
+		t.Errorf("cause = %v, want %v", context.Cause(ctx), errSlowClient)
+	}
+	if wc.send(model.WebSocketResponse{Type: model.WebSocketEvent}) {
+		t.Errorf("send() after disconnect = true, want false")
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"guestbook-example/internal/domain"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminActor is the actor of the changes made by requests carrying the admin
+// token, as recorded in the audit log.
+const AdminActor = "admin"
+
+// AdminAuth returns a middleware only letting requests through that carry
+// the admin token as bearer token, which act as AdminActor. An empty token
+// rejects every request.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(domain.ContextWithActor(c.Request.Context(), AdminActor))
+		c.Next()
+	}
+}
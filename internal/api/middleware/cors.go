@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OriginAllowList decides whether origins that are not configured statically
+// are allowed, e.g. from a list stored in the database.
+type OriginAllowList interface {
+	IsAllowed(ctx context.Context, origin string) (bool, error)
+}
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the API. "*" allows
+	// every origin, without credentials.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in cross-origin requests.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in cross-origin
+	// requests.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers readable by cross-origin
+	// callers.
+	ExposedHeaders []string
+	// AllowCredentials allows cross-origin requests with cookies from the
+	// origins that are listed, rather than allowed by "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns the default CORS configuration, which allows no
+// origin until configured.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-CSRF-Token"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// CORS returns a middleware implementing the CORS protocol for the origins
+// allowed by cfg or by allowList, which may be nil. Requests from other
+// origins are served without CORS headers, so browsers do not expose the
+// response to the caller; their preflight requests are rejected.
+func CORS(logger *slog.Logger, cfg CORSConfig, allowList OriginAllowList) gin.HandlerFunc {
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	lowerAllowedHeaders := make([]string, len(cfg.AllowedHeaders))
+	for i, h := range cfg.AllowedHeaders {
+		lowerAllowedHeaders[i] = strings.ToLower(h)
+	}
+
+	isListed := OriginChecker(logger, cfg.AllowedOrigins, allowList)
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+
+	// allowOrigin returns the Access-Control-Allow-Origin of a request from
+	// origin, or an empty string if the origin is not allowed.
+	allowOrigin := func(c *gin.Context, origin string) string {
+		switch {
+		case isListed(c, origin):
+			return origin
+		case wildcard:
+			return "*"
+		default:
+			return ""
+		}
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		allowedOrigin := allowOrigin(c, origin)
+		if allowedOrigin == "" {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// Listed origins are echoed rather than "*", which browsers refuse
+		// for credentialed requests. Credentials are never allowed to every
+		// origin, as any site could then read the responses to its visitors'
+		// cookie-authenticated requests.
+		h.Set("Access-Control-Allow-Origin", allowedOrigin)
+		if cfg.AllowCredentials && allowedOrigin != "*" {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposedHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(cfg.AllowedMethods, c.GetHeader("Access-Control-Request-Method")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(c.GetHeader("Access-Control-Request-Headers"), ",") {
+			header = strings.ToLower(strings.TrimSpace(header))
+			if header != "" && !slices.Contains(lowerAllowedHeaders, header) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+
+		h.Set("Access-Control-Allow-Methods", allowedMethods)
+		if allowedHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", allowedHeaders)
+		}
+		if cfg.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// OriginChecker returns a function reporting whether an origin is one of
+// origins or is allowed by allowList, which may be nil. The CORS, CSRF and
+// WebSocket checks share it, so an origin allowed at runtime is allowed by
+// all of them. Errors of allowList are logged and deny the origin.
+func OriginChecker(logger *slog.Logger, origins []string, allowList OriginAllowList) func(context.Context, string) bool {
+	return func(ctx context.Context, origin string) bool {
+		if slices.Contains(origins, origin) {
+			return true
+		}
+		if allowList == nil {
+			return false
+		}
+		allowed, err := allowList.IsAllowed(ctx, origin)
+		if err != nil {
+			logger.Error("failed to check origin", slog.String("error", err.Error()))
+			return false
+		}
+		return allowed
+	}
+}
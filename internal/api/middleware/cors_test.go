@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"guestbook-example/internal/api/middleware/mocks"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://static.example"}
+	cfg.AllowCredentials = true
+	cfg.ExposedHeaders = []string{"Location"}
+	cfg.MaxAge = time.Hour
+
+	tests := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		allowList      func() OriginAllowList
+		expectedStatus int
+		wantHeaders    map[string]string
+	}{
+		{
+			name:           "same-origin request",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			wantHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:           "configured origin",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Origin": "https://static.example"},
+			expectedStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://static.example",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "Location",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:    "origin allowed by allow list",
+			method:  http.MethodPost,
+			headers: map[string]string{"Origin": "https://db.example"},
+			allowList: func() OriginAllowList {
+				m := new(mocks.OriginAllowList)
+				m.On("IsAllowed", mock.Anything, "https://db.example").Return(true, nil)
+				return m
+			},
+			expectedStatus: http.StatusOK,
+			wantHeaders:    map[string]string{"Access-Control-Allow-Origin": "https://db.example"},
+		},
+		{
+			name:    "unknown origin",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://evil.example"},
+			allowList: func() OriginAllowList {
+				m := new(mocks.OriginAllowList)
+				m.On("IsAllowed", mock.Anything, "https://evil.example").Return(false, nil)
+				return m
+			},
+			expectedStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:    "allow list failure",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://db.example"},
+			allowList: func() OriginAllowList {
+				m := new(mocks.OriginAllowList)
+				m.On("IsAllowed", mock.Anything, "https://db.example").Return(false, errors.New("db down"))
+				return m
+			},
+			expectedStatus: http.StatusOK,
+			wantHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://static.example",
+				"Access-Control-Request-Method":  http.MethodDelete,
+				"Access-Control-Request-Headers": "content-type, x-csrf-token",
+			},
+			expectedStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://static.example",
+				"Access-Control-Allow-Methods":     "GET, POST, PUT, DELETE",
+				"Access-Control-Allow-Headers":     "Content-Type, Authorization, X-CSRF-Token",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "3600",
+			},
+		},
+		{
+			name:   "preflight with disallowed method",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://static.example",
+				"Access-Control-Request-Method": http.MethodPatch,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "preflight with disallowed header",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://static.example",
+				"Access-Control-Request-Method":  http.MethodPut,
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "preflight from unknown origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example",
+				"Access-Control-Request-Method": http.MethodPut,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var allowList OriginAllowList
+			if tt.allowList != nil {
+				allowList = tt.allowList()
+			}
+
+			router := gin.New()
+			router.Use(CORS(logger, cfg, allowList))
+			router.Any("/api/v1/messages", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/api/v1/messages", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCORS_WildcardOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"*", "https://static.example"}
+	cfg.AllowCredentials = true
+
+	router := gin.New()
+	router.Use(CORS(slog.New(slog.NewJSONHandler(io.Discard, nil)), cfg, nil))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{origin: "https://anyone.example", wantOrigin: "*"},
+		{origin: "https://static.example", wantOrigin: "https://static.example", wantCredentials: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantCredentials, w.Header().Get("Access-Control-Allow-Credentials"))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"guestbook-example/internal/api/middleware/mocks"
+)
+
+const testCSRFToken = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func newCSRFRouter(cfg CSRFConfig, allowList OriginAllowList) *gin.Engine {
+	router := gin.New()
+	router.Use(CSRF(slog.New(slog.NewJSONHandler(io.Discard, nil)), cfg, allowList))
+	router.GET("/api/v1/messages", func(c *gin.Context) {
+		c.String(http.StatusOK, CSRFToken(c))
+	})
+	router.POST("/api/v1/messages", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.DELETE("/api/v1/messages/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/csp-reports", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := DefaultCSRFConfig()
+	cfg.TrustedOrigins = []string{"https://partner.example"}
+
+	allowList := new(mocks.OriginAllowList)
+	allowList.On("IsAllowed", mock.Anything, "https://db.example").Return(true, nil)
+	allowList.On("IsAllowed", mock.Anything, "https://broken.example").Return(false, errors.New("db error"))
+	allowList.On("IsAllowed", mock.Anything, mock.Anything).Return(false, nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		cookie         string
+		expectedStatus int
+	}{
+		{
+			name:           "safe method",
+			method:         http.MethodGet,
+			path:           "/api/v1/messages",
+			cookie:         "other",
+			headers:        map[string]string{"Sec-Fetch-Site": "cross-site"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid token",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "http://example.com"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "valid token from trusted origin",
+			method:         http.MethodDelete,
+			path:           "/api/v1/messages/1",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "https://partner.example"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid token from origin allowed by allow list",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "https://db.example"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "allow list error",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "https://broken.example"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing token",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "mismatching token",
+			method:         http.MethodDelete,
+			path:           "/api/v1/messages/1",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "cross-origin request",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "https://evil.example"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "opaque origin",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Origin": "null"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "cross-site fetch metadata",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"X-CSRF-Token": testCSRFToken, "Sec-Fetch-Site": "cross-site"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "bearer token client",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			cookie:         testCSRFToken,
+			headers:        map[string]string{"Authorization": "Bearer abc", "Origin": "https://evil.example"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "request without cookies",
+			method:         http.MethodPost,
+			path:           "/api/v1/messages",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "exempt path",
+			method:         http.MethodPost,
+			path:           "/api/v1/csp-reports",
+			cookie:         testCSRFToken,
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newCSRFRouter(cfg, allowList)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: cfg.CookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCSRF_IssuesToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := DefaultCSRFConfig()
+	cfg.SecureCookie = true
+	router := newCSRFRouter(cfg, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/messages", nil))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, cfg.CookieName, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	assert.Equal(t, cookies[0].Value, w.Body.String())
+
+	// An existing valid token is kept.
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/messages", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Result().Cookies())
+	assert.Equal(t, cookies[0].Value, w.Body.String())
+}
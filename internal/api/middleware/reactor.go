@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReactorConfig configures the reactor middleware.
+type ReactorConfig struct {
+	// CookieName is the name of the cookie identifying the visitor.
+	CookieName string
+	// SecureCookie sets the Secure attribute on the cookie.
+	SecureCookie bool
+	// MaxAge is how long the cookie is kept.
+	MaxAge time.Duration
+}
+
+// DefaultReactorConfig returns the default reactor configuration.
+func DefaultReactorConfig() ReactorConfig {
+	return ReactorConfig{
+		CookieName: "reactor_id",
+		MaxAge:     365 * 24 * time.Hour,
+	}
+}
+
+// Reactor returns a middleware identifying the visitor reacting to messages,
+// available through domain.ReactorFromContext.
+//
+// Visitors are identified by a random ID kept in a cookie, which is set on
+// their first request. Clients that do not keep cookies, such as the
+// embedded widget, are identified by a fingerprint of their IP address and
+// user agent instead, which visitors sharing both cannot be told apart by.
+func Reactor(cfg ReactorConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reactor string
+		if id, err := c.Cookie(cfg.CookieName); err == nil && validReactorID(id) {
+			reactor = "c:" + id
+		} else {
+			id, err := newReactorID()
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to generate reactor id: %w", err))
+				return
+			}
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     cfg.CookieName,
+				Value:    id,
+				Path:     "/",
+				MaxAge:   int(cfg.MaxAge.Seconds()),
+				HttpOnly: true,
+				Secure:   cfg.SecureCookie,
+				SameSite: http.SameSiteLaxMode,
+			})
+			reactor = "f:" + reactorFingerprint(c)
+		}
+
+		c.Request = c.Request.WithContext(domain.ContextWithReactor(c.Request.Context(), reactor))
+		c.Next()
+	}
+}
+
+func reactorFingerprint(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.ClientIP() + "\x00" + c.Request.UserAgent()))
+	return hex.EncodeToString(sum[:16])
+}
+
+func newReactorID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validReactorID(id string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil && len(b) == 16
+}
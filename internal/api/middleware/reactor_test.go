@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"guestbook-example/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReactorRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(Reactor(DefaultReactorConfig()))
+	router.GET("/", func(c *gin.Context) {
+		reactor, _ := domain.ReactorFromContext(c.Request.Context())
+		c.String(http.StatusOK, reactor)
+	})
+	return router
+}
+
+func TestReactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newReactorRouter()
+
+	t.Run("identifies visitors by cookie", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "reactor_id", Value: "AAAAAAAAAAAAAAAAAAAAAA"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "c:AAAAAAAAAAAAAAAAAAAAAA", w.Body.String())
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("fingerprints visitors without cookie and sets one", func(t *testing.T) {
+		fingerprint := func(ua string) (string, []*http.Cookie) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("User-Agent", ua)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Body.String(), w.Result().Cookies()
+		}
+
+		first, cookies := fingerprint("Firefox")
+		second, _ := fingerprint("Firefox")
+		other, _ := fingerprint("Chrome")
+
+		assert.True(t, strings.HasPrefix(first, "f:"))
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, other)
+
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "reactor_id", cookies[0].Name)
+		assert.True(t, validReactorID(cookies[0].Value))
+		assert.True(t, cookies[0].HttpOnly)
+	})
+
+	t.Run("ignores invalid cookie", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "reactor_id", Value: "forged"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.True(t, strings.HasPrefix(w.Body.String(), "f:"))
+	})
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header carrying the ID of a request.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest request ID kept from a request header.
+const maxRequestIDLength = 64
+
+// RequestID returns a middleware identifying every request, available through
+// domain.RequestIDFromContext and sent back in the X-Request-ID response
+// header. The ID a proxy set in the X-Request-ID request header is kept if it
+// is made of at most 64 letters, digits, dots, dashes and underscores;
+// otherwise a random ID is generated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to generate request id: %w", err))
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(domain.ContextWithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"guestbook-example/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		id, _ := domain.RequestIDFromContext(c.Request.Context())
+		c.String(http.StatusOK, id)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "generated"},
+		{name: "from proxy", header: "3f1c2a9e-7b1d-4e5f.9_0", keep: true},
+		{name: "invalid characters", header: "id\r\nSet-Cookie: x"},
+		{name: "too long", header: strings.Repeat("a", 65)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Body.String()
+			assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+			if tt.keep {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+		})
+	}
+
+	// Generated IDs differ.
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEqual(t, first.Body.String(), second.Body.String())
+}
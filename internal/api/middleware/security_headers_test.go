@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		cfg           SecurityHeadersConfig
+		wantHeaders   map[string]string
+		absentHeaders []string
+	}{
+		{
+			name: "default config",
+			cfg:  DefaultSecurityHeadersConfig(),
+			wantHeaders: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"X-Content-Type-Options":    "nosniff",
+			},
+			absentHeaders: []string{"Content-Security-Policy-Report-Only"},
+		},
+		{
+			name: "report only policy",
+			cfg: SecurityHeadersConfig{
+				ContentSecurityPolicy: "default-src 'self'",
+				CSPReportOnly:         true,
+			},
+			wantHeaders: map[string]string{
+				"Content-Security-Policy-Report-Only": "default-src 'self'",
+			},
+			absentHeaders: []string{
+				"Content-Security-Policy",
+				"Strict-Transport-Security",
+				"X-Frame-Options",
+				"Referrer-Policy",
+				"X-Content-Type-Options",
+			},
+		},
+		{
+			name: "report uri is appended",
+			cfg: SecurityHeadersConfig{
+				ContentSecurityPolicy: "default-src 'self';",
+				CSPReportURI:          "/api/v1/csp-reports",
+				HSTSMaxAge:            time.Hour,
+			},
+			wantHeaders: map[string]string{
+				"Content-Security-Policy":   "default-src 'self'; report-uri /api/v1/csp-reports",
+				"Strict-Transport-Security": "max-age=3600",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(SecurityHeaders(tt.cfg))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+			for _, k := range tt.absentHeaders {
+				assert.Empty(t, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders_Nonce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var nonces []string
+	router := gin.New()
+	router.Use(SecurityHeaders(DefaultSecurityHeadersConfig()))
+	router.GET("/", func(c *gin.Context) {
+		nonces = append(nonces, CSPNonce(c))
+		c.Status(http.StatusOK)
+	})
+
+	var policies []string
+	for range 2 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		policies = append(policies, w.Header().Get("Content-Security-Policy"))
+	}
+
+	require.Len(t, nonces, 2)
+	assert.NotEmpty(t, nonces[0])
+	assert.NotEqual(t, nonces[0], nonces[1], "nonce must differ per request")
+	for i, p := range policies {
+		assert.Contains(t, p, "script-src 'nonce-"+nonces[i]+"'")
+		assert.NotContains(t, p, NoncePlaceholder)
+		assert.True(t, strings.HasSuffix(p, "report-uri /api/v1/csp-reports"))
+	}
+}
+
+func TestCSPNonce_WithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.Empty(t, CSPNonce(c))
+}
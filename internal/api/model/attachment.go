@@ -0,0 +1,32 @@
+package model
+
+import "guestbook-example/internal/domain"
+
+// AttachmentURLPrefix is the path attachments are served from, followed by
+// their key.
+const AttachmentURLPrefix = "/api/v1/attachments/"
+
+// AttachmentResponse is the representation of the image attached to a
+// message. Width and Height are the size of the image in pixels.
+type AttachmentResponse struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+func NewAttachmentResponse(entity *domain.Attachment) *AttachmentResponse {
+	if entity == nil {
+		return nil
+	}
+	return &AttachmentResponse{
+		URL:          AttachmentURLPrefix + entity.Key,
+		ThumbnailURL: AttachmentURLPrefix + entity.ThumbnailKey,
+		ContentType:  entity.ContentType,
+		Size:         entity.Size,
+		Width:        entity.Width,
+		Height:       entity.Height,
+	}
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"fmt"
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// ListAuditEntriesQuery is the query string of the audit log listing. Since
+// and Until are RFC 3339 timestamps or dates, like in ListMessagesQuery.
+// AfterID and Limit page through the entries.
+type ListAuditEntriesQuery struct {
+	Actor     string `form:"actor"`
+	Action    string `form:"action"`
+	MessageID int64  `form:"message_id"`
+	Since     string `form:"since"`
+	Until     string `form:"until"`
+	AfterID   int64  `form:"after_id"`
+	Limit     int    `form:"limit"`
+}
+
+func (q *ListAuditEntriesQuery) ToEntity() (domain.AuditQuery, error) {
+	since, err := parseQueryTime(q.Since)
+	if err != nil {
+		return domain.AuditQuery{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseQueryTime(q.Until)
+	if err != nil {
+		return domain.AuditQuery{}, fmt.Errorf("invalid until: %w", err)
+	}
+	return domain.AuditQuery{
+		Actor:     q.Actor,
+		Action:    domain.AuditAction(q.Action),
+		MessageID: q.MessageID,
+		Since:     since,
+		Until:     until,
+		AfterID:   q.AfterID,
+		Limit:     q.Limit,
+	}, nil
+}
+
+// MessageSnapshot is a message before or after a change in the audit log.
+type MessageSnapshot struct {
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Author   string `json:"author"`
+	Content  string `json:"content"`
+}
+
+func NewMessageSnapshot(entity *domain.MessageSnapshot) *MessageSnapshot {
+	if entity == nil {
+		return nil
+	}
+	return &MessageSnapshot{
+		ParentID: entity.ParentID,
+		Author:   entity.Author,
+		Content:  entity.Message,
+	}
+}
+
+// AuditEntryResponse is an entry of the audit log. Before is omitted for
+// creations and After for deletions.
+type AuditEntryResponse struct {
+	ID          int64            `json:"id"`
+	GuestbookID int64            `json:"guestbook_id"`
+	Actor       string           `json:"actor"`
+	Action      string           `json:"action"`
+	MessageID   int64            `json:"message_id"`
+	Before      *MessageSnapshot `json:"before,omitempty"`
+	After       *MessageSnapshot `json:"after,omitempty"`
+	RequestID   string           `json:"request_id,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	PrevHash    string           `json:"prev_hash"`
+	Hash        string           `json:"hash"`
+}
+
+func NewAuditEntryResponse(entity *domain.AuditEntry) *AuditEntryResponse {
+	return &AuditEntryResponse{
+		ID:          entity.ID,
+		GuestbookID: entity.GuestbookID,
+		Actor:       entity.Actor,
+		Action:      string(entity.Action),
+		MessageID:   entity.MessageID,
+		Before:      NewMessageSnapshot(entity.Before),
+		After:       NewMessageSnapshot(entity.After),
+		RequestID:   entity.RequestID,
+		CreatedAt:   entity.CreatedAt.UTC(),
+		PrevHash:    entity.PrevHash,
+		Hash:        entity.Hash,
+	}
+}
+
+type ListAuditEntriesResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+}
+
+func NewListAuditEntriesResponse(entities []*domain.AuditEntry) *ListAuditEntriesResponse {
+	entries := make([]AuditEntryResponse, len(entities))
+	for i, entity := range entities {
+		entries[i] = *NewAuditEntryResponse(entity)
+	}
+	return &ListAuditEntriesResponse{
+		Entries: entries,
+	}
+}
+
+// VerifyAuditLogResponse is the outcome of checking the hash chain of the
+// audit log. BrokenAt is the first entry that was altered or follows a
+// removed one.
+type VerifyAuditLogResponse struct {
+	Valid    bool   `json:"valid"`
+	Entries  int    `json:"entries"`
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+}
+
+func NewVerifyAuditLogResponse(entity *domain.AuditVerification) *VerifyAuditLogResponse {
+	res := &VerifyAuditLogResponse{
+		Valid:   entity.BrokenAt == 0,
+		Entries: entity.Entries,
+	}
+	if entity.BrokenAt != 0 {
+		res.BrokenAt = &entity.BrokenAt
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListAuditEntriesQuery_ToEntity(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       ListAuditEntriesQuery
+		want    domain.AuditQuery
+		wantErr bool
+	}{
+		{name: "empty", want: domain.AuditQuery{}},
+		{
+			name: "filters",
+			q: ListAuditEntriesQuery{
+				Actor:     "admin",
+				Action:    "delete",
+				MessageID: 1,
+				Since:     "2024-06-01",
+				Until:     "2024-06-02T12:00:00Z",
+				AfterID:   10,
+				Limit:     20,
+			},
+			want: domain.AuditQuery{
+				Actor:     "admin",
+				Action:    domain.AuditActionDelete,
+				MessageID: 1,
+				Since:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				Until:     time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC),
+				AfterID:   10,
+				Limit:     20,
+			},
+		},
+		{name: "invalid since", q: ListAuditEntriesQuery{Since: "yesterday"}, wantErr: true},
+		{name: "invalid until", q: ListAuditEntriesQuery{Until: "tomorrow"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.ToEntity()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListAuditEntriesQuery.ToEntity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListAuditEntriesQuery.ToEntity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
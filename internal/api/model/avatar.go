@@ -0,0 +1,14 @@
+package model
+
+// AvatarURLPrefix is the path avatars are served from, followed by their ID
+// and .png.
+const AvatarURLPrefix = "/api/v1/avatars/"
+
+// AvatarURL returns the URL of the avatar identified by id, or an empty
+// string without id.
+func AvatarURL(id string) string {
+	if id == "" {
+		return ""
+	}
+	return AvatarURLPrefix + id + ".png"
+}
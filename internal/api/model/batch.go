@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"guestbook-example/internal/domain"
+)
+
+// BatchMessagesRequest is a list of operations on messages, applied in order
+// in a single transaction, or each on its own with BestEffort.
+type BatchMessagesRequest struct {
+	BestEffort bool                      `json:"best_effort"`
+	Operations []MessageOperationRequest `json:"operations"`
+}
+
+// MessageOperationRequest is an operation of a batch. Op is create, with the
+// fields of CreateMessageRequest, update, with an ID and the fields of
+// UpdateMessageRequest, or delete, with an ID.
+type MessageOperationRequest struct {
+	Op       string `json:"op"`
+	ID       int64  `json:"id"`
+	ParentID *int64 `json:"parent_id"`
+	Author   string `json:"author"`
+	Content  string `json:"content"`
+}
+
+// ToEntity returns the batch, or an error naming the first operation with an
+// unknown op or missing ID.
+func (r *BatchMessagesRequest) ToEntity() (domain.MessageBatch, error) {
+	ops := make([]domain.MessageOperation, len(r.Operations))
+	for i, op := range r.Operations {
+		typ := domain.MessageOperationType(op.Op)
+		switch typ {
+		case domain.MessageOperationCreate:
+			ops[i] = domain.MessageOperation{Type: typ, Message: &domain.Message{
+				ParentID: op.ParentID,
+				Author:   op.Author,
+				Message:  op.Content,
+			}}
+			continue
+		case domain.MessageOperationUpdate, domain.MessageOperationDelete:
+		default:
+			return domain.MessageBatch{}, fmt.Errorf("operation %d: invalid op %q", i, op.Op)
+		}
+		if op.ID <= 0 {
+			return domain.MessageBatch{}, fmt.Errorf("operation %d: empty of invalid id", i)
+		}
+		ops[i] = domain.MessageOperation{Type: typ, Message: &domain.Message{
+			ID:      op.ID,
+			Author:  op.Author,
+			Message: op.Content,
+		}}
+	}
+	return domain.MessageBatch{
+		Operations: ops,
+		BestEffort: r.BestEffort,
+	}, nil
+}
+
+type BatchMessagesResponse struct {
+	Succeeded int                        `json:"succeeded"`
+	Failed    int                        `json:"failed"`
+	Results   []MessageOperationResponse `json:"results"`
+}
+
+// MessageOperationResponse is the outcome of an operation of a batch: the
+// status and ID it would have been answered with on its own, or the status
+// and error it failed with. Operations rolled back because another one
+// failed have the status 424 Failed Dependency.
+type MessageOperationResponse struct {
+	Status int    `json:"status"`
+	ID     int64  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func NewBatchMessagesResponse(results []MessageOperationResponse) *BatchMessagesResponse {
+	res := &BatchMessagesResponse{Results: results}
+	for _, r := range results {
+		if r.Error == "" {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestBatchMessagesRequest_ToEntity(t *testing.T) {
+	parentID := int64(1)
+
+	tests := []struct {
+		name    string
+		r       BatchMessagesRequest
+		want    domain.MessageBatch
+		wantErr string
+	}{
+		{
+			name: "operations",
+			r: BatchMessagesRequest{BestEffort: true, Operations: []MessageOperationRequest{
+				{Op: "create", ID: 9, ParentID: &parentID, Author: "Arthur Morgan", Content: "Hey"},
+				{Op: "update", ID: 2, ParentID: &parentID, Author: "Dutch van der Linde", Content: "Plan"},
+				{Op: "delete", ID: 3},
+			}},
+			want: domain.MessageBatch{BestEffort: true, Operations: []domain.MessageOperation{
+				{Type: domain.MessageOperationCreate, Message: &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey"}},
+				{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 2, Author: "Dutch van der Linde", Message: "Plan"}},
+				{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 3}},
+			}},
+		},
+		{
+			name:    "unknown op",
+			r:       BatchMessagesRequest{Operations: []MessageOperationRequest{{Op: "Delete", ID: 1}}},
+			wantErr: `operation 0: invalid op "Delete"`,
+		},
+		{
+			name:    "missing id",
+			r:       BatchMessagesRequest{Operations: []MessageOperationRequest{{Op: "delete", ID: 1}, {Op: "update"}}},
+			wantErr: "operation 1: empty of invalid id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.ToEntity()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("BatchMessagesRequest.ToEntity() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BatchMessagesRequest.ToEntity() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BatchMessagesRequest.ToEntity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBatchMessagesResponse(t *testing.T) {
+	got := NewBatchMessagesResponse([]MessageOperationResponse{
+		{Status: 201, ID: 1},
+		{Status: 404, Error: "message not found"},
+		{Status: 200, ID: 2},
+	})
+	if got.Succeeded != 2 || got.Failed != 1 || len(got.Results) != 3 {
+		t.Errorf("NewBatchMessagesResponse() = %+v, want 2 succeeded and 1 failed", got)
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "guestbook-example/internal/domain"
+
+type CreateCORSOriginRequest struct {
+	Origin string `json:"origin"`
+}
+
+func (r *CreateCORSOriginRequest) ToEntity() *domain.CORSOrigin {
+	return &domain.CORSOrigin{
+		Origin: r.Origin,
+	}
+}
+
+type GetCORSOriginResponse struct {
+	ID     int64  `json:"id"`
+	Origin string `json:"origin"`
+}
+
+func NewGetCORSOriginResponse(entity *domain.CORSOrigin) *GetCORSOriginResponse {
+	return &GetCORSOriginResponse{
+		ID:     entity.ID,
+		Origin: entity.Origin,
+	}
+}
+
+type ListCORSOriginsResponse struct {
+	Origins []GetCORSOriginResponse `json:"origins"`
+}
+
+func NewListCORSOriginsResponse(entities []*domain.CORSOrigin) *ListCORSOriginsResponse {
+	origins := make([]GetCORSOriginResponse, len(entities))
+	for i, entity := range entities {
+		origins[i] = *NewGetCORSOriginResponse(entity)
+	}
+	return &ListCORSOriginsResponse{
+		Origins: origins,
+	}
+}
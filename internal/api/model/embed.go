@@ -0,0 +1,24 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"net/url"
+)
+
+// GetEmbedConfigResponse is the configuration fetched by the guestbook
+// widget. URLs are relative to the server the widget is loaded from.
+type GetEmbedConfigResponse struct {
+	Guestbook    string `json:"guestbook"`
+	Title        string `json:"title"`
+	AllowPosting bool   `json:"allow_posting"`
+	MessagesURL  string `json:"messages_url"`
+}
+
+func NewGetEmbedConfigResponse(entity *domain.EmbedConfig) *GetEmbedConfigResponse {
+	return &GetEmbedConfigResponse{
+		Guestbook:    entity.GuestbookSlug,
+		Title:        entity.Title,
+		AllowPosting: entity.AllowPosting,
+		MessagesURL:  "/api/v1/guestbooks/" + url.PathEscape(entity.GuestbookSlug) + "/messages",
+	}
+}
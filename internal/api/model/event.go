@@ -0,0 +1,29 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// MessageEventResponse is the data of an event of the message stream. Message
+// follows the output encoding contract of GetMessageResponse and is omitted
+// for deletions.
+type MessageEventResponse struct {
+	Type      string              `json:"type"`
+	MessageID int64               `json:"message_id"`
+	Message   *GetMessageResponse `json:"message,omitempty"`
+	Time      time.Time           `json:"time"`
+}
+
+func NewMessageEventResponse(entity domain.MessageEvent) *MessageEventResponse {
+	var message *GetMessageResponse
+	if entity.Message != nil {
+		message = NewGetMessageResponse(entity.Message)
+	}
+	return &MessageEventResponse{
+		Type:      string(entity.Type),
+		MessageID: entity.MessageID,
+		Message:   message,
+		Time:      entity.Time,
+	}
+}
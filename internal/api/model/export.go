@@ -0,0 +1,121 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats. NDJSON is another name for JSON Lines.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSONL  = "jsonl"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// ExportMessagesQuery is the query string of the message export. Format is
+// csv or jsonl, jsonl if empty. Timestamps adds the creation and update times
+// of the messages, and Deleted adds the soft-deleted messages with their
+// deletion time.
+type ExportMessagesQuery struct {
+	Format     string `form:"format"`
+	Timestamps bool   `form:"timestamps"`
+	Deleted    bool   `form:"deleted"`
+}
+
+func (q *ExportMessagesQuery) ToEntity() domain.MessageExportQuery {
+	return domain.MessageExportQuery{
+		IncludeDeleted: q.Deleted,
+	}
+}
+
+// ExportedMessage is a line of a JSON Lines export.
+type ExportedMessage struct {
+	ID          int64      `json:"id"`
+	GuestbookID int64      `json:"guestbook_id"`
+	ParentID    *int64     `json:"parent_id"`
+	Author      string     `json:"author"`
+	Content     string     `json:"content"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewExportedMessage returns the exported message with the timestamps the
+// query asks for, in UTC.
+func NewExportedMessage(entity *domain.ExportedMessage, q ExportMessagesQuery) *ExportedMessage {
+	m := &ExportedMessage{
+		ID:          entity.ID,
+		GuestbookID: entity.GuestbookID,
+		ParentID:    entity.ParentID,
+		Author:      entity.Author,
+		Content:     entity.Message,
+	}
+	if q.Timestamps {
+		m.CreatedAt = utcPtr(entity.CreatedAt)
+		m.UpdatedAt = utcPtr(entity.UpdatedAt)
+	}
+	if q.Deleted && entity.DeletedAt != nil {
+		m.DeletedAt = utcPtr(*entity.DeletedAt)
+	}
+	return m
+}
+
+// ExportedMessagesCSVHeader returns the header row of a CSV export, naming
+// the columns of ExportedMessage.CSVRecord.
+func ExportedMessagesCSVHeader(q ExportMessagesQuery) []string {
+	header := []string{"id", "guestbook_id", "parent_id", "author", "content"}
+	if q.Timestamps {
+		header = append(header, "created_at", "updated_at")
+	}
+	if q.Deleted {
+		header = append(header, "deleted_at")
+	}
+	return header
+}
+
+// CSVRecord returns the row of a CSV export. Missing parent IDs and times are
+// empty, and the author and content are escaped with escapeCSVFormula.
+func (m *ExportedMessage) CSVRecord(q ExportMessagesQuery) []string {
+	record := []string{
+		strconv.FormatInt(m.ID, 10),
+		strconv.FormatInt(m.GuestbookID, 10),
+		"",
+		escapeCSVFormula(m.Author),
+		escapeCSVFormula(m.Content),
+	}
+	if m.ParentID != nil {
+		record[2] = strconv.FormatInt(*m.ParentID, 10)
+	}
+	if q.Timestamps {
+		record = append(record, formatCSVTime(m.CreatedAt), formatCSVTime(m.UpdatedAt))
+	}
+	if q.Deleted {
+		record = append(record, formatCSVTime(m.DeletedAt))
+	}
+	return record
+}
+
+// escapeCSVFormula prefixes cells spreadsheets would evaluate as formulas
+// with a quote, so opening an export cannot run formulas posted by guests.
+// Cells starting with a quote are prefixed too, so imports can tell the
+// escaped cells apart and restore them all.
+func escapeCSVFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r'", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func utcPtr(t time.Time) *time.Time {
+	t = t.UTC()
+	return &t
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
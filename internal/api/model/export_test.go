@@ -0,0 +1,80 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportedMessage_CSVRecord(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.FixedZone("CST", -6*3600))
+	parentID := int64(1)
+	entity := &domain.ExportedMessage{
+		ID:          2,
+		GuestbookID: 1,
+		ParentID:    &parentID,
+		Author:      "Dutch van der Linde",
+		Message:     "I have a plan!",
+		CreatedAt:   at,
+		UpdatedAt:   at,
+		DeletedAt:   &at,
+	}
+
+	tests := []struct {
+		name       string
+		q          ExportMessagesQuery
+		wantHeader []string
+		want       []string
+	}{
+		{
+			name:       "without options",
+			wantHeader: []string{"id", "guestbook_id", "parent_id", "author", "content"},
+			want:       []string{"2", "1", "1", "Dutch van der Linde", "I have a plan!"},
+		},
+		{
+			name:       "with timestamps and deletions",
+			q:          ExportMessagesQuery{Timestamps: true, Deleted: true},
+			wantHeader: []string{"id", "guestbook_id", "parent_id", "author", "content", "created_at", "updated_at", "deleted_at"},
+			want: []string{"2", "1", "1", "Dutch van der Linde", "I have a plan!",
+				"1899-04-01T18:00:00Z", "1899-04-01T18:00:00Z", "1899-04-01T18:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExportedMessagesCSVHeader(tt.q); !reflect.DeepEqual(got, tt.wantHeader) {
+				t.Errorf("ExportedMessagesCSVHeader() = %v, want %v", got, tt.wantHeader)
+			}
+			if got := NewExportedMessage(entity, tt.q).CSVRecord(tt.q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExportedMessage.CSVRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportedMessage_CSVRecord_Formulas(t *testing.T) {
+	tests := []struct {
+		name    string
+		author  string
+		content string
+		want    []string
+	}{
+		{name: "plain", author: "Sadie", content: "2 + 2 = 4", want: []string{"Sadie", "2 + 2 = 4"}},
+		{name: "equals", author: "=HYPERLINK(\"https://evil.example\")", content: "=1+1", want: []string{"'=HYPERLINK(\"https://evil.example\")", "'=1+1"}},
+		{name: "plus", author: "+Sadie", content: "+1", want: []string{"'+Sadie", "'+1"}},
+		{name: "minus", author: "Sadie", content: "-1+cmd|' /C calc'!A0", want: []string{"Sadie", "'-1+cmd|' /C calc'!A0"}},
+		{name: "at", author: "@SUM(A1)", content: "hi", want: []string{"'@SUM(A1)", "hi"}},
+		{name: "tab", author: "Sadie", content: "\t=1+1", want: []string{"Sadie", "'\t=1+1"}},
+		{name: "carriage return", author: "Sadie", content: "\r=1+1", want: []string{"Sadie", "'\r=1+1"}},
+		{name: "quote", author: "'Sadie'", content: "'=1+1", want: []string{"''Sadie'", "''=1+1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := &domain.ExportedMessage{ID: 1, GuestbookID: 1, Author: tt.author, Message: tt.content}
+			got := NewExportedMessage(entity, ExportMessagesQuery{}).CSVRecord(ExportMessagesQuery{})
+			if !reflect.DeepEqual(got[3:], tt.want) {
+				t.Errorf("ExportedMessage.CSVRecord() = %q, want author and content %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package model
+
+import "guestbook-example/internal/domain"
+
+type GuestbookSettings struct {
+	AllowPosting *bool `json:"allow_posting"`
+}
+
+// toEntity returns the settings, posting being allowed unless disabled
+// explicitly.
+func (s *GuestbookSettings) toEntity() domain.GuestbookSettings {
+	return domain.GuestbookSettings{
+		AllowPosting: s.AllowPosting == nil || *s.AllowPosting,
+	}
+}
+
+type CreateGuestbookRequest struct {
+	Slug     string            `json:"slug"`
+	Title    string            `json:"title"`
+	Settings GuestbookSettings `json:"settings"`
+}
+
+func (r *CreateGuestbookRequest) ToEntity() *domain.Guestbook {
+	return &domain.Guestbook{
+		Slug:     r.Slug,
+		Title:    r.Title,
+		Settings: r.Settings.toEntity(),
+	}
+}
+
+type UpdateGuestbookRequest struct {
+	Slug     string
+	Title    string            `json:"title"`
+	Settings GuestbookSettings `json:"settings"`
+}
+
+func (r *UpdateGuestbookRequest) ToEntity() *domain.Guestbook {
+	return &domain.Guestbook{
+		Slug:     r.Slug,
+		Title:    r.Title,
+		Settings: r.Settings.toEntity(),
+	}
+}
+
+type GetGuestbookResponse struct {
+	ID       int64                    `json:"id"`
+	Slug     string                   `json:"slug"`
+	Title    string                   `json:"title"`
+	Settings domain.GuestbookSettings `json:"settings"`
+}
+
+func NewGetGuestbookResponse(entity *domain.Guestbook) *GetGuestbookResponse {
+	return &GetGuestbookResponse{
+		ID:       entity.ID,
+		Slug:     entity.Slug,
+		Title:    entity.Title,
+		Settings: entity.Settings,
+	}
+}
+
+type ListGuestbooksResponse struct {
+	Guestbooks []GetGuestbookResponse `json:"guestbooks"`
+}
+
+func NewListGuestbooksResponse(entities []*domain.Guestbook) *ListGuestbooksResponse {
+	guestbooks := make([]GetGuestbookResponse, len(entities))
+	for i, entity := range entities {
+		guestbooks[i] = *NewGetGuestbookResponse(entity)
+	}
+	return &ListGuestbooksResponse{
+		Guestbooks: guestbooks,
+	}
+}
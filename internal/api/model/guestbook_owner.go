@@ -0,0 +1,74 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+type CreateGuestbookOwnerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// Frequency is one of instant, daily or off, instant if empty.
+	Frequency string `json:"frequency"`
+}
+
+func (r *CreateGuestbookOwnerRequest) ToEntity() *domain.GuestbookOwner {
+	return newGuestbookOwnerEntity(0, r.Name, r.Email, r.Frequency)
+}
+
+type UpdateGuestbookOwnerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// Frequency is one of instant, daily or off, instant if empty.
+	Frequency string `json:"frequency"`
+}
+
+func (r *UpdateGuestbookOwnerRequest) ToEntity(id int64) *domain.GuestbookOwner {
+	return newGuestbookOwnerEntity(id, r.Name, r.Email, r.Frequency)
+}
+
+func newGuestbookOwnerEntity(id int64, name, email, frequency string) *domain.GuestbookOwner {
+	if frequency == "" {
+		frequency = string(domain.NotifyInstant)
+	}
+	return &domain.GuestbookOwner{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Frequency: domain.NotificationFrequency(frequency),
+	}
+}
+
+type GetGuestbookOwnerResponse struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	Frequency      string    `json:"frequency"`
+	LastNotifiedAt time.Time `json:"last_notified_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewGetGuestbookOwnerResponse(entity *domain.GuestbookOwner) *GetGuestbookOwnerResponse {
+	return &GetGuestbookOwnerResponse{
+		ID:             entity.ID,
+		Name:           entity.Name,
+		Email:          entity.Email,
+		Frequency:      string(entity.Frequency),
+		LastNotifiedAt: entity.LastNotifiedAt,
+		CreatedAt:      entity.CreatedAt,
+	}
+}
+
+type ListGuestbookOwnersResponse struct {
+	Owners []GetGuestbookOwnerResponse `json:"owners"`
+}
+
+func NewListGuestbookOwnersResponse(entities []*domain.GuestbookOwner) *ListGuestbookOwnersResponse {
+	owners := make([]GetGuestbookOwnerResponse, len(entities))
+	for i, entity := range entities {
+		owners[i] = *NewGetGuestbookOwnerResponse(entity)
+	}
+	return &ListGuestbookOwnersResponse{
+		Owners: owners,
+	}
+}
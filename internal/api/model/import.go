@@ -0,0 +1,68 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"mime"
+)
+
+// ImportMessagesQuery is the query string of the message import. Format is
+// csv or jsonl (also ndjson); when empty, it is csv for a text/csv body and
+// jsonl otherwise. DryRun validates the file without creating messages.
+type ImportMessagesQuery struct {
+	Format string `form:"format"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// ToEntity returns the import options of a body of the given content type.
+func (q *ImportMessagesQuery) ToEntity(contentType string) domain.MessageImportOptions {
+	format := domain.MessageImportFormat(q.Format)
+	switch format {
+	case "":
+		format = domain.MessageImportJSONL
+		if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/csv" {
+			format = domain.MessageImportCSV
+		}
+	case ExportFormatNDJSON:
+		format = domain.MessageImportJSONL
+	}
+	return domain.MessageImportOptions{
+		Format: format,
+		DryRun: q.DryRun,
+	}
+}
+
+// ImportMessagesResponse is the report of an import. Error is set when the
+// import stopped early, in which case the report covers the rows before the
+// failure, whose accepted rows were created.
+type ImportMessagesResponse struct {
+	DryRun   bool                 `json:"dry_run"`
+	Accepted int                  `json:"accepted"`
+	Rejected int                  `json:"rejected"`
+	Rows     []ImportedMessageRow `json:"rows"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// ImportedMessageRow is the outcome of a row of an import: the ID of the
+// created message, or why the row was rejected.
+type ImportedMessageRow struct {
+	Line  int    `json:"line"`
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func NewImportMessagesResponse(entity *domain.MessageImportReport) *ImportMessagesResponse {
+	rows := make([]ImportedMessageRow, len(entity.Rows))
+	for i, row := range entity.Rows {
+		rows[i] = ImportedMessageRow{
+			Line:  row.Line,
+			ID:    row.ID,
+			Error: row.Error,
+		}
+	}
+	return &ImportMessagesResponse{
+		DryRun:   entity.DryRun,
+		Accepted: entity.Accepted,
+		Rejected: entity.Rejected,
+		Rows:     rows,
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"testing"
+)
+
+func TestImportMessagesQuery_ToEntity(t *testing.T) {
+	tests := []struct {
+		name        string
+		q           ImportMessagesQuery
+		contentType string
+		want        domain.MessageImportFormat
+	}{
+		{name: "default", want: domain.MessageImportJSONL},
+		{name: "csv body", contentType: "text/csv; charset=utf-8", want: domain.MessageImportCSV},
+		{name: "csv", q: ImportMessagesQuery{Format: "csv"}, contentType: "application/octet-stream", want: domain.MessageImportCSV},
+		{name: "ndjson", q: ImportMessagesQuery{Format: "ndjson"}, contentType: "text/csv", want: domain.MessageImportJSONL},
+		{name: "unknown", q: ImportMessagesQuery{Format: "xml"}, want: "xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.ToEntity(tt.contentType); got.Format != tt.want {
+				t.Errorf("ImportMessagesQuery.ToEntity() format = %q, want %q", got.Format, tt.want)
+			}
+		})
+	}
+}
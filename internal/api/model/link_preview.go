@@ -0,0 +1,33 @@
+package model
+
+import "guestbook-example/internal/domain"
+
+// LinkPreviewResponse is the preview of a link in a message. ImageURL is the
+// thumbnail of the image of the linked page, served like attachments, and
+// empty when the page has none.
+type LinkPreviewResponse struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+func NewLinkPreviewResponses(entities []*domain.LinkPreview) []LinkPreviewResponse {
+	if len(entities) == 0 {
+		return nil
+	}
+	res := make([]LinkPreviewResponse, len(entities))
+	for i, p := range entities {
+		res[i] = LinkPreviewResponse{
+			URL:         p.URL,
+			Title:       p.Title,
+			Description: p.Description,
+			SiteName:    p.SiteName,
+		}
+		if p.ImageKey != "" {
+			res[i].ImageURL = AttachmentURLPrefix + p.ImageKey
+		}
+	}
+	return res
+}
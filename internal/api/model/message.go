@@ -1,35 +1,190 @@
 package model
 
-import "guestbook-example/internal/domain"
+import (
+	"fmt"
+	"guestbook-example/internal/domain"
+	"time"
+)
 
+// CreateMessageRequest is a message to create. Content is at most
+// domain.MaxContentLength characters. Email is optional and only used for
+// the avatar of the author: it is hashed right away, and neither it nor its
+// hash is ever returned.
 type CreateMessageRequest struct {
-	Author  string `json:"author"`
-	Content string `json:"content"`
+	ParentID *int64 `json:"parent_id"`
+	Author   string `json:"author"`
+	Content  string `json:"content"`
+	Email    string `json:"email"`
 }
 
 func (r *CreateMessageRequest) ToEntity() *domain.Message {
-	return &domain.Message{
-		Author:  r.Author,
-		Message: r.Content,
+	m := &domain.Message{
+		ParentID: r.ParentID,
+		Author:   r.Author,
+		Message:  r.Content,
 	}
+	if r.Email != "" {
+		m.EmailHash = domain.HashEmail(r.Email)
+	}
+	return m
 }
 
 type CreateMessageResponse struct {
 	ID int64 `json:"id"`
 }
 
+// ContentTypePlainText is the content type of the text fields in
+// GetMessageResponse.
+const ContentTypePlainText = "text/plain; charset=utf-8"
+
+// GetMessageResponse is the representation of a message returned to clients.
+//
+// Output encoding contract: Author and Content are plain text, never HTML,
+// as announced by ContentType. They have been sanitized on the way in, but
+// clients must still treat them as untrusted and insert them with text APIs
+// (e.g. textContent) or encode them for the context they are rendered in.
+// Responses are encoded with encoding/json, which escapes <, > and & as
+// \u003c, \u003e and \u0026, so the JSON body itself is safe to embed in HTML.
+//
+// In threaded listings, Replies holds the replies down to the requested depth
+// and ReplyCount the number of direct replies, which is larger than
+// len(Replies) where the thread was cut off.
+//
+// Content is the raw Markdown the message was written in. ContentHTML is its
+// rendering to HTML, when the server renders Markdown: unlike the other
+// fields, it is sanitized HTML, restricted to basic formatting, lists, quotes,
+// code and http, https or mailto links marked rel="nofollow ugc", so it is
+// safe to insert as HTML. Clients without it fall back to Content as text.
+//
+// AvatarURL is the URL of the avatar of the author, generated from the email
+// they gave or, without email, from their name. It is identified by a keyed
+// hash, so the URL does not tell which email it was generated from.
+//
+// Attachment is the image attached to the message, served from URL, with a
+// thumbnail served from ThumbnailURL.
+//
+// Links is the previews of the links in the content, built in the background
+// from the pages they link to, so they appear some time after the message
+// is posted. Their text fields are plain text under the same contract, and
+// their URL must only be used as the target of a link.
+//
+// Edited tells whether the message was updated since it was posted, EditCount
+// how many times and EditedAt when it was last updated. Its previous versions
+// are listed by GET /messages/:id/revisions.
+//
+// Search results carry a Snippet of the content split into segments, the
+// segments matching the search terms having Match set. The segments are plain
+// text under the same contract; clients highlight matches by wrapping them in
+// an element they create themselves, never by building HTML from the text.
 type GetMessageResponse struct {
-	ID      int64  `json:"id"`
-	Author  string `json:"author"`
-	Content string `json:"content"`
+	ID          int64                 `json:"id"`
+	ParentID    *int64                `json:"parent_id,omitempty"`
+	Author      string                `json:"author"`
+	AvatarURL   string                `json:"avatar_url,omitempty"`
+	Content     string                `json:"content"`
+	ContentType string                `json:"content_type"`
+	ContentHTML string                `json:"content_html,omitempty"`
+	Attachment  *AttachmentResponse   `json:"attachment,omitempty"`
+	Links       []LinkPreviewResponse `json:"links,omitempty"`
+	Replies     []GetMessageResponse  `json:"replies,omitempty"`
+	CreatedAt   *time.Time            `json:"created_at,omitempty"`
+	Edited      bool                  `json:"edited"`
+	EditCount   int                   `json:"edit_count,omitempty"`
+	EditedAt    *time.Time            `json:"edited_at,omitempty"`
+	ReplyCount  int                   `json:"reply_count,omitempty"`
+	Reactions   []ReactionCount       `json:"reactions,omitempty"`
+	Snippet     []TextSegment         `json:"snippet,omitempty"`
+}
+
+// TextSegment is a run of plain text in a search snippet.
+type TextSegment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+func NewTextSegments(segments []domain.TextSegment) []TextSegment {
+	if len(segments) == 0 {
+		return nil
+	}
+	res := make([]TextSegment, len(segments))
+	for i, s := range segments {
+		res[i] = TextSegment{Text: s.Text, Match: s.Match}
+	}
+	return res
 }
 
 func NewGetMessageResponse(entity *domain.Message) *GetMessageResponse {
+	var replies []GetMessageResponse
+	if len(entity.Replies) > 0 {
+		replies = NewListMessagesResponse(entity.Replies).Messages
+	}
 	return &GetMessageResponse{
-		ID:      entity.ID,
-		Author:  entity.Author,
-		Content: entity.Message,
+		ID:          entity.ID,
+		ParentID:    entity.ParentID,
+		Author:      entity.Author,
+		AvatarURL:   AvatarURL(entity.AvatarID),
+		Content:     entity.Message,
+		ContentType: ContentTypePlainText,
+		ContentHTML: entity.HTML,
+		Attachment:  NewAttachmentResponse(entity.Attachment),
+		Links:       NewLinkPreviewResponses(entity.Links),
+		CreatedAt:   timePtr(entity.CreatedAt),
+		Edited:      entity.EditCount > 0,
+		EditCount:   entity.EditCount,
+		EditedAt:    timePtr(entity.EditedAt),
+		Replies:     replies,
+		ReplyCount:  entity.ReplyCount,
+		Reactions:   NewReactionCounts(entity.Reactions),
+		Snippet:     NewTextSegments(entity.Snippet),
+	}
+}
+
+// ListMessagesQuery is the query string of the message listing. Since and
+// Until are RFC 3339 timestamps or dates, a date meaning its midnight UTC.
+type ListMessagesQuery struct {
+	Author string `form:"author"`
+	Since  string `form:"since"`
+	Until  string `form:"until"`
+	Sort   string `form:"sort"`
+}
+
+// IsZero reports whether the query neither filters nor sorts.
+func (q *ListMessagesQuery) IsZero() bool {
+	return *q == ListMessagesQuery{}
+}
+
+func (q *ListMessagesQuery) ToEntity() (domain.MessageQuery, error) {
+	since, err := parseQueryTime(q.Since)
+	if err != nil {
+		return domain.MessageQuery{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseQueryTime(q.Until)
+	if err != nil {
+		return domain.MessageQuery{}, fmt.Errorf("invalid until: %w", err)
+	}
+	return domain.MessageQuery{
+		Author: q.Author,
+		Since:  since,
+		Until:  until,
+		Sort:   domain.MessageSort(q.Sort),
+	}, nil
+}
+
+func parseQueryTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
 	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 type ListMessagesResponse struct {
@@ -46,6 +201,8 @@ func NewListMessagesResponse(entities []*domain.Message) *ListMessagesResponse {
 	}
 }
 
+// UpdateMessageRequest is the new author and content of a message, under the
+// limits of CreateMessageRequest.
 type UpdateMessageRequest struct {
 	ID      int64
 	Author  string `json:"author"`
@@ -64,6 +221,19 @@ type UpdateMessageResponse struct {
 	ID int64 `json:"id"`
 }
 
+// PreviewMessageRequest is the content of a message being written, at most
+// domain.MaxContentLength characters like the content of messages.
+type PreviewMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// PreviewMessageResponse is the HTML the content would be rendered to, under
+// the contract of GetMessageResponse.ContentHTML. It is empty when the server
+// does not render Markdown.
+type PreviewMessageResponse struct {
+	ContentHTML string `json:"content_html"`
+}
+
 type DeleteMessageRequest struct {
 	ID int64 `json:"id"`
 }
@@ -0,0 +1,266 @@
+package model
+
+import (
+	"encoding/json"
+	"guestbook-example/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewGetMessageResponse(t *testing.T) {
+	got := NewGetMessageResponse(&domain.Message{
+		ID:      1,
+		Author:  "Arthur Morgan",
+		Message: "Hey, Dutch!",
+	})
+
+	if got.ContentType != ContentTypePlainText {
+		t.Errorf("NewGetMessageResponse().ContentType = %q, want %q", got.ContentType, ContentTypePlainText)
+	}
+}
+
+func TestNewGetMessageResponse_Replies(t *testing.T) {
+	parentID := int64(1)
+	got := NewGetMessageResponse(&domain.Message{
+		ID:         1,
+		Author:     "Arthur Morgan",
+		AvatarID:   "576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c",
+		Message:    "Hey, Dutch!",
+		ReplyCount: 1,
+		Replies: []*domain.Message{
+			{ID: 2, ParentID: &parentID, Author: "Dutch van der Linde", Message: "I have a plan!", ReplyCount: 3},
+		},
+	})
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"id":1,"author":"Arthur Morgan","avatar_url":"/api/v1/avatars/576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c.png",` +
+		`"content":"Hey, Dutch!","content_type":"text/plain; charset=utf-8","replies":[` +
+		`{"id":2,"parent_id":1,"author":"Dutch van der Linde",` +
+		`"content":"I have a plan!","content_type":"text/plain; charset=utf-8","edited":false,"reply_count":3}` +
+		`],"edited":false,"reply_count":1}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+}
+
+func TestNewGetMessageResponse_Snippet(t *testing.T) {
+	got := NewGetMessageResponse(&domain.Message{
+		ID:      1,
+		Author:  "Arthur Morgan",
+		Message: "Hey, Dutch!",
+		Snippet: []domain.TextSegment{{Text: "Hey, "}, {Text: "Dutch", Match: true}, {Text: "!"}},
+	})
+
+	b, err := json.Marshal(got.Snippet)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `[{"text":"Hey, "},{"text":"Dutch","match":true},{"text":"!"}]`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+}
+
+func TestGetMessageResponse_JSONEncoding(t *testing.T) {
+	payloads := []string{
+		`<script>alert(1)</script>`,
+		`</script><script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`"><svg onload=alert(1)>`,
+		`&lt;script&gt;`,
+	}
+	for _, payload := range payloads {
+		t.Run(payload, func(t *testing.T) {
+			b, err := json.Marshal(NewGetMessageResponse(&domain.Message{
+				ID:      1,
+				Author:  payload,
+				Message: payload,
+			}))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if strings.ContainsAny(string(b), "<>&") {
+				t.Errorf("json.Marshal() = %s, contains unescaped HTML characters", b)
+			}
+
+			var got GetMessageResponse
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if got.Content != payload {
+				t.Errorf("round trip Content = %q, want %q", got.Content, payload)
+			}
+		})
+	}
+}
+
+func TestListMessagesQuery_ToEntity(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       ListMessagesQuery
+		want    domain.MessageQuery
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			q:    ListMessagesQuery{},
+			want: domain.MessageQuery{},
+		},
+		{
+			name: "dates and timestamps",
+			q:    ListMessagesQuery{Author: "Arthur Morgan", Since: "1899-04-01", Until: "1899-04-01T18:30:00+02:00", Sort: "created_at"},
+			want: domain.MessageQuery{
+				Author: "Arthur Morgan",
+				Since:  time.Date(1899, 4, 1, 0, 0, 0, 0, time.UTC),
+				Until:  time.Date(1899, 4, 1, 16, 30, 0, 0, time.UTC),
+				Sort:   domain.MessageSortCreatedAt,
+			},
+		},
+		{
+			name:    "invalid since",
+			q:       ListMessagesQuery{Since: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid until",
+			q:       ListMessagesQuery{Until: "1899-13-01"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.ToEntity()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListMessagesQuery.ToEntity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Since.Equal(tt.want.Since) || !got.Until.Equal(tt.want.Until) ||
+				got.Author != tt.want.Author || got.Sort != tt.want.Sort {
+				t.Errorf("ListMessagesQuery.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGetMessageResponse_Edited(t *testing.T) {
+	editedAt := time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC)
+	got := NewGetMessageResponse(&domain.Message{
+		ID:        1,
+		Author:    "Arthur Morgan",
+		Message:   "Hey, Dutch!",
+		EditCount: 2,
+		EditedAt:  editedAt,
+	})
+
+	if !got.Edited || got.EditCount != 2 || got.EditedAt == nil || !got.EditedAt.Equal(editedAt) {
+		t.Errorf("NewGetMessageResponse() = %+v, want the message edited twice", got)
+	}
+}
+
+func TestNewGetMessageResponse_ContentHTML(t *testing.T) {
+	got, err := json.Marshal(NewGetMessageResponse(&domain.Message{
+		ID:       1,
+		Author:   "Arthur Morgan",
+		AvatarID: "576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c",
+		Message:  "**Hey**, Dutch!",
+		HTML:     "<p><strong>Hey</strong>, Dutch!</p>\n",
+	}))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `{"id":1,"author":"Arthur Morgan","avatar_url":"/api/v1/avatars/576434a6ac76d9f42840e5a868adaed50878ccf3126aaf6663e781bdc976869c.png",` +
+		`"content":"**Hey**, Dutch!","content_type":"text/plain; charset=utf-8",` +
+		`"content_html":"\u003cp\u003e\u003cstrong\u003eHey\u003c/strong\u003e, Dutch!\u003c/p\u003e\n","edited":false}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestNewGetMessageResponse_Attachment(t *testing.T) {
+	got := NewGetMessageResponse(&domain.Message{
+		ID:      1,
+		Author:  "Arthur Morgan",
+		Message: "Look!",
+		Attachment: &domain.Attachment{
+			ContentType:  "image/jpeg",
+			Size:         1024,
+			Width:        640,
+			Height:       480,
+			Key:          "0123456789abcdef0123456789abcdef.jpg",
+			ThumbnailKey: "0123456789abcdef0123456789abcdef-thumb.jpg",
+		},
+	})
+
+	b, err := json.Marshal(got.Attachment)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"url":"/api/v1/attachments/0123456789abcdef0123456789abcdef.jpg",` +
+		`"thumbnail_url":"/api/v1/attachments/0123456789abcdef0123456789abcdef-thumb.jpg",` +
+		`"content_type":"image/jpeg","size":1024,"width":640,"height":480}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+
+	if NewGetMessageResponse(&domain.Message{ID: 2}).Attachment != nil {
+		t.Error("NewGetMessageResponse() of a message without attachment has an attachment")
+	}
+}
+
+func TestNewGetMessageResponse_Links(t *testing.T) {
+	got := NewGetMessageResponse(&domain.Message{
+		ID:      1,
+		Author:  "Arthur Morgan",
+		Message: "See https://example.com and https://example.org",
+		Links: []*domain.LinkPreview{
+			{URL: "https://example.com", Title: "Example", Description: "An <example>", SiteName: "example.com",
+				ImageKey: "0123456789abcdef0123456789abcdef-thumb.png"},
+			{URL: "https://example.org", Title: "Other"},
+		},
+	})
+
+	b, err := json.Marshal(got.Links)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `[{"url":"https://example.com","title":"Example","description":"An \u003cexample\u003e","site_name":"example.com",` +
+		`"image_url":"/api/v1/attachments/0123456789abcdef0123456789abcdef-thumb.png"},` +
+		`{"url":"https://example.org","title":"Other"}]`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+
+	if NewGetMessageResponse(&domain.Message{ID: 2}).Links != nil {
+		t.Error("NewGetMessageResponse() of a message without links has links")
+	}
+}
+
+func TestCreateMessageRequest_ToEntity_Email(t *testing.T) {
+	req := CreateMessageRequest{Author: "Arthur Morgan", Content: "Hey!", Email: " Arthur@Example.com"}
+	got := req.ToEntity()
+	if want := domain.HashEmail("arthur@example.com"); got.EmailHash != want {
+		t.Errorf("CreateMessageRequest.ToEntity().EmailHash = %q, want %q", got.EmailHash, want)
+	}
+
+	got.AvatarID = domain.NewAvatarID([]byte("key"), got)
+	b, err := json.Marshal(NewGetMessageResponse(got))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(strings.ToLower(string(b)), "example.com") || strings.Contains(string(b), got.EmailHash) {
+		t.Errorf("json.Marshal() = %s, exposes the email", b)
+	}
+	if want := `"avatar_url":"/api/v1/avatars/` + got.AvatarID + `.png"`; !strings.Contains(string(b), want) {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+
+	// Without an avatar ID, there is no avatar URL.
+	anonymous := NewGetMessageResponse((&CreateMessageRequest{Author: "Arthur Morgan", Content: "Hey!"}).ToEntity())
+	if anonymous.AvatarURL != "" {
+		t.Errorf("AvatarURL without avatar ID = %q, want empty", anonymous.AvatarURL)
+	}
+}
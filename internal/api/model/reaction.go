@@ -0,0 +1,36 @@
+package model
+
+import "guestbook-example/internal/domain"
+
+// ReactionCount is the number of reactions with an emoji. Reacted tells
+// whether the requesting visitor is one of the reactors.
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+func NewReactionCounts(entities []domain.ReactionCount) []ReactionCount {
+	if len(entities) == 0 {
+		return nil
+	}
+	counts := make([]ReactionCount, len(entities))
+	for i, entity := range entities {
+		counts[i] = ReactionCount(entity)
+	}
+	return counts
+}
+
+type ListReactionsResponse struct {
+	Reactions []ReactionCount `json:"reactions"`
+}
+
+func NewListReactionsResponse(entities []domain.ReactionCount) *ListReactionsResponse {
+	counts := NewReactionCounts(entities)
+	if counts == nil {
+		counts = []ReactionCount{}
+	}
+	return &ListReactionsResponse{
+		Reactions: counts,
+	}
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// MessageRevisionResponse is a version of a message. Revision numbers start
+// at 1 for the original message, and the current version has Current set.
+// Diff is the change of the content from the previous version, as segments
+// that join back into the previous content when skipping the inserted ones,
+// and into Content when skipping the deleted ones. Author, Content and the
+// segments are plain text, like in GetMessageResponse.
+type MessageRevisionResponse struct {
+	Revision    int           `json:"revision"`
+	Author      string        `json:"author"`
+	Content     string        `json:"content"`
+	ContentType string        `json:"content_type"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Current     bool          `json:"current,omitempty"`
+	Diff        []DiffSegment `json:"diff"`
+}
+
+// DiffSegment is a run of text kept ("equal"), inserted ("insert") or deleted
+// ("delete") by an edit.
+type DiffSegment struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+func NewMessageRevisionResponse(entity *domain.MessageRevision) *MessageRevisionResponse {
+	diff := make([]DiffSegment, len(entity.Diff))
+	for i, s := range entity.Diff {
+		diff[i] = DiffSegment{Op: string(s.Op), Text: s.Text}
+	}
+	return &MessageRevisionResponse{
+		Revision:    entity.Revision,
+		Author:      entity.Author,
+		Content:     entity.Message,
+		ContentType: ContentTypePlainText,
+		CreatedAt:   entity.CreatedAt,
+		Current:     entity.Current,
+		Diff:        diff,
+	}
+}
+
+type ListMessageRevisionsResponse struct {
+	Revisions []MessageRevisionResponse `json:"revisions"`
+}
+
+func NewListMessageRevisionsResponse(entities []*domain.MessageRevision) *ListMessageRevisionsResponse {
+	revisions := make([]MessageRevisionResponse, len(entities))
+	for i, entity := range entities {
+		revisions[i] = *NewMessageRevisionResponse(entity)
+	}
+	return &ListMessageRevisionsResponse{
+		Revisions: revisions,
+	}
+}
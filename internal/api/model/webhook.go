@@ -0,0 +1,130 @@
+package model
+
+import (
+	"encoding/json"
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+func (r *CreateWebhookRequest) ToEntity() *domain.Webhook {
+	return newWebhookEntity(0, r.URL, r.Secret, r.Events, r.Active)
+}
+
+type UpdateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret keeps the current secret when empty.
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+func (r *UpdateWebhookRequest) ToEntity(id int64) *domain.Webhook {
+	return newWebhookEntity(id, r.URL, r.Secret, r.Events, r.Active)
+}
+
+func newWebhookEntity(id int64, url, secret string, events []string, active *bool) *domain.Webhook {
+	types := make([]domain.MessageEventType, len(events))
+	for i, e := range events {
+		types[i] = domain.MessageEventType(e)
+	}
+	return &domain.Webhook{
+		ID:         id,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: types,
+		Active:     active == nil || *active,
+	}
+}
+
+// GetWebhookResponse leaves out the secret of the webhook.
+type GetWebhookResponse struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewGetWebhookResponse(entity *domain.Webhook) *GetWebhookResponse {
+	events := make([]string, len(entity.EventTypes))
+	for i, t := range entity.EventTypes {
+		events[i] = string(t)
+	}
+	return &GetWebhookResponse{
+		ID:        entity.ID,
+		URL:       entity.URL,
+		Events:    events,
+		Active:    entity.Active,
+		CreatedAt: entity.CreatedAt,
+	}
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []GetWebhookResponse `json:"webhooks"`
+}
+
+func NewListWebhooksResponse(entities []*domain.Webhook) *ListWebhooksResponse {
+	webhooks := make([]GetWebhookResponse, len(entities))
+	for i, entity := range entities {
+		webhooks[i] = *NewGetWebhookResponse(entity)
+	}
+	return &ListWebhooksResponse{
+		Webhooks: webhooks,
+	}
+}
+
+type GetWebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventID        int64           `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+func NewGetWebhookDeliveryResponse(entity *domain.WebhookDelivery) *GetWebhookDeliveryResponse {
+	resp := &GetWebhookDeliveryResponse{
+		ID:             entity.ID,
+		EventID:        entity.EventID,
+		Event:          string(entity.EventType),
+		Payload:        json.RawMessage(entity.Payload),
+		Status:         string(entity.Status),
+		Attempts:       entity.Attempts,
+		LastStatusCode: entity.LastStatusCode,
+		LastError:      entity.LastError,
+		CreatedAt:      entity.CreatedAt,
+		DeliveredAt:    entity.DeliveredAt,
+	}
+	if entity.Status == domain.WebhookDeliveryPending {
+		resp.NextAttemptAt = &entity.NextAttemptAt
+	}
+	return resp
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []GetWebhookDeliveryResponse `json:"deliveries"`
+}
+
+func NewListWebhookDeliveriesResponse(entities []*domain.WebhookDelivery) *ListWebhookDeliveriesResponse {
+	deliveries := make([]GetWebhookDeliveryResponse, len(entities))
+	for i, entity := range entities {
+		deliveries[i] = *NewGetWebhookDeliveryResponse(entity)
+	}
+	return &ListWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+// Types of the messages exchanged over the WebSocket API.
+const (
+	// Sent by clients.
+	WebSocketSubscribe   = "subscribe"
+	WebSocketUnsubscribe = "unsubscribe"
+	WebSocketPost        = "post"
+
+	// Sent by the server.
+	WebSocketSubscribed   = "subscribed"
+	WebSocketUnsubscribed = "unsubscribed"
+	WebSocketEvent        = "event"
+	WebSocketReset        = "reset"
+	WebSocketCreated      = "created"
+	WebSocketError        = "error"
+)
+
+// WebSocketRequest is a command sent by a client over the WebSocket API.
+// Guestbook is the slug of the guestbook the command applies to, the default
+// guestbook if empty. Ref is echoed in the response to the command.
+type WebSocketRequest struct {
+	Type        string                `json:"type"`
+	Ref         string                `json:"ref,omitempty"`
+	Guestbook   string                `json:"guestbook,omitempty"`
+	LastEventID int64                 `json:"last_event_id,omitempty"`
+	Message     *CreateMessageRequest `json:"message,omitempty"`
+}
+
+// WebSocketResponse is a message sent by the server over the WebSocket API,
+// either in response to a command or for an event of a subscribed guestbook.
+type WebSocketResponse struct {
+	Type      string                `json:"type"`
+	Ref       string                `json:"ref,omitempty"`
+	Guestbook string                `json:"guestbook,omitempty"`
+	EventID   int64                 `json:"event_id,omitempty"`
+	Event     *MessageEventResponse `json:"event,omitempty"`
+	MessageID int64                 `json:"message_id,omitempty"`
+	Error     string                `json:"error,omitempty"`
+}
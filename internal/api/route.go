@@ -1,6 +1,9 @@
 package api
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -8,6 +11,7 @@ type MessageHandler interface {
 	Create(c *gin.Context)
 	GetAll(c *gin.Context)
 	Get(c *gin.Context)
+	GetReplies(c *gin.Context)
 	Update(c *gin.Context)
 	Delete(c *gin.Context)
 }
@@ -16,16 +20,415 @@ type StaticFileHandler interface {
 	Get(c *gin.Context)
 }
 
-func SetupRouter(messageHandler MessageHandler, staticFileHandler StaticFileHandler) *gin.Engine {
+type CSPReportHandler interface {
+	Create(c *gin.Context)
+}
+
+type CSRFHandler interface {
+	Get(c *gin.Context)
+}
+
+type CORSOriginHandler interface {
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type EmbedHandler interface {
+	GetConfig(c *gin.Context)
+}
+
+type ReactionHandler interface {
+	Create(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type StreamHandler interface {
+	Stream(c *gin.Context)
+}
+
+type ExportHandler interface {
+	Export(c *gin.Context)
+}
+
+type BatchHandler interface {
+	Batch(c *gin.Context)
+}
+
+type ImportHandler interface {
+	Import(c *gin.Context)
+}
+
+type PreviewHandler interface {
+	Preview(c *gin.Context)
+}
+
+type AttachmentHandler interface {
+	Get(c *gin.Context)
+}
+
+type AvatarHandler interface {
+	Get(c *gin.Context)
+}
+
+type RevisionHandler interface {
+	GetAll(c *gin.Context)
+	Revert(c *gin.Context)
+}
+
+type WebSocketHandler interface {
+	Serve(c *gin.Context)
+}
+
+type WebhookHandler interface {
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	GetDeliveries(c *gin.Context)
+	Redeliver(c *gin.Context)
+}
+
+type GuestbookOwnerHandler interface {
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type AuditHandler interface {
+	GetAll(c *gin.Context)
+	Verify(c *gin.Context)
+}
+
+type GuestbookHandler interface {
+	Scope(c *gin.Context)
+	Get(c *gin.Context)
+	GetAll(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+}
+
+// RouterOption configures the optional middlewares and routes of the router.
+type RouterOption func(*routerOptions)
+
+type routerOptions struct {
+	trustedProxies    []string
+	middlewares       []gin.HandlerFunc
+	cspReportHandler  CSPReportHandler
+	csrfHandler       CSRFHandler
+	embedHandler      EmbedHandler
+	guestbookHandler  GuestbookHandler
+	reactionHandler   ReactionHandler
+	batchHandler      BatchHandler
+	previewHandler    PreviewHandler
+	attachmentHandler AttachmentHandler
+	avatarHandler     AvatarHandler
+	revisionHandler   RevisionHandler
+	streamHandler     StreamHandler
+	webSocketHandler  WebSocketHandler
+
+	adminMiddlewares  []gin.HandlerFunc
+	corsOriginHandler CORSOriginHandler
+	exportHandler     ExportHandler
+	importHandler     ImportHandler
+	webhookHandler    WebhookHandler
+	ownerHandler      GuestbookOwnerHandler
+	auditHandler      AuditHandler
+}
+
+// WithTrustedProxies trusts the X-Forwarded-For header of requests from the
+// given IP addresses or CIDR ranges for the client IP. Without it, no proxy
+// is trusted, so clients cannot choose their IP with the header.
+func WithTrustedProxies(proxies ...string) RouterOption {
+	return func(o *routerOptions) {
+		o.trustedProxies = proxies
+	}
+}
+
+// WithMiddlewares adds middlewares applied to every route.
+func WithMiddlewares(middlewares ...gin.HandlerFunc) RouterOption {
+	return func(o *routerOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithCSPReportHandler registers the CSP violation report endpoint.
+func WithCSPReportHandler(h CSPReportHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.cspReportHandler = h
+	}
+}
+
+// WithCSRFHandler registers the CSRF token endpoint.
+func WithCSRFHandler(h CSRFHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.csrfHandler = h
+	}
+}
+
+// WithEmbedHandler registers the configuration endpoint of the embeddable
+// guestbook widget.
+func WithEmbedHandler(h EmbedHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.embedHandler = h
+	}
+}
+
+// WithGuestbookHandler registers the guestbook endpoints and scopes the
+// message routes to a guestbook: /api/v1/guestbooks/:slug/messages to the
+// named one and the legacy /api/v1/messages to the default one.
+func WithGuestbookHandler(h GuestbookHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.guestbookHandler = h
+	}
+}
+
+// WithReactionHandler registers the endpoints reacting to messages.
+func WithReactionHandler(h ReactionHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.reactionHandler = h
+	}
+}
+
+// WithBatchHandler registers the endpoint applying batches of message
+// operations.
+func WithBatchHandler(h BatchHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.batchHandler = h
+	}
+}
+
+// WithPreviewHandler registers the endpoint previewing the rendering of a
+// message being written.
+func WithPreviewHandler(h PreviewHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.previewHandler = h
+	}
+}
+
+// WithAttachmentHandler registers the endpoint serving the images attached
+// to messages and their thumbnails.
+func WithAttachmentHandler(h AttachmentHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.attachmentHandler = h
+	}
+}
+
+// WithAvatarHandler registers the endpoint serving the avatars of message
+// authors.
+func WithAvatarHandler(h AvatarHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.avatarHandler = h
+	}
+}
+
+// WithRevisionHandler registers the endpoints listing the revisions of a
+// message and, protected by the admin middlewares, reverting to one.
+func WithRevisionHandler(h RevisionHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.revisionHandler = h
+	}
+}
+
+// WithStreamHandler registers the Server-Sent Events stream of message
+// changes.
+func WithStreamHandler(h StreamHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.streamHandler = h
+	}
+}
+
+// WithWebSocketHandler registers the WebSocket API.
+func WithWebSocketHandler(h WebSocketHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.webSocketHandler = h
+	}
+}
+
+// WithAdminMiddlewares adds middlewares applied to the /api/v1/admin routes
+// and the message export and import, typically authentication.
+func WithAdminMiddlewares(middlewares ...gin.HandlerFunc) RouterOption {
+	return func(o *routerOptions) {
+		o.adminMiddlewares = append(o.adminMiddlewares, middlewares...)
+	}
+}
+
+// WithCORSOriginHandler registers the admin endpoints managing the origins
+// allowed to call the API.
+func WithCORSOriginHandler(h CORSOriginHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.corsOriginHandler = h
+	}
+}
+
+// WithExportHandler registers the message export endpoint, which is
+// protected by the admin middlewares.
+func WithExportHandler(h ExportHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.exportHandler = h
+	}
+}
+
+// WithImportHandler registers the message import endpoint, which is
+// protected by the admin middlewares.
+func WithImportHandler(h ImportHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.importHandler = h
+	}
+}
+
+// WithWebhookHandler registers the admin endpoints managing the webhooks
+// message events are delivered to.
+func WithWebhookHandler(h WebhookHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.webhookHandler = h
+	}
+}
+
+// WithGuestbookOwnerHandler registers the admin endpoints managing the
+// owners of a guestbook, which requires WithGuestbookHandler.
+func WithGuestbookOwnerHandler(h GuestbookOwnerHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.ownerHandler = h
+	}
+}
+
+// WithAuditHandler registers the admin endpoints querying and verifying the
+// audit log of the message changes.
+func WithAuditHandler(h AuditHandler) RouterOption {
+	return func(o *routerOptions) {
+		o.auditHandler = h
+	}
+}
+
+func SetupRouter(messageHandler MessageHandler, staticFileHandler StaticFileHandler, opts ...RouterOption) *gin.Engine {
+	var o routerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	router := gin.Default()
+	// gin trusts every proxy by default, which lets any client choose the IP
+	// it is identified by, e.g. for reaction fingerprints.
+	if err := router.SetTrustedProxies(o.trustedProxies); err != nil {
+		panic(fmt.Sprintf("invalid trusted proxies: %v", err))
+	}
+	// Lets handlers pass the gin context on to services, which read the
+	// guestbook from the request context.
+	router.ContextWithFallback = true
+	router.Use(o.middlewares...)
+
+	var scope []gin.HandlerFunc
+	if o.guestbookHandler != nil {
+		scope = append(scope, o.guestbookHandler.Scope)
+	}
+	messageRoutes := func(g *gin.RouterGroup) {
+		g.POST("/messages", messageHandler.Create)
+		g.GET("/messages", messageHandler.GetAll)
+		if o.batchHandler != nil {
+			// gin cannot route a literal colon, so the custom method of
+			// /messages:batch is matched as a parameter. Batches are a
+			// moderation tool, so they require the admin middlewares.
+			batch := []gin.HandlerFunc{func(c *gin.Context) {
+				if c.Param("method") != ":batch" {
+					staticFileHandler.Get(c)
+					c.Abort()
+				}
+			}}
+			batch = append(batch, o.adminMiddlewares...)
+			g.POST("/messages:method", append(batch, o.batchHandler.Batch)...)
+		}
+		if o.previewHandler != nil {
+			g.POST("/messages/preview", o.previewHandler.Preview)
+		}
+		if o.streamHandler != nil {
+			g.GET("/messages/stream", o.streamHandler.Stream)
+		}
+		if o.exportHandler != nil {
+			g.GET("/messages/export", append(slices.Clone(o.adminMiddlewares), o.exportHandler.Export)...)
+		}
+		if o.importHandler != nil {
+			g.POST("/messages/import", append(slices.Clone(o.adminMiddlewares), o.importHandler.Import)...)
+		}
+		g.GET("/messages/:id", messageHandler.Get)
+		g.GET("/messages/:id/replies", messageHandler.GetReplies)
+		g.PUT("/messages/:id", messageHandler.Update)
+		g.DELETE("/messages/:id", messageHandler.Delete)
+
+		if o.reactionHandler != nil {
+			g.POST("/messages/:id/reactions/:emoji", o.reactionHandler.Create)
+			g.DELETE("/messages/:id/reactions/:emoji", o.reactionHandler.Delete)
+		}
+		if o.revisionHandler != nil {
+			g.GET("/messages/:id/revisions", o.revisionHandler.GetAll)
+			g.POST("/messages/:id/revisions/:revision/revert", append(slices.Clone(o.adminMiddlewares), o.revisionHandler.Revert)...)
+		}
+	}
 
 	api := router.Group("/api/v1")
 	{
-		api.POST("/messages", messageHandler.Create)
-		api.GET("/messages", messageHandler.GetAll)
-		api.GET("/messages/:id", messageHandler.Get)
-		api.PUT("/messages/:id", messageHandler.Update)
-		api.DELETE("/messages/:id", messageHandler.Delete)
+		messageRoutes(api.Group("", scope...))
+
+		if o.guestbookHandler != nil {
+			api.GET("/guestbooks/:slug", o.guestbookHandler.Get)
+			messageRoutes(api.Group("/guestbooks/:slug", scope...))
+		}
+
+		if o.cspReportHandler != nil {
+			api.POST("/csp-reports", o.cspReportHandler.Create)
+		}
+		if o.csrfHandler != nil {
+			api.GET("/csrf-token", o.csrfHandler.Get)
+		}
+		if o.embedHandler != nil {
+			api.GET("/embed/:guestbook/config", o.embedHandler.GetConfig)
+		}
+		if o.webSocketHandler != nil {
+			api.GET("/ws", o.webSocketHandler.Serve)
+		}
+		if o.attachmentHandler != nil {
+			api.GET("/attachments/:key", o.attachmentHandler.Get)
+		}
+		if o.avatarHandler != nil {
+			// gin cannot route a parameter followed by a suffix, so the
+			// handler strips .png from the file name.
+			api.GET("/avatars/:file", o.avatarHandler.Get)
+		}
+	}
+
+	admin := api.Group("/admin", o.adminMiddlewares...)
+	{
+		if o.corsOriginHandler != nil {
+			admin.GET("/cors-origins", o.corsOriginHandler.GetAll)
+			admin.POST("/cors-origins", o.corsOriginHandler.Create)
+			admin.DELETE("/cors-origins/:id", o.corsOriginHandler.Delete)
+		}
+		if o.guestbookHandler != nil {
+			admin.GET("/guestbooks", o.guestbookHandler.GetAll)
+			admin.POST("/guestbooks", o.guestbookHandler.Create)
+			admin.PUT("/guestbooks/:slug", o.guestbookHandler.Update)
+
+			if o.ownerHandler != nil {
+				owners := admin.Group("/guestbooks/:slug/owners", scope...)
+				owners.GET("", o.ownerHandler.GetAll)
+				owners.POST("", o.ownerHandler.Create)
+				owners.PUT("/:id", o.ownerHandler.Update)
+				owners.DELETE("/:id", o.ownerHandler.Delete)
+			}
+		}
+		if o.webhookHandler != nil {
+			admin.GET("/webhooks", o.webhookHandler.GetAll)
+			admin.POST("/webhooks", o.webhookHandler.Create)
+			admin.PUT("/webhooks/:id", o.webhookHandler.Update)
+			admin.DELETE("/webhooks/:id", o.webhookHandler.Delete)
+			admin.GET("/webhooks/:id/deliveries", o.webhookHandler.GetDeliveries)
+			admin.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", o.webhookHandler.Redeliver)
+		}
+		if o.auditHandler != nil {
+			admin.GET("/audit-log", o.auditHandler.GetAll)
+			admin.GET("/audit-log/verify", o.auditHandler.Verify)
+		}
 	}
 
 	router.NoRoute(staticFileHandler.Get)
@@ -29,6 +29,27 @@ func TestSetupRouter(t *testing.T) {
 	// Create instances of the mocks
 	mockMessageHandler := &mocks.MessageHandler{}
 	mockStaticFileHandler := &mocks.StaticFileHandler{}
+	mockCSPReportHandler := &mocks.CSPReportHandler{}
+	mockCSRFHandler := &mocks.CSRFHandler{}
+	mockCORSOriginHandler := &mocks.CORSOriginHandler{}
+	mockEmbedHandler := &mocks.EmbedHandler{}
+	mockGuestbookHandler := &mocks.GuestbookHandler{}
+	mockReactionHandler := &mocks.ReactionHandler{}
+	mockStreamHandler := &mocks.StreamHandler{}
+	mockExportHandler := &mocks.ExportHandler{}
+	mockImportHandler := &mocks.ImportHandler{}
+	mockBatchHandler := &mocks.BatchHandler{}
+	mockPreviewHandler := &mocks.PreviewHandler{}
+	mockAttachmentHandler := &mocks.AttachmentHandler{}
+	mockAvatarHandler := &mocks.AvatarHandler{}
+	mockRevisionHandler := &mocks.RevisionHandler{}
+	mockWebSocketHandler := &mocks.WebSocketHandler{}
+	mockWebhookHandler := &mocks.WebhookHandler{}
+	mockGuestbookOwnerHandler := &mocks.GuestbookOwnerHandler{}
+	mockAuditHandler := &mocks.AuditHandler{}
+	mockGuestbookHandler.On("Scope", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*gin.Context).Next()
+	})
 
 	// Table-driven mock setup
 	mockSetups := []struct {
@@ -39,9 +60,44 @@ func TestSetupRouter(t *testing.T) {
 		{mockMessageHandler, "Create", http.StatusCreated},
 		{mockMessageHandler, "GetAll", http.StatusOK},
 		{mockMessageHandler, "Get", http.StatusOK},
+		{mockMessageHandler, "GetReplies", http.StatusOK},
 		{mockMessageHandler, "Update", http.StatusOK},
 		{mockMessageHandler, "Delete", http.StatusNoContent},
 		{mockStaticFileHandler, "Get", http.StatusOK},
+		{mockCSPReportHandler, "Create", http.StatusNoContent},
+		{mockCSRFHandler, "Get", http.StatusOK},
+		{mockCORSOriginHandler, "GetAll", http.StatusOK},
+		{mockCORSOriginHandler, "Create", http.StatusCreated},
+		{mockCORSOriginHandler, "Delete", http.StatusOK},
+		{mockEmbedHandler, "GetConfig", http.StatusOK},
+		{mockGuestbookHandler, "Get", http.StatusOK},
+		{mockGuestbookHandler, "GetAll", http.StatusOK},
+		{mockGuestbookHandler, "Create", http.StatusCreated},
+		{mockGuestbookHandler, "Update", http.StatusOK},
+		{mockReactionHandler, "Create", http.StatusOK},
+		{mockReactionHandler, "Delete", http.StatusOK},
+		{mockStreamHandler, "Stream", http.StatusOK},
+		{mockExportHandler, "Export", http.StatusOK},
+		{mockImportHandler, "Import", http.StatusOK},
+		{mockBatchHandler, "Batch", http.StatusOK},
+		{mockWebSocketHandler, "Serve", http.StatusOK},
+		{mockWebhookHandler, "GetAll", http.StatusOK},
+		{mockWebhookHandler, "Create", http.StatusCreated},
+		{mockWebhookHandler, "Update", http.StatusOK},
+		{mockWebhookHandler, "Delete", http.StatusOK},
+		{mockWebhookHandler, "GetDeliveries", http.StatusOK},
+		{mockWebhookHandler, "Redeliver", http.StatusAccepted},
+		{mockGuestbookOwnerHandler, "GetAll", http.StatusOK},
+		{mockGuestbookOwnerHandler, "Create", http.StatusCreated},
+		{mockGuestbookOwnerHandler, "Update", http.StatusOK},
+		{mockGuestbookOwnerHandler, "Delete", http.StatusOK},
+		{mockPreviewHandler, "Preview", http.StatusOK},
+		{mockAttachmentHandler, "Get", http.StatusOK},
+		{mockAvatarHandler, "Get", http.StatusOK},
+		{mockRevisionHandler, "GetAll", http.StatusOK},
+		{mockRevisionHandler, "Revert", http.StatusOK},
+		{mockAuditHandler, "GetAll", http.StatusOK},
+		{mockAuditHandler, "Verify", http.StatusOK},
 	}
 
 	// Configure all mocks using the table-driven approach
@@ -57,11 +113,120 @@ func TestSetupRouter(t *testing.T) {
 				c := args.Get(0).(*gin.Context)
 				c.Status(setup.returnCode)
 			})
+		case *mocks.CSPReportHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.CSRFHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.CORSOriginHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.EmbedHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.ReactionHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.StreamHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.ExportHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.ImportHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.BatchHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.WebSocketHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.WebhookHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.GuestbookOwnerHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.PreviewHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.AttachmentHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.AvatarHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.RevisionHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.AuditHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
+		case *mocks.GuestbookHandler:
+			h.On(setup.methodName, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(0).(*gin.Context)
+				c.Status(setup.returnCode)
+			})
 		}
 	}
 
 	// Call SetupRouter with the mocks
-	router := SetupRouter(mockMessageHandler, mockStaticFileHandler)
+	router := SetupRouter(mockMessageHandler, mockStaticFileHandler,
+		WithCSPReportHandler(mockCSPReportHandler),
+		WithCSRFHandler(mockCSRFHandler),
+		WithCORSOriginHandler(mockCORSOriginHandler),
+		WithEmbedHandler(mockEmbedHandler),
+		WithGuestbookHandler(mockGuestbookHandler),
+		WithReactionHandler(mockReactionHandler),
+		WithStreamHandler(mockStreamHandler),
+		WithExportHandler(mockExportHandler),
+		WithImportHandler(mockImportHandler),
+		WithBatchHandler(mockBatchHandler),
+		WithPreviewHandler(mockPreviewHandler),
+		WithAttachmentHandler(mockAttachmentHandler),
+		WithAvatarHandler(mockAvatarHandler),
+		WithRevisionHandler(mockRevisionHandler),
+		WithWebSocketHandler(mockWebSocketHandler),
+		WithWebhookHandler(mockWebhookHandler),
+		WithGuestbookOwnerHandler(mockGuestbookOwnerHandler),
+		WithAuditHandler(mockAuditHandler),
+	)
 
 	// Table-driven test cases
 	testCases := []struct {
@@ -88,6 +253,38 @@ func TestSetupRouter(t *testing.T) {
 			handlerMethod:  "GetAll",
 			mockHandler:    &mockMessageHandler.Mock,
 		},
+		{
+			name:           "GET /api/v1/messages/stream",
+			method:         "GET",
+			path:           "/api/v1/messages/stream",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Stream",
+			mockHandler:    &mockStreamHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/messages/export",
+			method:         "GET",
+			path:           "/api/v1/messages/export",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Export",
+			mockHandler:    &mockExportHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/messages/import",
+			method:         "POST",
+			path:           "/api/v1/messages/import",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Import",
+			mockHandler:    &mockImportHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/messages:batch",
+			method:         "POST",
+			path:           "/api/v1/messages:batch",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Batch",
+			mockHandler:    &mockBatchHandler.Mock,
+		},
 		{
 			name:           "GET /api/v1/messages/123",
 			method:         "GET",
@@ -96,6 +293,14 @@ func TestSetupRouter(t *testing.T) {
 			handlerMethod:  "Get",
 			mockHandler:    &mockMessageHandler.Mock,
 		},
+		{
+			name:           "GET /api/v1/messages/123/replies",
+			method:         "GET",
+			path:           "/api/v1/messages/123/replies",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetReplies",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
 		{
 			name:           "PUT /api/v1/messages/123",
 			method:         "PUT",
@@ -112,6 +317,358 @@ func TestSetupRouter(t *testing.T) {
 			handlerMethod:  "Delete",
 			mockHandler:    &mockMessageHandler.Mock,
 		},
+		{
+			name:           "POST /api/v1/messages/123/reactions/+1",
+			method:         "POST",
+			path:           "/api/v1/messages/123/reactions/%F0%9F%91%8D",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Create",
+			mockHandler:    &mockReactionHandler.Mock,
+		},
+		{
+			name:           "DELETE /api/v1/guestbooks/wedding/messages/123/reactions/+1",
+			method:         "DELETE",
+			path:           "/api/v1/guestbooks/wedding/messages/123/reactions/%F0%9F%91%8D",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Delete",
+			mockHandler:    &mockReactionHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/messages/preview",
+			method:         "POST",
+			path:           "/api/v1/messages/preview",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Preview",
+			mockHandler:    &mockPreviewHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/guestbooks/wedding/messages/preview",
+			method:         "POST",
+			path:           "/api/v1/guestbooks/wedding/messages/preview",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Preview",
+			mockHandler:    &mockPreviewHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/attachments/:key",
+			method:         "GET",
+			path:           "/api/v1/attachments/0123456789abcdef0123456789abcdef.jpg",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockAttachmentHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/avatars/:file",
+			method:         "GET",
+			path:           "/api/v1/avatars/0123456789abcdef0123456789abcdef.png",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockAvatarHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/messages/123/revisions",
+			method:         "GET",
+			path:           "/api/v1/messages/123/revisions",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockRevisionHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages/123/revisions",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages/123/revisions",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockRevisionHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/messages/123/revisions/1/revert",
+			method:         "POST",
+			path:           "/api/v1/messages/123/revisions/1/revert",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Revert",
+			mockHandler:    &mockRevisionHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/guestbooks/wedding/messages/123/revisions/1/revert",
+			method:         "POST",
+			path:           "/api/v1/guestbooks/wedding/messages/123/revisions/1/revert",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Revert",
+			mockHandler:    &mockRevisionHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/csp-reports",
+			method:         "POST",
+			path:           "/api/v1/csp-reports",
+			expectedStatus: http.StatusNoContent,
+			handlerMethod:  "Create",
+			mockHandler:    &mockCSPReportHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/csrf-token",
+			method:         "GET",
+			path:           "/api/v1/csrf-token",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockCSRFHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/ws",
+			method:         "GET",
+			path:           "/api/v1/ws",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Serve",
+			mockHandler:    &mockWebSocketHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/cors-origins",
+			method:         "GET",
+			path:           "/api/v1/admin/cors-origins",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockCORSOriginHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/admin/cors-origins",
+			method:         "POST",
+			path:           "/api/v1/admin/cors-origins",
+			expectedStatus: http.StatusCreated,
+			handlerMethod:  "Create",
+			mockHandler:    &mockCORSOriginHandler.Mock,
+		},
+		{
+			name:           "DELETE /api/v1/admin/cors-origins/1",
+			method:         "DELETE",
+			path:           "/api/v1/admin/cors-origins/1",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Delete",
+			mockHandler:    &mockCORSOriginHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/embed/default/config",
+			method:         "GET",
+			path:           "/api/v1/embed/default/config",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetConfig",
+			mockHandler:    &mockEmbedHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockGuestbookHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/guestbooks/wedding/messages",
+			method:         "POST",
+			path:           "/api/v1/guestbooks/wedding/messages",
+			expectedStatus: http.StatusCreated,
+			handlerMethod:  "Create",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages/stream",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages/stream",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Stream",
+			mockHandler:    &mockStreamHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages/export",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages/export",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Export",
+			mockHandler:    &mockExportHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/guestbooks/wedding/messages/import",
+			method:         "POST",
+			path:           "/api/v1/guestbooks/wedding/messages/import",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Import",
+			mockHandler:    &mockImportHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/guestbooks/wedding/messages:batch",
+			method:         "POST",
+			path:           "/api/v1/guestbooks/wedding/messages:batch",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Batch",
+			mockHandler:    &mockBatchHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages/123",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages/123",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/guestbooks/wedding/messages/123/replies",
+			method:         "GET",
+			path:           "/api/v1/guestbooks/wedding/messages/123/replies",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetReplies",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "PUT /api/v1/guestbooks/wedding/messages/123",
+			method:         "PUT",
+			path:           "/api/v1/guestbooks/wedding/messages/123",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Update",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "DELETE /api/v1/guestbooks/wedding/messages/123",
+			method:         "DELETE",
+			path:           "/api/v1/guestbooks/wedding/messages/123",
+			expectedStatus: http.StatusNoContent,
+			handlerMethod:  "Delete",
+			mockHandler:    &mockMessageHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/guestbooks",
+			method:         "GET",
+			path:           "/api/v1/admin/guestbooks",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockGuestbookHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/admin/guestbooks",
+			method:         "POST",
+			path:           "/api/v1/admin/guestbooks",
+			expectedStatus: http.StatusCreated,
+			handlerMethod:  "Create",
+			mockHandler:    &mockGuestbookHandler.Mock,
+		},
+		{
+			name:           "PUT /api/v1/admin/guestbooks/wedding",
+			method:         "PUT",
+			path:           "/api/v1/admin/guestbooks/wedding",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Update",
+			mockHandler:    &mockGuestbookHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/webhooks",
+			method:         "GET",
+			path:           "/api/v1/admin/webhooks",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/admin/webhooks",
+			method:         "POST",
+			path:           "/api/v1/admin/webhooks",
+			expectedStatus: http.StatusCreated,
+			handlerMethod:  "Create",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "PUT /api/v1/admin/webhooks/1",
+			method:         "PUT",
+			path:           "/api/v1/admin/webhooks/1",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Update",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "DELETE /api/v1/admin/webhooks/1",
+			method:         "DELETE",
+			path:           "/api/v1/admin/webhooks/1",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Delete",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/webhooks/1/deliveries",
+			method:         "GET",
+			path:           "/api/v1/admin/webhooks/1/deliveries",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetDeliveries",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/admin/webhooks/1/deliveries/2/redeliver",
+			method:         "POST",
+			path:           "/api/v1/admin/webhooks/1/deliveries/2/redeliver",
+			expectedStatus: http.StatusAccepted,
+			handlerMethod:  "Redeliver",
+			mockHandler:    &mockWebhookHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/guestbooks/wedding/owners",
+			method:         "GET",
+			path:           "/api/v1/admin/guestbooks/wedding/owners",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockGuestbookOwnerHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/admin/guestbooks/wedding/owners",
+			method:         "POST",
+			path:           "/api/v1/admin/guestbooks/wedding/owners",
+			expectedStatus: http.StatusCreated,
+			handlerMethod:  "Create",
+			mockHandler:    &mockGuestbookOwnerHandler.Mock,
+		},
+		{
+			name:           "PUT /api/v1/admin/guestbooks/wedding/owners/1",
+			method:         "PUT",
+			path:           "/api/v1/admin/guestbooks/wedding/owners/1",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Update",
+			mockHandler:    &mockGuestbookOwnerHandler.Mock,
+		},
+		{
+			name:           "DELETE /api/v1/admin/guestbooks/wedding/owners/1",
+			method:         "DELETE",
+			path:           "/api/v1/admin/guestbooks/wedding/owners/1",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Delete",
+			mockHandler:    &mockGuestbookOwnerHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/audit-log",
+			method:         "GET",
+			path:           "/api/v1/admin/audit-log",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "GetAll",
+			mockHandler:    &mockAuditHandler.Mock,
+		},
+		{
+			name:           "GET /api/v1/admin/audit-log/verify",
+			method:         "GET",
+			path:           "/api/v1/admin/audit-log/verify",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Verify",
+			mockHandler:    &mockAuditHandler.Mock,
+		},
+		{
+			name:           "POST /api/v1/messages:unknown",
+			method:         "POST",
+			path:           "/api/v1/messages:unknown",
+			expectedStatus: http.StatusOK,
+			handlerMethod:  "Get",
+			mockHandler:    &mockStaticFileHandler.Mock,
+		},
 		{
 			name:           "NoRoute handler",
 			method:         "GET",
@@ -133,4 +690,137 @@ func TestSetupRouter(t *testing.T) {
 	// Verify all expectations were met
 	mockMessageHandler.AssertExpectations(t)
 	mockStaticFileHandler.AssertExpectations(t)
+	mockCSPReportHandler.AssertExpectations(t)
+	mockCSRFHandler.AssertExpectations(t)
+	mockCORSOriginHandler.AssertExpectations(t)
+	mockEmbedHandler.AssertExpectations(t)
+	mockGuestbookHandler.AssertExpectations(t)
+	mockGuestbookHandler.AssertNumberOfCalls(t, "Scope", 33)
+	mockReactionHandler.AssertExpectations(t)
+	mockStreamHandler.AssertExpectations(t)
+	mockStreamHandler.AssertNumberOfCalls(t, "Stream", 2)
+	mockExportHandler.AssertExpectations(t)
+	mockExportHandler.AssertNumberOfCalls(t, "Export", 2)
+	mockImportHandler.AssertExpectations(t)
+	mockImportHandler.AssertNumberOfCalls(t, "Import", 2)
+	mockBatchHandler.AssertExpectations(t)
+	mockBatchHandler.AssertNumberOfCalls(t, "Batch", 2)
+	mockPreviewHandler.AssertExpectations(t)
+	mockPreviewHandler.AssertNumberOfCalls(t, "Preview", 2)
+	mockAttachmentHandler.AssertExpectations(t)
+	mockAttachmentHandler.AssertNumberOfCalls(t, "Get", 1)
+	mockAvatarHandler.AssertExpectations(t)
+	mockAvatarHandler.AssertNumberOfCalls(t, "Get", 1)
+	mockRevisionHandler.AssertExpectations(t)
+	mockRevisionHandler.AssertNumberOfCalls(t, "GetAll", 2)
+	mockRevisionHandler.AssertNumberOfCalls(t, "Revert", 2)
+	mockWebSocketHandler.AssertExpectations(t)
+	mockWebhookHandler.AssertExpectations(t)
+	mockGuestbookOwnerHandler.AssertExpectations(t)
+	mockAuditHandler.AssertExpectations(t)
+	mockMessageHandler.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestSetupRouter_WithAdminMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	mockCORSOriginHandler := &mocks.CORSOriginHandler{}
+	mockExportHandler := &mocks.ExportHandler{}
+	mockImportHandler := &mocks.ImportHandler{}
+	mockAuditHandler := &mocks.AuditHandler{}
+	mockRevisionHandler := &mocks.RevisionHandler{}
+	mockBatchHandler := &mocks.BatchHandler{}
+
+	router := SetupRouter(&mocks.MessageHandler{}, &mocks.StaticFileHandler{},
+		WithAdminMiddlewares(func(c *gin.Context) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}),
+		WithCORSOriginHandler(mockCORSOriginHandler),
+		WithExportHandler(mockExportHandler),
+		WithImportHandler(mockImportHandler),
+		WithAuditHandler(mockAuditHandler),
+		WithRevisionHandler(mockRevisionHandler),
+		WithBatchHandler(mockBatchHandler),
+	)
+
+	w := performRequest(router, "GET", "/api/v1/admin/cors-origins")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockCORSOriginHandler.AssertNotCalled(t, "GetAll", mock.Anything)
+
+	w = performRequest(router, "GET", "/api/v1/messages/export")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockExportHandler.AssertNotCalled(t, "Export", mock.Anything)
+
+	w = performRequest(router, "POST", "/api/v1/messages/import")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockImportHandler.AssertNotCalled(t, "Import", mock.Anything)
+
+	w = performRequest(router, "GET", "/api/v1/admin/audit-log")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockAuditHandler.AssertNotCalled(t, "GetAll", mock.Anything)
+
+	w = performRequest(router, "POST", "/api/v1/messages/1/revisions/1/revert")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockRevisionHandler.AssertNotCalled(t, "Revert", mock.Anything)
+
+	w = performRequest(router, "POST", "/api/v1/messages:batch")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockBatchHandler.AssertNotCalled(t, "Batch", mock.Anything)
+}
+
+func TestSetupRouter_WithMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	mockMessageHandler := &mocks.MessageHandler{}
+	mockStaticFileHandler := &mocks.StaticFileHandler{}
+	mockStaticFileHandler.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*gin.Context).Status(http.StatusOK)
+	})
+
+	router := SetupRouter(mockMessageHandler, mockStaticFileHandler,
+		WithMiddlewares(func(c *gin.Context) {
+			c.Header("X-Test", "applied")
+			c.Next()
+		}),
+	)
+
+	w := performRequest(router, "GET", "/index.html")
+	assert.Equal(t, "applied", w.Header().Get("X-Test"))
+
+	w = performRequest(router, "POST", "/api/v1/csp-reports")
+	assert.Equal(t, "applied", w.Header().Get("X-Test"))
+	mockStaticFileHandler.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestSetupRouter_WithTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	tests := []struct {
+		name string
+		opts []RouterOption
+		want string
+	}{
+		{name: "no proxy is trusted by default", want: "192.0.2.1"},
+		{name: "trusted proxy", opts: []RouterOption{WithTrustedProxies("192.0.2.0/24")}, want: "203.0.113.7"},
+		{name: "untrusted proxy", opts: []RouterOption{WithTrustedProxies("10.0.0.0/8")}, want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var clientIP string
+			opts := append(tt.opts, WithMiddlewares(func(c *gin.Context) {
+				clientIP = c.ClientIP()
+				c.AbortWithStatus(http.StatusNoContent)
+			}))
+			router := SetupRouter(&mocks.MessageHandler{}, &mocks.StaticFileHandler{}, opts...)
+
+			req := httptest.NewRequest(http.MethodGet, "/index.html", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.want, clientIP)
+		})
+	}
 }
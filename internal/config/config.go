@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the application configuration, loaded from environment variables.
+type Config struct {
+	// Title is the title of the guestbook shown in embedded widgets.
+	// GUESTBOOK_TITLE, default "Guestbook".
+	Title string
+
+	// CSPReportOnly only reports CSP violations instead of enforcing the
+	// policy. GUESTBOOK_CSP_REPORT_ONLY, default false.
+	CSPReportOnly bool
+	// HSTSMaxAge is the max-age of Strict-Transport-Security, 0 disables the
+	// header. GUESTBOOK_HSTS_MAX_AGE, default 8760h.
+	HSTSMaxAge time.Duration
+	// SecureCookies sets the Secure attribute on cookies, enable it when
+	// served over HTTPS. GUESTBOOK_SECURE_COOKIES, default false.
+	SecureCookies bool
+	// CSRFTrustedOrigins are other origins allowed to send state-changing
+	// requests with cookies. GUESTBOOK_CSRF_TRUSTED_ORIGINS, comma separated.
+	CSRFTrustedOrigins []string
+	// TrustedProxies are the IP addresses or CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For header is trusted for the client IP; by
+	// default none is, and the client IP is the address of the connection.
+	// GUESTBOOK_TRUSTED_PROXIES, comma separated.
+	TrustedProxies []string
+
+	// CORSAllowedOrigins are the origins allowed to call the API from the
+	// browser, in addition to the ones stored in the database; "*" allows
+	// every origin, and cannot be combined with CORSAllowCredentials.
+	// GUESTBOOK_CORS_ALLOWED_ORIGINS, comma separated.
+	CORSAllowedOrigins []string
+	// CORSAllowedMethods are the methods allowed in cross-origin requests.
+	// GUESTBOOK_CORS_ALLOWED_METHODS, comma separated, default
+	// GET,POST,PUT,DELETE.
+	CORSAllowedMethods []string
+	// CORSAllowedHeaders are the headers allowed in cross-origin requests.
+	// GUESTBOOK_CORS_ALLOWED_HEADERS, comma separated, default
+	// Content-Type,Authorization,X-CSRF-Token.
+	CORSAllowedHeaders []string
+	// CORSAllowCredentials allows cross-origin requests with cookies.
+	// GUESTBOOK_CORS_ALLOW_CREDENTIALS, default false.
+	CORSAllowCredentials bool
+	// CORSMaxAge is how long browsers may cache preflight responses.
+	// GUESTBOOK_CORS_MAX_AGE, default 10m.
+	CORSMaxAge time.Duration
+
+	// AdminToken is the bearer token required by the /api/v1/admin
+	// endpoints, which are disabled when empty. GUESTBOOK_ADMIN_TOKEN.
+	AdminToken string
+
+	// SMTPAddr is the host:port of the SMTP server guestbook owners are
+	// emailed through, which is disabled when empty. GUESTBOOK_SMTP_ADDR.
+	SMTPAddr string
+	// SMTPUsername and SMTPPassword authenticate with the SMTP server.
+	// GUESTBOOK_SMTP_USERNAME and GUESTBOOK_SMTP_PASSWORD.
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPFrom is the sender of the emails. GUESTBOOK_SMTP_FROM, default
+	// "Guestbook <guestbook@localhost>".
+	SMTPFrom string
+	// DigestHour is the hour of the day, in local time, daily digests are
+	// sent at. GUESTBOOK_DIGEST_HOUR, default 8.
+	DigestHour int
+
+	// AttachmentDir is the directory images attached to messages are stored
+	// in. GUESTBOOK_ATTACHMENT_DIR, default "attachments".
+	AttachmentDir string
+
+	// AvatarKey is the secret the IDs of avatars are derived from, so that
+	// their URLs do not reveal the emails they were generated from. When
+	// empty, a random key is generated on startup and avatars change on
+	// every restart. GUESTBOOK_AVATAR_KEY.
+	AvatarKey string
+
+	// LinkPreviews enables fetching the pages linked to in messages to show
+	// previews of them. GUESTBOOK_LINK_PREVIEWS, default true.
+	LinkPreviews bool
+}
+
+// Load reads the configuration from the environment.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Title: "Guestbook",
+
+		CSPReportOnly: false,
+		HSTSMaxAge:    365 * 24 * time.Hour,
+		SecureCookies: false,
+
+		CORSAllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		CORSAllowedHeaders:   []string{"Content-Type", "Authorization", "X-CSRF-Token"},
+		CORSAllowCredentials: false,
+		CORSMaxAge:           10 * time.Minute,
+
+		SMTPFrom:   "Guestbook <guestbook@localhost>",
+		DigestHour: 8,
+
+		AttachmentDir: "attachments",
+		LinkPreviews:  true,
+	}
+
+	if v := os.Getenv("GUESTBOOK_TITLE"); v != "" {
+		cfg.Title = v
+	}
+
+	var err error
+	if cfg.CSPReportOnly, err = lookupBool("GUESTBOOK_CSP_REPORT_ONLY", cfg.CSPReportOnly); err != nil {
+		return nil, err
+	}
+	if cfg.HSTSMaxAge, err = lookupDuration("GUESTBOOK_HSTS_MAX_AGE", cfg.HSTSMaxAge); err != nil {
+		return nil, err
+	}
+
+	if cfg.SecureCookies, err = lookupBool("GUESTBOOK_SECURE_COOKIES", cfg.SecureCookies); err != nil {
+		return nil, err
+	}
+	cfg.CSRFTrustedOrigins = lookupList("GUESTBOOK_CSRF_TRUSTED_ORIGINS", cfg.CSRFTrustedOrigins)
+	cfg.TrustedProxies = lookupList("GUESTBOOK_TRUSTED_PROXIES", cfg.TrustedProxies)
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("invalid GUESTBOOK_TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+
+	cfg.CORSAllowedOrigins = lookupList("GUESTBOOK_CORS_ALLOWED_ORIGINS", cfg.CORSAllowedOrigins)
+	cfg.CORSAllowedMethods = lookupList("GUESTBOOK_CORS_ALLOWED_METHODS", cfg.CORSAllowedMethods)
+	cfg.CORSAllowedHeaders = lookupList("GUESTBOOK_CORS_ALLOWED_HEADERS", cfg.CORSAllowedHeaders)
+	if cfg.CORSAllowCredentials, err = lookupBool("GUESTBOOK_CORS_ALLOW_CREDENTIALS", cfg.CORSAllowCredentials); err != nil {
+		return nil, err
+	}
+	if cfg.CORSMaxAge, err = lookupDuration("GUESTBOOK_CORS_MAX_AGE", cfg.CORSMaxAge); err != nil {
+		return nil, err
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		return nil, errors.New("invalid GUESTBOOK_CORS_ALLOWED_ORIGINS: \"*\" cannot be combined with GUESTBOOK_CORS_ALLOW_CREDENTIALS")
+	}
+
+	cfg.AdminToken = os.Getenv("GUESTBOOK_ADMIN_TOKEN")
+
+	cfg.SMTPAddr = os.Getenv("GUESTBOOK_SMTP_ADDR")
+	cfg.SMTPUsername = os.Getenv("GUESTBOOK_SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("GUESTBOOK_SMTP_PASSWORD")
+	if v := os.Getenv("GUESTBOOK_SMTP_FROM"); v != "" {
+		cfg.SMTPFrom = v
+	}
+	if cfg.DigestHour, err = lookupInt("GUESTBOOK_DIGEST_HOUR", cfg.DigestHour); err != nil {
+		return nil, err
+	}
+	if cfg.DigestHour < 0 || cfg.DigestHour > 23 {
+		return nil, fmt.Errorf("invalid GUESTBOOK_DIGEST_HOUR: %d is not an hour", cfg.DigestHour)
+	}
+
+	if v := os.Getenv("GUESTBOOK_ATTACHMENT_DIR"); v != "" {
+		cfg.AttachmentDir = v
+	}
+	cfg.AvatarKey = os.Getenv("GUESTBOOK_AVATAR_KEY")
+
+	if cfg.LinkPreviews, err = lookupBool("GUESTBOOK_LINK_PREVIEWS", cfg.LinkPreviews); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func lookupList(key string, fallback []string) []string {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, v := range strings.Split(v, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func lookupBool(key string, fallback bool) (bool, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func lookupInt(key string, fallback int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return i, nil
+}
+
+func lookupDuration(key string, fallback time.Duration) (time.Duration, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: &Config{
+				Title:                "Guestbook",
+				CSPReportOnly:        false,
+				HSTSMaxAge:           365 * 24 * time.Hour,
+				CORSAllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+				CORSAllowedHeaders:   []string{"Content-Type", "Authorization", "X-CSRF-Token"},
+				CORSAllowCredentials: false,
+				CORSMaxAge:           10 * time.Minute,
+				SMTPFrom:             "Guestbook <guestbook@localhost>",
+				DigestHour:           8,
+				AttachmentDir:        "attachments",
+				LinkPreviews:         true,
+			},
+		},
+		{
+			name: "from environment",
+			env: map[string]string{
+				"GUESTBOOK_TITLE":                  "Wedding guestbook",
+				"GUESTBOOK_CSP_REPORT_ONLY":        "true",
+				"GUESTBOOK_HSTS_MAX_AGE":           "0s",
+				"GUESTBOOK_SECURE_COOKIES":         "1",
+				"GUESTBOOK_CSRF_TRUSTED_ORIGINS":   "https://a.example, https://b.example,",
+				"GUESTBOOK_TRUSTED_PROXIES":        "10.0.0.0/8, 192.0.2.1",
+				"GUESTBOOK_CORS_ALLOWED_ORIGINS":   "https://partner.example",
+				"GUESTBOOK_CORS_ALLOWED_METHODS":   "GET",
+				"GUESTBOOK_CORS_ALLOWED_HEADERS":   "Content-Type",
+				"GUESTBOOK_CORS_ALLOW_CREDENTIALS": "true",
+				"GUESTBOOK_CORS_MAX_AGE":           "1h",
+				"GUESTBOOK_ADMIN_TOKEN":            "secret",
+				"GUESTBOOK_SMTP_ADDR":              "smtp.example:587",
+				"GUESTBOOK_SMTP_USERNAME":          "guestbook",
+				"GUESTBOOK_SMTP_PASSWORD":          "hunter2",
+				"GUESTBOOK_SMTP_FROM":              "Wedding <wedding@example.com>",
+				"GUESTBOOK_DIGEST_HOUR":            "18",
+				"GUESTBOOK_ATTACHMENT_DIR":         "/var/lib/guestbook/attachments",
+				"GUESTBOOK_AVATAR_KEY":             "s3cr3t",
+				"GUESTBOOK_LINK_PREVIEWS":          "false",
+			},
+			want: &Config{
+				Title:                "Wedding guestbook",
+				CSPReportOnly:        true,
+				HSTSMaxAge:           0,
+				SecureCookies:        true,
+				CSRFTrustedOrigins:   []string{"https://a.example", "https://b.example"},
+				TrustedProxies:       []string{"10.0.0.0/8", "192.0.2.1"},
+				CORSAllowedOrigins:   []string{"https://partner.example"},
+				CORSAllowedMethods:   []string{"GET"},
+				CORSAllowedHeaders:   []string{"Content-Type"},
+				CORSAllowCredentials: true,
+				CORSMaxAge:           time.Hour,
+				AdminToken:           "secret",
+				SMTPAddr:             "smtp.example:587",
+				SMTPUsername:         "guestbook",
+				SMTPPassword:         "hunter2",
+				SMTPFrom:             "Wedding <wedding@example.com>",
+				DigestHour:           18,
+				AttachmentDir:        "/var/lib/guestbook/attachments",
+				AvatarKey:            "s3cr3t",
+			},
+		},
+		{
+			name:    "invalid bool",
+			env:     map[string]string{"GUESTBOOK_CSP_REPORT_ONLY": "maybe"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cors max age",
+			env:     map[string]string{"GUESTBOOK_CORS_MAX_AGE": "forever"},
+			wantErr: true,
+		},
+		{
+			name: "wildcard cors origin with credentials",
+			env: map[string]string{
+				"GUESTBOOK_CORS_ALLOWED_ORIGINS":   "*",
+				"GUESTBOOK_CORS_ALLOW_CREDENTIALS": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid trusted proxy",
+			env:     map[string]string{"GUESTBOOK_TRUSTED_PROXIES": "proxy.example"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest hour",
+			env:     map[string]string{"GUESTBOOK_DIGEST_HOUR": "24"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"GUESTBOOK_HSTS_MAX_AGE": "1 year"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Attachment is an image attached to a message. The image and its thumbnail
+// are stored as blobs under Key and ThumbnailKey.
+type Attachment struct {
+	ID          int64  `json:"id"`
+	MessageID   int64  `json:"message_id"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Key         string `json:"key"`
+	// ThumbnailKey is the key of the thumbnail, whose content type is
+	// ThumbnailContentType.
+	ThumbnailKey         string    `json:"thumbnail_key"`
+	ThumbnailContentType string    `json:"thumbnail_content_type"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// MaxAttachmentSize is the largest image that can be attached to a message,
+// in bytes.
+const MaxAttachmentSize = 5 << 20
+
+// MaxAttachmentPixels is the largest number of pixels of an image that can be
+// attached to a message, which bounds the memory needed to decode it.
+const MaxAttachmentPixels = 25_000_000
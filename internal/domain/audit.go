@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of change to a message an audit entry records.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditActions are the actions recorded in the audit log.
+var AuditActions = []AuditAction{AuditActionCreate, AuditActionUpdate, AuditActionDelete}
+
+// MessageSnapshot is the state of a message before or after a change.
+type MessageSnapshot struct {
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Author   string `json:"author"`
+	Message  string `json:"message"`
+}
+
+// NewMessageSnapshot returns the snapshot of m, or nil if m is nil.
+func NewMessageSnapshot(m *Message) *MessageSnapshot {
+	if m == nil {
+		return nil
+	}
+	return &MessageSnapshot{
+		ParentID: m.ParentID,
+		Author:   m.Author,
+		Message:  m.Message,
+	}
+}
+
+// AuditEntry records a change to a message: who made it in which request,
+// and the message before and after it. Before is nil for a creation and
+// After for a deletion.
+//
+// Entries form a hash chain: Hash covers the entry and PrevHash, the hash of
+// the entry before it, so an entry cannot be altered, removed or reordered
+// without breaking the chain from there on.
+type AuditEntry struct {
+	ID          int64
+	GuestbookID int64
+	Actor       string
+	Action      AuditAction
+	MessageID   int64
+	Before      *MessageSnapshot
+	After       *MessageSnapshot
+	RequestID   string
+	CreatedAt   time.Time
+	PrevHash    string
+	Hash        string
+}
+
+// ComputeHash returns the hex-encoded SHA-256 hash of the entry, which
+// covers every field but ID and Hash.
+func (e *AuditEntry) ComputeHash() string {
+	// Struct fields are encoded in order, so the encoding is stable.
+	b, _ := json.Marshal(struct {
+		PrevHash    string           `json:"prev_hash"`
+		CreatedAt   string           `json:"created_at"`
+		GuestbookID int64            `json:"guestbook_id"`
+		Actor       string           `json:"actor"`
+		Action      AuditAction      `json:"action"`
+		MessageID   int64            `json:"message_id"`
+		Before      *MessageSnapshot `json:"before"`
+		After       *MessageSnapshot `json:"after"`
+		RequestID   string           `json:"request_id"`
+	}{
+		PrevHash:    e.PrevHash,
+		CreatedAt:   e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		GuestbookID: e.GuestbookID,
+		Actor:       e.Actor,
+		Action:      e.Action,
+		MessageID:   e.MessageID,
+		Before:      e.Before,
+		After:       e.After,
+		RequestID:   e.RequestID,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// DefaultAuditLimit is the number of audit entries listed unless a limit is
+// requested, and MaxAuditLimit the most that can be requested at once.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditQuery filters a listing of audit entries, oldest first. Zero fields
+// do not filter.
+type AuditQuery struct {
+	Actor     string
+	Action    AuditAction
+	MessageID int64
+	// Since and Until only list the entries created in [Since, Until).
+	Since time.Time
+	Until time.Time
+	// AfterID only lists the entries with a greater ID, for paging.
+	AfterID int64
+	// Limit is the maximum number of entries listed, DefaultAuditLimit if 0.
+	Limit int
+}
+
+// AuditVerification is the outcome of checking the hash chain of the audit
+// log. BrokenAt is the ID of the first entry that does not match its hash or
+// the hash of the entry before it, or 0 if the chain is intact.
+type AuditVerification struct {
+	Entries  int
+	BrokenAt int64
+}
+
+type actorContextKey struct{}
+
+type requestIDContextKey struct{}
+
+// ContextWithActor returns a copy of ctx identifying who makes the changes,
+// such as an admin.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns who makes the changes with ctx: the actor set with
+// ContextWithActor, or else the reactor identifying the visitor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	if actor, ok := ctx.Value(actorContextKey{}).(string); ok && actor != "" {
+		return actor, true
+	}
+	return ReactorFromContext(ctx)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the ID of the request
+// it serves.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the request ctx serves, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// HashEmail returns the hash identifying the avatar of an email address, the
+// way Gravatar does: the hex encoded SHA-256 of the trimmed, lowercased
+// address.
+func HashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAvatarID returns the public identifier of the avatar of m: the hex
+// encoded HMAC-SHA256, under the server's key, of the hash of the email of
+// its author, or of the author's name when it was posted without email.
+// Unlike the email hash, which anyone can compute from a known address, it
+// cannot be traced back to the email without the key.
+func NewAvatarID(key []byte, m *Message) string {
+	mac := hmac.New(sha256.New, key)
+	if m.EmailHash != "" {
+		mac.Write([]byte("email:" + m.EmailHash))
+	} else {
+		// The prefixes keep a name from having the avatar of an email.
+		mac.Write([]byte("author:" + m.Author))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DefaultAvatarSize is the width and height of avatars, in pixels, unless
+// another size is requested.
+const DefaultAvatarSize = 80
+
+// MinAvatarSize and MaxAvatarSize bound the sizes avatars can be requested
+// in.
+const (
+	MinAvatarSize = 16
+	MaxAvatarSize = 512
+)
@@ -0,0 +1,7 @@
+package domain
+
+// CORSOrigin is an origin allowed to call the API from the browser.
+type CORSOrigin struct {
+	ID     int64  `json:"id"`
+	Origin string `json:"origin"`
+}
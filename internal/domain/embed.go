@@ -0,0 +1,9 @@
+package domain
+
+// EmbedConfig is the configuration of a guestbook widget embedded into
+// another website.
+type EmbedConfig struct {
+	GuestbookSlug string `json:"guestbook"`
+	Title         string `json:"title"`
+	AllowPosting  bool   `json:"allow_posting"`
+}
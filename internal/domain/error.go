@@ -3,3 +3,24 @@ package domain
 import "errors"
 
 var ErrNotFound = errors.New("resource not found")
+
+var ErrInvalidArgument = errors.New("invalid argument")
+
+var ErrAlreadyExists = errors.New("resource already exists")
+
+var ErrForbidden = errors.New("forbidden")
+
+// ErrNoGuestbook is returned when tenant scoped data is accessed with a
+// context that is not scoped to a guestbook.
+var ErrNoGuestbook = errors.New("no guestbook in context")
+
+// ErrAborted is returned for the operations of a batch that were rolled back
+// or never applied because another operation of the batch failed.
+var ErrAborted = errors.New("aborted")
+
+// ErrTooLarge is returned when data exceeds a size limit.
+var ErrTooLarge = errors.New("too large")
+
+// ErrUnsupportedMediaType is returned for uploaded files of a type that is not
+// accepted.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
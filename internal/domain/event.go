@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// MessageEventType is the kind of change a MessageEvent reports.
+type MessageEventType string
+
+const (
+	MessageCreated MessageEventType = "message.created"
+	MessageUpdated MessageEventType = "message.updated"
+	MessageDeleted MessageEventType = "message.deleted"
+)
+
+// MessageEvent reports a change to a message of a guestbook.
+type MessageEvent struct {
+	// ID identifies and orders the events. It is assigned when the event is
+	// recorded in the outbox, and increases with every event. It is also the
+	// ID of the event in the message stream.
+	ID          int64            `json:"id"`
+	Type        MessageEventType `json:"type"`
+	GuestbookID int64            `json:"guestbook_id"`
+	MessageID   int64            `json:"message_id"`
+	// Message is the message after the change. It is nil for deletions,
+	// which also delete all replies to the message.
+	Message *Message  `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// OutboxEvent is a MessageEvent recorded in the outbox in the same
+// transaction as the change it reports, until it has been dispatched.
+type OutboxEvent struct {
+	ID int64
+	// Attempts is the number of failed attempts to dispatch the event.
+	Attempts int
+	// Handled is the names of the handlers that have handled the event, in
+	// the attempts that failed for other handlers.
+	Handled []string
+	Event   MessageEvent
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "context"
+
+// DefaultGuestbookSlug identifies the guestbook served by the routes that do
+// not name a guestbook, e.g. /api/v1/messages.
+const DefaultGuestbookSlug = "default"
+
+// Guestbook is a tenant owning its own set of messages.
+type Guestbook struct {
+	ID       int64             `json:"id"`
+	Slug     string            `json:"slug"`
+	Title    string            `json:"title"`
+	Settings GuestbookSettings `json:"settings"`
+}
+
+// GuestbookSettings are the per-guestbook settings.
+type GuestbookSettings struct {
+	// AllowPosting allows visitors to sign the guestbook.
+	AllowPosting bool `json:"allow_posting"`
+}
+
+type guestbookContextKey struct{}
+
+// ContextWithGuestbook returns a copy of ctx scoped to the guestbook.
+func ContextWithGuestbook(ctx context.Context, g *Guestbook) context.Context {
+	return context.WithValue(ctx, guestbookContextKey{}, g)
+}
+
+// GuestbookFromContext returns the guestbook ctx is scoped to, if any.
+func GuestbookFromContext(ctx context.Context) (*Guestbook, bool) {
+	g, ok := ctx.Value(guestbookContextKey{}).(*Guestbook)
+	return g, ok && g != nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// LinkPreviewStatus is the state of the preview of a link.
+type LinkPreviewStatus string
+
+const (
+	// LinkPreviewPending previews are waiting to be fetched.
+	LinkPreviewPending LinkPreviewStatus = "pending"
+	// LinkPreviewReady previews were fetched and can be shown.
+	LinkPreviewReady LinkPreviewStatus = "ready"
+	// LinkPreviewFailed previews could not be fetched, and are never shown.
+	LinkPreviewFailed LinkPreviewStatus = "failed"
+)
+
+// LinkPreview is the preview of a link in a message, built from the
+// metadata of the page it links to. Title, Description and SiteName are
+// plain text, and the image, if any, is stored as a blob under ImageKey.
+type LinkPreview struct {
+	ID          int64             `json:"id"`
+	MessageID   int64             `json:"message_id"`
+	URL         string            `json:"url"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	SiteName    string            `json:"site_name"`
+	ImageKey    string            `json:"image_key"`
+	Status      LinkPreviewStatus `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	FetchedAt   time.Time         `json:"fetched_at"`
+}
+
+// LinkMetadata is the metadata of a web page, from its OpenGraph properties
+// or their HTML equivalents. URL is the URL of the page after redirects, and
+// ImageURL an absolute URL.
+type LinkMetadata struct {
+	URL         string
+	Title       string
+	Description string
+	SiteName    string
+	ImageURL    string
+}
+
+// MaxLinkPreviews is the number of links of a message that get a preview.
+// Later links are left without one.
+const MaxLinkPreviews = 3
@@ -1,7 +1,62 @@
 package domain
 
+import "time"
+
 type Message struct {
-	ID      int64  `json:"id"`
-	Author  string `json:"author"`
-	Message string `json:"message"`
+	ID          int64     `json:"id"`
+	GuestbookID int64     `json:"guestbook_id"`
+	ParentID    *int64    `json:"parent_id"`
+	Author      string    `json:"author"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// EmailHash is the hash of the email the author gave, see HashEmail. The
+	// email itself is never stored, and neither is ever exposed.
+	EmailHash string `json:"-"`
+
+	// AvatarID identifies the avatar of the author, see NewAvatarID. It is
+	// not stored, and only set when an avatar key is configured.
+	AvatarID string `json:"-"`
+
+	// HTML is the content rendered from Markdown to sanitized HTML. It is
+	// not stored, and only set when a renderer is configured.
+	HTML string `json:"html"`
+
+	// Attachment is the image attached to the message, if any.
+	Attachment *Attachment `json:"attachment"`
+
+	// Links is the previews of the links in the content that were fetched,
+	// in order of appearance.
+	Links []*LinkPreview `json:"links"`
+
+	// EditCount is the number of times the message was updated, and EditedAt
+	// the time of the last update, zero if it was never updated.
+	EditCount int       `json:"edit_count"`
+	EditedAt  time.Time `json:"edited_at"`
+
+	// Replies and ReplyCount are only set on messages arranged in threads.
+	// Replies is cut off at the requested depth, while ReplyCount is the
+	// number of direct replies.
+	Replies    []*Message `json:"replies"`
+	ReplyCount int        `json:"reply_count"`
+
+	// Reactions is the number of reactions per emoji, in order of the first
+	// reaction with each emoji.
+	Reactions []ReactionCount `json:"reactions"`
+
+	// Snippet is only set on search results. It is an excerpt of the content
+	// around the words matching the search terms.
+	Snippet []TextSegment `json:"snippet"`
 }
+
+// MaxContentLength is the largest number of characters in the content of a
+// message.
+const MaxContentLength = 10_000
+
+// DefaultThreadDepth is the number of reply levels shown below top-level
+// messages unless a depth is requested.
+const DefaultThreadDepth = 3
+
+// MaxThreadDepth is the deepest reply level that can be requested at once.
+// Deeper replies are loaded level by level.
+const MaxThreadDepth = 10
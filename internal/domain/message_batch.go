@@ -0,0 +1,37 @@
+package domain
+
+// MessageOperationType is the kind of change a batch operation makes.
+type MessageOperationType string
+
+const (
+	MessageOperationCreate MessageOperationType = "create"
+	MessageOperationUpdate MessageOperationType = "update"
+	MessageOperationDelete MessageOperationType = "delete"
+)
+
+// MaxBatchOperations is the largest number of operations in a batch.
+const MaxBatchOperations = 500
+
+// MessageOperation is a change to a message within a batch. Creations use
+// the parent, author and content of Message, updates its ID, author and
+// content, and deletions only its ID.
+type MessageOperation struct {
+	Type    MessageOperationType
+	Message *Message
+}
+
+// MessageBatch is a list of operations applied in order. By default they are
+// applied in a single transaction, so they all fail if one fails. BestEffort
+// applies the operations that succeed and skips the others.
+type MessageBatch struct {
+	Operations []MessageOperation
+	BestEffort bool
+}
+
+// MessageOperationResult is the outcome of an operation of a batch: the ID of
+// the message it changed, or the error it failed with. The operations of a
+// batch rolled back because of another operation fail with ErrAborted.
+type MessageOperationResult struct {
+	ID  int64
+	Err error
+}
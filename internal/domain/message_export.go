@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// MessageExportQuery selects the messages of an export. The zero value
+// exports the messages that are not deleted.
+type MessageExportQuery struct {
+	// IncludeDeleted also exports the soft-deleted messages.
+	IncludeDeleted bool
+}
+
+// ExportedMessage is a message as stored, with all its timestamps. DeletedAt
+// is only set on soft-deleted messages.
+type ExportedMessage struct {
+	ID          int64
+	GuestbookID int64
+	ParentID    *int64
+	Author      string
+	Message     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
@@ -0,0 +1,36 @@
+package domain
+
+// MessageImportFormat is the format of a file of messages to import.
+type MessageImportFormat string
+
+const (
+	// MessageImportCSV is a CSV file with a header row naming its columns.
+	MessageImportCSV MessageImportFormat = "csv"
+	// MessageImportJSONL is a JSON Lines file, one JSON object per line.
+	MessageImportJSONL MessageImportFormat = "jsonl"
+)
+
+// MessageImportOptions configures an import.
+type MessageImportOptions struct {
+	Format MessageImportFormat
+	// DryRun validates the messages without creating them.
+	DryRun bool
+}
+
+// MessageImportReport is the outcome of an import, row by row.
+type MessageImportReport struct {
+	DryRun   bool
+	Accepted int
+	Rejected int
+	Rows     []MessageImportRow
+}
+
+// MessageImportRow is the outcome of importing a row. Rejected rows have an
+// Error, and accepted rows the ID of the created message unless the import
+// is a dry run.
+type MessageImportRow struct {
+	// Line is the line of the file the row starts on, from 1.
+	Line  int
+	ID    int64
+	Error string
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// MessageSort is the order messages are listed in.
+type MessageSort string
+
+const (
+	// MessageSortID lists messages in the order they were posted. It is the
+	// default order.
+	MessageSortID MessageSort = "id"
+	// MessageSortCreatedAt lists the oldest messages first.
+	MessageSortCreatedAt MessageSort = "created_at"
+	// MessageSortCreatedAtDesc lists the newest messages first.
+	MessageSortCreatedAtDesc MessageSort = "-created_at"
+)
+
+// MessageSorts are the supported sort orders.
+var MessageSorts = []MessageSort{MessageSortID, MessageSortCreatedAt, MessageSortCreatedAtDesc}
+
+// MessageQuery filters and orders a listing of messages. Zero fields do not
+// filter, so the zero value lists all messages in the default order.
+type MessageQuery struct {
+	// Author only lists the messages of the author with exactly this name.
+	Author string
+	// Since only lists the messages created at or after it.
+	Since time.Time
+	// Until only lists the messages created before it.
+	Until time.Time
+	// AfterID only lists the messages with a greater ID, i.e. posted later.
+	AfterID int64
+	// Sort is the order of the messages, MessageSortID if empty.
+	Sort MessageSort
+	// Limit is the maximum number of messages listed, all of them if 0.
+	Limit int
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// MessageRevision is a version of a message. Every update stores the version
+// it replaces, numbered from 1 for the original message, so the current
+// version of a message edited n times is revision n+1.
+type MessageRevision struct {
+	MessageID int64
+	Revision  int
+	Author    string
+	Message   string
+	// CreatedAt is when the version was written.
+	CreatedAt time.Time
+	// Current is set on the current version, which is not stored.
+	Current bool
+	// Diff is the change of the content from the previous version. The whole
+	// content of the original message is inserted.
+	Diff []DiffSegment
+}
+
+// DiffOp is what a DiffSegment does to the content of the previous version.
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffSegment is a run of text kept, inserted or deleted by an edit.
+type DiffSegment struct {
+	Op   DiffOp
+	Text string
+}
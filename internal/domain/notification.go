@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// NotificationFrequency is how often a guestbook owner is emailed about new
+// messages.
+type NotificationFrequency string
+
+const (
+	// NotifyInstant emails new messages as soon as they are posted.
+	NotifyInstant NotificationFrequency = "instant"
+	// NotifyDaily emails a digest of the messages posted during the day.
+	NotifyDaily NotificationFrequency = "daily"
+	// NotifyOff never emails.
+	NotifyOff NotificationFrequency = "off"
+)
+
+// NotificationFrequencies are the supported notification frequencies.
+var NotificationFrequencies = []NotificationFrequency{NotifyInstant, NotifyDaily, NotifyOff}
+
+// GuestbookOwner is a person emailed about the new messages of a guestbook.
+type GuestbookOwner struct {
+	ID          int64                 `json:"id"`
+	GuestbookID int64                 `json:"guestbook_id"`
+	Name        string                `json:"name"`
+	Email       string                `json:"email"`
+	Frequency   NotificationFrequency `json:"frequency"`
+	// LastMessageID is the last message the owner was notified of, and
+	// LastNotifiedAt when. Owners are notified of the messages posted after
+	// they were added.
+	LastMessageID  int64     `json:"last_message_id"`
+	LastNotifiedAt time.Time `json:"last_notified_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Email is an email with a plain text and an HTML body.
+type Email struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Reactions are the emoji visitors can react to messages with.
+var Reactions = []string{"👍", "❤️", "😂", "🎉", "😮", "😢"}
+
+// NormalizeReaction returns the reaction emoji matches, accepting emoji
+// written without variation selectors, e.g. "❤" for "❤️".
+func NormalizeReaction(emoji string) (string, bool) {
+	for _, r := range Reactions {
+		if emoji == r || emoji == strings.ReplaceAll(r, "\uFE0F", "") {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// Reaction is the reaction of a reactor, a visitor identified by a cookie or
+// an anonymous fingerprint, to a message.
+type Reaction struct {
+	MessageID int64  `json:"message_id"`
+	Reactor   string `json:"reactor"`
+	Emoji     string `json:"emoji"`
+}
+
+// ReactionCount is the number of reactions with an emoji to a message.
+// Reacted tells whether the reactor viewing the message is one of them.
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+type reactorContextKey struct{}
+
+// ContextWithReactor returns a copy of ctx identifying the visitor reacting
+// to messages.
+func ContextWithReactor(ctx context.Context, reactor string) context.Context {
+	return context.WithValue(ctx, reactorContextKey{}, reactor)
+}
+
+// ReactorFromContext returns the reactor ctx identifies, if any.
+func ReactorFromContext(ctx context.Context) (string, bool) {
+	reactor, ok := ctx.Value(reactorContextKey{}).(string)
+	return reactor, ok && reactor != ""
+}
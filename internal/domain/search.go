@@ -0,0 +1,23 @@
+package domain
+
+// MaxSearchTerms is the largest number of terms a search query may have.
+const MaxSearchTerms = 8
+
+// MaxSearchResults is the largest number of messages a search returns.
+const MaxSearchResults = 50
+
+// SearchQuery is a parsed full-text search query.
+type SearchQuery struct {
+	// Terms are lower-cased words made of letters and digits only. A message
+	// matches when its author or content has a word starting with each term.
+	Terms []string
+	// Limit is the largest number of messages to return, best matches first.
+	Limit int
+}
+
+// TextSegment is a run of text in a search snippet. Match tells whether the
+// run matches one of the search terms.
+type TextSegment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Webhook is an external URL subscribed to message events, which are
+// delivered to it as signed JSON payloads.
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret is the key the payloads are signed with.
+	Secret     string             `json:"secret"`
+	EventTypes []MessageEventType `json:"event_types"`
+	Active     bool               `json:"active"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// Subscribes reports whether events of type t are delivered to the webhook.
+func (w *Webhook) Subscribes(t MessageEventType) bool {
+	return w.Active && slices.Contains(w.EventTypes, t)
+}
+
+// WebhookDeliveryStatus is the state of a WebhookDelivery.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are attempted until they succeed or
+	// run out of attempts.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered deliveries were accepted by the webhook.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead deliveries ran out of attempts, and are only
+	// attempted again when redelivered.
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is the delivery of an event to a webhook, which is also
+// the log of the attempts made.
+type WebhookDelivery struct {
+	ID        int64            `json:"id"`
+	WebhookID int64            `json:"webhook_id"`
+	EventID   int64            `json:"event_id"`
+	EventType MessageEventType `json:"event_type"`
+	// Payload is the JSON body delivered to the webhook.
+	Payload       string                `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	// LastStatusCode and LastError are the outcome of the last attempt. The
+	// status code is 0 if no response was received.
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
@@ -0,0 +1,111 @@
+// Package blob stores binary objects, such as the images attached to
+// messages.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem stores blobs as files of a directory, named after their keys.
+type FileSystem struct {
+	logger *slog.Logger
+	dir    string
+}
+
+// NewFileSystem returns a FileSystem storing blobs in dir, which is created
+// if it does not exist.
+func NewFileSystem(logger *slog.Logger, dir string) (*FileSystem, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FileSystem{
+		logger: logger,
+		dir:    dir,
+	}, nil
+}
+
+// Put stores the content of r under key, replacing the blob stored under it.
+// The blob is written to a temporary file first, so a failed write never
+// leaves a partial blob behind.
+func (s *FileSystem) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+
+	return nil
+}
+
+// Open returns the content of the blob stored under key, which the caller
+// must close.
+func (s *FileSystem) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open blob: %w", errors.Join(domain.ErrNotFound, err))
+		}
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	return f, nil
+}
+
+// Delete deletes the blob stored under key. Deleting a blob that does not
+// exist is not an error.
+func (s *FileSystem) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the path of the file of the blob stored under key. Keys are
+// file names: they cannot name a file outside the directory, nor one of the
+// temporary files.
+func (s *FileSystem) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || strings.HasPrefix(key, ".") ||
+		strings.ContainsAny(key, `/\`+"\x00") || filepath.Base(key) != key {
+		return "", fmt.Errorf("%w: invalid blob key %q", domain.ErrInvalidArgument, key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestFileSystem(t *testing.T) *FileSystem {
+	t.Helper()
+	s, err := NewFileSystem(slog.New(slog.NewTextHandler(io.Discard, nil)), t.TempDir()+"/blobs")
+	if err != nil {
+		t.Fatalf("NewFileSystem() error = %v", err)
+	}
+	return s
+}
+
+func TestFileSystem(t *testing.T) {
+	s := newTestFileSystem(t)
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "a.jpg", strings.NewReader("first")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put(ctx, "a.jpg", strings.NewReader("second")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	r, err := s.Open(ctx, "a.jpg")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if string(got) != "second" {
+		t.Errorf("Open() content = %q, want %q", got, "second")
+	}
+
+	if err := s.Delete(ctx, "a.jpg"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := s.Delete(ctx, "a.jpg"); err != nil {
+		t.Errorf("Delete() of a deleted blob error = %v", err)
+	}
+	if _, err := s.Open(ctx, "a.jpg"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Open() of a deleted blob error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	// No temporary file is left behind.
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("blob directory has %d entries, want 0", len(entries))
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestFileSystem_PutFails(t *testing.T) {
+	s := newTestFileSystem(t)
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "a.jpg", io.MultiReader(strings.NewReader("partial"), failingReader{})); err == nil {
+		t.Fatal("Put() error = nil, want the read error")
+	}
+	if _, err := s.Open(ctx, "a.jpg"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Open() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 0 {
+		t.Errorf("blob directory has %d entries, want 0", len(entries))
+	}
+}
+
+func TestFileSystem_InvalidKeys(t *testing.T) {
+	s := newTestFileSystem(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", ".", "..", "../a.jpg", "a/b.jpg", `a\b.jpg`, ".tmp-1", "/etc/passwd", "a\x00.jpg"} {
+		if err := s.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("Put(%q) error = %v, want %v", key, err, domain.ErrInvalidArgument)
+		}
+		if _, err := s.Open(ctx, key); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("Open(%q) error = %v, want %v", key, err, domain.ErrInvalidArgument)
+		}
+		if err := s.Delete(ctx, key); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("Delete(%q) error = %v, want %v", key, err, domain.ErrInvalidArgument)
+		}
+	}
+}
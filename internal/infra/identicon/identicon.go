@@ -0,0 +1,123 @@
+// Package identicon generates avatars from hashes: a symmetric pattern of 5×5
+// cells in a color derived from the hash, so the same hash always gets the
+// same avatar and different hashes are easy to tell apart.
+package identicon
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// cells is the number of cells of a row and column of the pattern.
+const cells = 5
+
+// background is the color of the cells that are off and of the margin.
+var background = color.NRGBA{R: 0xF0, G: 0xF0, B: 0xF0, A: 0xFF}
+
+// Generator generates identicons as PNG images.
+type Generator struct{}
+
+// NewGenerator returns a new Generator.
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// PNG returns the identicon of hash, which must be at least 8 bytes long, as
+// a PNG image of size×size pixels.
+func (g *Generator) PNG(hash []byte, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, Image(hash, size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Image returns the identicon of hash, which must be at least 8 bytes long,
+// as an image of size×size pixels.
+//
+// Each of the 15 cells of the left three columns is on when its nibble of the
+// hash is even, and the right columns mirror the left ones. The last bytes of
+// the hash give the hue, saturation and lightness of the cells. The pattern
+// is centered with a margin of half a cell.
+func Image(hash []byte, size int) *image.Paletted {
+	fg := foreground(hash)
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{background, fg})
+
+	margin := size / (2*cells + 2)
+	inner := size - 2*margin
+	for row := 0; row < cells; row++ {
+		for col := 0; col < (cells+1)/2; col++ {
+			if !on(hash, row*((cells+1)/2)+col) {
+				continue
+			}
+			fill(img, margin, inner, col, row)
+			fill(img, margin, inner, cells-1-col, row)
+		}
+	}
+	return img
+}
+
+// on tells whether the cell i is on.
+func on(hash []byte, i int) bool {
+	nibble := hash[i/2%len(hash)]
+	if i%2 == 0 {
+		nibble >>= 4
+	}
+	return nibble&1 == 0
+}
+
+// fill sets the pixels of the cell at col and row to the foreground color.
+// Cell boundaries are rounded so the cells cover the inner square exactly.
+func fill(img *image.Paletted, margin, inner, col, row int) {
+	x0, x1 := margin+col*inner/cells, margin+(col+1)*inner/cells
+	y0, y1 := margin+row*inner/cells, margin+(row+1)*inner/cells
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			img.SetColorIndex(x, y, 1)
+		}
+	}
+}
+
+// foreground returns the color of the cells that are on, with a hue taken
+// from the hash and a saturation and lightness kept in a range that stands
+// out from the background.
+func foreground(hash []byte) color.NRGBA {
+	n := len(hash)
+	hue := float64(int(hash[n-3])<<8|int(hash[n-2])) / 65536 * 360
+	saturation := 0.45 + float64(hash[n-1]>>4)/15*0.2
+	lightness := 0.4 + float64(hash[n-1]&0x0F)/15*0.15
+	return hsl(hue, saturation, lightness)
+}
+
+// hsl converts a color from HSL, with the hue in degrees and the saturation
+// and lightness between 0 and 1, to RGB.
+func hsl(h, s, l float64) color.NRGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := l - c/2
+	return color.NRGBA{R: channel(r + m), G: channel(g + m), B: channel(b + m), A: 0xFF}
+}
+
+func channel(v float64) uint8 {
+	return uint8(min(max(v*255+0.5, 0), 255))
+}
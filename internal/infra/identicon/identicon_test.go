@@ -0,0 +1,87 @@
+package identicon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	hash := sha256.Sum256([]byte("arthur@example.com"))
+	img := Image(hash[:], 120)
+
+	if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 120 {
+		t.Fatalf("Image() is %dx%d, want 120x120", b.Dx(), b.Dy())
+	}
+	// The margin is half a cell: 120 / 12 pixels.
+	for i := 0; i < 120; i++ {
+		for _, p := range [][2]int{{i, 0}, {0, i}, {i, 119}, {119, i}, {i, 9}, {9, i}} {
+			if img.ColorIndexAt(p[0], p[1]) != 0 {
+				t.Fatalf("pixel %v of the margin is not the background", p)
+			}
+		}
+	}
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 120; x++ {
+			if img.ColorIndexAt(x, y) != img.ColorIndexAt(119-x, y) {
+				t.Fatalf("pixel (%d, %d) does not mirror (%d, %d)", x, y, 119-x, y)
+			}
+		}
+	}
+
+	// Each cell is filled when its nibble is even.
+	for row := 0; row < cells; row++ {
+		for col := 0; col < 3; col++ {
+			x, y := 10+col*20+10, 10+row*20+10
+			if got, want := img.ColorIndexAt(x, y) == 1, on(hash[:], row*3+col); got != want {
+				t.Errorf("cell (%d, %d) on = %v, want %v", col, row, got, want)
+			}
+		}
+	}
+}
+
+func TestImage_Deterministic(t *testing.T) {
+	a := sha256.Sum256([]byte("arthur@example.com"))
+	b := sha256.Sum256([]byte("dutch@example.com"))
+
+	if !bytes.Equal(Image(a[:], 80).Pix, Image(a[:], 80).Pix) {
+		t.Error("Image() of the same hash differs")
+	}
+	if Image(a[:], 80).Palette[1] == Image(b[:], 80).Palette[1] && bytes.Equal(Image(a[:], 80).Pix, Image(b[:], 80).Pix) {
+		t.Error("Image() of different hashes are the same")
+	}
+}
+
+func TestGenerator_PNG(t *testing.T) {
+	hash := sha256.Sum256([]byte("arthur@example.com"))
+	data, err := NewGenerator().PNG(hash[:], 16)
+	if err != nil {
+		t.Fatalf("Generator.PNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 16 {
+		t.Errorf("Generator.PNG() is %dx%d, want 16x16", b.Dx(), b.Dy())
+	}
+}
+
+func TestHSL(t *testing.T) {
+	tests := []struct {
+		h, s, l float64
+		want    color.NRGBA
+	}{
+		{h: 0, s: 1, l: 0.5, want: color.NRGBA{R: 255, A: 255}},
+		{h: 120, s: 1, l: 0.5, want: color.NRGBA{G: 255, A: 255}},
+		{h: 240, s: 1, l: 0.5, want: color.NRGBA{B: 255, A: 255}},
+		{h: 300, s: 0, l: 1, want: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		if got := hsl(tt.h, tt.s, tt.l); got != tt.want {
+			t.Errorf("hsl(%v, %v, %v) = %v, want %v", tt.h, tt.s, tt.l, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPConfig configures an SMTPSender.
+type SMTPConfig struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Username and Password authenticate with PLAIN auth when Username is
+	// set, which requires TLS unless the server is on localhost.
+	Username string
+	Password string
+	// From is the sender address, e.g. "Guestbook <guestbook@example.com>".
+	From string
+	// Timeout bounds sending an email, including connecting.
+	Timeout time.Duration
+}
+
+// SMTPSender sends emails through an SMTP server, upgrading the connection
+// with STARTTLS when the server supports it.
+type SMTPSender struct {
+	logger *slog.Logger
+	config SMTPConfig
+}
+
+// NewSMTPSender returns a new SMTPSender instance.
+func NewSMTPSender(logger *slog.Logger, config SMTPConfig) *SMTPSender {
+	return &SMTPSender{
+		logger: logger,
+		config: config,
+	}
+}
+
+// Send sends an email as a multipart/alternative message with its text and
+// HTML bodies.
+func (s *SMTPSender) Send(ctx context.Context, e *domain.Email) error {
+	from, err := mail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	if len(e.To) == 0 {
+		return fmt.Errorf("failed to build email: %w: no recipients", domain.ErrInvalidArgument)
+	}
+	to := make([]*mail.Address, len(e.To))
+	for i, addr := range e.To {
+		if to[i], err = mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("failed to build email: %w: invalid recipient %q", domain.ErrInvalidArgument, addr)
+		}
+	}
+	msg, err := buildMessage(from, to, e, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+	if err := s.send(ctx, from, to, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SMTPSender) send(ctx context.Context, from *mail.Address, to []*mail.Address, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.config.Addr)
+	if err != nil {
+		return err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// buildMessage formats an email as an RFC 5322 message.
+func buildMessage(from *mail.Address, to []*mail.Address, e *domain.Email, now time.Time) ([]byte, error) {
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/alternative; boundary="`+body.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	// Clients show the last alternative they support, so HTML goes last.
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", e.Text},
+		{"text/html; charset=utf-8", e.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, s); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the domain of the sender.
+func messageID(from string) string {
+	host := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		host = from[i+1:]
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + host + ">"
+}
@@ -0,0 +1,190 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a local SMTP server accepting a single email, or rejecting
+// the recipients when reject is set.
+type smtpStandIn struct {
+	listener net.Listener
+	reject   bool
+
+	from string
+	to   []string
+	data string
+	done chan struct{}
+}
+
+func newSMTPStandIn(t *testing.T, reject bool) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpStandIn{listener: l, reject: reject, done: make(chan struct{})}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "MAIL":
+			s.from = arg
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			if s.reject {
+				_ = tp.PrintfLine("550 no such user")
+				continue
+			}
+			s.to = append(s.to, arg)
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, _ := io.ReadAll(tp.DotReader())
+			s.data = string(data)
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 unsupported")
+		}
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := newSMTPStandIn(t, false)
+	sender := NewSMTPSender(logger, SMTPConfig{
+		Addr:    server.listener.Addr().String(),
+		From:    "Guestbook <guestbook@example.com>",
+		Timeout: 5 * time.Second,
+	})
+
+	err := sender.Send(context.Background(), &domain.Email{
+		To:      []string{"Dutch van der Linde <dutch@example.com>"},
+		Subject: "Nouveau message: café",
+		Text:    "Arthur signed the guestbook: Hey, Dutch!",
+		HTML:    "<p>Arthur signed the guestbook: <q>Hey, Dutch!</q></p>",
+	})
+	if err != nil {
+		t.Fatalf("SMTPSender.Send() error = %v", err)
+	}
+	<-server.done
+
+	if server.from != "FROM:<guestbook@example.com>" {
+		t.Errorf("MAIL %s, want FROM:<guestbook@example.com>", server.from)
+	}
+	if len(server.to) != 1 || server.to[0] != "TO:<dutch@example.com>" {
+		t.Errorf("RCPT %v, want TO:<dutch@example.com>", server.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	if got := msg.Header.Get("To"); got != `"Dutch van der Linde" <dutch@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Nouveau message: café" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Errorf("headers = %v, want a Message-ID and a Date", msg.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Arthur signed the guestbook: Hey, Dutch!"},
+		{"text/html; charset=utf-8", "<p>Arthur signed the guestbook: <q>Hey, Dutch!</q></p>"},
+	}
+	for _, w := range want {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("failed to read %s part: %v", w.contentType, err)
+		}
+		// NextPart decodes quoted-printable.
+		body, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Type"); got != w.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, w.contentType)
+		}
+		if string(body) != w.body {
+			t.Errorf("part body = %q, want %q", body, w.body)
+		}
+	}
+	if _, err := parts.NextPart(); !errors.Is(err, io.EOF) {
+		t.Errorf("NextPart() error = %v, want io.EOF", err)
+	}
+}
+
+func TestSMTPSender_Send_errors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	email := &domain.Email{To: []string{"dutch@example.com"}, Subject: "Hi", Text: "Hi"}
+
+	t.Run("recipient rejected", func(t *testing.T) {
+		server := newSMTPStandIn(t, true)
+		sender := NewSMTPSender(logger, SMTPConfig{Addr: server.listener.Addr().String(), From: "guestbook@example.com"})
+
+		if err := sender.Send(context.Background(), email); err == nil || !strings.Contains(err.Error(), "550") {
+			t.Errorf("SMTPSender.Send() error = %v, want the 550 reply", err)
+		}
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		sender := NewSMTPSender(logger, SMTPConfig{Addr: "127.0.0.1:1", From: "guestbook@example.com"})
+
+		err := sender.Send(context.Background(), &domain.Email{To: []string{"not an address"}})
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("SMTPSender.Send() error = %v, want %v", err, domain.ErrInvalidArgument)
+		}
+	})
+
+	t.Run("server down", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		addr := l.Addr().String()
+		l.Close()
+		sender := NewSMTPSender(logger, SMTPConfig{Addr: addr, From: "guestbook@example.com", Timeout: time.Second})
+
+		if err := sender.Send(context.Background(), email); err == nil {
+			t.Error("SMTPSender.Send() error = nil, want a connection error")
+		}
+	})
+}
@@ -0,0 +1,532 @@
+// Package markdown renders the Markdown of messages to HTML.
+//
+// It supports the subset of Markdown a guestbook needs:
+//
+//   - paragraphs separated by blank lines, single newlines being line breaks
+//   - bulleted lists (-, * or +) and numbered lists (1. or 1)), one level deep
+//   - block quotes (>) and fenced code blocks (```)
+//   - **strong** or __strong__, *emphasis* or _emphasis_, ~~deleted~~ and
+//     `code`
+//   - [links](https://example.com) and bare http and https URLs
+//   - backslash escapes of punctuation
+//
+// Anything else, including raw HTML, is rendered as text. Links only keep
+// http, https and mailto URLs and are marked rel="nofollow ugc". The output
+// is passed through an allowlist sanitizer, so it is safe to insert as HTML
+// whatever the input.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxURLLength is the longest URL of a link. It bounds the text scanned
+// for the URL of each [.
+const maxURLLength = 2048
+
+// maxDepth bounds the nesting of block quotes and inline elements. Deeper
+// markup is rendered as text.
+const maxDepth = 8
+
+// linkRel is the rel attribute of every link, which comes from visitors.
+const linkRel = "nofollow ugc"
+
+// Render returns the sanitized HTML of the Markdown src.
+func Render(src string) string {
+	src = strings.ReplaceAll(strings.ToValidUTF8(src, "\uFFFD"), "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), 0)
+	return Sanitize(b.String())
+}
+
+// renderBlocks renders lines as a sequence of blocks.
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case isFence(line):
+			i = renderCode(b, lines, i)
+		case depth < maxDepth && isQuote(line):
+			i = renderQuote(b, lines, i, depth)
+		case listMarker(line) != nil:
+			i = renderList(b, lines, i, depth)
+		default:
+			i = renderParagraph(b, lines, i, depth)
+		}
+	}
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " "), "```")
+}
+
+func isQuote(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+// renderCode renders the fenced code block starting at lines[i], up to the
+// closing fence or the end, and returns the index of the next line.
+func renderCode(b *strings.Builder, lines []string, i int) int {
+	var code []string
+	for i++; i < len(lines) && !isFence(lines[i]); i++ {
+		code = append(code, lines[i])
+	}
+	b.WriteString("<pre><code>")
+	b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+	b.WriteString("</code></pre>\n")
+	if i < len(lines) {
+		i++
+	}
+	return i
+}
+
+// renderQuote renders the block quote starting at lines[i] and returns the
+// index of the next line.
+func renderQuote(b *strings.Builder, lines []string, i, depth int) int {
+	var quoted []string
+	for ; i < len(lines) && isQuote(lines[i]); i++ {
+		line := strings.TrimPrefix(strings.TrimLeft(lines[i], " "), ">")
+		quoted = append(quoted, strings.TrimPrefix(line, " "))
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, quoted, depth+1)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// marker is the marker of a list item.
+type marker struct {
+	ordered bool
+	start   int
+	// width is the length of the marker and the space after it.
+	width int
+}
+
+// listMarker returns the marker line starts with, or nil.
+func listMarker(line string) *marker {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(line) - len(trimmed)
+	if indent > 3 || trimmed == "" {
+		return nil
+	}
+	if c := trimmed[0]; c == '-' || c == '*' || c == '+' {
+		if len(trimmed) > 1 && trimmed[1] == ' ' {
+			return &marker{width: indent + 2}
+		}
+		return nil
+	}
+	n := 0
+	for n < len(trimmed) && n < 9 && '0' <= trimmed[n] && trimmed[n] <= '9' {
+		n++
+	}
+	if n == 0 || n+1 >= len(trimmed) || (trimmed[n] != '.' && trimmed[n] != ')') || trimmed[n+1] != ' ' {
+		return nil
+	}
+	start, _ := strconv.Atoi(trimmed[:n])
+	return &marker{ordered: true, start: start, width: indent + n + 2}
+}
+
+// renderList renders the list starting at lines[i], which ends at a blank
+// line or an item of the other kind of list, and returns the index of the
+// next line. Lines that are not items continue the previous item.
+func renderList(b *strings.Builder, lines []string, i, depth int) int {
+	first := listMarker(lines[i])
+	if first.ordered {
+		if first.start != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(first.start) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	var item []string
+	flush := func() {
+		if item == nil {
+			return
+		}
+		b.WriteString("<li>")
+		renderInlineLines(b, item, depth)
+		b.WriteString("</li>\n")
+		item = nil
+	}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || isFence(line) || isQuote(line) {
+			break
+		}
+		m := listMarker(line)
+		if m == nil {
+			item = append(item, strings.TrimSpace(line))
+			continue
+		}
+		if m.ordered != first.ordered {
+			break
+		}
+		flush()
+		item = []string{line[m.width:]}
+	}
+	flush()
+
+	if first.ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// renderParagraph renders the paragraph starting at lines[i] and returns the
+// index of the next line.
+func renderParagraph(b *strings.Builder, lines []string, i, depth int) int {
+	var para []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		// Too deeply nested block quotes are paragraphs of their own.
+		if len(para) > 0 && (isFence(line) || isQuote(line) || listMarker(line) != nil) {
+			break
+		}
+		para = append(para, strings.TrimSpace(line))
+	}
+	b.WriteString("<p>")
+	renderInlineLines(b, para, depth)
+	b.WriteString("</p>\n")
+	return i
+}
+
+// renderInlineLines renders lines as inline content separated by line
+// breaks.
+func renderInlineLines(b *strings.Builder, lines []string, depth int) {
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("<br>\n")
+		}
+		p := inlineParser{src: line, links: true}
+		p.render(b, depth)
+	}
+}
+
+// inlineParser renders the inline markup of a line. Delimiters without a
+// closing delimiter are text. Failed lookups are remembered and brackets are
+// matched once per line, so no delimiter makes the parser scan the rest of
+// the line more than once per nesting level.
+type inlineParser struct {
+	src string
+	// links is false inside the text of a link, which cannot nest links.
+	links bool
+	// unclosed holds the delimiters known to have no closing delimiter in
+	// the rest of src.
+	unclosed map[string]bool
+	// brackets maps the index of each [ to the index of its closing ], and
+	// parens holds the indexes of the ) in src, in order. Both are computed
+	// on the first link.
+	brackets map[int]int
+	parens   []int
+}
+
+// emphasis maps the delimiters of inline elements to their tags, longest
+// delimiters first.
+var emphasis = []struct {
+	delim string
+	tag   string
+}{
+	{"**", "strong"},
+	{"__", "strong"},
+	{"~~", "del"},
+	{"*", "em"},
+	{"_", "em"},
+}
+
+func (p *inlineParser) render(b *strings.Builder, depth int) {
+	src := p.src
+	text := 0
+	flush := func(end int) {
+		b.WriteString(html.EscapeString(src[text:end]))
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && isASCIIPunct(src[i+1]):
+			flush(i)
+			b.WriteString(html.EscapeString(src[i+1 : i+2]))
+			i += 2
+			text = i
+			continue
+
+		case c == '`':
+			if end, code, ok := p.codeSpan(i); ok {
+				flush(i)
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i, text = end, end
+				continue
+			}
+			// Skip the whole run, which no other run can close.
+			for i < len(src) && src[i] == '`' {
+				i++
+			}
+			continue
+
+		case c == '[' && p.links && depth < maxDepth:
+			if end, label, href, ok := p.link(i); ok {
+				flush(i)
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">`)
+				inner := inlineParser{src: label}
+				inner.render(b, depth+1)
+				b.WriteString("</a>")
+				i, text = end, end
+				continue
+			}
+
+		case (c == 'h' || c == 'H') && p.links && atWordStart(src, i):
+			if end, href, ok := autolink(src, i); ok {
+				flush(i)
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">` + html.EscapeString(src[i:end]) + "</a>")
+				i, text = end, end
+				continue
+			}
+
+		case (c == '*' || c == '_' || c == '~') && depth < maxDepth:
+			if end, tag, inner, ok := p.emphasis(i); ok {
+				flush(i)
+				b.WriteString("<" + tag + ">")
+				child := inlineParser{src: inner, links: p.links}
+				child.render(b, depth+1)
+				b.WriteString("</" + tag + ">")
+				i, text = end, end
+				continue
+			}
+		}
+		i++
+	}
+	flush(len(src))
+}
+
+// codeSpan returns the end and content of the code span opened by the run of
+// backticks at i, closed by a run of the same length.
+func (p *inlineParser) codeSpan(i int) (int, string, bool) {
+	n := 0
+	for i+n < len(p.src) && p.src[i+n] == '`' {
+		n++
+	}
+	key := strings.Repeat("`", n)
+	if p.unclosed[key] {
+		return 0, "", false
+	}
+	for j := i + n; j < len(p.src); {
+		k := strings.IndexByte(p.src[j:], '`')
+		if k < 0 {
+			break
+		}
+		j += k
+		m := 0
+		for j+m < len(p.src) && p.src[j+m] == '`' {
+			m++
+		}
+		if m == n {
+			code := p.src[i+n : j]
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+				code = code[1 : len(code)-1]
+			}
+			return j + m, code, true
+		}
+		j += m
+	}
+	p.markUnclosed(key)
+	return 0, "", false
+}
+
+// link returns the end, text and URL of the link [text](url) at i. Links to
+// URLs of other schemes are not links.
+func (p *inlineParser) link(i int) (int, string, string, bool) {
+	if p.brackets == nil {
+		p.matchBrackets()
+	}
+	close, ok := p.brackets[i]
+	if !ok || close == i+1 || close+1 >= len(p.src) || p.src[close+1] != '(' {
+		return 0, "", "", false
+	}
+	// The URL ends at the first ) after the opening parenthesis.
+	k, _ := slices.BinarySearch(p.parens, close+2)
+	if k == len(p.parens) {
+		return 0, "", "", false
+	}
+	end := p.parens[k]
+	if end-close-2 > maxURLLength {
+		return 0, "", "", false
+	}
+	href, ok := safeURL(strings.TrimSpace(p.src[close+2 : end]))
+	if !ok {
+		return 0, "", "", false
+	}
+	return end + 1, p.src[i+1 : close], href, true
+}
+
+// matchBrackets matches the brackets of src, skipping escaped ones, and
+// finds its closing parentheses.
+func (p *inlineParser) matchBrackets() {
+	p.brackets = make(map[int]int)
+	var open []int
+	for j := 0; j < len(p.src); j++ {
+		switch p.src[j] {
+		case '\\':
+			j++
+		case '[':
+			open = append(open, j)
+		case ']':
+			if n := len(open); n > 0 {
+				p.brackets[open[n-1]] = j
+				open = open[:n-1]
+			}
+		case ')':
+			p.parens = append(p.parens, j)
+		}
+	}
+}
+
+// emphasis returns the end, tag and content of the element opened by the
+// delimiter at i. The content must neither start nor end with whitespace,
+// and _ only delimits whole words, so snake_case stays as it is.
+func (p *inlineParser) emphasis(i int) (int, string, string, bool) {
+	for _, e := range emphasis {
+		d := e.delim
+		if !strings.HasPrefix(p.src[i:], d) || p.unclosed[d] {
+			continue
+		}
+		start := i + len(d)
+		if start >= len(p.src) || isSpaceAt(p.src, start) || strings.HasPrefix(p.src[start:], d) {
+			continue
+		}
+		if d[0] == '_' && i > 0 && isWordBefore(p.src, i) {
+			continue
+		}
+		for j := start + 1; j <= len(p.src)-len(d); j++ {
+			if p.src[j] == '\\' {
+				j++
+				continue
+			}
+			if p.src[j] != d[0] {
+				continue
+			}
+			// Only a run of the delimiter's length closes: the runs of
+			// other lengths delimit nested elements.
+			run := j
+			for run < len(p.src) && p.src[run] == d[0] {
+				run++
+			}
+			if run-j != len(d) || isSpaceBefore(p.src, j) {
+				j = run - 1
+				continue
+			}
+			if d[0] == '_' && j+len(d) < len(p.src) && isWordAt(p.src, j+len(d)) {
+				continue
+			}
+			return j + len(d), e.tag, p.src[start:j], true
+		}
+		p.markUnclosed(d)
+	}
+	return 0, "", "", false
+}
+
+func (p *inlineParser) markUnclosed(delim string) {
+	if p.unclosed == nil {
+		p.unclosed = make(map[string]bool)
+	}
+	p.unclosed[delim] = true
+}
+
+// autolink returns the end and URL of the bare URL at i. Trailing
+// punctuation is left out, as is a closing parenthesis without an opening
+// one in the URL.
+func autolink(src string, i int) (int, string, bool) {
+	rest := src[i:]
+	lower := strings.ToLower(rest[:min(len(rest), 8)])
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return 0, "", false
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '<' || r == '>' || r == '"' })
+	if end < 0 {
+		end = len(rest)
+	}
+	for end > 0 {
+		last := rest[end-1]
+		if strings.IndexByte(".,:;!?'*_~", last) >= 0 ||
+			(last == ')' && strings.Count(rest[:end], "(") < strings.Count(rest[:end], ")")) {
+			end--
+			continue
+		}
+		break
+	}
+	href, ok := safeURL(rest[:end])
+	if !ok {
+		return 0, "", false
+	}
+	return i + end, href, true
+}
+
+// safeURL returns the URL to link to, if it is an absolute http, https or
+// mailto URL.
+func safeURL(raw string) (string, bool) {
+	if raw == "" || strings.ContainsFunc(raw, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+		if u.Opaque == "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isSpaceAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsSpace(r)
+}
+
+func isSpaceBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return unicode.IsSpace(r)
+}
+
+func isWordAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// atWordStart reports whether i does not follow a letter or digit, so URLs
+// are only recognized as words of their own.
+func atWordStart(s string, i int) bool {
+	return i == 0 || !isWordBefore(s, i)
+}
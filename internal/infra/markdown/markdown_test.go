@@ -0,0 +1,195 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			src:  "Hello\nworld\n\nBye",
+			want: "<p>Hello<br>\nworld</p>\n<p>Bye</p>\n",
+		},
+		{
+			name: "CRLF",
+			src:  "a\r\nb",
+			want: "<p>a<br>\nb</p>\n",
+		},
+		{
+			name: "strong and emphasis",
+			src:  "**bold** __bold__ *em* _em_ ~~gone~~",
+			want: "<p><strong>bold</strong> <strong>bold</strong> <em>em</em> <em>em</em> <del>gone</del></p>\n",
+		},
+		{
+			name: "nested emphasis",
+			src:  "**very *nice* indeed** and *a **b** c*",
+			want: "<p><strong>very <em>nice</em> indeed</strong> and <em>a <strong>b</strong> c</em></p>\n",
+		},
+		{
+			name: "underscores inside words",
+			src:  "snake_case_name and 2_000_000",
+			want: "<p>snake_case_name and 2_000_000</p>\n",
+		},
+		{
+			name: "unclosed delimiters",
+			src:  "**not bold and * not em",
+			want: "<p>**not bold and * not em</p>\n",
+		},
+		{
+			name: "delimiters followed by whitespace",
+			src:  "2 * 3 * 4",
+			want: "<p>2 * 3 * 4</p>\n",
+		},
+		{
+			name: "code span",
+			src:  "use `<b>**x**</b>` here",
+			want: "<p>use <code>&lt;b&gt;**x**&lt;/b&gt;</code> here</p>\n",
+		},
+		{
+			name: "code span with backticks",
+			src:  "``a ` b``",
+			want: "<p><code>a ` b</code></p>\n",
+		},
+		{
+			name: "escapes",
+			src:  `\*not em\* and \_x\_`,
+			want: "<p>*not em* and _x_</p>\n",
+		},
+		{
+			name: "link",
+			src:  "see [the *site*](https://example.com/a?b=1&c=2)",
+			want: `<p>see <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow ugc">the <em>site</em></a></p>` + "\n",
+		},
+		{
+			name: "mailto link",
+			src:  "[mail me](mailto:me@example.com)",
+			want: `<p><a href="mailto:me@example.com" rel="nofollow ugc">mail me</a></p>` + "\n",
+		},
+		{
+			name: "javascript link",
+			src:  "[click](javascript:alert(1))",
+			want: "<p>[click](javascript:alert(1))</p>\n",
+		},
+		{
+			name: "relative link",
+			src:  "[home](/admin)",
+			want: "<p>[home](/admin)</p>\n",
+		},
+		{
+			name: "nested links",
+			src:  "[a [b](https://b.example)](https://a.example)",
+			want: `<p><a href="https://a.example" rel="nofollow ugc">a [b](https://b.example)</a></p>` + "\n",
+		},
+		{
+			name: "autolink",
+			src:  "Visit https://example.com/path, or (http://example.org/x_(y)).",
+			want: `<p>Visit <a href="https://example.com/path" rel="nofollow ugc">https://example.com/path</a>, or (<a href="http://example.org/x_(y)" rel="nofollow ugc">http://example.org/x_(y)</a>).</p>` + "\n",
+		},
+		{
+			name: "autolink inside a word",
+			src:  "xhttps://example.com",
+			want: "<p>xhttps://example.com</p>\n",
+		},
+		{
+			name: "bulleted list",
+			src:  "- one\n* two\n  more\n+ three",
+			want: "<ul>\n<li>one</li>\n<li>two<br>\nmore</li>\n<li>three</li>\n</ul>\n",
+		},
+		{
+			name: "numbered list",
+			src:  "3. three\n4) four",
+			want: "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n",
+		},
+		{
+			name: "list after paragraph",
+			src:  "Todo:\n1. a\n\n- b",
+			want: "<p>Todo:</p>\n<ol>\n<li>a</li>\n</ol>\n<ul>\n<li>b</li>\n</ul>\n",
+		},
+		{
+			name: "not a list",
+			src:  "-1 and *bold*",
+			want: "<p>-1 and <em>bold</em></p>\n",
+		},
+		{
+			name: "block quote",
+			src:  "> quoted **text**\n> > nested\n\nafter",
+			want: "<blockquote>\n<p>quoted <strong>text</strong></p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n<p>after</p>\n",
+		},
+		{
+			name: "fenced code",
+			src:  "```go\nfunc main() {\n\t*x* <b>\n}\n```\ntext",
+			want: "<pre><code>func main() {\n\t*x* &lt;b&gt;\n}</code></pre>\n<p>text</p>\n",
+		},
+		{
+			name: "unclosed fence",
+			src:  "```\ncode",
+			want: "<pre><code>code</code></pre>\n",
+		},
+		{
+			name: "raw HTML",
+			src:  `<script>alert("x")</script><img src=x onerror=alert(1)>`,
+			want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name: "character references",
+			src:  "&lt;b&gt; &amp;",
+			want: "<p>&amp;lt;b&amp;gt; &amp;amp;</p>\n",
+		},
+		{
+			name: "quotes in link",
+			src:  `[x](https://example.com/"onmouseover="alert(1))`,
+			want: `<p><a href="https://example.com/%22onmouseover=%22alert%281" rel="nofollow ugc">x</a>)</p>` + "\n",
+		},
+		{
+			name: "empty",
+			src:  "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q) =\n%q\nwant\n%q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_Depth(t *testing.T) {
+	src := strings.Repeat(">", 100) + " deep"
+	got := Render(src)
+	if n := strings.Count(got, "<blockquote>"); n != maxDepth {
+		t.Errorf("got %d block quotes, want %d", n, maxDepth)
+	}
+	if !strings.Contains(got, "&gt;") {
+		t.Errorf("deeper quote markers are not text: %q", got)
+	}
+}
+
+func TestRender_Linear(t *testing.T) {
+	// Unclosed delimiters and nested brackets must not make rendering
+	// quadratic.
+	for _, src := range []string{
+		strings.Repeat("*a ", 50000),
+		strings.Repeat("_a ", 50000),
+		strings.Repeat("[a", 50000),
+		strings.Repeat("[", 50000) + strings.Repeat("]", 50000),
+		strings.Repeat("[a](", 50000) + ")",
+		strings.Repeat("[a]", 50000),
+		strings.Repeat("`a", 50000),
+		strings.Repeat("**", 50000),
+	} {
+		start := time.Now()
+		Render(src)
+		if d := time.Since(start); d > 2*time.Second {
+			t.Errorf("rendering %q... took %v", src[:10], d)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// DefaultCacheSize is the default number of rendered messages a Renderer
+// keeps.
+const DefaultCacheSize = 1024
+
+// Renderer renders Markdown like Render, keeping the output of the most
+// recently rendered sources so the messages of a page are not rendered again
+// on every request.
+type Renderer struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	// recent orders the entries from the most to the least recently used.
+	recent *list.List
+}
+
+type cacheEntry struct {
+	key  [sha256.Size]byte
+	html string
+}
+
+// NewRenderer returns a Renderer keeping the output of the last size
+// rendered sources.
+func NewRenderer(size int) *Renderer {
+	return &Renderer{
+		size:    max(size, 1),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// Render returns the sanitized HTML of the Markdown src.
+func (r *Renderer) Render(src string) string {
+	key := keyOf(src)
+
+	r.mu.Lock()
+	if e, ok := r.entries[key]; ok {
+		r.recent.MoveToFront(e)
+		html := e.Value.(*cacheEntry).html
+		r.mu.Unlock()
+		return html
+	}
+	r.mu.Unlock()
+
+	html := Render(src)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[key]; !ok {
+		r.entries[key] = r.recent.PushFront(&cacheEntry{key: key, html: html})
+		if r.recent.Len() > r.size {
+			oldest := r.recent.Back()
+			r.recent.Remove(oldest)
+			delete(r.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	return html
+}
+
+// Len returns the number of rendered sources kept.
+func (r *Renderer) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recent.Len()
+}
+
+// keyOf returns the cache key of src.
+func keyOf(src string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(src))
+}
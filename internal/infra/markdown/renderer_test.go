@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	r := NewRenderer(2)
+
+	if got, want := r.Render("**a**"), Render("**a**"); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if got, want := r.Render("**a**"), Render("**a**"); got != want {
+		t.Errorf("cached Render() = %q, want %q", got, want)
+	}
+	if r.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", r.Len())
+	}
+
+	r.Render("b")
+	r.Render("**a**") // "b" is now the least recently used
+	r.Render("c")
+	if r.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", r.Len())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, src := range []string{"**a**", "c"} {
+		if e, ok := r.entries[keyOf(src)]; !ok || e.Value.(*cacheEntry).html != Render(src) {
+			t.Errorf("%q is not cached", src)
+		}
+	}
+	if _, ok := r.entries[keyOf("b")]; ok {
+		t.Error(`"b" is still cached`)
+	}
+}
+
+func TestRenderer_Concurrent(t *testing.T) {
+	r := NewRenderer(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				src := fmt.Sprintf("*%d*", j%16)
+				if got, want := r.Render(src), Render(src); got != want {
+					t.Errorf("Render(%q) = %q, want %q", src, got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if r.Len() != 8 {
+		t.Errorf("Len() = %d, want 8", r.Len())
+	}
+}
@@ -0,0 +1,95 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedElements maps the elements Sanitize keeps to their allowed
+// attributes.
+var allowedElements = map[string]map[string]bool{
+	"p":          nil,
+	"br":         nil,
+	"strong":     nil,
+	"em":         nil,
+	"del":        nil,
+	"code":       nil,
+	"pre":        nil,
+	"ul":         nil,
+	"ol":         {"start": true},
+	"li":         nil,
+	"blockquote": nil,
+	"a":          {"href": true},
+}
+
+// Sanitize returns the HTML s with only the elements and attributes the
+// renderer produces: other elements are removed, keeping their text, links
+// must have an http, https or mailto URL and are marked rel="nofollow ugc",
+// and unclosed elements are closed.
+//
+// Render already sanitizes its output; Sanitize is the last line of defense
+// against a bug in the renderer letting markup through.
+func Sanitize(s string) string {
+	var b strings.Builder
+	var open []string
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(string(z.Text())))
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			t := z.Token()
+			attrs, ok := allowedElements[t.Data]
+			if !ok {
+				continue
+			}
+			b.WriteString("<" + t.Data)
+			for _, a := range t.Attr {
+				if a.Namespace != "" || !attrs[a.Key] {
+					continue
+				}
+				val := a.Val
+				if a.Key == "href" {
+					href, ok := safeURL(val)
+					if !ok {
+						continue
+					}
+					val = href
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(val) + `"`)
+			}
+			if t.Data == "a" {
+				b.WriteString(` rel="` + linkRel + `"`)
+			}
+			b.WriteString(">")
+			if t.Data != "br" {
+				open = append(open, t.Data)
+			}
+
+		case xhtml.EndTagToken:
+			t := z.Token()
+			i := len(open) - 1
+			for i >= 0 && open[i] != t.Data {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			// Close the elements left open inside the closed one.
+			for j := len(open) - 1; j >= i; j-- {
+				b.WriteString("</" + open[j] + ">")
+			}
+			open = open[:i]
+		}
+	}
+}
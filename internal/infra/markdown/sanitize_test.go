@@ -0,0 +1,59 @@
+package markdown
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "allowed elements",
+			in:   "<p><strong>a</strong><br><em>b</em></p>",
+			want: "<p><strong>a</strong><br><em>b</em></p>",
+		},
+		{
+			name: "disallowed elements keep their text",
+			in:   `<div><span>a</span><script>b</script></div>`,
+			want: "ab",
+		},
+		{
+			name: "disallowed attributes",
+			in:   `<p onclick="x" class="y">a</p><ol start="2" type="a"><li>b</li></ol>`,
+			want: `<p>a</p><ol start="2"><li>b</li></ol>`,
+		},
+		{
+			name: "links get rel",
+			in:   `<a href="https://example.com" rel="follow" target="_blank">a</a>`,
+			want: `<a href="https://example.com" rel="nofollow ugc">a</a>`,
+		},
+		{
+			name: "unsafe link",
+			in:   `<a href="javascript:alert(1)">a</a>`,
+			want: `<a rel="nofollow ugc">a</a>`,
+		},
+		{
+			name: "unclosed elements",
+			in:   "<p><strong>a</p><em>b",
+			want: "<p><strong>a</strong></p><em>b</em>",
+		},
+		{
+			name: "stray end tags",
+			in:   "a</strong></p>",
+			want: "a",
+		},
+		{
+			name: "text is escaped",
+			in:   "a &lt;b&gt; &amp; \"c\"",
+			want: "a &lt;b&gt; &amp; &#34;c&#34;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package netguard keeps requests to URLs given by users from reaching the
+// internal network. Addresses are checked when they are dialed, after DNS
+// resolution, so a name cannot resolve to a private address once checked.
+package netguard
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes lists the ranges that are not reachable on the internet,
+// or that reach other ranges through translation, on top of the private,
+// loopback, link-local, multicast and unspecified addresses.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fec0::/10"),
+}
+
+// IsPublic tells whether ip is a public unicast address. IPv4-mapped IPv6
+// addresses are checked as the IPv4 address they map.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicAddr tells whether addr is a public address, on any port.
+func PublicAddr(addr netip.AddrPort) bool {
+	return IsPublic(addr.Addr())
+}
+
+// Check returns an error wrapping domain.ErrForbidden unless allow allows
+// address, the host:port being dialed.
+func Check(address string, allow func(netip.AddrPort) bool) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %q", domain.ErrForbidden, address)
+	}
+	if !allow(addr) {
+		return fmt.Errorf("%w: address %s is not allowed", domain.ErrForbidden, addr)
+	}
+	return nil
+}
+
+// NewTransport returns a transport whose dialer calls control on every
+// address it connects to, refusing the connection if it returns an error,
+// such as one from Check. It never goes through a proxy, which would connect
+// on its behalf.
+func NewTransport(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		ForceAttemptHTTP2:      true,
+		TLSHandshakeTimeout:    timeout,
+		ResponseHeaderTimeout:  timeout,
+		MaxResponseHeaderBytes: 64 << 10,
+		IdleConnTimeout:        30 * time.Second,
+	}
+}
+
+// NewClient returns a client that only connects to public addresses, on any
+// port, and does not follow redirects, which could lead it anywhere: a
+// redirect is returned as the response.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(timeout, func(_, address string, _ syscall.RawConn) error {
+			return Check(address, PublicAddr)
+		}),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// HostValidator checks the hosts of URLs given by users when they are
+// stored, so that they are told right away about a URL that would never be
+// connected to.
+type HostValidator struct {
+	resolver *net.Resolver
+}
+
+// NewHostValidator returns a new HostValidator instance.
+func NewHostValidator() *HostValidator {
+	return &HostValidator{resolver: net.DefaultResolver}
+}
+
+// ValidateHost returns an error wrapping domain.ErrForbidden if host is, or
+// resolves to, an address that is not public.
+func (v *HostValidator) ValidateHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !IsPublic(ip) {
+			return fmt.Errorf("%w: address %s is not allowed", domain.ErrForbidden, ip)
+		}
+		return nil
+	}
+
+	ips, err := v.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if !IsPublic(ip) {
+			return fmt.Errorf("%w: %s resolves to %s, which is not allowed", domain.ErrForbidden, host, ip)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"64:ff9b::a00:1", false},
+		{"2002:a00:1::", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := IsPublic(netip.MustParseAddr(tt.ip)); got != tt.want {
+				t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("93.184.215.14:8443", PublicAddr); err != nil {
+		t.Errorf("Check() of a public address error = %v", err)
+	}
+	for _, address := range []string{"127.0.0.1:8443", "[::1]:443", "localhost:80"} {
+		if err := Check(address, PublicAddr); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("Check(%q) error = %v, want %v", address, err, domain.ErrForbidden)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	// The test server listens on a loopback address, which is refused.
+	if _, err := NewClient(time.Second).Get(srv.URL); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Client.Get() of a loopback address error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	// Redirects are not followed.
+	c := NewClient(time.Second)
+	c.Transport = NewTransport(time.Second, func(_, address string, _ syscall.RawConn) error {
+		return Check(address, func(addr netip.AddrPort) bool { return addr.Addr().IsLoopback() })
+	})
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Client.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Client.Get() status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+}
+
+func TestHostValidator_ValidateHost(t *testing.T) {
+	v := NewHostValidator()
+	if err := v.ValidateHost(context.Background(), "93.184.215.14"); err != nil {
+		t.Errorf("ValidateHost() of a public address error = %v", err)
+	}
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "::1", "localhost"} {
+		if err := v.ValidateHost(context.Background(), host); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("ValidateHost(%q) error = %v, want %v", host, err, domain.ErrForbidden)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package pubsub
+
+import (
+	"context"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"sync"
+)
+
+// DefaultHistorySize is the default number of recent events a Broker keeps
+// for subscribers resuming after a disconnect.
+const DefaultHistorySize = 1024
+
+// subscriberBuffer is the number of events a subscriber can fall behind
+// before it is disconnected.
+const subscriberBuffer = 64
+
+// Broker is an in-process publish/subscribe hub for message events. Events
+// keep the ID they were given in the outbox, and the most recent ones are kept
+// so a subscriber that reconnects can resume where it left off.
+//
+// Events only reach the subscribers of the same process, and the history
+// starts over when the process restarts.
+type Broker struct {
+	logger      *slog.Logger
+	historySize int
+
+	mu sync.Mutex
+	// droppedID is the ID of the last event dropped from the history, which
+	// holds every event published after it.
+	droppedID   int64
+	history     []domain.MessageEvent
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	guestbookID int64
+	ch          chan domain.MessageEvent
+}
+
+// NewBroker returns a Broker keeping the last historySize events.
+func NewBroker(logger *slog.Logger, historySize int) *Broker {
+	return &Broker{
+		logger:      logger,
+		historySize: historySize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish delivers an event to the subscribers of its guestbook. It never
+// blocks: a subscriber that does not keep up is disconnected and expected to
+// resume from the history.
+func (b *Broker) Publish(_ context.Context, ev domain.MessageEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.history = append(b.history, ev)
+	if len(b.history) > b.historySize {
+		dropped := len(b.history) - b.historySize
+		b.droppedID = b.history[dropped-1].ID
+		b.history = b.history[dropped:]
+	}
+
+	for sub := range b.subscribers {
+		if sub.guestbookID != ev.GuestbookID {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			b.logger.Warn("disconnecting slow subscriber", slog.Int64("guestbook_id", sub.guestbookID))
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of a guestbook that are
+// published after lastEventID, starting with those still in the history. A
+// lastEventID of 0 subscribes to new events only. The channel is closed when
+// ctx is done or the subscriber falls behind.
+//
+// resumed is false if lastEventID is neither in the history nor the last
+// event dropped from it, as when it is no longer kept or was published before
+// the process restarted, so the subscriber may have missed some events and
+// should reload the messages.
+func (b *Broker) Subscribe(ctx context.Context, guestbookID, lastEventID int64) (events <-chan domain.MessageEvent, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []domain.MessageEvent
+	resumed = true
+	if lastEventID > 0 {
+		start, found := 0, lastEventID == b.droppedID
+		// Events redelivered by the outbox may be published twice, so the
+		// subscriber resumes after the last copy.
+		for i := len(b.history) - 1; i >= 0; i-- {
+			if b.history[i].ID == lastEventID {
+				start, found = i+1, true
+				break
+			}
+		}
+		if !found {
+			resumed = false
+		} else {
+			for _, ev := range b.history[start:] {
+				if ev.GuestbookID == guestbookID {
+					backlog = append(backlog, ev)
+				}
+			}
+		}
+	}
+
+	sub := &subscriber{
+		guestbookID: guestbookID,
+		ch:          make(chan domain.MessageEvent, subscriberBuffer+len(backlog)),
+	}
+	for _, ev := range backlog {
+		sub.ch <- ev
+	}
+	b.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	}()
+
+	return sub.ch, resumed
+}
+
+// remove closes the channel of a subscriber, unless it is already removed.
+// b.mu must be held.
+func (b *Broker) remove(sub *subscriber) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
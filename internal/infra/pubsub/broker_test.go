@@ -0,0 +1,164 @@
+package pubsub
+
+import (
+	"context"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestBroker(historySize int) *Broker {
+	return NewBroker(slog.New(slog.NewTextHandler(io.Discard, nil)), historySize)
+}
+
+// receive returns the events that can be received from ch without waiting.
+func receive(ch <-chan domain.MessageEvent) (ids []int64, closed bool) {
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, ev.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	b := newTestBroker(DefaultHistorySize)
+	ctx := context.Background()
+
+	wedding, resumed := b.Subscribe(ctx, 2, 0)
+	if !resumed {
+		t.Errorf("Broker.Subscribe() resumed = false, want true")
+	}
+	funeral, _ := b.Subscribe(ctx, 3, 0)
+
+	b.Publish(ctx, domain.MessageEvent{ID: 1, Type: domain.MessageCreated, GuestbookID: 2, MessageID: 1})
+	b.Publish(ctx, domain.MessageEvent{ID: 2, Type: domain.MessageCreated, GuestbookID: 3, MessageID: 2})
+	b.Publish(ctx, domain.MessageEvent{ID: 3, Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 1})
+
+	if got, _ := receive(wedding); !equalIDs(got, []int64{1, 3}) {
+		t.Errorf("wedding events = %v, want [1 3]", got)
+	}
+	if got, _ := receive(funeral); !equalIDs(got, []int64{2}) {
+		t.Errorf("funeral events = %v, want [2]", got)
+	}
+}
+
+func TestBroker_Resume(t *testing.T) {
+	b := newTestBroker(3)
+	ctx := context.Background()
+	// The outbox numbers events across guestbooks and restarts, so the IDs
+	// published by a process have gaps and do not start at 1.
+	for i := 0; i < 5; i++ {
+		b.Publish(ctx, domain.MessageEvent{ID: int64(10 * (i + 1)), Type: domain.MessageCreated, GuestbookID: int64(2 + i%2)})
+	}
+	// The history holds events 30, 40 and 50.
+
+	tests := []struct {
+		name        string
+		lastEventID int64
+		want        []int64
+		wantResumed bool
+	}{
+		{name: "new events only", lastEventID: 0, wantResumed: true},
+		{name: "last dropped", lastEventID: 20, want: []int64{30, 50}, wantResumed: true},
+		{name: "from history", lastEventID: 30, want: []int64{50}, wantResumed: true},
+		{name: "up to date", lastEventID: 50, wantResumed: true},
+		{name: "expired", lastEventID: 10, wantResumed: false},
+		{name: "unknown", lastEventID: 35, wantResumed: false},
+		{name: "before restart", lastEventID: 60, wantResumed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			ch, resumed := b.Subscribe(ctx, 2, tt.lastEventID)
+			if resumed != tt.wantResumed {
+				t.Errorf("Broker.Subscribe() resumed = %v, want %v", resumed, tt.wantResumed)
+			}
+			if got, _ := receive(ch); !equalIDs(got, tt.want) {
+				t.Errorf("Broker.Subscribe() events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroker_Unsubscribe(t *testing.T) {
+	b := newTestBroker(DefaultHistorySize)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, _ := b.Subscribe(ctx, 2, 0)
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatalf("received an event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("channel not closed after cancel")
+	}
+
+	// Publishing to no subscribers must not block or panic.
+	b.Publish(context.Background(), domain.MessageEvent{GuestbookID: 2})
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := newTestBroker(DefaultHistorySize)
+	ctx := context.Background()
+
+	ch, _ := b.Subscribe(ctx, 2, 0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(ctx, domain.MessageEvent{ID: int64(i + 1), GuestbookID: 2})
+	}
+
+	got, closed := receive(ch)
+	if len(got) != subscriberBuffer || !closed {
+		t.Errorf("received %d events, closed = %v, want %d events and closed", len(got), closed, subscriberBuffer)
+	}
+
+	// The subscriber resumes from the history after reconnecting.
+	ch, resumed := b.Subscribe(ctx, 2, got[len(got)-1])
+	if got, _ := receive(ch); !resumed || !equalIDs(got, []int64{subscriberBuffer + 1}) {
+		t.Errorf("resumed events = %v, resumed = %v, want [%d]", got, resumed, subscriberBuffer+1)
+	}
+}
+
+func TestBroker_Restart(t *testing.T) {
+	ctx := context.Background()
+
+	// A client saw event 7 before the server restarted, and missed event 8.
+	// The new process publishes the events recorded after the restart, and
+	// those redelivered by the outbox.
+	after := newTestBroker(DefaultHistorySize)
+	after.Publish(ctx, domain.MessageEvent{ID: 9, GuestbookID: 2})
+	after.Publish(ctx, domain.MessageEvent{ID: 10, GuestbookID: 2})
+	after.Publish(ctx, domain.MessageEvent{ID: 9, GuestbookID: 2})
+
+	if _, resumed := after.Subscribe(ctx, 2, 7); resumed {
+		t.Errorf("Broker.Subscribe() after a restart resumed = true, want false")
+	}
+	ch, resumed := after.Subscribe(ctx, 2, 9)
+	if got, _ := receive(ch); !resumed || len(got) != 0 {
+		t.Errorf("Broker.Subscribe() = %v, resumed = %v, want no events after the redelivered one", got, resumed)
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// MessageAttachment is an image attached to a message. It is created with
+// the message, and deleted with its blobs once the message is deleted.
+type MessageAttachment struct {
+	ID                   uint   `gorm:"primarykey"`
+	MessageID            uint   `gorm:"not null;uniqueIndex"`
+	ContentType          string `gorm:"not null;size:32"`
+	Size                 int64  `gorm:"not null"`
+	Width                int    `gorm:"not null"`
+	Height               int    `gorm:"not null"`
+	Key                  string `gorm:"not null;size:64"`
+	ThumbnailKey         string `gorm:"not null;size:64"`
+	ThumbnailContentType string `gorm:"not null;size:32"`
+	CreatedAt            time.Time
+}
+
+// newMessageAttachment returns the attachment a of the message messageID.
+func newMessageAttachment(messageID uint, a *domain.Attachment) *MessageAttachment {
+	return &MessageAttachment{
+		MessageID:            messageID,
+		ContentType:          a.ContentType,
+		Size:                 a.Size,
+		Width:                a.Width,
+		Height:               a.Height,
+		Key:                  a.Key,
+		ThumbnailKey:         a.ThumbnailKey,
+		ThumbnailContentType: a.ThumbnailContentType,
+	}
+}
+
+func (a *MessageAttachment) ToEntity() *domain.Attachment {
+	return &domain.Attachment{
+		ID:                   int64(a.ID),
+		MessageID:            int64(a.MessageID),
+		ContentType:          a.ContentType,
+		Size:                 a.Size,
+		Width:                a.Width,
+		Height:               a.Height,
+		Key:                  a.Key,
+		ThumbnailKey:         a.ThumbnailKey,
+		ThumbnailContentType: a.ThumbnailContentType,
+		CreatedAt:            a.CreatedAt,
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewAttachmentRepo(logger *slog.Logger, db *gorm.DB) *AttachmentRepo {
+	return &AttachmentRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// GetByMessages returns the attachments of the given messages, by message ID.
+// Messages without an attachment are left out.
+func (r *AttachmentRepo) GetByMessages(ctx context.Context, messageIDs []int64) (map[int64]*domain.Attachment, error) {
+	attachments := make(map[int64]*domain.Attachment)
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	var pos []MessageAttachment
+	if err := conn(ctx, r.db).Where("message_id IN ?", messageIDs).Find(&pos).Error; err != nil {
+		return nil, fmt.Errorf("failed to get attachments from repository: %w", err)
+	}
+
+	for i := range pos {
+		attachments[int64(pos[i].MessageID)] = pos[i].ToEntity()
+	}
+
+	return attachments, nil
+}
+
+// DeleteOrphans deletes the attachment of a message if the message is
+// deleted, and returns it so its blobs can be deleted.
+func (r *AttachmentRepo) DeleteOrphans(ctx context.Context, messageID int64) ([]*domain.Attachment, error) {
+	pos, err := deleteOrphans[MessageAttachment](conn(ctx, r.db), messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned attachments from repository: %w", err)
+	}
+
+	attachments := make([]*domain.Attachment, len(pos))
+	for i := range pos {
+		attachments[i] = pos[i].ToEntity()
+	}
+
+	return attachments, nil
+}
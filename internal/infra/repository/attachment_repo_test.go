@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_attachmentRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &MessageAttachment{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	messageRepo := NewMessageRepo(logger, gormdb)
+	r := NewAttachmentRepo(logger, gormdb)
+
+	attachment := &domain.Attachment{
+		ContentType:          "image/png",
+		Size:                 2048,
+		Width:                800,
+		Height:               600,
+		Key:                  "0123456789abcdef0123456789abcdef.png",
+		ThumbnailKey:         "0123456789abcdef0123456789abcdef-thumb.png",
+		ThumbnailContentType: "image/png",
+	}
+	withAttachment, err := messageRepo.Create(guestbookCtx, &domain.Message{Author: "Arthur Morgan", Message: "Look!", Attachment: attachment})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+	reply, err := messageRepo.Create(guestbookCtx, &domain.Message{ParentID: &withAttachment, Author: "Dutch", Message: "Nice"})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+
+	got, err := r.GetByMessages(guestbookCtx, []int64{withAttachment, reply})
+	if err != nil {
+		t.Fatalf("attachmentRepo.GetByMessages() error = %v", err)
+	}
+	if len(got) != 1 || got[withAttachment] == nil {
+		t.Fatalf("attachmentRepo.GetByMessages() = %v, want the attachment of message %d", got, withAttachment)
+	}
+	if a := got[withAttachment]; a.Key != attachment.Key || a.ThumbnailKey != attachment.ThumbnailKey || a.Width != 800 || a.MessageID != withAttachment {
+		t.Errorf("attachmentRepo.GetByMessages() = %+v, want %+v", a, attachment)
+	}
+
+	var event OutboxEvent
+	if err := gormdb.Where("message_id = ?", withAttachment).First(&event).Error; err != nil {
+		t.Fatalf("failed to get outbox event: %v", err)
+	}
+	ev, err := event.ToEntity()
+	if err != nil {
+		t.Fatalf("OutboxEvent.ToEntity() error = %v", err)
+	}
+	if ev.Event.Message.Attachment == nil || ev.Event.Message.Attachment.Key != attachment.Key {
+		t.Errorf("created event attachment = %+v, want %+v", ev.Event.Message.Attachment, attachment)
+	}
+
+	orphans, err := r.DeleteOrphans(guestbookCtx, withAttachment)
+	if err != nil {
+		t.Fatalf("attachmentRepo.DeleteOrphans() error = %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("attachmentRepo.DeleteOrphans() = %v, want none", orphans)
+	}
+
+	if err := messageRepo.Delete(guestbookCtx, withAttachment); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+	orphans, err = r.DeleteOrphans(guestbookCtx, withAttachment)
+	if err != nil {
+		t.Fatalf("attachmentRepo.DeleteOrphans() error = %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Key != attachment.Key {
+		t.Errorf("attachmentRepo.DeleteOrphans() = %v, want the attachment of the deleted message", orphans)
+	}
+	if got, _ := r.GetByMessages(guestbookCtx, []int64{withAttachment}); len(got) != 0 {
+		t.Errorf("attachmentRepo.GetByMessages() after deletion = %v, want none", got)
+	}
+}
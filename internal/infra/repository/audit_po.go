@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// AuditEntry is an entry of the append-only audit log. Before and After are
+// the snapshots of the message as JSON, empty where there is none. PrevHash
+// is unique, so concurrent appends cannot fork the hash chain.
+type AuditEntry struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"not null;index"`
+	GuestbookID uint      `gorm:"not null"`
+	Actor       string    `gorm:"not null;size:255;index"`
+	Action      string    `gorm:"not null;size:16"`
+	MessageID   uint      `gorm:"not null;index"`
+	Before      string    `gorm:"type:text"`
+	After       string    `gorm:"type:text"`
+	RequestID   string    `gorm:"not null;size:64"`
+	PrevHash    string    `gorm:"not null;size:64;uniqueIndex"`
+	Hash        string    `gorm:"not null;size:64"`
+}
+
+func newAuditEntry(e *domain.AuditEntry) (*AuditEntry, error) {
+	before, err := encodeSnapshot(e.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := encodeSnapshot(e.After)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditEntry{
+		CreatedAt:   e.CreatedAt,
+		GuestbookID: uint(e.GuestbookID),
+		Actor:       e.Actor,
+		Action:      string(e.Action),
+		MessageID:   uint(e.MessageID),
+		Before:      before,
+		After:       after,
+		RequestID:   e.RequestID,
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}, nil
+}
+
+func (e *AuditEntry) ToEntity() (*domain.AuditEntry, error) {
+	before, err := decodeSnapshot(e.Before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audit entry %d: %w", e.ID, err)
+	}
+	after, err := decodeSnapshot(e.After)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audit entry %d: %w", e.ID, err)
+	}
+	return &domain.AuditEntry{
+		ID:          int64(e.ID),
+		GuestbookID: int64(e.GuestbookID),
+		Actor:       e.Actor,
+		Action:      domain.AuditAction(e.Action),
+		MessageID:   int64(e.MessageID),
+		Before:      before,
+		After:       after,
+		RequestID:   e.RequestID,
+		CreatedAt:   e.CreatedAt,
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}, nil
+}
+
+func encodeSnapshot(s *domain.MessageSnapshot) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode message snapshot: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeSnapshot(s string) (*domain.MessageSnapshot, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var snapshot domain.MessageSnapshot
+	if err := json.Unmarshal([]byte(s), &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
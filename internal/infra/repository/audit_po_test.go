@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditEntry_ToEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	parentID := int64(1)
+	entity := &domain.AuditEntry{
+		GuestbookID: 2,
+		Actor:       "admin",
+		Action:      domain.AuditActionUpdate,
+		MessageID:   7,
+		Before:      &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+		After:       &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, John!"},
+		RequestID:   "req-1",
+		CreatedAt:   at,
+		PrevHash:    "prev",
+		Hash:        "hash",
+	}
+
+	po, err := newAuditEntry(entity)
+	if err != nil {
+		t.Fatalf("newAuditEntry() error = %v", err)
+	}
+	if po.Before != `{"parent_id":1,"author":"Arthur Morgan","message":"Hey, Dutch!"}` {
+		t.Errorf("newAuditEntry() before = %s", po.Before)
+	}
+
+	po.ID = 3
+	got, err := po.ToEntity()
+	if err != nil {
+		t.Fatalf("AuditEntry.ToEntity() error = %v", err)
+	}
+	want := *entity
+	want.ID = 3
+	if !reflect.DeepEqual(got, &want) {
+		t.Errorf("AuditEntry.ToEntity() = %+v, want %+v", got, &want)
+	}
+
+	// Creations have no before, and deletions no after.
+	po = &AuditEntry{ID: 4, Action: "create", After: `{"author":"Sadie Adler","message":"Hello"}`}
+	if got, err := po.ToEntity(); err != nil || got.Before != nil || got.After.Author != "Sadie Adler" {
+		t.Errorf("AuditEntry.ToEntity() = %+v, %v, want no before", got, err)
+	}
+
+	po = &AuditEntry{ID: 5, After: "{"}
+	if _, err := po.ToEntity(); err == nil {
+		t.Errorf("AuditEntry.ToEntity() error = nil, want an error for an invalid snapshot")
+	}
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var sqliteAuditDDL = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_update BEFORE UPDATE ON audit_entries BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete BEFORE DELETE ON audit_entries BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END`,
+}
+
+var mysqlAuditDDL = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_update BEFORE UPDATE ON audit_entries FOR EACH ROW
+		SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log is append-only'`,
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete BEFORE DELETE ON audit_entries FOR EACH ROW
+		SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log is append-only'`,
+}
+
+// MigrateAuditLog makes the audit log append-only with triggers rejecting
+// updates and deletions of its entries. It must run after the audit_entries
+// table has been migrated.
+func MigrateAuditLog(db *gorm.DB) error {
+	var ddl []string
+	switch name := db.Dialector.Name(); name {
+	case "sqlite":
+		ddl = sqliteAuditDDL
+	case "mysql":
+		ddl = mysqlAuditDDL
+	default:
+		return fmt.Errorf("failed to migrate audit log: append-only tables are not supported on %s", name)
+	}
+	for _, stmt := range ddl {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to migrate audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+type AuditRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewAuditRepo(logger *slog.Logger, db *gorm.DB) *AuditRepo {
+	return &AuditRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// maxAppendAttempts is how many times Append tries to chain entries to the
+// last entry of the log before giving up on concurrent appends.
+const maxAppendAttempts = 5
+
+// Append appends entries to the audit log, setting their ID, creation time
+// and hashes. The entries are chained to the last entry of the log, which is
+// read with a lock in the same transaction. Where the database does not lock
+// it, a concurrent append chaining to the same entry violates the unique
+// index on the previous hash and is rolled back, and the entries are chained
+// again to the new last entry.
+func (r *AuditRepo) Append(ctx context.Context, entries ...*domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	db := conn(ctx, r.db)
+	var err error
+	for range maxAppendAttempts {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return appendAuditEntries(tx, entries)
+		})
+		if !isDuplicatedKey(db, err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append audit entries from repository: %w", err)
+	}
+
+	return nil
+}
+
+// appendAuditEntries chains entries to the last entry of the log and creates
+// them, as part of the transaction tx.
+func appendAuditEntries(tx *gorm.DB, entries []*domain.AuditEntry) error {
+	var last []string
+	err := tx.Model(&AuditEntry{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Order("id DESC").Limit(1).Pluck("hash", &last).Error
+	if err != nil {
+		return err
+	}
+	prev := ""
+	if len(last) > 0 {
+		prev = last[0]
+	}
+
+	// Times are kept to the millisecond, which every database stores
+	// exactly, so the hashes can be recomputed from what is read back.
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	pos := make([]*AuditEntry, len(entries))
+	for i, e := range entries {
+		e.CreatedAt = now
+		e.PrevHash = prev
+		e.Hash = e.ComputeHash()
+		prev = e.Hash
+
+		po, err := newAuditEntry(e)
+		if err != nil {
+			return err
+		}
+		pos[i] = po
+	}
+	if err := tx.Create(&pos).Error; err != nil {
+		return err
+	}
+
+	for i, po := range pos {
+		entries[i].ID = int64(po.ID)
+	}
+	return nil
+}
+
+// isDuplicatedKey tells whether err is the violation of a unique index, as
+// translated by the dialect of db.
+func isDuplicatedKey(db *gorm.DB, err error) bool {
+	if err == nil {
+		return false
+	}
+	if t, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = t.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// GetAll returns the entries matching q, oldest first.
+func (r *AuditRepo) GetAll(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditEntry, error) {
+	db := conn(ctx, r.db)
+	if q.Actor != "" {
+		db = db.Where("actor = ?", q.Actor)
+	}
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	if q.MessageID > 0 {
+		db = db.Where("message_id = ?", q.MessageID)
+	}
+	if !q.Since.IsZero() {
+		db = db.Where("created_at >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		db = db.Where("created_at < ?", q.Until.UTC())
+	}
+	if q.AfterID > 0 {
+		db = db.Where("id > ?", q.AfterID)
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+
+	var pos []*AuditEntry
+	if err := db.Order("id").Find(&pos).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit entries from repository: %w", err)
+	}
+
+	entries := make([]*domain.AuditEntry, len(pos))
+	for i, po := range pos {
+		e, err := po.ToEntity()
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = e
+	}
+
+	return entries, nil
+}
+
+// Scan calls fn with every entry of the audit log in order, reading them with
+// a cursor. An error returned by fn stops the scan and is returned.
+func (r *AuditRepo) Scan(ctx context.Context, fn func(*domain.AuditEntry) error) error {
+	db := conn(ctx, r.db)
+	rows, err := db.Model(&AuditEntry{}).Order("id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to scan audit entries from repository: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var po AuditEntry
+		if err := db.ScanRows(rows, &po); err != nil {
+			return fmt.Errorf("failed to scan audit entries from repository: %w", err)
+		}
+		e, err := po.ToEntity()
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
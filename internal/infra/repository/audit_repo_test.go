@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_auditRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&AuditEntry{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+	if err := MigrateAuditLog(gormdb); err != nil {
+		t.Fatalf("MigrateAuditLog() error = %v", err)
+	}
+	// Migrations run on every start.
+	if err := MigrateAuditLog(gormdb); err != nil {
+		t.Fatalf("MigrateAuditLog() again error = %v", err)
+	}
+
+	r := NewAuditRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	ctx := context.Background()
+	start := time.Now().Add(-time.Second)
+
+	first := &domain.AuditEntry{GuestbookID: 1, Actor: "c:arthur", Action: domain.AuditActionCreate, MessageID: 1,
+		After: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, Dutch!"}}
+	if err := r.Append(ctx, first); err != nil {
+		t.Fatalf("auditRepo.Append() error = %v", err)
+	}
+	entries := []*domain.AuditEntry{
+		{GuestbookID: 1, Actor: "admin", Action: domain.AuditActionUpdate, MessageID: 1, RequestID: "req-2",
+			Before: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+			After:  &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, John!"}},
+		{GuestbookID: 1, Actor: "admin", Action: domain.AuditActionDelete, MessageID: 1, RequestID: "req-2",
+			Before: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, John!"}},
+	}
+	if err := r.Append(ctx, entries...); err != nil {
+		t.Fatalf("auditRepo.Append() error = %v", err)
+	}
+	if err := r.Append(ctx); err != nil {
+		t.Fatalf("auditRepo.Append() without entries error = %v", err)
+	}
+
+	if first.ID != 1 || first.PrevHash != "" || first.Hash != first.ComputeHash() {
+		t.Errorf("auditRepo.Append() first entry = %+v, want the start of the chain", first)
+	}
+	if entries[0].ID != 2 || entries[0].PrevHash != first.Hash || entries[1].PrevHash != entries[0].Hash {
+		t.Errorf("auditRepo.Append() entries = %+v, %+v, want them chained", entries[0], entries[1])
+	}
+
+	tests := []struct {
+		name string
+		q    domain.AuditQuery
+		want []int64
+	}{
+		{name: "all", want: []int64{1, 2, 3}},
+		{name: "actor", q: domain.AuditQuery{Actor: "admin"}, want: []int64{2, 3}},
+		{name: "action", q: domain.AuditQuery{Action: domain.AuditActionDelete}, want: []int64{3}},
+		{name: "message", q: domain.AuditQuery{MessageID: 2}, want: []int64{}},
+		{name: "since", q: domain.AuditQuery{Since: start}, want: []int64{1, 2, 3}},
+		{name: "until", q: domain.AuditQuery{Until: start}, want: []int64{}},
+		{name: "page", q: domain.AuditQuery{AfterID: 1, Limit: 1}, want: []int64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAll(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("auditRepo.GetAll() error = %v", err)
+			}
+			ids := make([]int64, len(got))
+			for i, e := range got {
+				ids[i] = e.ID
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("auditRepo.GetAll() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	// Entries read back hash as they were written.
+	var scanned int
+	err = r.Scan(ctx, func(e *domain.AuditEntry) error {
+		scanned++
+		if e.Hash != e.ComputeHash() {
+			t.Errorf("auditRepo.Scan() entry %d hashes to %s, want %s", e.ID, e.ComputeHash(), e.Hash)
+		}
+		return nil
+	})
+	if err != nil || scanned != 3 {
+		t.Errorf("auditRepo.Scan() = %d entries, %v, want 3", scanned, err)
+	}
+
+	// The log is append-only.
+	if err := gormdb.Model(&AuditEntry{}).Where("id = ?", 2).Update("actor", "c:micah").Error; err == nil {
+		t.Errorf("updating an audit entry error = nil, want it rejected")
+	}
+	if err := gormdb.Delete(&AuditEntry{}, 3).Error; err == nil {
+		t.Errorf("deleting an audit entry error = nil, want it rejected")
+	}
+}
+
+func Test_auditRepo_SQLite_ConcurrentAppend(t *testing.T) {
+	// An in-memory database is private to its connection, so concurrent
+	// appends need a file.
+	dsn := SQLiteDSN(filepath.Join(t.TempDir(), "audit.db"))
+	gormdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&AuditEntry{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewAuditRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	const appends = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, appends)
+	for i := range appends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- r.Append(context.Background(),
+				&domain.AuditEntry{GuestbookID: 1, Actor: "admin", Action: domain.AuditActionCreate, MessageID: int64(i + 1)},
+				&domain.AuditEntry{GuestbookID: 1, Actor: "admin", Action: domain.AuditActionDelete, MessageID: int64(i + 1)},
+			)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("auditRepo.Append() error = %v", err)
+		}
+	}
+
+	// The entries form a single chain.
+	var scanned int
+	prev := ""
+	err = r.Scan(context.Background(), func(e *domain.AuditEntry) error {
+		scanned++
+		if e.PrevHash != prev {
+			t.Errorf("entry %d is chained to %q, want %q", e.ID, e.PrevHash, prev)
+		}
+		prev = e.Hash
+		return nil
+	})
+	if err != nil || scanned != 2*appends {
+		t.Errorf("auditRepo.Scan() = %d entries, %v, want %d", scanned, err, 2*appends)
+	}
+}
+
+func Test_isDuplicatedKey(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&AuditEntry{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	if err := gormdb.Create(&AuditEntry{Hash: "a"}).Error; isDuplicatedKey(gormdb, err) {
+		t.Errorf("isDuplicatedKey(%v) = true, want false", err)
+	}
+	// Both entries are chained to the start of the log.
+	if err := gormdb.Create(&AuditEntry{Hash: "b"}).Error; !isDuplicatedKey(gormdb, err) {
+		t.Errorf("isDuplicatedKey(%v) = false, want true", err)
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type CORSOrigin struct {
+	gorm.Model
+	Origin string `gorm:"not null;uniqueIndex;size:255"`
+}
+
+func (o *CORSOrigin) ToEntity() *domain.CORSOrigin {
+	return &domain.CORSOrigin{
+		ID:     int64(o.ID),
+		Origin: o.Origin,
+	}
+}
+
+type CORSOrigins []*CORSOrigin
+
+func (cos CORSOrigins) ToEntity() []*domain.CORSOrigin {
+	entities := make([]*domain.CORSOrigin, len(cos))
+	for i, o := range cos {
+		entities[i] = o.ToEntity()
+	}
+	return entities
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCORSOrigins_ToEntity(t *testing.T) {
+	tests := []struct {
+		name string
+		cos  CORSOrigins
+		want []*domain.CORSOrigin
+	}{
+		{
+			name: "success",
+			cos: CORSOrigins{
+				{
+					Model:  gorm.Model{ID: 1},
+					Origin: "https://partner.example",
+				},
+				{
+					Model:  gorm.Model{ID: 2},
+					Origin: "http://localhost:3000",
+				},
+			},
+			want: []*domain.CORSOrigin{
+				{ID: 1, Origin: "https://partner.example"},
+				{ID: 2, Origin: "http://localhost:3000"},
+			},
+		},
+		{
+			name: "empty",
+			cos:  CORSOrigins{},
+			want: []*domain.CORSOrigin{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cos.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CORSOrigins.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
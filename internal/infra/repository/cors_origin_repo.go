@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type CORSOriginRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewCORSOriginRepo(logger *slog.Logger, db *gorm.DB) *CORSOriginRepo {
+	return &CORSOriginRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (r *CORSOriginRepo) Create(ctx context.Context, o *domain.CORSOrigin) (int64, error) {
+	po := &CORSOrigin{
+		Origin: o.Origin,
+	}
+
+	tx := conn(ctx, r.db).Create(po)
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to create cors origin from repository: %w", tx.Error)
+	}
+
+	return int64(po.ID), nil
+}
+
+func (r *CORSOriginRepo) GetAll(ctx context.Context) ([]*domain.CORSOrigin, error) {
+	var origins CORSOrigins
+	if err := conn(ctx, r.db).Find(&origins).Error; err != nil {
+		return nil, err
+	}
+
+	return origins.ToEntity(), nil
+}
+
+func (r *CORSOriginRepo) Delete(ctx context.Context, id int64) error {
+	// Hard delete, so the origin can be allowed again later.
+	tx := conn(ctx, r.db).Unscoped().Delete(&CORSOrigin{}, id)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func Test_corsOriginRepo_Create(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		origin  *domain.CORSOrigin
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `cors_origins` .*").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "https://partner.example").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			origin: &domain.CORSOrigin{Origin: "https://partner.example"},
+			want:   1,
+		},
+		{
+			name: "failed to create cors origin",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `cors_origins` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			origin:  &domain.CORSOrigin{Origin: "https://partner.example"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewCORSOriginRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.Create(context.Background(), tt.origin)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("corsOriginRepo.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("corsOriginRepo.Create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_corsOriginRepo_GetAll(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		want    []*domain.CORSOrigin
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery("SELECT \\* FROM `cors_origins` WHERE `cors_origins`.`deleted_at` IS NULL").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "origin"}).
+						AddRow(1, "https://partner.example"))
+			},
+			want: []*domain.CORSOrigin{{ID: 1, Origin: "https://partner.example"}},
+		},
+		{
+			name: "failed to get all cors origins",
+			setup: func() {
+				mock.ExpectQuery(".*").WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewCORSOriginRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.GetAll(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("corsOriginRepo.GetAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("corsOriginRepo.GetAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_corsOriginRepo_Delete(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name      string
+		setup     func()
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `cors_origins` WHERE `cors_origins`.`id` = ?").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `cors_origins` .*").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to delete cors origin",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `cors_origins` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewCORSOriginRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			err := r.Delete(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("corsOriginRepo.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("corsOriginRepo.Delete() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
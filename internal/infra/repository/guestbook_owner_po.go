@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GuestbookOwner struct {
+	gorm.Model
+	GuestbookID    uint      `gorm:"not null;uniqueIndex:idx_guestbook_owners_guestbook_email,priority:1"`
+	Name           string    `gorm:"not null;size:255"`
+	Email          string    `gorm:"not null;size:255;uniqueIndex:idx_guestbook_owners_guestbook_email,priority:2"`
+	Frequency      string    `gorm:"not null;size:16"`
+	LastMessageID  uint      `gorm:"not null;default:0"`
+	LastNotifiedAt time.Time `gorm:"not null"`
+}
+
+func (o *GuestbookOwner) ToEntity() *domain.GuestbookOwner {
+	return &domain.GuestbookOwner{
+		ID:             int64(o.ID),
+		GuestbookID:    int64(o.GuestbookID),
+		Name:           o.Name,
+		Email:          o.Email,
+		Frequency:      domain.NotificationFrequency(o.Frequency),
+		LastMessageID:  int64(o.LastMessageID),
+		LastNotifiedAt: o.LastNotifiedAt,
+		CreatedAt:      o.CreatedAt,
+	}
+}
+
+type GuestbookOwners []*GuestbookOwner
+
+func (os GuestbookOwners) ToEntity() []*domain.GuestbookOwner {
+	entities := make([]*domain.GuestbookOwner, len(os))
+	for i, o := range os {
+		entities[i] = o.ToEntity()
+	}
+	return entities
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestGuestbookOwner_ToEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		o    *GuestbookOwner
+		want *domain.GuestbookOwner
+	}{
+		{
+			name: "success",
+			o: &GuestbookOwner{
+				Model:          gorm.Model{ID: 1, CreatedAt: at},
+				GuestbookID:    2,
+				Name:           "Dutch van der Linde",
+				Email:          "dutch@example.com",
+				Frequency:      "daily",
+				LastMessageID:  3,
+				LastNotifiedAt: at,
+			},
+			want: &domain.GuestbookOwner{
+				ID:             1,
+				GuestbookID:    2,
+				Name:           "Dutch van der Linde",
+				Email:          "dutch@example.com",
+				Frequency:      domain.NotifyDaily,
+				LastMessageID:  3,
+				LastNotifiedAt: at,
+				CreatedAt:      at,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.o.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GuestbookOwner.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GuestbookOwnerRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewGuestbookOwnerRepo(logger *slog.Logger, db *gorm.DB) *GuestbookOwnerRepo {
+	return &GuestbookOwnerRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// scoped returns a session restricted to the owners of the guestbook ctx is
+// scoped to, like MessageRepo.scoped.
+func (r *GuestbookOwnerRepo) scoped(ctx context.Context) (*gorm.DB, uint, error) {
+	g, ok := domain.GuestbookFromContext(ctx)
+	if !ok {
+		return nil, 0, domain.ErrNoGuestbook
+	}
+
+	guestbookID := uint(g.ID)
+	return conn(ctx, r.db).Where("guestbook_id = ?", guestbookID), guestbookID, nil
+}
+
+// GetAll returns the owners of the guestbook ctx is scoped to.
+func (r *GuestbookOwnerRepo) GetAll(ctx context.Context) ([]*domain.GuestbookOwner, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var os GuestbookOwners
+	if err := db.Order("id").Find(&os).Error; err != nil {
+		return nil, err
+	}
+
+	return os.ToEntity(), nil
+}
+
+// Create adds an owner to the guestbook ctx is scoped to. The owner is
+// notified of the messages posted from now on.
+func (r *GuestbookOwnerRepo) Create(ctx context.Context, o *domain.GuestbookOwner) (int64, error) {
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook owner from repository: %w", err)
+	}
+
+	po := &GuestbookOwner{
+		GuestbookID:    guestbookID,
+		Name:           o.Name,
+		Email:          o.Email,
+		Frequency:      string(o.Frequency),
+		LastNotifiedAt: time.Now(),
+	}
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var lastMessageID *uint
+		err := tx.Unscoped().Model(&Message{}).
+			Where("guestbook_id = ?", guestbookID).
+			Select("MAX(id)").
+			Scan(&lastMessageID).Error
+		if err != nil {
+			return err
+		}
+		if lastMessageID != nil {
+			po.LastMessageID = *lastMessageID
+		}
+		return tx.Create(po).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook owner from repository: %w", err)
+	}
+
+	return int64(po.ID), nil
+}
+
+// Update changes the name, email and notification frequency of an owner.
+func (r *GuestbookOwnerRepo) Update(ctx context.Context, o *domain.GuestbookOwner) error {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx := db.Model(&GuestbookOwner{}).
+		Where("id = ?", o.ID).
+		Select("name", "email", "frequency").
+		Updates(&GuestbookOwner{Name: o.Name, Email: o.Email, Frequency: string(o.Frequency)})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *GuestbookOwnerRepo) Delete(ctx context.Context, id int64) error {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx := db.Unscoped().Delete(&GuestbookOwner{}, id)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetSubscribed returns the owners of every guestbook who have not turned
+// notifications off.
+func (r *GuestbookOwnerRepo) GetSubscribed(ctx context.Context) ([]*domain.GuestbookOwner, error) {
+	var os GuestbookOwners
+	err := conn(ctx, r.db).
+		Where("frequency <> ?", domain.NotifyOff).
+		Order("id").
+		Find(&os).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ToEntity(), nil
+}
+
+// MarkNotified records that an owner was notified of the messages up to
+// lastMessageID at the given time.
+func (r *GuestbookOwnerRepo) MarkNotified(ctx context.Context, id, lastMessageID int64, at time.Time) error {
+	tx := conn(ctx, r.db).Model(&GuestbookOwner{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_message_id": lastMessageID, "last_notified_at": at})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test_guestbookOwnerRepo_SQLite runs against an in-memory SQLite database.
+func Test_guestbookOwnerRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &GuestbookOwner{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewGuestbookOwnerRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	other := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 3, Slug: "funeral"})
+
+	// Owners are only notified of the messages posted after they were added.
+	for _, m := range []*Message{
+		{GuestbookID: 2, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+		{GuestbookID: 2, Author: "Dutch van der Linde", Message: "I have a plan!"},
+		{GuestbookID: 3, Author: "Sadie Adler", Message: "Rest easy."},
+	} {
+		if err := gormdb.Create(m).Error; err != nil {
+			t.Fatalf("failed to create message, got error: %v", err)
+		}
+	}
+
+	id, err := r.Create(guestbookCtx, &domain.GuestbookOwner{
+		Name:      "Dutch van der Linde",
+		Email:     "dutch@example.com",
+		Frequency: domain.NotifyInstant,
+	})
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.Create() error = %v", err)
+	}
+	otherID, err := r.Create(other, &domain.GuestbookOwner{
+		Name:      "Hosea Matthews",
+		Email:     "hosea@example.com",
+		Frequency: domain.NotifyOff,
+	})
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.Create() error = %v", err)
+	}
+	if _, err := r.Create(guestbookCtx, &domain.GuestbookOwner{Email: "dutch@example.com", Frequency: domain.NotifyDaily}); err == nil {
+		t.Error("GuestbookOwnerRepo.Create() error = nil, want a duplicate email error")
+	}
+
+	os, err := r.GetAll(guestbookCtx)
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.GetAll() error = %v", err)
+	}
+	if len(os) != 1 || os[0].ID != id || os[0].LastMessageID != 2 || os[0].LastNotifiedAt.IsZero() {
+		t.Fatalf("GuestbookOwnerRepo.GetAll() = %+v, want the owner notified up to message 2", os)
+	}
+
+	// Owners of other guestbooks are out of scope.
+	if err := r.Update(guestbookCtx, &domain.GuestbookOwner{ID: otherID, Email: "hosea@example.com", Frequency: domain.NotifyDaily}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GuestbookOwnerRepo.Update() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	if err := r.Delete(guestbookCtx, otherID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GuestbookOwnerRepo.Delete() error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	err = r.Update(guestbookCtx, &domain.GuestbookOwner{ID: id, Name: "Dutch", Email: "dutch@blackwater.example", Frequency: domain.NotifyDaily})
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.Update() error = %v", err)
+	}
+
+	// Owners who turned notifications off are not subscribed.
+	subscribed, err := r.GetSubscribed(context.Background())
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.GetSubscribed() error = %v", err)
+	}
+	if len(subscribed) != 1 || subscribed[0].Email != "dutch@blackwater.example" || subscribed[0].Frequency != domain.NotifyDaily {
+		t.Fatalf("GuestbookOwnerRepo.GetSubscribed() = %+v, want the updated owner", subscribed)
+	}
+
+	at := time.Date(1899, 4, 2, 8, 0, 0, 0, time.UTC)
+	if err := r.MarkNotified(context.Background(), id, 5, at); err != nil {
+		t.Fatalf("GuestbookOwnerRepo.MarkNotified() error = %v", err)
+	}
+	if err := r.MarkNotified(context.Background(), 42, 5, at); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GuestbookOwnerRepo.MarkNotified() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	os, err = r.GetAll(guestbookCtx)
+	if err != nil {
+		t.Fatalf("GuestbookOwnerRepo.GetAll() error = %v", err)
+	}
+	if os[0].LastMessageID != 5 || !os[0].LastNotifiedAt.Equal(at) {
+		t.Errorf("GuestbookOwnerRepo.GetAll() = %+v, want the owner notified up to message 5 at %v", os[0], at)
+	}
+
+	if err := r.Delete(guestbookCtx, id); err != nil {
+		t.Fatalf("GuestbookOwnerRepo.Delete() error = %v", err)
+	}
+	if os, _ := r.GetAll(guestbookCtx); len(os) != 0 {
+		t.Errorf("GuestbookOwnerRepo.GetAll() = %+v, want no owners", os)
+	}
+
+	if _, err := r.GetAll(context.Background()); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("GuestbookOwnerRepo.GetAll() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// defaultGuestbookID is the ID of the default guestbook. Messages created
+// before guestbooks existed are assigned to it by the column default of
+// Message.GuestbookID.
+const defaultGuestbookID = 1
+
+type Guestbook struct {
+	gorm.Model
+	Slug     string                   `gorm:"not null;uniqueIndex;size:64"`
+	Title    string                   `gorm:"not null"`
+	Settings domain.GuestbookSettings `gorm:"not null;serializer:json"`
+}
+
+func (g *Guestbook) ToEntity() *domain.Guestbook {
+	return &domain.Guestbook{
+		ID:       int64(g.ID),
+		Slug:     g.Slug,
+		Title:    g.Title,
+		Settings: g.Settings,
+	}
+}
+
+type Guestbooks []*Guestbook
+
+func (gs Guestbooks) ToEntity() []*domain.Guestbook {
+	entities := make([]*domain.Guestbook, len(gs))
+	for i, g := range gs {
+		entities[i] = g.ToEntity()
+	}
+	return entities
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestGuestbooks_ToEntity(t *testing.T) {
+	tests := []struct {
+		name string
+		gs   Guestbooks
+		want []*domain.Guestbook
+	}{
+		{
+			name: "success",
+			gs: Guestbooks{
+				{
+					Model:    gorm.Model{ID: 1},
+					Slug:     "default",
+					Title:    "Guestbook",
+					Settings: domain.GuestbookSettings{AllowPosting: true},
+				},
+				{
+					Model: gorm.Model{ID: 2},
+					Slug:  "archive",
+					Title: "Archive",
+				},
+			},
+			want: []*domain.Guestbook{
+				{ID: 1, Slug: "default", Title: "Guestbook", Settings: domain.GuestbookSettings{AllowPosting: true}},
+				{ID: 2, Slug: "archive", Title: "Archive"},
+			},
+		},
+		{
+			name: "empty",
+			gs:   Guestbooks{},
+			want: []*domain.Guestbook{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.gs.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Guestbooks.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
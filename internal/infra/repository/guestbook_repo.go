@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type GuestbookRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewGuestbookRepo(logger *slog.Logger, db *gorm.DB) *GuestbookRepo {
+	return &GuestbookRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// EnsureDefault creates the default guestbook, which owns the messages
+// created before guestbooks existed, unless it exists already.
+func (r *GuestbookRepo) EnsureDefault(ctx context.Context, title string) (*domain.Guestbook, error) {
+	po := &Guestbook{
+		Model:    gorm.Model{ID: defaultGuestbookID},
+		Slug:     domain.DefaultGuestbookSlug,
+		Title:    title,
+		Settings: domain.GuestbookSettings{AllowPosting: true},
+	}
+
+	err := conn(ctx, r.db).
+		Where(&Guestbook{Slug: domain.DefaultGuestbookSlug}).
+		FirstOrCreate(po).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure default guestbook from repository: %w", err)
+	}
+
+	return po.ToEntity(), nil
+}
+
+func (r *GuestbookRepo) Create(ctx context.Context, g *domain.Guestbook) (int64, error) {
+	po := &Guestbook{
+		Slug:     g.Slug,
+		Title:    g.Title,
+		Settings: g.Settings,
+	}
+
+	tx := conn(ctx, r.db).Create(po)
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to create guestbook from repository: %w", tx.Error)
+	}
+
+	return int64(po.ID), nil
+}
+
+func (r *GuestbookRepo) GetBySlug(ctx context.Context, slug string) (*domain.Guestbook, error) {
+	var g Guestbook
+	if err := conn(ctx, r.db).Where(&Guestbook{Slug: slug}).First(&g).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(domain.ErrNotFound, err)
+		}
+		return nil, err
+	}
+
+	return g.ToEntity(), nil
+}
+
+func (r *GuestbookRepo) GetAll(ctx context.Context) ([]*domain.Guestbook, error) {
+	var gs Guestbooks
+	if err := conn(ctx, r.db).Order("id").Find(&gs).Error; err != nil {
+		return nil, err
+	}
+
+	return gs.ToEntity(), nil
+}
+
+func (r *GuestbookRepo) Update(ctx context.Context, g *domain.Guestbook) error {
+	tx := conn(ctx, r.db).Model(&Guestbook{}).
+		Where("id = ?", g.ID).
+		Select("title", "settings").
+		Updates(&Guestbook{Title: g.Title, Settings: g.Settings})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func Test_guestbookRepo_GetBySlug(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name      string
+		setup     func()
+		want      *domain.Guestbook
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery("SELECT \\* FROM `guestbooks` WHERE `guestbooks`.`slug` = \\? .*").
+					WithArgs("wedding", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "title", "settings"}).
+						AddRow(2, "wedding", "Wedding guestbook", `{"allow_posting":true}`))
+			},
+			want: &domain.Guestbook{
+				ID:       2,
+				Slug:     "wedding",
+				Title:    "Wedding guestbook",
+				Settings: domain.GuestbookSettings{AllowPosting: true},
+			},
+		},
+		{
+			name: "not found",
+			setup: func() {
+				mock.ExpectQuery(".*").
+					WithArgs("wedding", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "title", "settings"}))
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to get guestbook",
+			setup: func() {
+				mock.ExpectQuery(".*").WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewGuestbookRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.GetBySlug(context.Background(), "wedding")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("guestbookRepo.GetBySlug() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("guestbookRepo.GetBySlug() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("guestbookRepo.GetBySlug() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_guestbookRepo_Create(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `guestbooks` .*").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "wedding", "Wedding guestbook", `{"allow_posting":true}`).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+			want: 2,
+		},
+		{
+			name: "failed to create guestbook",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `guestbooks` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewGuestbookRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.Create(context.Background(), &domain.Guestbook{
+				Slug:     "wedding",
+				Title:    "Wedding guestbook",
+				Settings: domain.GuestbookSettings{AllowPosting: true},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("guestbookRepo.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("guestbookRepo.Create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_guestbookRepo_Update(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name      string
+		setup     func()
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `guestbooks` .*").
+					WithArgs(sqlmock.AnyArg(), "Wedding guestbook", `{"allow_posting":false}`, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `guestbooks` .*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to update guestbook",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `guestbooks` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewGuestbookRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			err := r.Update(context.Background(), &domain.Guestbook{
+				ID:    2,
+				Slug:  "wedding",
+				Title: "Wedding guestbook",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("guestbookRepo.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("guestbookRepo.Update() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
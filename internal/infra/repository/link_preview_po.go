@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// LinkPreview is the preview of a link in a message. Previews are created
+// pending when the message is created or updated, filled in once the link is
+// fetched, and deleted with their images once the message is deleted.
+type LinkPreview struct {
+	ID          uint   `gorm:"primarykey"`
+	MessageID   uint   `gorm:"not null;index"`
+	Position    int    `gorm:"not null"`
+	URL         string `gorm:"not null;size:2048"`
+	Title       string `gorm:"not null;size:255;default:''"`
+	Description string `gorm:"not null;size:1024;default:''"`
+	SiteName    string `gorm:"not null;size:255;default:''"`
+	ImageKey    string `gorm:"not null;size:64;default:''"`
+	Status      string `gorm:"not null;size:16;index"`
+	CreatedAt   time.Time
+	FetchedAt   *time.Time
+}
+
+func (p *LinkPreview) ToEntity() *domain.LinkPreview {
+	var fetchedAt time.Time
+	if p.FetchedAt != nil {
+		fetchedAt = *p.FetchedAt
+	}
+	return &domain.LinkPreview{
+		ID:          int64(p.ID),
+		MessageID:   int64(p.MessageID),
+		URL:         p.URL,
+		Title:       p.Title,
+		Description: p.Description,
+		SiteName:    p.SiteName,
+		ImageKey:    p.ImageKey,
+		Status:      domain.LinkPreviewStatus(p.Status),
+		CreatedAt:   p.CreatedAt,
+		FetchedAt:   fetchedAt,
+	}
+}
+
+type LinkPreviews []LinkPreview
+
+func (ps LinkPreviews) ToEntity() []*domain.LinkPreview {
+	entities := make([]*domain.LinkPreview, len(ps))
+	for i := range ps {
+		entities[i] = ps[i].ToEntity()
+	}
+	return entities
+}
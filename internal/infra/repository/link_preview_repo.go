@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type LinkPreviewRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewLinkPreviewRepo(logger *slog.Logger, db *gorm.DB) *LinkPreviewRepo {
+	return &LinkPreviewRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// GetByMessages returns the ready previews of the given messages, by message
+// ID, in order of appearance of their links.
+func (r *LinkPreviewRepo) GetByMessages(ctx context.Context, messageIDs []int64) (map[int64][]*domain.LinkPreview, error) {
+	previews := make(map[int64][]*domain.LinkPreview)
+	if len(messageIDs) == 0 {
+		return previews, nil
+	}
+
+	var pos LinkPreviews
+	err := conn(ctx, r.db).
+		Where("message_id IN ? AND status = ?", messageIDs, domain.LinkPreviewReady).
+		Order("message_id, position").
+		Find(&pos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link previews from repository: %w", err)
+	}
+
+	for _, p := range pos.ToEntity() {
+		previews[p.MessageID] = append(previews[p.MessageID], p)
+	}
+
+	return previews, nil
+}
+
+// Replace makes urls the links of a message to preview, and returns the
+// previews it replaced, so their images can be deleted. Nothing changes when
+// the message already has previews for the same links.
+func (r *LinkPreviewRepo) Replace(ctx context.Context, messageID int64, urls []string) ([]*domain.LinkPreview, error) {
+	var old LinkPreviews
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Order("position").Find(&old).Error; err != nil {
+			return err
+		}
+		oldURLs := make([]string, len(old))
+		for i := range old {
+			oldURLs[i] = old[i].URL
+		}
+		if slices.Equal(oldURLs, urls) {
+			old = nil
+			return nil
+		}
+
+		if len(old) > 0 {
+			if err := tx.Where("message_id = ?", messageID).Delete(&LinkPreview{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		pos := make([]LinkPreview, len(urls))
+		for i, u := range urls {
+			pos[i] = LinkPreview{
+				MessageID: uint(messageID),
+				Position:  i,
+				URL:       u,
+				Status:    string(domain.LinkPreviewPending),
+			}
+		}
+		return tx.Create(&pos).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace link previews in repository: %w", err)
+	}
+
+	return old.ToEntity(), nil
+}
+
+// GetPending returns up to limit previews waiting to be fetched, oldest
+// first.
+func (r *LinkPreviewRepo) GetPending(ctx context.Context, limit int) ([]*domain.LinkPreview, error) {
+	var pos LinkPreviews
+	err := conn(ctx, r.db).
+		Where("status = ?", domain.LinkPreviewPending).
+		Order("id").
+		Limit(limit).
+		Find(&pos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending link previews from repository: %w", err)
+	}
+
+	return pos.ToEntity(), nil
+}
+
+// Update records the result of fetching a preview. It reports
+// domain.ErrNotFound when the preview was replaced in the meantime.
+func (r *LinkPreviewRepo) Update(ctx context.Context, p *domain.LinkPreview) error {
+	fetchedAt := p.FetchedAt
+	res := conn(ctx, r.db).Model(&LinkPreview{ID: uint(p.ID)}).
+		Select("title", "description", "site_name", "image_key", "status", "fetched_at").
+		Updates(&LinkPreview{
+			Title:       p.Title,
+			Description: p.Description,
+			SiteName:    p.SiteName,
+			ImageKey:    p.ImageKey,
+			Status:      string(p.Status),
+			FetchedAt:   &fetchedAt,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("failed to update link preview in repository: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("failed to update link preview in repository: %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// DeleteOrphans deletes the previews of a message if the message is
+// deleted, and returns them so their images can be deleted.
+func (r *LinkPreviewRepo) DeleteOrphans(ctx context.Context, messageID int64) ([]*domain.LinkPreview, error) {
+	pos, err := deleteOrphans[LinkPreview](conn(ctx, r.db), messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned link previews from repository: %w", err)
+	}
+
+	return LinkPreviews(pos).ToEntity(), nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_linkPreviewRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &LinkPreview{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	messageRepo := NewMessageRepo(logger, gormdb)
+	r := NewLinkPreviewRepo(logger, gormdb)
+
+	id, err := messageRepo.Create(guestbookCtx, &domain.Message{Author: "Arthur Morgan", Message: "See https://a.example and https://b.example"})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+
+	urls := []string{"https://a.example", "https://b.example"}
+	replaced, err := r.Replace(guestbookCtx, id, urls)
+	if err != nil {
+		t.Fatalf("linkPreviewRepo.Replace() error = %v", err)
+	}
+	if len(replaced) != 0 {
+		t.Errorf("linkPreviewRepo.Replace() = %v, want none", replaced)
+	}
+
+	pending, err := r.GetPending(guestbookCtx, 10)
+	if err != nil {
+		t.Fatalf("linkPreviewRepo.GetPending() error = %v", err)
+	}
+	if len(pending) != 2 || pending[0].URL != urls[0] || pending[1].URL != urls[1] || pending[0].MessageID != id {
+		t.Fatalf("linkPreviewRepo.GetPending() = %v, want previews of %v", pending, urls)
+	}
+	if got, _ := r.GetByMessages(guestbookCtx, []int64{id}); len(got) != 0 {
+		t.Errorf("linkPreviewRepo.GetByMessages() = %v, want no ready preview", got)
+	}
+
+	// The same links are not fetched again.
+	if _, err := r.Replace(guestbookCtx, id, urls); err != nil {
+		t.Fatalf("linkPreviewRepo.Replace() error = %v", err)
+	}
+	if again, _ := r.GetPending(guestbookCtx, 10); len(again) != 2 || again[0].ID != pending[0].ID {
+		t.Errorf("linkPreviewRepo.GetPending() after same links = %v, want %v", again, pending)
+	}
+
+	ready := *pending[1]
+	ready.Title = "B"
+	ready.ImageKey = "0123456789abcdef0123456789abcdef-thumb.png"
+	ready.Status = domain.LinkPreviewReady
+	ready.FetchedAt = time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	if err := r.Update(guestbookCtx, &ready); err != nil {
+		t.Fatalf("linkPreviewRepo.Update() error = %v", err)
+	}
+	failed := *pending[0]
+	failed.Status = domain.LinkPreviewFailed
+	if err := r.Update(guestbookCtx, &failed); err != nil {
+		t.Fatalf("linkPreviewRepo.Update() error = %v", err)
+	}
+
+	got, err := r.GetByMessages(guestbookCtx, []int64{id})
+	if err != nil {
+		t.Fatalf("linkPreviewRepo.GetByMessages() error = %v", err)
+	}
+	if len(got[id]) != 1 || got[id][0].Title != "B" || got[id][0].URL != urls[1] || !got[id][0].FetchedAt.Equal(ready.FetchedAt) {
+		t.Errorf("linkPreviewRepo.GetByMessages() = %v, want the ready preview", got)
+	}
+	if pending, _ := r.GetPending(guestbookCtx, 10); len(pending) != 0 {
+		t.Errorf("linkPreviewRepo.GetPending() after update = %v, want none", pending)
+	}
+
+	replaced, err = r.Replace(guestbookCtx, id, []string{"https://c.example"})
+	if err != nil {
+		t.Fatalf("linkPreviewRepo.Replace() error = %v", err)
+	}
+	if len(replaced) != 2 || replaced[1].ImageKey != ready.ImageKey {
+		t.Errorf("linkPreviewRepo.Replace() = %v, want the previous previews", replaced)
+	}
+	if err := r.Update(guestbookCtx, &ready); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("linkPreviewRepo.Update() of a replaced preview error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	if orphans, _ := r.DeleteOrphans(guestbookCtx, id); len(orphans) != 0 {
+		t.Errorf("linkPreviewRepo.DeleteOrphans() = %v, want none", orphans)
+	}
+	if err := messageRepo.Delete(guestbookCtx, id); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+	orphans, err := r.DeleteOrphans(guestbookCtx, id)
+	if err != nil {
+		t.Fatalf("linkPreviewRepo.DeleteOrphans() error = %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].URL != "https://c.example" {
+		t.Errorf("linkPreviewRepo.DeleteOrphans() = %v, want the preview of the deleted message", orphans)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Batch applies the operations of a batch in order to the messages of the
+// guestbook ctx is scoped to, recording their events like Create, Update and
+// Delete, and returns their results in the same order.
+//
+// The batch runs in a single transaction, each operation within a savepoint.
+// An operation failing is rolled back alone in a best-effort batch, and
+// otherwise rolls the whole batch back, the other operations failing with
+// domain.ErrAborted. The error is only set when the batch could not be
+// applied at all.
+func (r *MessageRepo) Batch(ctx context.Context, batch domain.MessageBatch) ([]domain.MessageOperationResult, error) {
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply batch from repository: %w", err)
+	}
+
+	results := make([]domain.MessageOperationResult, len(batch.Operations))
+	errAborted := errors.New("batch aborted")
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i, op := range batch.Operations {
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				var err error
+				results[i].ID, err = applyMessageOperation(tx, guestbookID, op)
+				return err
+			})
+			if err != nil {
+				results[i] = domain.MessageOperationResult{Err: err}
+				if !batch.BestEffort {
+					return errAborted
+				}
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errAborted) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = domain.MessageOperationResult{Err: domain.ErrAborted}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply batch from repository: %w", err)
+	}
+
+	return results, nil
+}
+
+// applyMessageOperation applies an operation of a batch as part of the
+// transaction tx, and returns the ID of the message it changed.
+func applyMessageOperation(tx *gorm.DB, guestbookID uint, op domain.MessageOperation) (int64, error) {
+	m := op.Message
+	switch op.Type {
+	case domain.MessageOperationCreate:
+		// The parent may have been deleted earlier in the batch. It is locked
+		// so it cannot be deleted concurrently either.
+		if m.ParentID != nil {
+			var parent Message
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("guestbook_id = ?", guestbookID).Select("id").First(&parent, *m.ParentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return 0, errors.Join(domain.ErrNotFound, err)
+				}
+				return 0, err
+			}
+		}
+		return createMessage(tx, guestbookID, m)
+	case domain.MessageOperationUpdate:
+		return m.ID, updateMessage(tx, guestbookID, m)
+	case domain.MessageOperationDelete:
+		return m.ID, deleteMessage(tx, guestbookID, m.ID)
+	default:
+		return 0, fmt.Errorf("%w: unknown operation %q", domain.ErrInvalidArgument, op.Type)
+	}
+}
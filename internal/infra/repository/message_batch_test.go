@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_messageRepo_Batch_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	parentID := int64(1)
+	for _, m := range []*domain.Message{
+		{Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+		{Author: "Dutch van der Linde", Message: "I have a plan!", ParentID: &parentID},
+		{Author: "John Marston", Message: "Buy some land"},
+	} {
+		if _, err := r.Create(guestbookCtx, m); err != nil {
+			t.Fatalf("messageRepo.Create() error = %v", err)
+		}
+	}
+	countEvents := func() int64 {
+		var events int64
+		if err := gormdb.Model(&OutboxEvent{}).Count(&events).Error; err != nil {
+			t.Fatalf("failed to count events, got error: %v", err)
+		}
+		return events
+	}
+
+	// An atomic batch with a failing operation changes nothing.
+	results, err := r.Batch(guestbookCtx, domain.MessageBatch{
+		Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 3}},
+			{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 99, Author: "Micah Bell", Message: "Spam"}},
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Sadie Adler", Message: "Hello"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("messageRepo.Batch() error = %v", err)
+	}
+	if len(results) != 3 || !errors.Is(results[0].Err, domain.ErrAborted) || !errors.Is(results[1].Err, domain.ErrNotFound) || !errors.Is(results[2].Err, domain.ErrAborted) {
+		t.Fatalf("messageRepo.Batch() = %+v, want the batch aborted by the update", results)
+	}
+	if ms, _ := r.GetAll(guestbookCtx, domain.MessageQuery{}); len(ms) != 3 {
+		t.Errorf("messageRepo.GetAll() = %d messages, want 3", len(ms))
+	}
+	if events := countEvents(); events != 3 {
+		t.Errorf("messageRepo.Batch() recorded %d events, want none", events-3)
+	}
+
+	// A best-effort batch applies the operations that succeed.
+	results, err = r.Batch(guestbookCtx, domain.MessageBatch{
+		BestEffort: true,
+		Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 1}},
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Sadie Adler", Message: "Hello", ParentID: &parentID}},
+			{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 3, Author: "John Marston", Message: "Buy some land!"}},
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Sadie Adler", Message: "Hello"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("messageRepo.Batch() error = %v", err)
+	}
+	want := []int64{1, 0, 3, 4}
+	for i, res := range results {
+		if res.ID != want[i] || (res.Err != nil) != (i == 1) {
+			t.Errorf("messageRepo.Batch() result %d = %+v, want ID %d", i, res, want[i])
+		}
+	}
+	if !errors.Is(results[1].Err, domain.ErrNotFound) {
+		t.Errorf("messageRepo.Batch() result 1 error = %v, want the deleted parent not found", results[1].Err)
+	}
+
+	ms, err := r.GetAll(guestbookCtx, domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("messageRepo.GetAll() error = %v", err)
+	}
+	if len(ms) != 2 || ms[0].ID != 3 || ms[0].Message != "Buy some land!" || ms[1].ID != 4 {
+		t.Errorf("messageRepo.GetAll() = %+v, want the updated and created messages", ms)
+	}
+	// The deletion of 1 and its reply, the update and the creation.
+	if events := countEvents(); events != 3+4 {
+		t.Errorf("messageRepo.Batch() recorded %d events, want 4", events-3)
+	}
+
+	if _, err := r.Batch(context.Background(), domain.MessageBatch{}); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Batch() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+}
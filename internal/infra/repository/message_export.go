@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"guestbook-example/internal/domain"
+)
+
+// Export calls fn with every message of the guestbook ctx is scoped to, in
+// the order they were posted. The messages are read with a cursor instead of
+// being loaded at once, so exporting a guestbook takes constant memory. An
+// error returned by fn stops the export and is returned.
+func (r *MessageRepo) Export(ctx context.Context, q domain.MessageExportQuery, fn func(*domain.ExportedMessage) error) error {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	if q.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
+	rows, err := db.Model(&Message{}).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m Message
+		if err := db.ScanRows(rows, &m); err != nil {
+			return err
+		}
+		if err := fn(m.ToExportEntity()); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_messageRepo_Export_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	other := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 3, Slug: "funeral"})
+
+	for _, m := range []struct {
+		ctx    context.Context
+		author string
+	}{
+		{guestbookCtx, "Arthur Morgan"},
+		{other, "Sadie Adler"},
+		{guestbookCtx, "Dutch van der Linde"},
+		{guestbookCtx, "Micah Bell"},
+	} {
+		if _, err := r.Create(m.ctx, &domain.Message{Author: m.author, Message: "Hey!"}); err != nil {
+			t.Fatalf("messageRepo.Create() error = %v", err)
+		}
+	}
+	if err := r.Delete(guestbookCtx, 4); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+
+	export := func(q domain.MessageExportQuery) []*domain.ExportedMessage {
+		t.Helper()
+		var got []*domain.ExportedMessage
+		err := r.Export(guestbookCtx, q, func(m *domain.ExportedMessage) error {
+			got = append(got, m)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("messageRepo.Export() error = %v", err)
+		}
+		return got
+	}
+
+	got := export(domain.MessageExportQuery{})
+	if len(got) != 2 || got[0].Author != "Arthur Morgan" || got[1].Author != "Dutch van der Linde" {
+		t.Errorf("messageRepo.Export() = %+v, want the messages of the guestbook that are not deleted", got)
+	}
+	if got[0].CreatedAt.IsZero() || got[0].UpdatedAt.IsZero() || got[0].DeletedAt != nil {
+		t.Errorf("messageRepo.Export() = %+v, want timestamps and no deletion time", got[0])
+	}
+
+	got = export(domain.MessageExportQuery{IncludeDeleted: true})
+	if len(got) != 3 || got[2].Author != "Micah Bell" || got[2].DeletedAt == nil {
+		t.Errorf("messageRepo.Export() = %+v, want the deleted message too", got)
+	}
+
+	// An error of fn stops the export.
+	stop := errors.New("client gone")
+	calls := 0
+	err = r.Export(guestbookCtx, domain.MessageExportQuery{}, func(*domain.ExportedMessage) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("messageRepo.Export() error = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+
+	if err := r.Export(context.Background(), domain.MessageExportQuery{}, nil); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Export() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+}
+
+func Test_messageRepo_Export_SQLiteConcurrentWrites(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(SQLiteDSN(filepath.Join(t.TempDir(), "export.db"))), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	for _, author := range []string{"Arthur Morgan", "Dutch van der Linde"} {
+		if _, err := r.Create(guestbookCtx, &domain.Message{Author: author, Message: "Hey!"}); err != nil {
+			t.Fatalf("messageRepo.Create() error = %v", err)
+		}
+	}
+
+	// Messages are posted while the export holds its cursor open, as it does
+	// while a slow client reads the response.
+	start := time.Now()
+	err = r.Export(guestbookCtx, domain.MessageExportQuery{}, func(m *domain.ExportedMessage) error {
+		_, err := r.Create(guestbookCtx, &domain.Message{Author: "Micah Bell", Message: "Hey!"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() during messageRepo.Export() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > sqliteBusyTimeout/2 {
+		t.Errorf("messageRepo.Create() during messageRepo.Export() waited %v for the export", elapsed)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+)
+
+// CreateBatch creates messages in the guestbook ctx is scoped to in a single
+// transaction, and returns their IDs in order. Messages with a CreatedAt keep
+// it. Unlike Create, it records no events: imported messages are not
+// announced to live updates or webhooks.
+func (r *MessageRepo) CreateBatch(ctx context.Context, ms []*domain.Message) ([]int64, error) {
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create messages from repository: %w", err)
+	}
+	if len(ms) == 0 {
+		return nil, nil
+	}
+
+	pos := make([]*Message, len(ms))
+	for i, m := range ms {
+		pos[i] = &Message{
+			GuestbookID: guestbookID,
+			ParentID:    toUintPtr(m.ParentID),
+			Author:      m.Author,
+			Message:     m.Message,
+		}
+		if !m.CreatedAt.IsZero() {
+			pos[i].CreatedAt = m.CreatedAt
+			pos[i].UpdatedAt = m.CreatedAt
+		}
+	}
+
+	// A single INSERT, which GORM runs in a transaction.
+	if err := conn(ctx, r.db).Create(&pos).Error; err != nil {
+		return nil, fmt.Errorf("failed to create messages from repository: %w", err)
+	}
+
+	ids := make([]int64, len(pos))
+	for i, po := range pos {
+		ids[i] = int64(po.ID)
+	}
+
+	return ids, nil
+}
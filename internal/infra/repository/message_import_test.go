@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_messageRepo_CreateBatch_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	parentID := int64(1)
+	ids, err := r.CreateBatch(guestbookCtx, []*domain.Message{
+		{Author: "Arthur Morgan", Message: "Hey, Dutch!", CreatedAt: at},
+		{Author: "Dutch van der Linde", Message: "I have a plan!", ParentID: &parentID},
+	})
+	if err != nil {
+		t.Fatalf("messageRepo.CreateBatch() error = %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("messageRepo.CreateBatch() = %v, want [1 2]", ids)
+	}
+
+	ms, err := r.GetAll(guestbookCtx, domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("messageRepo.GetAll() error = %v", err)
+	}
+	if len(ms) != 2 || !ms[0].CreatedAt.Equal(at) || ms[1].CreatedAt.IsZero() || ms[1].ParentID == nil || *ms[1].ParentID != 1 {
+		t.Errorf("messageRepo.GetAll() = %+v, want the imported messages", ms)
+	}
+
+	// Imports are not announced.
+	var events int64
+	if err := gormdb.Model(&OutboxEvent{}).Count(&events).Error; err != nil {
+		t.Fatalf("failed to count events, got error: %v", err)
+	}
+	if events != 0 {
+		t.Errorf("messageRepo.CreateBatch() recorded %d events, want none", events)
+	}
+
+	if ids, err := r.CreateBatch(guestbookCtx, nil); err != nil || ids != nil {
+		t.Errorf("messageRepo.CreateBatch() = %v, %v, want nothing", ids, err)
+	}
+	if _, err := r.CreateBatch(context.Background(), []*domain.Message{{Author: "Arthur Morgan", Message: "Hey"}}); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.CreateBatch() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+}
@@ -8,16 +8,54 @@ import (
 
 type Message struct {
 	gorm.Model
-	Author  string `gorm:"not null"`
-	Message string `gorm:"not null"`
+	GuestbookID uint   `gorm:"not null;default:1;index"`
+	ParentID    *uint  `gorm:"index"`
+	Author      string `gorm:"not null"`
+	Message     string `gorm:"not null"`
+	// EmailHash is the hash of the email of the author, empty if they gave
+	// none.
+	EmailHash string `gorm:"size:64;not null;default:''"`
+	// EditCount is the number of updates, each of which stored a
+	// MessageRevision.
+	EditCount int `gorm:"not null;default:0"`
+	// Attachment is only set on messages just created with an attachment,
+	// for their event. Attachments are loaded with AttachmentRepo.
+	Attachment *MessageAttachment `gorm:"-"`
 }
 
 func (m *Message) ToEntity() *domain.Message {
-	return &domain.Message{
-		ID:      int64(m.ID),
-		Author:  m.Author,
-		Message: m.Message,
+	e := &domain.Message{
+		ID:          int64(m.ID),
+		GuestbookID: int64(m.GuestbookID),
+		ParentID:    toInt64Ptr(m.ParentID),
+		Author:      m.Author,
+		Message:     m.Message,
+		EmailHash:   m.EmailHash,
+		CreatedAt:   m.CreatedAt,
+		EditCount:   m.EditCount,
 	}
+	if m.EditCount > 0 {
+		e.EditedAt = m.UpdatedAt
+	}
+	return e
+}
+
+// ToExportEntity returns the message with its update and deletion times.
+func (m *Message) ToExportEntity() *domain.ExportedMessage {
+	e := &domain.ExportedMessage{
+		ID:          int64(m.ID),
+		GuestbookID: int64(m.GuestbookID),
+		ParentID:    toInt64Ptr(m.ParentID),
+		Author:      m.Author,
+		Message:     m.Message,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if m.DeletedAt.Valid {
+		deletedAt := m.DeletedAt.Time
+		e.DeletedAt = &deletedAt
+	}
+	return e
 }
 
 type Messages []*Message
@@ -29,3 +67,19 @@ func (ms Messages) ToEntity() []*domain.Message {
 	}
 	return entities
 }
+
+func toInt64Ptr(id *uint) *int64 {
+	if id == nil {
+		return nil
+	}
+	v := int64(*id)
+	return &v
+}
+
+func toUintPtr(id *int64) *uint {
+	if id == nil {
+		return nil
+	}
+	v := uint(*id)
+	return &v
+}
@@ -4,6 +4,7 @@ import (
 	"guestbook-example/internal/domain"
 	"reflect"
 	"testing"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,15 +19,80 @@ func TestMessage_ToEntity(t *testing.T) {
 			name: "success",
 			m: &Message{
 				Model: gorm.Model{
-					ID: 1,
+					ID:        1,
+					CreatedAt: time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC),
 				},
-				Author:  "Arthur Morgan",
-				Message: "Hey, Dutch!",
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
 			},
 			want: &domain.Message{
-				ID:      1,
-				Author:  "Arthur Morgan",
-				Message: "Hey, Dutch!",
+				ID:          1,
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				CreatedAt:   time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "reply",
+			m: &Message{
+				Model: gorm.Model{
+					ID: 2,
+				},
+				GuestbookID: 2,
+				ParentID:    func() *uint { id := uint(1); return &id }(),
+				Author:      "Dutch van der Linde",
+				Message:     "I have a plan!",
+			},
+			want: &domain.Message{
+				ID:          2,
+				GuestbookID: 2,
+				ParentID:    func() *int64 { id := int64(1); return &id }(),
+				Author:      "Dutch van der Linde",
+				Message:     "I have a plan!",
+			},
+		},
+		{
+			name: "with email hash",
+			m: &Message{
+				Model: gorm.Model{
+					ID: 4,
+				},
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				EmailHash:   "5e4c3b2a",
+			},
+			want: &domain.Message{
+				ID:          4,
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				EmailHash:   "5e4c3b2a",
+			},
+		},
+		{
+			name: "edited",
+			m: &Message{
+				Model: gorm.Model{
+					ID:        3,
+					CreatedAt: time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC),
+					UpdatedAt: time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+				},
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				EditCount:   2,
+			},
+			want: &domain.Message{
+				ID:          3,
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				CreatedAt:   time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC),
+				EditCount:   2,
+				EditedAt:    time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
 			},
 		},
 	}
@@ -85,3 +151,50 @@ func TestMessages_ToEntity(t *testing.T) {
 		})
 	}
 }
+
+func TestMessage_ToExportEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		m    *Message
+		want *domain.ExportedMessage
+	}{
+		{
+			name: "success",
+			m: &Message{
+				Model:       gorm.Model{ID: 1, CreatedAt: at, UpdatedAt: at.Add(time.Hour)},
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+			},
+			want: &domain.ExportedMessage{
+				ID:          1,
+				GuestbookID: 2,
+				Author:      "Arthur Morgan",
+				Message:     "Hey, Dutch!",
+				CreatedAt:   at,
+				UpdatedAt:   at.Add(time.Hour),
+			},
+		},
+		{
+			name: "deleted",
+			m: &Message{
+				Model:       gorm.Model{ID: 2, DeletedAt: gorm.DeletedAt{Time: at, Valid: true}},
+				GuestbookID: 2,
+			},
+			want: &domain.ExportedMessage{
+				ID:          2,
+				GuestbookID: 2,
+				DeletedAt:   &at,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.ToExportEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Message.ToExportEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
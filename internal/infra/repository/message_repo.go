@@ -8,6 +8,7 @@ import (
 	"log/slog"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageRepo struct {
@@ -22,23 +23,64 @@ func NewMessageRepo(logger *slog.Logger, db *gorm.DB) *MessageRepo {
 	}
 }
 
+// scoped returns a session restricted to the messages of the guestbook ctx
+// is scoped to. It fails if ctx is not scoped to a guestbook, so a query can
+// never read or write the messages of another guestbook.
+func (r *MessageRepo) scoped(ctx context.Context) (*gorm.DB, uint, error) {
+	g, ok := domain.GuestbookFromContext(ctx)
+	if !ok {
+		return nil, 0, domain.ErrNoGuestbook
+	}
+
+	guestbookID := uint(g.ID)
+	return conn(ctx, r.db).Where("guestbook_id = ?", guestbookID), guestbookID, nil
+}
+
+// Create creates a message, and records a domain.MessageCreated event in the
+// outbox in the same transaction.
 func (r *MessageRepo) Create(ctx context.Context, m *domain.Message) (int64, error) {
-	po := &Message{
-		Author:  m.Author,
-		Message: m.Message,
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message from repository: %w", err)
 	}
 
-	tx := r.db.Create(po)
-	if tx.Error != nil {
-		return 0, fmt.Errorf("failed to create message from repository: %w", tx.Error)
+	var id int64
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		id, err = createMessage(tx, guestbookID, m)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message from repository: %w", err)
 	}
 
-	return int64(po.ID), nil
+	return id, nil
 }
 
 func (r *MessageRepo) Get(ctx context.Context, id int64) (*domain.Message, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return getMessage(db, id)
+}
+
+// GetForUpdate returns a message like Get, and locks its row until the end
+// of the unit of work of ctx, so the message cannot be changed or deleted
+// before the unit of work ends. SQLite does not lock rows, but serializes
+// the transactions of databases opened with SQLiteDSN instead.
+func (r *MessageRepo) GetForUpdate(ctx context.Context, id int64) (*domain.Message, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return getMessage(db.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func getMessage(db *gorm.DB, id int64) (*domain.Message, error) {
 	var m Message
-	if err := r.db.First(&m, id).Error; err != nil {
+	if err := db.First(&m, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(domain.ErrNotFound, err)
 		}
@@ -48,27 +90,210 @@ func (r *MessageRepo) Get(ctx context.Context, id int64) (*domain.Message, error
 	return m.ToEntity(), nil
 }
 
-func (r *MessageRepo) GetAll(ctx context.Context) ([]*domain.Message, error) {
+// messageSortOrders translates sort orders into ORDER BY clauses. Messages
+// can only be sorted on the columns listed here. Messages created at the same
+// time are ordered by ID, so the order is always stable.
+var messageSortOrders = map[domain.MessageSort][]clause.OrderByColumn{
+	"":                   {{Column: clause.Column{Name: "id"}}},
+	domain.MessageSortID: {{Column: clause.Column{Name: "id"}}},
+	domain.MessageSortCreatedAt: {
+		{Column: clause.Column{Name: "created_at"}},
+		{Column: clause.Column{Name: "id"}},
+	},
+	domain.MessageSortCreatedAtDesc: {
+		{Column: clause.Column{Name: "created_at"}, Desc: true},
+		{Column: clause.Column{Name: "id"}, Desc: true},
+	},
+}
+
+// GetAll returns the messages matching q, in the order q asks for.
+func (r *MessageRepo) GetAll(ctx context.Context, q domain.MessageQuery) ([]*domain.Message, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, ok := messageSortOrders[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", domain.ErrInvalidArgument, q.Sort)
+	}
+	if q.Author != "" {
+		db = db.Where("author = ?", q.Author)
+	}
+	// GORM writes created_at in local time.
+	if !q.Since.IsZero() {
+		db = db.Where("created_at >= ?", q.Since.Local())
+	}
+	if !q.Until.IsZero() {
+		db = db.Where("created_at < ?", q.Until.Local())
+	}
+	if q.AfterID > 0 {
+		db = db.Where("id > ?", q.AfterID)
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+
 	var ms *Messages
-	if err := r.db.Find(&ms).Error; err != nil {
+	if err := db.Order(clause.OrderBy{Columns: order}).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	return ms.ToEntity(), nil
+}
+
+// GetReplies returns the direct replies to a message, oldest first.
+func (r *MessageRepo) GetReplies(ctx context.Context, parentID int64) ([]*domain.Message, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms Messages
+	if err := db.Where("parent_id = ?", parentID).Order("id").Find(&ms).Error; err != nil {
 		return nil, err
 	}
 
 	return ms.ToEntity(), nil
 }
 
+// Update updates the author and content of a message, stores the version it
+// replaces as a revision, and records a domain.MessageUpdated event in the
+// outbox in the same transaction.
 func (r *MessageRepo) Update(ctx context.Context, m *domain.Message) error {
-	po := &Message{
-		Model: gorm.Model{
-			ID: uint(m.ID),
-		},
-		Author:  m.Author,
-		Message: m.Message,
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return err
 	}
 
-	return r.db.Save(po).Error
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return updateMessage(tx, guestbookID, m)
+	})
 }
 
+// Delete deletes a message together with all replies below it, so no reply
+// is left pointing at a deleted message, and the reactions to and revisions
+// of them. It records a domain.MessageDeleted event for each deleted message
+// in the outbox in the same transaction.
 func (r *MessageRepo) Delete(ctx context.Context, id int64) error {
-	return r.db.Delete(&Message{}, id).Error
+	_, guestbookID, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return deleteMessage(tx, guestbookID, id)
+	})
+}
+
+// createMessage creates a message with its attachment and records its event,
+// as part of the transaction tx.
+func createMessage(tx *gorm.DB, guestbookID uint, m *domain.Message) (int64, error) {
+	po := &Message{
+		GuestbookID: guestbookID,
+		ParentID:    toUintPtr(m.ParentID),
+		Author:      m.Author,
+		Message:     m.Message,
+		EmailHash:   m.EmailHash,
+	}
+	if err := tx.Create(po).Error; err != nil {
+		return 0, err
+	}
+	if m.Attachment != nil {
+		po.Attachment = newMessageAttachment(po.ID, m.Attachment)
+		if err := tx.Create(po.Attachment).Error; err != nil {
+			return 0, err
+		}
+	}
+	if err := writeOutbox(tx, domain.MessageCreated, guestbookID, po); err != nil {
+		return 0, err
+	}
+
+	return int64(po.ID), nil
+}
+
+// updateMessage updates a message and records its event, as part of the
+// transaction tx.
+func updateMessage(tx *gorm.DB, guestbookID uint, m *domain.Message) error {
+	// Locking the message makes concurrent updates wait for each other, so
+	// each stores the revision the previous one left rather than all storing
+	// the same one, which the unique index would reject.
+	var po Message
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("guestbook_id = ?", guestbookID).First(&po, m.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Join(domain.ErrNotFound, err)
+		}
+		return err
+	}
+
+	if err := tx.Create(newMessageRevision(&po)).Error; err != nil {
+		return err
+	}
+
+	err := tx.Model(&po).
+		Select("author", "message", "edit_count").
+		Updates(&Message{Author: m.Author, Message: m.Message, EditCount: po.EditCount + 1}).Error
+	if err != nil {
+		return err
+	}
+
+	return writeOutbox(tx, domain.MessageUpdated, guestbookID, &po)
+}
+
+// deleteMessage deletes a message with its replies and reactions and records
+// their events, as part of the transaction tx.
+func deleteMessage(tx *gorm.DB, guestbookID uint, id int64) error {
+	// Locking the root waits for replies being created to it, which lock it
+	// too, so that they are found below.
+	var root Message
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("guestbook_id = ?", guestbookID).Select("id").First(&root, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Join(domain.ErrNotFound, err)
+		}
+		return err
+	}
+
+	ids := []uint{root.ID}
+	for parents := ids; len(parents) > 0; {
+		var children []uint
+		err := tx.Model(&Message{}).
+			Where("guestbook_id = ? AND parent_id IN ?", guestbookID, parents).
+			Pluck("id", &children).Error
+		if err != nil {
+			return err
+		}
+		ids = append(ids, children...)
+		parents = children
+	}
+
+	if err := tx.Where("message_id IN ?", ids).Delete(&Reaction{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("message_id IN ?", ids).Delete(&MessageRevision{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("guestbook_id = ?", guestbookID).Delete(&Message{}, ids).Error; err != nil {
+		return err
+	}
+
+	events := make([]*OutboxEvent, len(ids))
+	for i, id := range ids {
+		var err error
+		if events[i], err = newOutboxEvent(domain.MessageDeleted, guestbookID, id, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Create(events).Error
+}
+
+// writeOutbox records the event reporting a change to m in the outbox, as
+// part of the transaction tx making the change.
+func writeOutbox(tx *gorm.DB, typ domain.MessageEventType, guestbookID uint, m *Message) error {
+	ev, err := newOutboxEvent(typ, guestbookID, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(ev).Error
 }
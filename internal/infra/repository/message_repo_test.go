@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"guestbook-example/internal/domain"
+	"io"
 	"log"
 	"log/slog"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -47,6 +50,9 @@ func initMessageDBMock(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, *sql.DB) {
 	return gormdb, mock, db
 }
 
+// guestbookCtx is scoped to the guestbook the test messages belong to.
+var guestbookCtx = domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 2, Slug: "wedding"})
+
 func Test_messageRepo_Create(t *testing.T) {
 	buff := &bytes.Buffer{}
 
@@ -79,8 +85,26 @@ func Test_messageRepo_Create(t *testing.T) {
 							sqlmock.AnyArg(),
 							sqlmock.AnyArg(),
 							nil,
+							2,
+							nil,
 							"Arthur Morgan",
 							"Hey, Dutch!",
+							"",
+							0,
+						).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("INSERT INTO `outbox_events` .*").
+						WithArgs(
+							"message.created",
+							2,
+							1,
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							0,
+							sqlmock.AnyArg(),
+							nil,
+							nil,
+							"",
 						).
 						WillReturnResult(sqlmock.NewResult(1, 1))
 					mock.ExpectCommit()
@@ -88,7 +112,7 @@ func Test_messageRepo_Create(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				m: &domain.Message{
 					Author:  "Arthur Morgan",
 					Message: "Hey, Dutch!",
@@ -97,6 +121,29 @@ func Test_messageRepo_Create(t *testing.T) {
 			want:    1,
 			wantErr: false,
 		},
+		{
+			name: "failed to write outbox",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				db: func() *gorm.DB {
+					mock.ExpectBegin()
+					mock.ExpectExec("INSERT INTO `messages` .*").
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("INSERT INTO `outbox_events` .*").
+						WillReturnError(sql.ErrConnDone)
+					mock.ExpectRollback()
+					return gormdb
+				}(),
+			},
+			args: args{
+				ctx: guestbookCtx,
+				m: &domain.Message{
+					Author:  "Arthur Morgan",
+					Message: "Hey, Dutch!",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "failed to create message",
 			fields: fields{
@@ -110,7 +157,7 @@ func Test_messageRepo_Create(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				m: &domain.Message{
 					Author:  "Arthur Morgan",
 					Message: "Hey, Dutch!",
@@ -163,14 +210,14 @@ func Test_messageRepo_Get(t *testing.T) {
 			fields: fields{
 				logger: slog.New(slog.NewTextHandler(buff, nil)),
 				db: func() *gorm.DB {
-					mock.ExpectQuery(".*").WithArgs(1, 1).
+					mock.ExpectQuery(".*").WithArgs(2, 1, 1).
 						WillReturnRows(sqlmock.NewRows([]string{"id", "author", "message"}).
 							AddRow(1, "Arthur Morgan", "Hey, Dutch!"))
 					return gormdb
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				id:  1,
 			},
 			want: &domain.Message{
@@ -185,13 +232,13 @@ func Test_messageRepo_Get(t *testing.T) {
 			fields: fields{
 				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 				db: func() *gorm.DB {
-					mock.ExpectQuery(".*").WithArgs(1, 1).
+					mock.ExpectQuery(".*").WithArgs(2, 1, 1).
 						WillReturnError(sql.ErrConnDone)
 					return gormdb
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				id:  1,
 			},
 			wantErr: true,
@@ -215,6 +262,31 @@ func Test_messageRepo_Get(t *testing.T) {
 	}
 }
 
+func Test_messageRepo_GetForUpdate(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND `messages`.`id` = \\? .* FOR UPDATE").
+		WithArgs(2, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author", "message"}).
+			AddRow(1, "Arthur Morgan", "Hey, Dutch!"))
+	mock.ExpectQuery(".* FOR UPDATE").
+		WithArgs(2, 9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	got, err := r.GetForUpdate(guestbookCtx, 1)
+	if err != nil || got.ID != 1 {
+		t.Errorf("messageRepo.GetForUpdate() = %v, %v, want message 1", got, err)
+	}
+	if _, err := r.GetForUpdate(guestbookCtx, 9); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("messageRepo.GetForUpdate() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func Test_messageRepo_GetAll(t *testing.T) {
 	buff := &bytes.Buffer{}
 
@@ -227,6 +299,7 @@ func Test_messageRepo_GetAll(t *testing.T) {
 	}
 	type args struct {
 		ctx context.Context
+		q   domain.MessageQuery
 	}
 	tests := []struct {
 		name    string
@@ -240,7 +313,8 @@ func Test_messageRepo_GetAll(t *testing.T) {
 			fields: fields{
 				logger: slog.New(slog.NewTextHandler(buff, nil)),
 				db: func() *gorm.DB {
-					mock.ExpectQuery(".*").
+					mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND `messages`.`deleted_at` IS NULL ORDER BY `id`").
+						WithArgs(2).
 						WillReturnRows(sqlmock.NewRows([]string{"id", "author", "message"}).
 							AddRow(1, "Arthur Morgan", "Hey, Dutch!").
 							AddRow(2, "Dutch van der Linde", "I have a plan!"))
@@ -248,7 +322,7 @@ func Test_messageRepo_GetAll(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 			},
 			want: []*domain.Message{
 				{
@@ -264,6 +338,72 @@ func Test_messageRepo_GetAll(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "filter and sort",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(buff, nil)),
+				db: func() *gorm.DB {
+					mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND author = \\? AND created_at >= \\? AND created_at < \\? AND `messages`.`deleted_at` IS NULL "+
+						"ORDER BY `created_at` DESC,`id` DESC").
+						WithArgs(2, "Arthur Morgan", sqlmock.AnyArg(), sqlmock.AnyArg()).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "author", "message"}).
+							AddRow(1, "Arthur Morgan", "Hey, Dutch!"))
+					return gormdb
+				}(),
+			},
+			args: args{
+				ctx: guestbookCtx,
+				q: domain.MessageQuery{
+					Author: "Arthur Morgan",
+					Since:  time.Date(1899, 4, 1, 0, 0, 0, 0, time.UTC),
+					Until:  time.Date(1899, 4, 2, 0, 0, 0, 0, time.UTC),
+					Sort:   domain.MessageSortCreatedAtDesc,
+				},
+			},
+			want: []*domain.Message{
+				{
+					ID:      1,
+					Author:  "Arthur Morgan",
+					Message: "Hey, Dutch!",
+				},
+			},
+		},
+		{
+			name: "after id with limit",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(buff, nil)),
+				db: func() *gorm.DB {
+					mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND id > \\? AND `messages`.`deleted_at` IS NULL ORDER BY `id` LIMIT \\?").
+						WithArgs(2, 1, 10).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "author", "message"}).
+							AddRow(2, "Dutch van der Linde", "I have a plan!"))
+					return gormdb
+				}(),
+			},
+			args: args{
+				ctx: guestbookCtx,
+				q:   domain.MessageQuery{AfterID: 1, Limit: 10},
+			},
+			want: []*domain.Message{
+				{
+					ID:      2,
+					Author:  "Dutch van der Linde",
+					Message: "I have a plan!",
+				},
+			},
+		},
+		{
+			name: "unknown sort",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				db:     gormdb,
+			},
+			args: args{
+				ctx: guestbookCtx,
+				q:   domain.MessageQuery{Sort: "author"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "get no message",
 			fields: fields{
@@ -275,7 +415,7 @@ func Test_messageRepo_GetAll(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 			},
 			want:    []*domain.Message{},
 			wantErr: false,
@@ -291,7 +431,7 @@ func Test_messageRepo_GetAll(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 			},
 			wantErr: true,
 		},
@@ -302,7 +442,7 @@ func Test_messageRepo_GetAll(t *testing.T) {
 				logger: tt.fields.logger,
 				db:     tt.fields.db,
 			}
-			got, err := m.GetAll(tt.args.ctx)
+			got, err := m.GetAll(tt.args.ctx, tt.args.q)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("messageRepo.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -341,14 +481,34 @@ func Test_messageRepo_Update(t *testing.T) {
 				logger: slog.New(slog.NewTextHandler(buff, nil)),
 				db: func() *gorm.DB {
 					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND `messages`.`id` = \\? .* FOR UPDATE").
+						WithArgs(2, 1, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "guestbook_id", "author", "message", "edit_count"}).
+							AddRow(1, 2, "Arthur Morgan", "Hey!", 0))
+					mock.ExpectExec("INSERT INTO `message_revisions` .*").
+						WithArgs(1, 1, "Arthur Morgan", "Hey!", sqlmock.AnyArg()).
+						WillReturnResult(sqlmock.NewResult(1, 1))
 					mock.ExpectExec("UPDATE `messages` .*").
 						WithArgs(
 							sqlmock.AnyArg(),
-							sqlmock.AnyArg(),
-							nil,
 							"Arthur Morgan",
 							"Hey, Dutch!",
 							1,
+							1,
+						).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("INSERT INTO `outbox_events` .*").
+						WithArgs(
+							"message.updated",
+							2,
+							1,
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							0,
+							sqlmock.AnyArg(),
+							nil,
+							nil,
+							"",
 						).
 						WillReturnResult(sqlmock.NewResult(1, 1))
 					mock.ExpectCommit()
@@ -356,7 +516,7 @@ func Test_messageRepo_Update(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				m: &domain.Message{
 					ID:      1,
 					Author:  "Arthur Morgan",
@@ -372,6 +532,11 @@ func Test_messageRepo_Update(t *testing.T) {
 				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 				db: func() *gorm.DB {
 					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT \\* FROM `messages` .*").
+						WillReturnRows(sqlmock.NewRows([]string{"id", "guestbook_id", "author", "message"}).
+							AddRow(1, 2, "Arthur Morgan", "Hey!"))
+					mock.ExpectExec("INSERT INTO `message_revisions` .*").
+						WillReturnResult(sqlmock.NewResult(1, 1))
 					mock.ExpectExec("UPDATE `messages` .*").
 						WillReturnError(sql.ErrConnDone)
 					mock.ExpectRollback()
@@ -379,7 +544,7 @@ func Test_messageRepo_Update(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				m: &domain.Message{
 					ID:      1,
 					Author:  "Arthur Morgan",
@@ -417,10 +582,11 @@ func Test_messageRepo_Delete(t *testing.T) {
 		id  int64
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name: "success",
@@ -428,28 +594,85 @@ func Test_messageRepo_Delete(t *testing.T) {
 				logger: slog.New(slog.NewTextHandler(buff, nil)),
 				db: func() *gorm.DB {
 					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT `id` FROM `messages` .* FOR UPDATE").
+						WithArgs(2, 1, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 2, 3).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 4).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+					mock.ExpectExec("DELETE FROM `reactions` WHERE message_id IN .*").
+						WithArgs(1, 2, 3, 4).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					mock.ExpectExec("DELETE FROM `message_revisions` WHERE message_id IN .*").
+						WithArgs(1, 2, 3, 4).
+						WillReturnResult(sqlmock.NewResult(0, 1))
 					mock.ExpectExec("UPDATE `messages` .*").
 						WithArgs(
 							sqlmock.AnyArg(),
-							1,
+							2,
+							1, 2, 3, 4,
 						).
-						WillReturnResult(sqlmock.NewResult(1, 1))
+						WillReturnResult(sqlmock.NewResult(0, 4))
+					mock.ExpectExec("INSERT INTO `outbox_events` .*").
+						WithArgs(
+							"message.deleted", 2, 1, "", sqlmock.AnyArg(), 0, sqlmock.AnyArg(), nil, nil, "",
+							"message.deleted", 2, 2, "", sqlmock.AnyArg(), 0, sqlmock.AnyArg(), nil, nil, "",
+							"message.deleted", 2, 3, "", sqlmock.AnyArg(), 0, sqlmock.AnyArg(), nil, nil, "",
+							"message.deleted", 2, 4, "", sqlmock.AnyArg(), 0, sqlmock.AnyArg(), nil, nil, "",
+						).
+						WillReturnResult(sqlmock.NewResult(1, 4))
 					mock.ExpectCommit()
 					return gormdb
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				id:  1,
 			},
 			wantErr: false,
 		},
+		{
+			name: "message not found",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(buff, nil)),
+				db: func() *gorm.DB {
+					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 1, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+					mock.ExpectRollback()
+					return gormdb
+				}(),
+			},
+			args: args{
+				ctx: guestbookCtx,
+				id:  1,
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
 		{
 			name: "failed to delete message",
 			fields: fields{
 				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 				db: func() *gorm.DB {
 					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 1, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectQuery("SELECT `id` FROM `messages` .*").
+						WithArgs(2, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+					mock.ExpectExec("DELETE FROM `reactions` .*").
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM `message_revisions` .*").
+						WillReturnResult(sqlmock.NewResult(0, 0))
 					mock.ExpectExec("UPDATE `messages` .*").
 						WillReturnError(sql.ErrConnDone)
 					mock.ExpectRollback()
@@ -457,7 +680,7 @@ func Test_messageRepo_Delete(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.Background(),
+				ctx: guestbookCtx,
 				id:  1,
 			},
 			wantErr: true,
@@ -469,9 +692,95 @@ func Test_messageRepo_Delete(t *testing.T) {
 				logger: tt.fields.logger,
 				db:     tt.fields.db,
 			}
-			if err := m.Delete(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
+			err := m.Delete(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("messageRepo.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageRepo.Delete() error = %v, want %v", err, tt.wantErrIs)
 			}
 		})
 	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func Test_messageRepo_GetReplies(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	parentID := int64(1)
+	tests := []struct {
+		name    string
+		setup   func()
+		want    []*domain.Message
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND parent_id = \\? .* ORDER BY id").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "guestbook_id", "parent_id", "author", "message"}).
+						AddRow(2, 2, 1, "Dutch van der Linde", "I have a plan!"))
+			},
+			want: []*domain.Message{
+				{ID: 2, GuestbookID: 2, ParentID: &parentID, Author: "Dutch van der Linde", Message: "I have a plan!"},
+			},
+		},
+		{
+			name: "failed to get replies",
+			setup: func() {
+				mock.ExpectQuery(".*").WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.GetReplies(guestbookCtx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageRepo.GetReplies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageRepo.GetReplies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageRepo_WithoutGuestbook(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	ctx := context.Background()
+
+	if _, err := r.Create(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Create() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	if _, err := r.Get(ctx, 1); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Get() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	if _, err := r.GetAll(ctx, domain.MessageQuery{}); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.GetAll() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	if _, err := r.GetReplies(ctx, 1); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.GetReplies() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	if err := r.Update(ctx, &domain.Message{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!"}); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Update() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	if err := r.Delete(ctx, 1); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("messageRepo.Delete() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
 }
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// revisions returns a session restricted to the revisions of a message of the
+// guestbook ctx is scoped to, which must not be deleted.
+func (r *MessageRepo) revisions(ctx context.Context, id int64) (*gorm.DB, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn(ctx, r.db).
+		Where("message_id = ?", id).
+		Where("message_id IN (?)", db.Model(&Message{}).Select("id").Where("id = ?", id)), nil
+}
+
+// GetRevisions returns the stored revisions of a message, oldest first,
+// without the current version.
+func (r *MessageRepo) GetRevisions(ctx context.Context, id int64) ([]*domain.MessageRevision, error) {
+	db, err := r.revisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message revisions from repository: %w", err)
+	}
+
+	var pos []*MessageRevision
+	if err := db.Order("revision").Find(&pos).Error; err != nil {
+		return nil, fmt.Errorf("failed to get message revisions from repository: %w", err)
+	}
+
+	revisions := make([]*domain.MessageRevision, len(pos))
+	for i, po := range pos {
+		revisions[i] = po.ToEntity()
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a stored revision of a message.
+func (r *MessageRepo) GetRevision(ctx context.Context, id int64, revision int) (*domain.MessageRevision, error) {
+	db, err := r.revisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message revision from repository: %w", err)
+	}
+
+	var po MessageRevision
+	if err := db.Where("revision = ?", revision).First(&po).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get message revision from repository: %w", errors.Join(domain.ErrNotFound, err))
+		}
+		return nil, fmt.Errorf("failed to get message revision from repository: %w", err)
+	}
+
+	return po.ToEntity(), nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// MessageRevision is a version of a message replaced by an update. It has no
+// soft delete: the revisions of a message are deleted with it.
+type MessageRevision struct {
+	ID        uint   `gorm:"primarykey"`
+	MessageID uint   `gorm:"not null;uniqueIndex:idx_message_revisions_message_revision,priority:1"`
+	Revision  int    `gorm:"not null;uniqueIndex:idx_message_revisions_message_revision,priority:2"`
+	Author    string `gorm:"not null"`
+	Message   string `gorm:"not null"`
+	// CreatedAt is when the version was written, not when it was replaced.
+	CreatedAt time.Time
+}
+
+// newMessageRevision returns the revision storing m before an update.
+func newMessageRevision(m *Message) *MessageRevision {
+	return &MessageRevision{
+		MessageID: m.ID,
+		Revision:  m.EditCount + 1,
+		Author:    m.Author,
+		Message:   m.Message,
+		CreatedAt: m.UpdatedAt,
+	}
+}
+
+func (r *MessageRevision) ToEntity() *domain.MessageRevision {
+	return &domain.MessageRevision{
+		MessageID: int64(r.MessageID),
+		Revision:  r.Revision,
+		Author:    r.Author,
+		Message:   r.Message,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func Test_newMessageRevision(t *testing.T) {
+	m := &Message{
+		Model: gorm.Model{
+			ID:        1,
+			CreatedAt: time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+		},
+		Author:    "Arthur Morgan",
+		Message:   "Hey, Dutch!",
+		EditCount: 1,
+	}
+	want := &MessageRevision{
+		MessageID: 1,
+		Revision:  2,
+		Author:    "Arthur Morgan",
+		Message:   "Hey, Dutch!",
+		CreatedAt: time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+	}
+	if got := newMessageRevision(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("newMessageRevision() = %v, want %v", got, want)
+	}
+}
+
+func TestMessageRevision_ToEntity(t *testing.T) {
+	r := &MessageRevision{
+		ID:        3,
+		MessageID: 1,
+		Revision:  2,
+		Author:    "Arthur Morgan",
+		Message:   "Hey, Dutch!",
+		CreatedAt: time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+	}
+	want := &domain.MessageRevision{
+		MessageID: 1,
+		Revision:  2,
+		Author:    "Arthur Morgan",
+		Message:   "Hey, Dutch!",
+		CreatedAt: time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC),
+	}
+	if got := r.ToEntity(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MessageRevision.ToEntity() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_messageRepo_Revisions_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	other := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 3, Slug: "funeral"})
+
+	id, err := r.Create(guestbookCtx, &domain.Message{Author: "Arthur Morgan", Message: "Hey"})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+	for _, content := range []string{"Hey, Dutch", "Hey, Dutch!"} {
+		if err := r.Update(guestbookCtx, &domain.Message{ID: id, Author: "Arthur Morgan", Message: content}); err != nil {
+			t.Fatalf("messageRepo.Update() error = %v", err)
+		}
+	}
+
+	m, err := r.Get(guestbookCtx, id)
+	if err != nil {
+		t.Fatalf("messageRepo.Get() error = %v", err)
+	}
+	if m.Message != "Hey, Dutch!" || m.EditCount != 2 || m.EditedAt.IsZero() {
+		t.Errorf("messageRepo.Get() = %+v, want the message edited twice", m)
+	}
+
+	revisions, err := r.GetRevisions(guestbookCtx, id)
+	if err != nil {
+		t.Fatalf("messageRepo.GetRevisions() error = %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[0].Message != "Hey" || revisions[1].Revision != 2 || revisions[1].Message != "Hey, Dutch" {
+		t.Errorf("messageRepo.GetRevisions() = %+v, want revisions 1 and 2", revisions)
+	}
+	if !revisions[0].CreatedAt.Equal(m.CreatedAt) {
+		t.Errorf("messageRepo.GetRevisions() revision 1 created at %v, want %v", revisions[0].CreatedAt, m.CreatedAt)
+	}
+
+	revision, err := r.GetRevision(guestbookCtx, id, 2)
+	if err != nil {
+		t.Fatalf("messageRepo.GetRevision() error = %v", err)
+	}
+	if revision.Message != "Hey, Dutch" {
+		t.Errorf("messageRepo.GetRevision() = %+v, want revision 2", revision)
+	}
+	if _, err := r.GetRevision(guestbookCtx, id, 3); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("messageRepo.GetRevision() error = %v, want %v for the current version", err, domain.ErrNotFound)
+	}
+
+	// The revisions of another guestbook's message are out of reach.
+	if revisions, err := r.GetRevisions(other, id); err != nil || len(revisions) != 0 {
+		t.Errorf("messageRepo.GetRevisions() = %v, %v, want no revisions in another guestbook", revisions, err)
+	}
+	if _, err := r.GetRevision(other, id, 1); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("messageRepo.GetRevision() error = %v, want %v in another guestbook", err, domain.ErrNotFound)
+	}
+
+	if err := r.Delete(guestbookCtx, id); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+	var count int64
+	if err := gormdb.Model(&MessageRevision{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count revisions, got error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d revisions left after deleting the message, want 0", count)
+	}
+}
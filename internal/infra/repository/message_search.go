@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// messagesFTSTable is the SQLite FTS5 table indexing the author and content
+// of messages. It is an external content table: it only stores the index and
+// reads the text from the messages table, kept in sync by triggers.
+const messagesFTSTable = "messages_fts"
+
+// messagesFulltextIndex is the MySQL FULLTEXT index on messages.
+const messagesFulltextIndex = "idx_messages_fulltext"
+
+var sqliteSearchDDL = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+		author, message, content='messages', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2')`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_ai AFTER INSERT ON messages BEGIN
+		INSERT INTO messages_fts(rowid, author, message) VALUES (new.id, new.author, new.message);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_ad AFTER DELETE ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, author, message) VALUES ('delete', old.id, old.author, old.message);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS messages_fts_au AFTER UPDATE OF author, message ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, author, message) VALUES ('delete', old.id, old.author, old.message);
+		INSERT INTO messages_fts(rowid, author, message) VALUES (new.id, new.author, new.message);
+	END`,
+}
+
+// MigrateMessageSearch creates the full-text index used by MessageRepo.Search:
+// an FTS5 table maintained by triggers on SQLite, or a FULLTEXT index on
+// MySQL. Messages that exist when the index is created are indexed as well.
+// It must run after the messages table has been migrated.
+func MigrateMessageSearch(db *gorm.DB) error {
+	switch name := db.Dialector.Name(); name {
+	case "sqlite":
+		return db.Transaction(func(tx *gorm.DB) error {
+			exists := tx.Migrator().HasTable(messagesFTSTable)
+			for _, ddl := range sqliteSearchDDL {
+				if err := tx.Exec(ddl).Error; err != nil {
+					return fmt.Errorf("failed to migrate message search: %w", err)
+				}
+			}
+			if exists {
+				return nil
+			}
+			if err := tx.Exec("INSERT INTO messages_fts(messages_fts) VALUES ('rebuild')").Error; err != nil {
+				return fmt.Errorf("failed to migrate message search: %w", err)
+			}
+			return nil
+		})
+	case "mysql":
+		if db.Migrator().HasIndex(&Message{}, messagesFulltextIndex) {
+			return nil
+		}
+		if err := db.Exec("ALTER TABLE messages ADD FULLTEXT INDEX " + messagesFulltextIndex + " (author, message)").Error; err != nil {
+			return fmt.Errorf("failed to migrate message search: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("failed to migrate message search: full-text search is not supported on %s", name)
+	}
+}
+
+// Search returns the messages matching q, best matches first. Messages are
+// ranked by BM25 on SQLite and by MySQL's FULLTEXT relevance on MySQL.
+func (r *MessageRepo) Search(ctx context.Context, q domain.SearchQuery) ([]*domain.Message, error) {
+	db, _, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Terms) == 0 {
+		return []*domain.Message{}, nil
+	}
+
+	switch name := db.Dialector.Name(); name {
+	case "sqlite":
+		db = db.Select("messages.*").
+			Joins("JOIN messages_fts ON messages_fts.rowid = messages.id").
+			Where("messages_fts MATCH ?", fts5Query(q.Terms)).
+			Order("bm25(messages_fts), messages.id DESC")
+	case "mysql":
+		match := clause.Expr{SQL: "MATCH (author, message) AGAINST (? IN BOOLEAN MODE)", Vars: []any{booleanModeQuery(q.Terms)}}
+		db = db.Where(match).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "? DESC, messages.id DESC", Vars: []any{match}}})
+	default:
+		return nil, fmt.Errorf("full-text search is not supported on %s", name)
+	}
+
+	var ms Messages
+	if err := db.Limit(q.Limit).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	return ms.ToEntity(), nil
+}
+
+// fts5Query returns an FTS5 query matching messages with a word starting
+// with each of terms. Terms are quoted so they are never read as operators.
+func fts5Query(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// booleanModeQuery returns a MySQL boolean mode query matching messages with a
+// word starting with each of terms. Terms only hold letters and digits, so
+// they cannot contain operators.
+func booleanModeQuery(terms []string) string {
+	required := make([]string, len(terms))
+	for i, t := range terms {
+		required[i] = "+" + t + "*"
+	}
+	return strings.Join(required, " ")
+}
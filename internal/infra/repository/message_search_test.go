@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_messageRepo_Search(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		ctx     context.Context
+		q       domain.SearchQuery
+		want    []*domain.Message
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery("SELECT \\* FROM `messages` WHERE guestbook_id = \\? AND MATCH \\(author, message\\) AGAINST \\(\\? IN BOOLEAN MODE\\) AND `messages`.`deleted_at` IS NULL "+
+					"ORDER BY MATCH \\(author, message\\) AGAINST \\(\\? IN BOOLEAN MODE\\) DESC, messages.id DESC LIMIT \\?").
+					WithArgs(2, "+dutch* +plan*", "+dutch* +plan*", 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "guestbook_id", "author", "message"}).
+						AddRow(1, 2, "Dutch van der Linde", "I have a plan!"))
+			},
+			ctx: guestbookCtx,
+			q:   domain.SearchQuery{Terms: []string{"dutch", "plan"}, Limit: 10},
+			want: []*domain.Message{
+				{ID: 1, GuestbookID: 2, Author: "Dutch van der Linde", Message: "I have a plan!"},
+			},
+		},
+		{
+			name:  "no terms",
+			setup: func() {},
+			ctx:   guestbookCtx,
+			q:     domain.SearchQuery{Limit: 10},
+			want:  []*domain.Message{},
+		},
+		{
+			name:    "without guestbook",
+			setup:   func() {},
+			ctx:     context.Background(),
+			q:       domain.SearchQuery{Terms: []string{"dutch"}, Limit: 10},
+			wantErr: true,
+		},
+		{
+			name: "failed to search messages",
+			setup: func() {
+				mock.ExpectQuery(".*").WillReturnError(sql.ErrConnDone)
+			},
+			ctx:     guestbookCtx,
+			q:       domain.SearchQuery{Terms: []string{"dutch"}, Limit: 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.Search(tt.ctx, tt.q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("messageRepo.Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageRepo.Search() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+// Test_messageRepo_Search_SQLite runs against an in-memory SQLite database,
+// as the FTS5 table and the triggers maintaining it cannot be mocked.
+func Test_messageRepo_Search_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Guestbook{}, &Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewMessageRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	other := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 3, Slug: "funeral"})
+
+	// Messages written before the index exists are indexed by the migration.
+	if _, err := r.Create(guestbookCtx, &domain.Message{Author: "Arthur Morgan", Message: "The quick brown fox"}); err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+	if err := MigrateMessageSearch(gormdb); err != nil {
+		t.Fatalf("MigrateMessageSearch() error = %v", err)
+	}
+	if err := MigrateMessageSearch(gormdb); err != nil {
+		t.Fatalf("MigrateMessageSearch() second run error = %v", err)
+	}
+	for _, m := range []struct {
+		ctx context.Context
+		m   *domain.Message
+	}{
+		{guestbookCtx, &domain.Message{Author: "Fox Mulder", Message: "I want to believe"}},
+		{guestbookCtx, &domain.Message{Author: "Dana Scully", Message: "Nothing to see at the Café"}},
+		{other, &domain.Message{Author: "Fox", Message: "Another guestbook"}},
+	} {
+		if _, err := r.Create(m.ctx, m.m); err != nil {
+			t.Fatalf("messageRepo.Create() error = %v", err)
+		}
+	}
+
+	search := func(ctx context.Context, terms ...string) []int64 {
+		t.Helper()
+		ms, err := r.Search(ctx, domain.SearchQuery{Terms: terms, Limit: 10})
+		if err != nil {
+			t.Fatalf("messageRepo.Search(%q) error = %v", terms, err)
+		}
+		ids := make([]int64, len(ms))
+		for i, m := range ms {
+			ids[i] = m.ID
+		}
+		return ids
+	}
+
+	// The author match ranks first, being a shorter document.
+	if got, want := search(guestbookCtx, "fox"), []int64{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search fox = %v, want %v", got, want)
+	}
+	if got, want := search(guestbookCtx, "cafe"), []int64{3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search cafe = %v, want %v", got, want)
+	}
+	if got, want := search(guestbookCtx, "qu", "brown"), []int64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search qu brown = %v, want %v", got, want)
+	}
+	if got, want := search(other, "fox"), []int64{4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search fox in other guestbook = %v, want %v", got, want)
+	}
+
+	// Updates are reindexed and deleted messages are not found.
+	if err := r.Update(guestbookCtx, &domain.Message{ID: 1, Author: "Arthur Morgan", Message: "A lazy dog"}); err != nil {
+		t.Fatalf("messageRepo.Update() error = %v", err)
+	}
+	if got, want := search(guestbookCtx, "lazy"), []int64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search lazy = %v, want %v", got, want)
+	}
+	if err := r.Delete(guestbookCtx, 2); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+	if got, want := search(guestbookCtx, "fox"), []int64{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search fox after update and delete = %v, want %v", got, want)
+	}
+	if err := gormdb.Unscoped().Delete(&Message{}, 2).Error; err != nil {
+		t.Fatalf("failed to purge message, got error: %v", err)
+	}
+	if got, want := search(guestbookCtx, "mulder"), []int64{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("search mulder after purge = %v, want %v", got, want)
+	}
+}
+
+func TestMigrateMessageSearch_Unsupported(t *testing.T) {
+	gormdb, err := gorm.Open(unsupportedDialector{sqlite.Open(":memory:")}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open db, got error: %v", err)
+	}
+	if err := MigrateMessageSearch(gormdb); err == nil {
+		t.Errorf("MigrateMessageSearch() error = %v, want unsupported error", err)
+	}
+}
+
+type unsupportedDialector struct {
+	gorm.Dialector
+}
+
+func (unsupportedDialector) Name() string { return "postgres" }
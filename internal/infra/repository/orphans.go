@@ -0,0 +1,34 @@
+package repository
+
+import "gorm.io/gorm"
+
+// deleteOrphans deletes the rows of PO, a model of the data of messages
+// with a message_id column, that belong to a message if it is deleted, and
+// returns them. Only the message's rows are read, rather than every row of
+// the table.
+func deleteOrphans[PO any](db *gorm.DB, messageID int64) ([]PO, error) {
+	var pos []PO
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// The query leaves out the soft-deleted messages.
+		var live int64
+		if err := tx.Model(&Message{}).Where("id = ?", messageID).Count(&live).Error; err != nil {
+			return err
+		}
+		if live > 0 {
+			return nil
+		}
+
+		if err := tx.Where("message_id = ?", messageID).Find(&pos).Error; err != nil {
+			return err
+		}
+		if len(pos) == 0 {
+			return nil
+		}
+		return tx.Delete(&pos).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pos, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// OutboxEvent is an event written in the same transaction as the change to
+// the message it reports. DispatchedAt is set once it has been dispatched,
+// and DeadAt once it ran out of attempts.
+type OutboxEvent struct {
+	ID          uint   `gorm:"primarykey"`
+	Type        string `gorm:"not null;size:32"`
+	GuestbookID uint   `gorm:"not null"`
+	MessageID   uint   `gorm:"not null"`
+	// Payload is the message after the change, as JSON, and empty for
+	// deletions.
+	Payload       string `gorm:"type:text"`
+	CreatedAt     time.Time
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_pending,priority:2"`
+	DispatchedAt  *time.Time `gorm:"index:idx_outbox_events_pending,priority:1"`
+	DeadAt        *time.Time
+	// Handled is the names of the handlers that have handled the event, as a
+	// JSON array, and empty until an attempt fails.
+	Handled string `gorm:"type:text"`
+}
+
+// outboxMessage is the snapshot of a message in the payload of an event.
+type outboxMessage struct {
+	ID        uint      `json:"id"`
+	ParentID  *uint     `json:"parent_id,omitempty"`
+	Author    string    `json:"author"`
+	Message   string    `json:"message"`
+	EmailHash string    `json:"email_hash,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	EditCount int       `json:"edit_count,omitempty"`
+	// EditedAt is only set on edited messages.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// Attachment is only set on the events of created messages.
+	Attachment *outboxAttachment `json:"attachment,omitempty"`
+}
+
+// outboxAttachment is the snapshot of the attachment of a message.
+type outboxAttachment struct {
+	ContentType          string `json:"content_type"`
+	Size                 int64  `json:"size"`
+	Width                int    `json:"width"`
+	Height               int    `json:"height"`
+	Key                  string `json:"key"`
+	ThumbnailKey         string `json:"thumbnail_key"`
+	ThumbnailContentType string `json:"thumbnail_content_type"`
+}
+
+// newOutboxEvent returns the event reporting a change to m, which is nil
+// for deletions.
+func newOutboxEvent(typ domain.MessageEventType, guestbookID, messageID uint, m *Message) (*OutboxEvent, error) {
+	po := &OutboxEvent{
+		Type:          string(typ),
+		GuestbookID:   guestbookID,
+		MessageID:     messageID,
+		NextAttemptAt: time.Now(),
+	}
+	if m != nil {
+		om := outboxMessage{
+			ID:        m.ID,
+			ParentID:  m.ParentID,
+			Author:    m.Author,
+			Message:   m.Message,
+			EmailHash: m.EmailHash,
+			CreatedAt: m.CreatedAt,
+			EditCount: m.EditCount,
+		}
+		if m.EditCount > 0 {
+			editedAt := m.UpdatedAt
+			om.EditedAt = &editedAt
+		}
+		if a := m.Attachment; a != nil {
+			om.Attachment = &outboxAttachment{
+				ContentType:          a.ContentType,
+				Size:                 a.Size,
+				Width:                a.Width,
+				Height:               a.Height,
+				Key:                  a.Key,
+				ThumbnailKey:         a.ThumbnailKey,
+				ThumbnailContentType: a.ThumbnailContentType,
+			}
+		}
+		payload, err := json.Marshal(om)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode outbox event: %w", err)
+		}
+		po.Payload = string(payload)
+	}
+	return po, nil
+}
+
+func (e *OutboxEvent) ToEntity() (*domain.OutboxEvent, error) {
+	ev := domain.MessageEvent{
+		ID:          int64(e.ID),
+		Type:        domain.MessageEventType(e.Type),
+		GuestbookID: int64(e.GuestbookID),
+		MessageID:   int64(e.MessageID),
+		Time:        e.CreatedAt,
+	}
+	if e.Payload != "" {
+		var m outboxMessage
+		if err := json.Unmarshal([]byte(e.Payload), &m); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox event %d: %w", e.ID, err)
+		}
+		ev.Message = &domain.Message{
+			ID:          int64(m.ID),
+			GuestbookID: int64(e.GuestbookID),
+			ParentID:    toInt64Ptr(m.ParentID),
+			Author:      m.Author,
+			Message:     m.Message,
+			EmailHash:   m.EmailHash,
+			CreatedAt:   m.CreatedAt,
+			EditCount:   m.EditCount,
+		}
+		if m.EditedAt != nil {
+			ev.Message.EditedAt = *m.EditedAt
+		}
+		if a := m.Attachment; a != nil {
+			ev.Message.Attachment = &domain.Attachment{
+				MessageID:            int64(m.ID),
+				ContentType:          a.ContentType,
+				Size:                 a.Size,
+				Width:                a.Width,
+				Height:               a.Height,
+				Key:                  a.Key,
+				ThumbnailKey:         a.ThumbnailKey,
+				ThumbnailContentType: a.ThumbnailContentType,
+			}
+		}
+	}
+
+	var handled []string
+	if e.Handled != "" {
+		if err := json.Unmarshal([]byte(e.Handled), &handled); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox event %d: %w", e.ID, err)
+		}
+	}
+
+	return &domain.OutboxEvent{
+		ID:       int64(e.ID),
+		Attempts: e.Attempts,
+		Handled:  handled,
+		Event:    ev,
+	}, nil
+}
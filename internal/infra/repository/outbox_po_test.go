@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOutboxEvent_ToEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	parentID := int64(1)
+
+	tests := []struct {
+		name    string
+		e       *OutboxEvent
+		want    *domain.OutboxEvent
+		wantErr bool
+	}{
+		{
+			name: "created",
+			e: &OutboxEvent{
+				ID:          3,
+				Type:        "message.created",
+				GuestbookID: 2,
+				MessageID:   7,
+				Payload:     `{"id":7,"parent_id":1,"author":"Arthur Morgan","message":"Hey, Dutch!","created_at":"1899-04-01T12:00:00Z"}`,
+				CreatedAt:   at,
+				Attempts:    1,
+				Handled:     `["stream"]`,
+			},
+			want: &domain.OutboxEvent{
+				ID:       3,
+				Attempts: 1,
+				Handled:  []string{"stream"},
+				Event: domain.MessageEvent{
+					ID:          3,
+					Type:        domain.MessageCreated,
+					GuestbookID: 2,
+					MessageID:   7,
+					Message: &domain.Message{
+						ID:          7,
+						GuestbookID: 2,
+						ParentID:    &parentID,
+						Author:      "Arthur Morgan",
+						Message:     "Hey, Dutch!",
+						CreatedAt:   at,
+					},
+					Time: at,
+				},
+			},
+		},
+		{
+			name: "created with attachment",
+			e: &OutboxEvent{
+				ID:          5,
+				Type:        "message.created",
+				GuestbookID: 2,
+				MessageID:   8,
+				Payload: `{"id":8,"author":"Arthur Morgan","message":"Look!","created_at":"1899-04-01T12:00:00Z",` +
+					`"attachment":{"content_type":"image/jpeg","size":1024,"width":640,"height":480,` +
+					`"key":"0123456789abcdef0123456789abcdef.jpg","thumbnail_key":"0123456789abcdef0123456789abcdef-thumb.jpg","thumbnail_content_type":"image/jpeg"}}`,
+				CreatedAt: at,
+			},
+			want: &domain.OutboxEvent{
+				ID: 5,
+				Event: domain.MessageEvent{
+					ID:          5,
+					Type:        domain.MessageCreated,
+					GuestbookID: 2,
+					MessageID:   8,
+					Message: &domain.Message{
+						ID:          8,
+						GuestbookID: 2,
+						Author:      "Arthur Morgan",
+						Message:     "Look!",
+						CreatedAt:   at,
+						Attachment: &domain.Attachment{
+							MessageID:            8,
+							ContentType:          "image/jpeg",
+							Size:                 1024,
+							Width:                640,
+							Height:               480,
+							Key:                  "0123456789abcdef0123456789abcdef.jpg",
+							ThumbnailKey:         "0123456789abcdef0123456789abcdef-thumb.jpg",
+							ThumbnailContentType: "image/jpeg",
+						},
+					},
+					Time: at,
+				},
+			},
+		},
+		{
+			name: "created with email hash",
+			e: &OutboxEvent{
+				ID:          6,
+				Type:        "message.created",
+				GuestbookID: 2,
+				MessageID:   9,
+				Payload:     `{"id":9,"author":"Arthur Morgan","message":"Hey!","email_hash":"` + domain.HashEmail("arthur@example.com") + `","created_at":"1899-04-01T12:00:00Z"}`,
+				CreatedAt:   at,
+			},
+			want: &domain.OutboxEvent{
+				ID: 6,
+				Event: domain.MessageEvent{
+					ID:          6,
+					Type:        domain.MessageCreated,
+					GuestbookID: 2,
+					MessageID:   9,
+					Message: &domain.Message{
+						ID:          9,
+						GuestbookID: 2,
+						Author:      "Arthur Morgan",
+						Message:     "Hey!",
+						EmailHash:   domain.HashEmail("arthur@example.com"),
+						CreatedAt:   at,
+					},
+					Time: at,
+				},
+			},
+		},
+		{
+			name: "updated",
+			e: &OutboxEvent{
+				ID:          4,
+				Type:        "message.updated",
+				GuestbookID: 2,
+				MessageID:   7,
+				Payload:     `{"id":7,"author":"Arthur Morgan","message":"Hey, Dutch!","created_at":"1899-04-01T12:00:00Z","edit_count":1,"edited_at":"1899-04-01T12:00:00Z"}`,
+				CreatedAt:   at,
+			},
+			want: &domain.OutboxEvent{
+				ID: 4,
+				Event: domain.MessageEvent{
+					ID:          4,
+					Type:        domain.MessageUpdated,
+					GuestbookID: 2,
+					MessageID:   7,
+					Message: &domain.Message{
+						ID:          7,
+						GuestbookID: 2,
+						Author:      "Arthur Morgan",
+						Message:     "Hey, Dutch!",
+						CreatedAt:   at,
+						EditCount:   1,
+						EditedAt:    at,
+					},
+					Time: at,
+				},
+			},
+		},
+		{
+			name: "deleted",
+			e:    &OutboxEvent{ID: 4, Type: "message.deleted", GuestbookID: 2, MessageID: 7, CreatedAt: at},
+			want: &domain.OutboxEvent{
+				ID:    4,
+				Event: domain.MessageEvent{ID: 4, Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 7, Time: at},
+			},
+		},
+		{
+			name:    "invalid payload",
+			e:       &OutboxEvent{ID: 5, Type: "message.created", Payload: "{"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.e.ToEntity()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OutboxEvent.ToEntity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OutboxEvent.ToEntity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepo reads the events MessageRepo writes to the outbox, for them to
+// be dispatched.
+type OutboxRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewOutboxRepo(logger *slog.Logger, db *gorm.DB) *OutboxRepo {
+	return &OutboxRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// GetPending returns up to limit events that have neither been dispatched
+// nor dead-lettered and are due for an attempt at now, oldest first.
+func (r *OutboxRepo) GetPending(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxEvent, error) {
+	var pos []*OutboxEvent
+	err := conn(ctx, r.db).
+		Where("dispatched_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&pos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending outbox events from repository: %w", err)
+	}
+
+	events := make([]*domain.OutboxEvent, 0, len(pos))
+	for _, po := range pos {
+		ev, err := po.ToEntity()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+// MarkDispatched records that an event has been dispatched.
+func (r *OutboxRepo) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	tx := conn(ctx, r.db).Model(&OutboxEvent{}).Where("id = ?", id).Update("dispatched_at", at)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to mark outbox event as dispatched from repository: %w", tx.Error)
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt to dispatch an event, to be attempted
+// again at next for the handlers that have not handled it.
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, handled []string, next time.Time) error {
+	if err := r.markFailed(ctx, id, handled, map[string]any{"next_attempt_at": next}); err != nil {
+		return fmt.Errorf("failed to mark outbox event as failed from repository: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDead records the last failed attempt to dispatch an event, which is
+// not attempted again.
+func (r *OutboxRepo) MarkDead(ctx context.Context, id int64, handled []string, at time.Time) error {
+	if err := r.markFailed(ctx, id, handled, map[string]any{"dead_at": at}); err != nil {
+		return fmt.Errorf("failed to mark outbox event as dead from repository: %w", err)
+	}
+
+	return nil
+}
+
+// markFailed counts a failed attempt to dispatch an event, recording the
+// handlers that have handled it along with updates.
+func (r *OutboxRepo) markFailed(ctx context.Context, id int64, handled []string, updates map[string]any) error {
+	b, err := json.Marshal(handled)
+	if err != nil {
+		return err
+	}
+	updates["attempts"] = gorm.Expr("attempts + 1")
+	updates["handled"] = string(b)
+
+	tx := conn(ctx, r.db).Model(&OutboxEvent{}).Where("id = ?", id).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteDispatched deletes the events dispatched or dead-lettered before a
+// time, and returns how many were deleted.
+func (r *OutboxRepo) DeleteDispatched(ctx context.Context, before time.Time) (int64, error) {
+	tx := conn(ctx, r.db).Where("dispatched_at < ? OR dead_at < ?", before, before).Delete(&OutboxEvent{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete dispatched outbox events from repository: %w", tx.Error)
+	}
+
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test_outboxRepo_SQLite runs against an in-memory SQLite database, writing
+// events through MessageRepo as in production.
+func Test_outboxRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Guestbook{}, &Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	messageRepo := NewMessageRepo(logger, gormdb)
+	r := NewOutboxRepo(logger, gormdb)
+	ctx := context.Background()
+
+	id, err := messageRepo.Create(guestbookCtx, &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"})
+	if err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+	if _, err := messageRepo.Create(guestbookCtx, &domain.Message{ParentID: &id, Author: "Dutch", Message: "Have faith"}); err != nil {
+		t.Fatalf("messageRepo.Create() error = %v", err)
+	}
+	if err := messageRepo.Update(guestbookCtx, &domain.Message{ID: id, Author: "Arthur Morgan", Message: "Hey!"}); err != nil {
+		t.Fatalf("messageRepo.Update() error = %v", err)
+	}
+	if err := messageRepo.Delete(guestbookCtx, id); err != nil {
+		t.Fatalf("messageRepo.Delete() error = %v", err)
+	}
+	// A failed change records no event.
+	if err := messageRepo.Update(guestbookCtx, &domain.Message{ID: 42, Author: "Nobody", Message: "Nothing"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("messageRepo.Update() error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	events, err := r.GetPending(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("outboxRepo.GetPending() error = %v", err)
+	}
+	type summary struct {
+		typ         domain.MessageEventType
+		guestbookID int64
+		messageID   int64
+		content     string
+	}
+	want := []summary{
+		{domain.MessageCreated, 2, 1, "Hey, Dutch!"},
+		{domain.MessageCreated, 2, 2, "Have faith"},
+		{domain.MessageUpdated, 2, 1, "Hey!"},
+		{domain.MessageDeleted, 2, 1, ""},
+		{domain.MessageDeleted, 2, 2, ""},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("outboxRepo.GetPending() returned %d events, want %d", len(events), len(want))
+	}
+	for i, ev := range events {
+		got := summary{ev.Event.Type, ev.Event.GuestbookID, ev.Event.MessageID, ""}
+		if ev.Event.Message != nil {
+			got.content = ev.Event.Message.Message
+		}
+		if got != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if reply := events[1].Event.Message; reply.ParentID == nil || *reply.ParentID != id {
+		t.Errorf("reply event parent = %v, want %d", reply.ParentID, id)
+	}
+
+	// Dispatched and dead events are no longer pending, and failed ones only
+	// once due.
+	now := time.Now()
+	if err := r.MarkDispatched(ctx, events[0].ID, now); err != nil {
+		t.Fatalf("outboxRepo.MarkDispatched() error = %v", err)
+	}
+	if err := r.MarkFailed(ctx, events[1].ID, []string{"stream"}, now.Add(time.Minute)); err != nil {
+		t.Fatalf("outboxRepo.MarkFailed() error = %v", err)
+	}
+	if err := r.MarkDead(ctx, events[4].ID, nil, now); err != nil {
+		t.Fatalf("outboxRepo.MarkDead() error = %v", err)
+	}
+	if err := r.MarkDispatched(ctx, 42, now); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("outboxRepo.MarkDispatched() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	pendingIDs := func(at time.Time) []int64 {
+		t.Helper()
+		events, err := r.GetPending(ctx, at, 10)
+		if err != nil {
+			t.Fatalf("outboxRepo.GetPending() error = %v", err)
+		}
+		ids := make([]int64, len(events))
+		for i, ev := range events {
+			ids[i] = ev.ID
+		}
+		return ids
+	}
+	if got := pendingIDs(now); !equalInt64s(got, []int64{3, 4}) {
+		t.Errorf("pending events = %v, want [3 4]", got)
+	}
+	later, err := r.GetPending(ctx, now.Add(2*time.Minute), 1)
+	if err != nil {
+		t.Fatalf("outboxRepo.GetPending() error = %v", err)
+	}
+	if len(later) != 1 || later[0].ID != 2 || later[0].Attempts != 1 || !slices.Equal(later[0].Handled, []string{"stream"}) {
+		t.Errorf("pending events later = %+v, want event 2 handled by stream after 1 attempt", later)
+	}
+
+	n, err := r.DeleteDispatched(ctx, now.Add(time.Second))
+	if err != nil || n != 2 {
+		t.Errorf("outboxRepo.DeleteDispatched() = %d, %v, want 2, nil", n, err)
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
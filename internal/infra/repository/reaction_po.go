@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"time"
+)
+
+// Reaction has no soft delete, so a reactor can react again with an emoji
+// after removing the reaction without violating the unique index.
+type Reaction struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	MessageID uint   `gorm:"not null;uniqueIndex:idx_reactions_message_reactor_emoji,priority:1"`
+	Reactor   string `gorm:"not null;size:64;uniqueIndex:idx_reactions_message_reactor_emoji,priority:2"`
+	Emoji     string `gorm:"not null;size:16;uniqueIndex:idx_reactions_message_reactor_emoji,priority:3"`
+}
+
+func (r *Reaction) ToEntity() *domain.Reaction {
+	return &domain.Reaction{
+		MessageID: int64(r.MessageID),
+		Reactor:   r.Reactor,
+		Emoji:     r.Emoji,
+	}
+}
+
+// reactionCount is a row of the per message and emoji reaction counts.
+type reactionCount struct {
+	MessageID uint
+	Emoji     string
+	Count     int
+	Reacted   bool
+}
+
+func (rc *reactionCount) ToEntity() domain.ReactionCount {
+	return domain.ReactionCount{
+		Emoji:   rc.Emoji,
+		Count:   rc.Count,
+		Reacted: rc.Reacted,
+	}
+}
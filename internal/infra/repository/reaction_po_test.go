@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestReaction_ToEntity(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Reaction
+		want *domain.Reaction
+	}{
+		{
+			name: "success",
+			r: &Reaction{
+				ID:        1,
+				MessageID: 2,
+				Reactor:   "c:visitor",
+				Emoji:     "👍",
+			},
+			want: &domain.Reaction{
+				MessageID: 2,
+				Reactor:   "c:visitor",
+				Emoji:     "👍",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reaction.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewReactionRepo(logger *slog.Logger, db *gorm.DB) *ReactionRepo {
+	return &ReactionRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Add adds a reaction unless the reactor has already reacted to the message
+// with the emoji.
+func (r *ReactionRepo) Add(ctx context.Context, reaction *domain.Reaction) error {
+	po := &Reaction{
+		MessageID: uint(reaction.MessageID),
+		Reactor:   reaction.Reactor,
+		Emoji:     reaction.Emoji,
+	}
+
+	tx := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(po)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to add reaction from repository: %w", tx.Error)
+	}
+
+	return nil
+}
+
+// Remove removes a reaction, if it exists.
+func (r *ReactionRepo) Remove(ctx context.Context, reaction *domain.Reaction) error {
+	tx := conn(ctx, r.db).
+		Where("message_id = ? AND reactor = ? AND emoji = ?", reaction.MessageID, reaction.Reactor, reaction.Emoji).
+		Delete(&Reaction{})
+	if tx.Error != nil {
+		return fmt.Errorf("failed to remove reaction from repository: %w", tx.Error)
+	}
+
+	return nil
+}
+
+// CountByMessages returns the reaction counts of messages, keyed by message
+// ID, telling for each emoji whether reactor is among the reactors.
+func (r *ReactionRepo) CountByMessages(ctx context.Context, messageIDs []int64, reactor string) (map[int64][]domain.ReactionCount, error) {
+	counts := make(map[int64][]domain.ReactionCount)
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []reactionCount
+	err := conn(ctx, r.db).Model(&Reaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, MAX(CASE WHEN reactor = ? THEN 1 ELSE 0 END) AS reacted", reactor).
+		Where("message_id IN ?", messageIDs).
+		Group("message_id, emoji").
+		Order("message_id, MIN(id)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions from repository: %w", err)
+	}
+
+	for _, row := range rows {
+		counts[int64(row.MessageID)] = append(counts[int64(row.MessageID)], row.ToEntity())
+	}
+
+	return counts, nil
+}
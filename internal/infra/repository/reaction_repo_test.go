@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func Test_reactionRepo_Add(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `reactions` .* ON DUPLICATE KEY UPDATE .*").
+					WithArgs(sqlmock.AnyArg(), 1, "c:visitor", "👍").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "already reacted",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `reactions` .*").
+					WithArgs(sqlmock.AnyArg(), 1, "c:visitor", "👍").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "failed to add reaction",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `reactions` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewReactionRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			err := r.Add(context.Background(), &domain.Reaction{MessageID: 1, Reactor: "c:visitor", Emoji: "👍"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reactionRepo.Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_reactionRepo_Remove(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `reactions` WHERE message_id = \\? AND reactor = \\? AND emoji = \\?").
+					WithArgs(1, "c:visitor", "👍").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not reacted",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `reactions` .*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "failed to remove reaction",
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `reactions` .*").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewReactionRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			err := r.Remove(context.Background(), &domain.Reaction{MessageID: 1, Reactor: "c:visitor", Emoji: "👍"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reactionRepo.Remove() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_reactionRepo_CountByMessages(t *testing.T) {
+	gormdb, mock, db := initMessageDBMock(t)
+	defer db.Close()
+
+	tests := []struct {
+		name       string
+		setup      func()
+		messageIDs []int64
+		want       map[int64][]domain.ReactionCount
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery("SELECT message_id, emoji, COUNT\\(\\*\\) AS count, .* FROM `reactions` WHERE message_id IN \\(\\?,\\?\\) GROUP BY message_id, emoji ORDER BY message_id, MIN\\(id\\)").
+					WithArgs("c:visitor", 1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted"}).
+						AddRow(1, "🎉", 3, 1).
+						AddRow(1, "👍", 1, 0).
+						AddRow(2, "😂", 2, 0))
+			},
+			messageIDs: []int64{1, 2},
+			want: map[int64][]domain.ReactionCount{
+				1: {{Emoji: "🎉", Count: 3, Reacted: true}, {Emoji: "👍", Count: 1}},
+				2: {{Emoji: "😂", Count: 2}},
+			},
+		},
+		{
+			name:       "no messages",
+			setup:      func() {},
+			messageIDs: nil,
+			want:       map[int64][]domain.ReactionCount{},
+		},
+		{
+			name: "failed to count reactions",
+			setup: func() {
+				mock.ExpectQuery(".*").WillReturnError(sql.ErrConnDone)
+			},
+			messageIDs: []int64{1},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			r := NewReactionRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+			got, err := r.CountByMessages(context.Background(), tt.messageIDs, "c:visitor")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reactionRepo.CountByMessages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reactionRepo.CountByMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
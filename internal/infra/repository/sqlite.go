@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+)
+
+// sqliteBusyTimeout is how long SQLite transactions wait for the write lock
+// of the database.
+const sqliteBusyTimeout = 10 * time.Second
+
+// SQLiteDSN returns the data source name of the SQLite database at path, set
+// up for concurrent units of work. Transactions take the write lock of the
+// database as they begin, waiting for other transactions to end, so that
+// what they read cannot change before they write: SQLite does not lock rows
+// read with FOR UPDATE, and would otherwise fail transactions upgrading to a
+// write lock concurrently instead of waiting. The database is journaled in
+// WAL mode, so that reads, such as the cursor of an export streamed to a slow
+// client, do not block writes.
+func SQLiteDSN(path string) string {
+	return path + "?_txlock=immediate&_pragma=busy_timeout(" + strconv.FormatInt(sqliteBusyTimeout.Milliseconds(), 10) + ")" +
+		"&_pragma=journal_mode(WAL)"
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction of a unit of work.
+type txKey struct{}
+
+// UnitOfWork runs functions in a transaction that the repositories sharing
+// its database take part in when called with the context of the function.
+type UnitOfWork struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewUnitOfWork(logger *slog.Logger, db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// WithinTx runs fn in a transaction, which is committed if fn returns nil and
+// rolled back otherwise. Repository calls made with the context passed to fn
+// run in the transaction. Within another unit of work, fn runs in a savepoint
+// of its transaction, so only its own changes are rolled back.
+func (u *UnitOfWork) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	return conn(ctx, u.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the session queries made with ctx run on: the transaction of
+// the unit of work ctx belongs to, or else db.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
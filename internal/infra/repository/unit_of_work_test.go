@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestUnitOfWork_WithinTx_SQLite(t *testing.T) {
+	// A file, as every connection to :memory: opens a database of its own.
+	gormdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "guestbook.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Message{}, &MessageRevision{}, &Reaction{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	uow := NewUnitOfWork(l, gormdb)
+	messageRepo := NewMessageRepo(l, gormdb)
+	reactionRepo := NewReactionRepo(l, gormdb)
+	errRollback := errors.New("rollback")
+
+	countMessages := func() int {
+		ms, err := messageRepo.GetAll(guestbookCtx, domain.MessageQuery{})
+		if err != nil {
+			t.Fatalf("messageRepo.GetAll() error = %v", err)
+		}
+		return len(ms)
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		err := uow.WithinTx(guestbookCtx, func(ctx context.Context) error {
+			id, err := messageRepo.Create(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"})
+			if err != nil {
+				return err
+			}
+			if err := reactionRepo.Add(ctx, &domain.Reaction{MessageID: id, Reactor: "dutch", Emoji: "👍"}); err != nil {
+				return err
+			}
+
+			// The transaction sees its own changes, which are not committed yet.
+			if _, err := messageRepo.Get(ctx, id); err != nil {
+				t.Errorf("messageRepo.Get() within the transaction error = %v", err)
+			}
+			var committed int64
+			if err := gormdb.Model(&Message{}).Count(&committed).Error; err != nil || committed != 0 {
+				t.Errorf("messages outside the transaction = %d, %v, want none", committed, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("UnitOfWork.WithinTx() error = %v", err)
+		}
+
+		counts, err := reactionRepo.CountByMessages(guestbookCtx, []int64{1}, "")
+		if err != nil {
+			t.Fatalf("reactionRepo.CountByMessages() error = %v", err)
+		}
+		if countMessages() != 1 || len(counts[1]) != 1 {
+			t.Errorf("after commit, got %d messages and reactions %v, want the message and its reaction", countMessages(), counts)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		err := uow.WithinTx(guestbookCtx, func(ctx context.Context) error {
+			if _, err := messageRepo.Create(ctx, &domain.Message{Author: "Micah Bell", Message: "Spam"}); err != nil {
+				return err
+			}
+			if err := messageRepo.Delete(ctx, 1); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("UnitOfWork.WithinTx() error = %v, want %v", err, errRollback)
+		}
+
+		if _, err := messageRepo.Get(guestbookCtx, 1); err != nil || countMessages() != 1 {
+			t.Errorf("after rollback, got %d messages, want only the first message", countMessages())
+		}
+		// The events of the rolled back changes are gone with them.
+		var events int64
+		if err := gormdb.Model(&OutboxEvent{}).Count(&events).Error; err != nil || events != 1 {
+			t.Errorf("after rollback, got %d events, want 1", events)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		err := uow.WithinTx(guestbookCtx, func(ctx context.Context) error {
+			if _, err := messageRepo.Create(ctx, &domain.Message{Author: "Sadie Adler", Message: "Hello"}); err != nil {
+				return err
+			}
+			err := uow.WithinTx(ctx, func(ctx context.Context) error {
+				if _, err := messageRepo.Create(ctx, &domain.Message{Author: "Micah Bell", Message: "Spam"}); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if !errors.Is(err, errRollback) {
+				t.Errorf("nested UnitOfWork.WithinTx() error = %v, want %v", err, errRollback)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("UnitOfWork.WithinTx() error = %v", err)
+		}
+
+		ms, err := messageRepo.GetAll(guestbookCtx, domain.MessageQuery{})
+		if err != nil {
+			t.Fatalf("messageRepo.GetAll() error = %v", err)
+		}
+		if len(ms) != 2 || ms[1].Author != "Sadie Adler" {
+			t.Errorf("after nested rollback, got %+v, want the outer message only", ms)
+		}
+	})
+}
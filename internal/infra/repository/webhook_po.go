@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Webhook struct {
+	gorm.Model
+	URL    string `gorm:"not null;size:2048"`
+	Secret string `gorm:"not null;size:255"`
+	// EventTypes is the comma separated list of event types delivered.
+	EventTypes string `gorm:"not null;size:255"`
+	Active     bool   `gorm:"not null"`
+}
+
+func newWebhook(w *domain.Webhook) *Webhook {
+	types := make([]string, len(w.EventTypes))
+	for i, t := range w.EventTypes {
+		types[i] = string(t)
+	}
+	return &Webhook{
+		URL:        w.URL,
+		Secret:     w.Secret,
+		EventTypes: strings.Join(types, ","),
+		Active:     w.Active,
+	}
+}
+
+func (w *Webhook) ToEntity() *domain.Webhook {
+	var types []domain.MessageEventType
+	for _, t := range strings.Split(w.EventTypes, ",") {
+		if t != "" {
+			types = append(types, domain.MessageEventType(t))
+		}
+	}
+	return &domain.Webhook{
+		ID:         int64(w.ID),
+		URL:        w.URL,
+		Secret:     w.Secret,
+		EventTypes: types,
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+type Webhooks []*Webhook
+
+func (ws Webhooks) ToEntity() []*domain.Webhook {
+	entities := make([]*domain.Webhook, len(ws))
+	for i, w := range ws {
+		entities[i] = w.ToEntity()
+	}
+	return entities
+}
+
+// WebhookDelivery is unique per webhook and event, so an event dispatched
+// more than once is only delivered once.
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primarykey"`
+	WebhookID      uint      `gorm:"not null;uniqueIndex:idx_webhook_deliveries_webhook_event,priority:1"`
+	EventID        uint      `gorm:"not null;uniqueIndex:idx_webhook_deliveries_webhook_event,priority:2"`
+	EventType      string    `gorm:"not null;size:32"`
+	Payload        string    `gorm:"not null;type:text"`
+	Status         string    `gorm:"not null;size:16;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int       `gorm:"not null;default:0"`
+	LastError      string    `gorm:"not null;size:1024;default:''"`
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+func (d *WebhookDelivery) ToEntity() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             int64(d.ID),
+		WebhookID:      int64(d.WebhookID),
+		EventID:        int64(d.EventID),
+		EventType:      domain.MessageEventType(d.EventType),
+		Payload:        d.Payload,
+		Status:         domain.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+type WebhookDeliveries []*WebhookDelivery
+
+func (ds WebhookDeliveries) ToEntity() []*domain.WebhookDelivery {
+	entities := make([]*domain.WebhookDelivery, len(ds))
+	for i, d := range ds {
+		entities[i] = d.ToEntity()
+	}
+	return entities
+}
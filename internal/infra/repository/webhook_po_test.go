@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestWebhook_ToEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		w    *Webhook
+		want *domain.Webhook
+	}{
+		{
+			name: "success",
+			w: &Webhook{
+				Model:      gorm.Model{ID: 1, CreatedAt: at},
+				URL:        "https://hooks.example/guestbook",
+				Secret:     "0123456789abcdef",
+				EventTypes: "message.created,message.deleted",
+				Active:     true,
+			},
+			want: &domain.Webhook{
+				ID:         1,
+				URL:        "https://hooks.example/guestbook",
+				Secret:     "0123456789abcdef",
+				EventTypes: []domain.MessageEventType{domain.MessageCreated, domain.MessageDeleted},
+				Active:     true,
+				CreatedAt:  at,
+			},
+		},
+		{
+			name: "no event types",
+			w:    &Webhook{Model: gorm.Model{ID: 2}},
+			want: &domain.Webhook{ID: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.w.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Webhook.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newWebhook(t *testing.T) {
+	w := &domain.Webhook{
+		ID:         1,
+		URL:        "https://hooks.example/guestbook",
+		Secret:     "0123456789abcdef",
+		EventTypes: []domain.MessageEventType{domain.MessageCreated, domain.MessageUpdated},
+		Active:     true,
+	}
+	want := &Webhook{
+		URL:        "https://hooks.example/guestbook",
+		Secret:     "0123456789abcdef",
+		EventTypes: "message.created,message.updated",
+		Active:     true,
+	}
+	if got := newWebhook(w); !reflect.DeepEqual(got, want) {
+		t.Errorf("newWebhook() = %v, want %v", got, want)
+	}
+}
+
+func TestWebhookDeliveries_ToEntity(t *testing.T) {
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	delivered := at.Add(time.Minute)
+
+	ds := WebhookDeliveries{
+		{
+			ID:             1,
+			WebhookID:      2,
+			EventID:        3,
+			EventType:      "message.created",
+			Payload:        `{"id":3}`,
+			Status:         "delivered",
+			Attempts:       2,
+			NextAttemptAt:  at,
+			LastStatusCode: 204,
+			CreatedAt:      at,
+			DeliveredAt:    &delivered,
+		},
+	}
+	want := []*domain.WebhookDelivery{
+		{
+			ID:             1,
+			WebhookID:      2,
+			EventID:        3,
+			EventType:      domain.MessageCreated,
+			Payload:        `{"id":3}`,
+			Status:         domain.WebhookDeliveryDelivered,
+			Attempts:       2,
+			NextAttemptAt:  at,
+			LastStatusCode: 204,
+			CreatedAt:      at,
+			DeliveredAt:    &delivered,
+		},
+	}
+	if got := ds.ToEntity(); !reflect.DeepEqual(got, want) {
+		t.Errorf("WebhookDeliveries.ToEntity() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepo struct {
+	logger *slog.Logger
+	db     *gorm.DB
+}
+
+func NewWebhookRepo(logger *slog.Logger, db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (r *WebhookRepo) GetAll(ctx context.Context) ([]*domain.Webhook, error) {
+	var ws Webhooks
+	if err := conn(ctx, r.db).Order("id").Find(&ws).Error; err != nil {
+		return nil, err
+	}
+
+	return ws.ToEntity(), nil
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, id int64) (*domain.Webhook, error) {
+	var w Webhook
+	if err := conn(ctx, r.db).First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(domain.ErrNotFound, err)
+		}
+		return nil, err
+	}
+
+	return w.ToEntity(), nil
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, w *domain.Webhook) (int64, error) {
+	po := newWebhook(w)
+
+	tx := conn(ctx, r.db).Create(po)
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to create webhook from repository: %w", tx.Error)
+	}
+
+	return int64(po.ID), nil
+}
+
+func (r *WebhookRepo) Update(ctx context.Context, w *domain.Webhook) error {
+	tx := conn(ctx, r.db).Model(&Webhook{}).
+		Where("id = ?", w.ID).
+		Select("url", "secret", "event_types", "active").
+		Updates(newWebhook(w))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a webhook together with its deliveries.
+func (r *WebhookRepo) Delete(ctx context.Context, id int64) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Delete(&Webhook{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+
+		return tx.Where("webhook_id = ?", id).Delete(&WebhookDelivery{}).Error
+	})
+}
+
+// CreateDeliveries queues deliveries, skipping those of an event already
+// queued for the same webhook.
+func (r *WebhookRepo) CreateDeliveries(ctx context.Context, ds []*domain.WebhookDelivery) error {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	pos := make([]*WebhookDelivery, len(ds))
+	for i, d := range ds {
+		pos[i] = &WebhookDelivery{
+			WebhookID:     uint(d.WebhookID),
+			EventID:       uint(d.EventID),
+			EventType:     string(d.EventType),
+			Payload:       d.Payload,
+			Status:        string(domain.WebhookDeliveryPending),
+			NextAttemptAt: d.NextAttemptAt,
+		}
+	}
+
+	tx := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(pos)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to create webhook deliveries from repository: %w", tx.Error)
+	}
+
+	return nil
+}
+
+// GetDueDeliveries returns up to limit pending deliveries due for an attempt
+// at now, oldest first.
+func (r *WebhookRepo) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var ds WebhookDeliveries
+	err := conn(ctx, r.db).
+		Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&ds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ToEntity(), nil
+}
+
+// GetDeliveries returns the last limit deliveries to a webhook, newest first.
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	var ds WebhookDeliveries
+	err := conn(ctx, r.db).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&ds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.ToEntity(), nil
+}
+
+func (r *WebhookRepo) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	var d WebhookDelivery
+	if err := conn(ctx, r.db).First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(domain.ErrNotFound, err)
+		}
+		return nil, err
+	}
+
+	return d.ToEntity(), nil
+}
+
+// UpdateDelivery records the state of a delivery after an attempt.
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	tx := conn(ctx, r.db).Model(&WebhookDelivery{}).
+		Where("id = ?", d.ID).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+		Updates(&WebhookDelivery{
+			Status:         string(d.Status),
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			DeliveredAt:    d.DeliveredAt,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test_webhookRepo_SQLite runs against an in-memory SQLite database.
+func Test_webhookRepo_SQLite(t *testing.T) {
+	gormdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	if err := gormdb.AutoMigrate(&Webhook{}, &WebhookDelivery{}); err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+
+	r := NewWebhookRepo(slog.New(slog.NewTextHandler(io.Discard, nil)), gormdb)
+	ctx := context.Background()
+
+	id, err := r.Create(ctx, &domain.Webhook{
+		URL:        "https://hooks.example/guestbook",
+		Secret:     "0123456789abcdef",
+		EventTypes: []domain.MessageEventType{domain.MessageCreated},
+		Active:     true,
+	})
+	if err != nil {
+		t.Fatalf("WebhookRepo.Create() error = %v", err)
+	}
+
+	// Update may deactivate a webhook, which is a zero value.
+	err = r.Update(ctx, &domain.Webhook{
+		ID:         id,
+		URL:        "https://hooks.example/v2",
+		Secret:     "fedcba9876543210",
+		EventTypes: []domain.MessageEventType{domain.MessageCreated, domain.MessageDeleted},
+	})
+	if err != nil {
+		t.Fatalf("WebhookRepo.Update() error = %v", err)
+	}
+	w, err := r.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("WebhookRepo.Get() error = %v", err)
+	}
+	if w.URL != "https://hooks.example/v2" || w.Secret != "fedcba9876543210" || w.Active || len(w.EventTypes) != 2 {
+		t.Errorf("WebhookRepo.Get() = %+v, want the updated webhook", w)
+	}
+	if err := r.Update(ctx, &domain.Webhook{ID: 42}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("WebhookRepo.Update() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	if _, err := r.Get(ctx, 42); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("WebhookRepo.Get() error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	now := time.Now()
+	queue := func(eventID int64) error {
+		return r.CreateDeliveries(ctx, []*domain.WebhookDelivery{{
+			WebhookID:     id,
+			EventID:       eventID,
+			EventType:     domain.MessageCreated,
+			Payload:       `{}`,
+			NextAttemptAt: now,
+		}})
+	}
+	for _, eventID := range []int64{1, 2, 1} {
+		if err := queue(eventID); err != nil {
+			t.Fatalf("WebhookRepo.CreateDeliveries() error = %v", err)
+		}
+	}
+
+	// The duplicate of event 1 was skipped.
+	due, err := r.GetDueDeliveries(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("WebhookRepo.GetDueDeliveries() error = %v", err)
+	}
+	if len(due) != 2 || due[0].EventID != 1 || due[1].EventID != 2 || due[0].Status != domain.WebhookDeliveryPending {
+		t.Fatalf("WebhookRepo.GetDueDeliveries() = %+v, want the deliveries of events 1 and 2", due)
+	}
+
+	// Delivered and retried later deliveries are not due.
+	deliveredAt := now
+	due[0].Status = domain.WebhookDeliveryDelivered
+	due[0].Attempts = 1
+	due[0].LastStatusCode = 204
+	due[0].DeliveredAt = &deliveredAt
+	due[1].Attempts = 1
+	due[1].LastStatusCode = 500
+	due[1].LastError = "unexpected status"
+	due[1].NextAttemptAt = now.Add(time.Minute)
+	for _, d := range due {
+		if err := r.UpdateDelivery(ctx, d); err != nil {
+			t.Fatalf("WebhookRepo.UpdateDelivery() error = %v", err)
+		}
+	}
+	if due, err := r.GetDueDeliveries(ctx, now, 10); err != nil || len(due) != 0 {
+		t.Errorf("WebhookRepo.GetDueDeliveries() = %v, %v, want none", due, err)
+	}
+	if due, err := r.GetDueDeliveries(ctx, now.Add(time.Minute), 10); err != nil || len(due) != 1 {
+		t.Errorf("WebhookRepo.GetDueDeliveries() = %v, %v, want one", due, err)
+	}
+
+	log, err := r.GetDeliveries(ctx, id, 10)
+	if err != nil {
+		t.Fatalf("WebhookRepo.GetDeliveries() error = %v", err)
+	}
+	if len(log) != 2 || log[0].EventID != 2 || log[0].LastError != "unexpected status" || log[1].DeliveredAt == nil {
+		t.Errorf("WebhookRepo.GetDeliveries() = %+v, want both deliveries, newest first", log)
+	}
+	d, err := r.GetDelivery(ctx, log[1].ID)
+	if err != nil || d.Status != domain.WebhookDeliveryDelivered || d.LastStatusCode != 204 {
+		t.Errorf("WebhookRepo.GetDelivery() = %+v, %v, want the delivered delivery", d, err)
+	}
+	if _, err := r.GetDelivery(ctx, 42); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("WebhookRepo.GetDelivery() error = %v, want %v", err, domain.ErrNotFound)
+	}
+
+	if err := r.Delete(ctx, id); err != nil {
+		t.Fatalf("WebhookRepo.Delete() error = %v", err)
+	}
+	if ws, err := r.GetAll(ctx); err != nil || len(ws) != 0 {
+		t.Errorf("WebhookRepo.GetAll() = %v, %v, want none", ws, err)
+	}
+	if log, err := r.GetDeliveries(ctx, id, 10); err != nil || len(log) != 0 {
+		t.Errorf("WebhookRepo.GetDeliveries() = %v, %v, want none", log, err)
+	}
+	if err := r.Delete(ctx, id); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("WebhookRepo.Delete() error = %v, want %v", err, domain.ErrNotFound)
+	}
+}
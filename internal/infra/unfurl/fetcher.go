@@ -0,0 +1,267 @@
+package unfurl
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/infra/netguard"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Config configures a Fetcher.
+type Config struct {
+	// Timeout bounds each fetch, including connecting and following
+	// redirects.
+	Timeout time.Duration
+	// MaxPageSize is the number of bytes of a page read for its metadata.
+	// The rest of the page is ignored.
+	MaxPageSize int64
+	// MaxImageSize is the largest image fetched, in bytes.
+	MaxImageSize int64
+	// MaxRedirects is the number of redirects followed.
+	MaxRedirects int
+	// UserAgent is sent with every request.
+	UserAgent string
+}
+
+// DefaultConfig returns the default fetcher configuration.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:      5 * time.Second,
+		MaxPageSize:  512 << 10,
+		MaxImageSize: domain.MaxAttachmentSize,
+		MaxRedirects: 3,
+		UserAgent:    "GuestbookLinkPreview/1.0",
+	}
+}
+
+// Fetcher fetches the metadata of the pages linked to in messages, and their
+// images. The links come from untrusted users, so it only connects to public
+// addresses on the HTTP and HTTPS ports, checked on the address actually
+// dialed, after DNS resolution and on every redirect. It never goes through a
+// proxy, which would connect on its behalf.
+type Fetcher struct {
+	logger *slog.Logger
+	config Config
+	client *http.Client
+
+	// allow tells whether an address may be connected to. Tests replace it
+	// to reach local servers.
+	allow func(netip.AddrPort) bool
+}
+
+// NewFetcher returns a new Fetcher instance.
+func NewFetcher(logger *slog.Logger, config Config) *Fetcher {
+	f := &Fetcher{
+		logger: logger,
+		config: config,
+		allow:  isAllowed,
+	}
+	f.client = &http.Client{
+		Transport:     netguard.NewTransport(config.Timeout, f.control),
+		CheckRedirect: f.checkRedirect,
+	}
+	return f
+}
+
+// control refuses to connect to addresses that are not allowed. It runs
+// after DNS resolution, so a name cannot resolve to a private address once
+// checked.
+func (f *Fetcher) control(_, address string, _ syscall.RawConn) error {
+	return netguard.Check(address, f.allow)
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.config.MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", f.config.MaxRedirects)
+	}
+	return validateURL(req.URL)
+}
+
+// FetchMetadata fetches the HTML page at rawURL and returns its metadata,
+// read from the head of the page.
+func (f *Fetcher) FetchMetadata(ctx context.Context, rawURL string) (*domain.LinkMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+	defer cancel()
+
+	resp, err := f.get(ctx, rawURL, "text/html,application/xhtml+xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("failed to fetch page: %w: %q is not an HTML page", domain.ErrUnsupportedMediaType, mediaType)
+	}
+
+	meta := parseMetadata(io.LimitReader(resp.Body, f.config.MaxPageSize), resp.Request.URL)
+	return meta, nil
+}
+
+// FetchImage fetches the image at rawURL.
+func (f *Fetcher) FetchImage(ctx context.Context, rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+	defer cancel()
+
+	resp, err := f.get(ctx, rawURL, "image/*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("failed to fetch image: %w: %q is not an image", domain.ErrUnsupportedMediaType, mediaType)
+	}
+	if resp.ContentLength > f.config.MaxImageSize {
+		return nil, fmt.Errorf("failed to fetch image: %w: image is larger than %d bytes", domain.ErrTooLarge, f.config.MaxImageSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+	if int64(len(data)) > f.config.MaxImageSize {
+		return nil, fmt.Errorf("failed to fetch image: %w: image is larger than %d bytes", domain.ErrTooLarge, f.config.MaxImageSize)
+	}
+
+	return data, nil
+}
+
+// get sends a GET request for rawURL, and returns the response if it is
+// successful.
+func (f *Fetcher) get(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid URL", domain.ErrInvalidArgument)
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// validateURL checks that u is an absolute http or https URL without
+// credentials.
+func validateURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q is not allowed", domain.ErrForbidden, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URLs with credentials are not allowed", domain.ErrForbidden)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: URL without host", domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// allowedPorts lists the ports that may be connected to.
+var allowedPorts = map[uint16]bool{
+	80:  true,
+	443: true,
+}
+
+// isAllowed tells whether addr is a public address on an allowed port.
+func isAllowed(addr netip.AddrPort) bool {
+	return allowedPorts[addr.Port()] && netguard.IsPublic(addr.Addr())
+}
+
+// parseMetadata reads the metadata of the page read from r, whose URL is
+// base. It stops at the end of the head, where the metadata ends, and keeps
+// what was read when the page is cut off or malformed.
+func parseMetadata(r io.Reader, base *url.URL) *domain.LinkMetadata {
+	props := make(map[string]string)
+	var title string
+
+	z := html.NewTokenizer(r)
+head:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			break head
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				break head
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				break head
+			case "title":
+				if title == "" && z.Next() == html.TextToken {
+					title = string(z.Text())
+				}
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(strings.TrimSpace(string(v)))
+					case "content":
+						content = string(v)
+					}
+				}
+				if _, ok := props[key]; key != "" && !ok {
+					props[key] = content
+				}
+			}
+		}
+	}
+
+	meta := &domain.LinkMetadata{
+		URL:         base.String(),
+		Title:       first(props["og:title"], props["twitter:title"], title),
+		Description: first(props["og:description"], props["twitter:description"], props["description"]),
+		SiteName:    props["og:site_name"],
+	}
+	image := first(props["og:image"], props["og:image:secure_url"], props["og:image:url"], props["twitter:image"], props["twitter:image:src"])
+	if image != "" {
+		if u, err := base.Parse(strings.TrimSpace(image)); err == nil && validateURL(u) == nil {
+			meta.ImageURL = u.String()
+		}
+	}
+
+	return meta
+}
+
+// first returns the first of values that is not blank.
+func first(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
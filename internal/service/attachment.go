@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"path"
+	"regexp"
+)
+
+// BlobStore stores the images attached to messages.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type AttachmentRepo interface {
+	GetByMessages(context.Context, []int64) (map[int64]*domain.Attachment, error)
+	DeleteOrphans(context.Context, int64) ([]*domain.Attachment, error)
+}
+
+// attachmentKeyPattern matches the keys of attachments and their thumbnails,
+// which are random, so they cannot be guessed.
+var attachmentKeyPattern = regexp.MustCompile(`^[0-9a-f]{32}(-thumb)?\.(jpg|png|gif)$`)
+
+// CreateWithImage sanitizes and creates a message like Create, with the image
+// read from image attached to it. The image is processed before it is stored,
+// see processImage, and rejected with domain.ErrTooLarge or
+// domain.ErrUnsupportedMediaType.
+func (s *MessageService) CreateWithImage(ctx context.Context, message *domain.Message, image io.Reader) (int64, error) {
+	if s.blobStore == nil {
+		return 0, fmt.Errorf("failed to create message: %w: attachments are not enabled", domain.ErrUnsupportedMediaType)
+	}
+	if g, ok := domain.GuestbookFromContext(ctx); ok && !g.Settings.AllowPosting {
+		return 0, fmt.Errorf("failed to create message: guestbook %q does not allow posting: %w", g.Slug, domain.ErrForbidden)
+	}
+
+	img, err := processImage(image)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	attachment, err := s.storeImage(ctx, img)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	withAttachment := *message
+	withAttachment.Attachment = attachment
+	id, err := s.Create(ctx, &withAttachment)
+	if err != nil {
+		// The message is not created, so neither is the attachment.
+		s.deleteBlobs(ctx, attachment)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// storeImage stores a processed image and its thumbnail under new keys, and
+// returns the attachment to create with the message.
+func (s *MessageService) storeImage(ctx context.Context, img *processedImage) (*domain.Attachment, error) {
+	name, err := newBlobName()
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &domain.Attachment{
+		ContentType:          img.contentType,
+		Size:                 int64(len(img.data)),
+		Width:                img.width,
+		Height:               img.height,
+		Key:                  name + imageTypes[img.contentType],
+		ThumbnailKey:         name + "-thumb" + imageTypes[img.thumbnailType],
+		ThumbnailContentType: img.thumbnailType,
+	}
+	if err := s.blobStore.Put(ctx, attachment.Key, bytes.NewReader(img.data)); err != nil {
+		return nil, err
+	}
+	if err := s.blobStore.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(img.thumbnail)); err != nil {
+		s.deleteBlobs(ctx, attachment)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// newBlobName returns a new random name for blobs, which cannot be guessed.
+func newBlobName() (string, error) {
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random[:]), nil
+}
+
+// loadAttachments sets the attachments of msgs.
+func (s *MessageService) loadAttachments(ctx context.Context, msgs ...*domain.Message) error {
+	if s.attachmentRepo == nil || len(msgs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+
+	attachments, err := s.attachmentRepo.GetByMessages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		m.Attachment = attachments[m.ID]
+	}
+
+	return nil
+}
+
+// DeleteOrphanedAttachments deletes the attachment of a deleted message,
+// with its images. It is subscribed to the OutboxDispatcher, which retries
+// the event if the attachment cannot be deleted. Failures to delete images
+// are only logged.
+func (s *MessageService) DeleteOrphanedAttachments(ctx context.Context, ev domain.MessageEvent) error {
+	if s.attachmentRepo == nil || ev.Type != domain.MessageDeleted {
+		return nil
+	}
+
+	orphans, err := s.attachmentRepo.DeleteOrphans(ctx, ev.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachments: %w", err)
+	}
+	for _, a := range orphans {
+		s.deleteBlobs(ctx, a)
+	}
+
+	return nil
+}
+
+// deleteBlobs deletes the image and thumbnail of an attachment, logging
+// failures.
+func (s *MessageService) deleteBlobs(ctx context.Context, a *domain.Attachment) {
+	for _, key := range []string{a.Key, a.ThumbnailKey} {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			s.logger.Error("failed to delete attachment blob", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// AttachmentService serves the images attached to messages.
+type AttachmentService struct {
+	logger    *slog.Logger
+	blobStore BlobStore
+}
+
+// NewAttachmentService returns a new AttachmentService instance.
+func NewAttachmentService(logger *slog.Logger, blobStore BlobStore) *AttachmentService {
+	return &AttachmentService{
+		logger:    logger,
+		blobStore: blobStore,
+	}
+}
+
+// Open returns the image or thumbnail stored under key and its content type.
+// The caller must close it.
+func (s *AttachmentService) Open(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	if !attachmentKeyPattern.MatchString(key) {
+		return nil, "", fmt.Errorf("failed to open attachment: %w: invalid key %q", domain.ErrNotFound, key)
+	}
+
+	r, err := s.blobStore.Open(ctx, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	return r, contentTypeOfKey(key), nil
+}
+
+// contentTypeOfKey returns the content type of the image stored under key,
+// from the extension of the key.
+func contentTypeOfKey(key string) string {
+	ext := path.Ext(key)
+	for contentType, e := range imageTypes {
+		if e == ext {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_CreateWithImage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	image := encodePNG(t, testImage(64, 32))
+
+	t.Run("success", func(t *testing.T) {
+		var stored []string
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Put", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = append(stored, args.String(1))
+		}).Return(nil)
+		var created *domain.Message
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*domain.Message)
+		}).Return(int64(1), nil)
+
+		s := NewMessageService(logger, messageRepo, WithAttachments(new(mocks.AttachmentRepo), blobStore))
+		message := &domain.Message{Author: "Arthur Morgan", Message: "Look!"}
+		id, err := s.CreateWithImage(ctx, message, bytes.NewReader(image))
+		if err != nil {
+			t.Fatalf("MessageService.CreateWithImage() error = %v", err)
+		}
+		if id != 1 {
+			t.Errorf("MessageService.CreateWithImage() = %d, want 1", id)
+		}
+		if message.Attachment != nil {
+			t.Error("MessageService.CreateWithImage() modified the message")
+		}
+
+		a := created.Attachment
+		if a == nil {
+			t.Fatal("message created without attachment")
+		}
+		if a.ContentType != "image/png" || a.Width != 64 || a.Height != 32 || a.Size == 0 {
+			t.Errorf("attachment = %+v, want a PNG of 64x32 pixels", a)
+		}
+		if len(stored) != 2 || stored[0] != a.Key || stored[1] != a.ThumbnailKey {
+			t.Errorf("stored %v, want %s and %s", stored, a.Key, a.ThumbnailKey)
+		}
+		for _, key := range stored {
+			if !attachmentKeyPattern.MatchString(key) {
+				t.Errorf("key %q does not match %s", key, attachmentKeyPattern)
+			}
+		}
+	})
+
+	t.Run("failed to create message", func(t *testing.T) {
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		blobStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Create", mock.Anything, mock.Anything).Return(int64(0), errors.New("boom"))
+
+		s := NewMessageService(logger, messageRepo, WithAttachments(new(mocks.AttachmentRepo), blobStore))
+		_, err := s.CreateWithImage(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Look!"}, bytes.NewReader(image))
+		if err == nil {
+			t.Fatal("MessageService.CreateWithImage() error = nil, want error")
+		}
+		blobStore.AssertNumberOfCalls(t, "Delete", 2)
+	})
+
+	t.Run("unsupported image", func(t *testing.T) {
+		blobStore := new(mocks.BlobStore)
+		messageRepo := new(mocks.MessageRepo)
+
+		s := NewMessageService(logger, messageRepo, WithAttachments(new(mocks.AttachmentRepo), blobStore))
+		_, err := s.CreateWithImage(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Look!"}, strings.NewReader("<svg/>"))
+		if !errors.Is(err, domain.ErrUnsupportedMediaType) {
+			t.Errorf("MessageService.CreateWithImage() error = %v, want %v", err, domain.ErrUnsupportedMediaType)
+		}
+		blobStore.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything)
+		messageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("attachments not enabled", func(t *testing.T) {
+		s := NewMessageService(logger, new(mocks.MessageRepo))
+		_, err := s.CreateWithImage(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Look!"}, bytes.NewReader(image))
+		if !errors.Is(err, domain.ErrUnsupportedMediaType) {
+			t.Errorf("MessageService.CreateWithImage() error = %v, want %v", err, domain.ErrUnsupportedMediaType)
+		}
+	})
+}
+
+func TestMessageService_Attachments(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	attachment := &domain.Attachment{ID: 1, MessageID: 1, Key: "a.png", ThumbnailKey: "a-thumb.png"}
+
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1, Message: "Look!"}, nil)
+	attachmentRepo := new(mocks.AttachmentRepo)
+	attachmentRepo.On("GetByMessages", mock.Anything, []int64{1}).Return(map[int64]*domain.Attachment{1: attachment}, nil)
+	attachmentRepo.On("DeleteOrphans", mock.Anything, int64(1)).Return([]*domain.Attachment{attachment}, nil)
+	attachmentRepo.On("DeleteOrphans", mock.Anything, int64(2)).Return(nil, errors.New("boom"))
+	blobStore := new(mocks.BlobStore)
+	blobStore.On("Delete", mock.Anything, "a.png").Return(nil)
+	blobStore.On("Delete", mock.Anything, "a-thumb.png").Return(errors.New("boom"))
+
+	s := NewMessageService(logger, messageRepo, WithAttachments(attachmentRepo, blobStore))
+
+	msg, err := s.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("MessageService.Get() error = %v", err)
+	}
+	if msg.Attachment != attachment {
+		t.Errorf("MessageService.Get() attachment = %+v, want %+v", msg.Attachment, attachment)
+	}
+
+	// Only deletions delete attachments.
+	if err := s.DeleteOrphanedAttachments(ctx, domain.MessageEvent{Type: domain.MessageUpdated, MessageID: 1}); err != nil {
+		t.Fatalf("MessageService.DeleteOrphanedAttachments() error = %v", err)
+	}
+	// Failing to delete the images does not fail the event, so it is not
+	// retried, while failing to delete the attachment does.
+	if err := s.DeleteOrphanedAttachments(ctx, domain.MessageEvent{Type: domain.MessageDeleted, MessageID: 1}); err != nil {
+		t.Fatalf("MessageService.DeleteOrphanedAttachments() error = %v", err)
+	}
+	if err := s.DeleteOrphanedAttachments(ctx, domain.MessageEvent{Type: domain.MessageDeleted, MessageID: 2}); err == nil {
+		t.Error("MessageService.DeleteOrphanedAttachments() error = nil, want the repository error")
+	}
+	attachmentRepo.AssertExpectations(t)
+	blobStore.AssertExpectations(t)
+}
+
+func TestAttachmentService_Open(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	key := "0123456789abcdef0123456789abcdef-thumb.png"
+
+	blobStore := new(mocks.BlobStore)
+	blobStore.On("Open", mock.Anything, key).Return(io.NopCloser(strings.NewReader("png")), nil)
+	s := NewAttachmentService(logger, blobStore)
+
+	r, contentType, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("AttachmentService.Open() error = %v", err)
+	}
+	r.Close()
+	if contentType != "image/png" {
+		t.Errorf("AttachmentService.Open() content type = %q, want image/png", contentType)
+	}
+
+	for _, key := range []string{"../message.db", "0123456789abcdef0123456789abcdef.svg", ".tmp-1234"} {
+		if _, _, err := s.Open(ctx, key); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("AttachmentService.Open(%q) error = %v, want %v", key, err, domain.ErrNotFound)
+		}
+	}
+	blobStore.AssertNumberOfCalls(t, "Open", 1)
+}
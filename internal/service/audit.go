@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"slices"
+)
+
+type AuditRepo interface {
+	Append(context.Context, ...*domain.AuditEntry) error
+	GetAll(context.Context, domain.AuditQuery) ([]*domain.AuditEntry, error)
+	Scan(context.Context, func(*domain.AuditEntry) error) error
+}
+
+// AuditService reads the audit log of the changes made to messages.
+type AuditService struct {
+	logger    *slog.Logger
+	auditRepo AuditRepo
+}
+
+// NewAuditService returns a new AuditService instance.
+func NewAuditService(logger *slog.Logger, auditRepo AuditRepo) *AuditService {
+	return &AuditService{
+		logger:    logger,
+		auditRepo: auditRepo,
+	}
+}
+
+// GetAll returns the audit entries matching q, oldest first.
+func (s *AuditService) GetAll(ctx context.Context, q domain.AuditQuery) ([]*domain.AuditEntry, error) {
+	if q.Action != "" && !slices.Contains(domain.AuditActions, q.Action) {
+		return nil, fmt.Errorf("failed to get audit entries: %w: unknown action %q", domain.ErrInvalidArgument, q.Action)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return nil, fmt.Errorf("failed to get audit entries: %w: since must be before until", domain.ErrInvalidArgument)
+	}
+	if q.Limit < 0 || q.Limit > domain.MaxAuditLimit {
+		return nil, fmt.Errorf("failed to get audit entries: %w: limit must be between 0 and %d", domain.ErrInvalidArgument, domain.MaxAuditLimit)
+	}
+	if q.Limit == 0 {
+		q.Limit = domain.DefaultAuditLimit
+	}
+
+	entries, err := s.auditRepo.GetAll(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Verify walks the hash chain of the audit log, and reports the first entry
+// that was altered or follows a removed one.
+func (s *AuditService) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	v := &domain.AuditVerification{}
+	prev := ""
+	err := s.auditRepo.Scan(ctx, func(e *domain.AuditEntry) error {
+		v.Entries++
+		if v.BrokenAt == 0 && (e.PrevHash != prev || e.ComputeHash() != e.Hash) {
+			v.BrokenAt = e.ID
+		}
+		prev = e.Hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify audit log: %w", err)
+	}
+
+	if v.BrokenAt != 0 {
+		s.logger.Warn("audit log hash chain is broken", slog.Int64("entry_id", v.BrokenAt))
+	}
+
+	return v, nil
+}
+
+// audit records changes to messages in the audit log, unless the service
+// has no audit repository. It must be called within the unit of work making
+// the changes, so they are recorded if and only if they are committed.
+func (s *MessageService) audit(ctx context.Context, entries ...*domain.AuditEntry) error {
+	if s.auditRepo == nil || len(entries) == 0 {
+		return nil
+	}
+
+	actor, _ := domain.ActorFromContext(ctx)
+	requestID, _ := domain.RequestIDFromContext(ctx)
+	var guestbookID int64
+	if g, ok := domain.GuestbookFromContext(ctx); ok {
+		guestbookID = g.ID
+	}
+	for _, e := range entries {
+		e.GuestbookID = guestbookID
+		e.Actor = actor
+		e.RequestID = requestID
+	}
+
+	if err := s.auditRepo.Append(ctx, entries...); err != nil {
+		return fmt.Errorf("failed to audit: %w", err)
+	}
+
+	return nil
+}
+
+// newAuditEntry returns the audit entry of a change to the message id from
+// before to after, either of which is nil for creations and deletions.
+func newAuditEntry(action domain.AuditAction, id int64, before, after *domain.Message) *domain.AuditEntry {
+	return &domain.AuditEntry{
+		Action:    action,
+		MessageID: id,
+		Before:    domain.NewMessageSnapshot(before),
+		After:     domain.NewMessageSnapshot(after),
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditService_GetAll(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("default limit", func(t *testing.T) {
+		mockRepo := new(mocks.AuditRepo)
+		mockRepo.On("GetAll", mock.Anything, domain.AuditQuery{Actor: "admin", Limit: domain.DefaultAuditLimit}).
+			Return([]*domain.AuditEntry{{ID: 1}}, nil)
+		s := NewAuditService(logger, mockRepo)
+
+		got, err := s.GetAll(context.Background(), domain.AuditQuery{Actor: "admin"})
+		if err != nil || len(got) != 1 {
+			t.Errorf("AuditService.GetAll() = %v, %v, want 1 entry", got, err)
+		}
+	})
+
+	for name, q := range map[string]domain.AuditQuery{
+		"unknown action": {Action: "purge"},
+		"empty range":    {Since: at, Until: at},
+		"limit too high": {Limit: domain.MaxAuditLimit + 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := NewAuditService(logger, new(mocks.AuditRepo))
+			if _, err := s.GetAll(context.Background(), q); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("AuditService.GetAll() error = %v, want %v", err, domain.ErrInvalidArgument)
+			}
+		})
+	}
+}
+
+func TestAuditService_Verify(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := func() []*domain.AuditEntry {
+		at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+		entries := []*domain.AuditEntry{
+			{ID: 1, Actor: "c:arthur", Action: domain.AuditActionCreate, MessageID: 1, After: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+			{ID: 2, Actor: "admin", Action: domain.AuditActionUpdate, MessageID: 1, Before: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, Dutch!"}, After: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, John!"}},
+			{ID: 3, Actor: "admin", Action: domain.AuditActionDelete, MessageID: 1, Before: &domain.MessageSnapshot{Author: "Arthur Morgan", Message: "Hey, John!"}},
+		}
+		prev := ""
+		for _, e := range entries {
+			e.CreatedAt = at
+			e.PrevHash = prev
+			e.Hash = e.ComputeHash()
+			prev = e.Hash
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]*domain.AuditEntry) []*domain.AuditEntry
+		want   domain.AuditVerification
+	}{
+		{
+			name:   "intact",
+			tamper: func(es []*domain.AuditEntry) []*domain.AuditEntry { return es },
+			want:   domain.AuditVerification{Entries: 3},
+		},
+		{
+			name: "altered",
+			tamper: func(es []*domain.AuditEntry) []*domain.AuditEntry {
+				es[1].Actor = "c:micah"
+				return es
+			},
+			want: domain.AuditVerification{Entries: 3, BrokenAt: 2},
+		},
+		{
+			name: "removed",
+			tamper: func(es []*domain.AuditEntry) []*domain.AuditEntry {
+				return append(es[:1], es[2:]...)
+			},
+			want: domain.AuditVerification{Entries: 2, BrokenAt: 3},
+		},
+		{
+			name: "rehashed",
+			tamper: func(es []*domain.AuditEntry) []*domain.AuditEntry {
+				es[0].After.Message = "Hey, Micah!"
+				es[0].Hash = es[0].ComputeHash()
+				return es
+			},
+			want: domain.AuditVerification{Entries: 3, BrokenAt: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(chain())
+			mockRepo := new(mocks.AuditRepo)
+			mockRepo.On("Scan", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					fn := args.Get(1).(func(*domain.AuditEntry) error)
+					for _, e := range entries {
+						_ = fn(e)
+					}
+				}).
+				Return(nil)
+			s := NewAuditService(logger, mockRepo)
+
+			got, err := s.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("AuditService.Verify() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("AuditService.Verify() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	t.Run("failed to verify audit log", func(t *testing.T) {
+		mockRepo := new(mocks.AuditRepo)
+		mockRepo.On("Scan", mock.Anything, mock.Anything).Return(errors.New("db down"))
+		s := NewAuditService(logger, mockRepo)
+
+		if _, err := s.Verify(context.Background()); err == nil {
+			t.Errorf("AuditService.Verify() error = nil, want error")
+		}
+	})
+}
+
+func TestMessageService_Audit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := &domain.Guestbook{ID: 2, Slug: "wedding", Settings: domain.GuestbookSettings{AllowPosting: true}}
+	ctx := domain.ContextWithRequestID(domain.ContextWithReactor(domain.ContextWithGuestbook(context.Background(), g), "c:arthur"), "req-1")
+	parentID := int64(1)
+	stored := &domain.Message{ID: 2, ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"}
+
+	audited := func(auditRepo *mocks.AuditRepo) *[]*domain.AuditEntry {
+		var entries []*domain.AuditEntry
+		auditRepo.On("Append", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				entries = append(entries, args.Get(1).([]*domain.AuditEntry)...)
+			}).
+			Return(nil)
+		return &entries
+	}
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Create", mock.Anything, mock.Anything).Return(int64(2), nil)
+		messageRepo.On("GetForUpdate", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+		messageRepo.On("Get", mock.Anything, int64(2)).Return(stored, nil)
+		messageRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		messageRepo.On("Delete", mock.Anything, int64(2)).Return(nil)
+		auditRepo := new(mocks.AuditRepo)
+		entries := audited(auditRepo)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo))
+
+		if _, err := s.Create(ctx, &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"}); err != nil {
+			t.Fatalf("MessageService.Create() error = %v", err)
+		}
+		if err := s.Update(domain.ContextWithActor(ctx, "admin"), &domain.Message{ID: 2, Author: "Arthur Morgan", Message: "<b>Hey</b>, John!"}); err != nil {
+			t.Fatalf("MessageService.Update() error = %v", err)
+		}
+		if err := s.Delete(ctx, 2); err != nil {
+			t.Fatalf("MessageService.Delete() error = %v", err)
+		}
+
+		want := []domain.AuditEntry{
+			{GuestbookID: 2, Actor: "c:arthur", RequestID: "req-1", Action: domain.AuditActionCreate, MessageID: 2,
+				After: &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+			{GuestbookID: 2, Actor: "admin", RequestID: "req-1", Action: domain.AuditActionUpdate, MessageID: 2,
+				Before: &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+				After:  &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, John!"}},
+			{GuestbookID: 2, Actor: "c:arthur", RequestID: "req-1", Action: domain.AuditActionDelete, MessageID: 2,
+				Before: &domain.MessageSnapshot{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+		}
+		if len(*entries) != len(want) {
+			t.Fatalf("audited %d entries, want %d", len(*entries), len(want))
+		}
+		for i, e := range *entries {
+			if !reflect.DeepEqual(*e, want[i]) {
+				t.Errorf("audit entry %d = %+v, want %+v", i, e, want[i])
+			}
+		}
+	})
+
+	t.Run("missing message", func(t *testing.T) {
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Get", mock.Anything, int64(9)).Return(nil, domain.ErrNotFound)
+		auditRepo := new(mocks.AuditRepo)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo))
+
+		if err := s.Delete(ctx, 9); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("MessageService.Delete() error = %v, want %v", err, domain.ErrNotFound)
+		}
+		messageRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		auditRepo.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed to audit", func(t *testing.T) {
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Create", mock.Anything, mock.Anything).Return(int64(2), nil)
+		auditRepo := new(mocks.AuditRepo)
+		auditRepo.On("Append", mock.Anything, mock.Anything).Return(errors.New("db down"))
+		eventNotifier := new(mocks.EventNotifier)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo), WithEventNotifier(eventNotifier))
+
+		if _, err := s.Create(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}); err == nil {
+			t.Fatalf("MessageService.Create() error = nil, want error")
+		}
+		eventNotifier.AssertNotCalled(t, "Notify")
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Get", mock.Anything, int64(2)).Return(stored, nil)
+		messageRepo.On("Get", mock.Anything, int64(9)).Return(nil, domain.ErrNotFound)
+		messageRepo.On("Batch", mock.Anything, mock.Anything).Return([]domain.MessageOperationResult{
+			{ID: 2}, {ID: 2}, {Err: domain.ErrNotFound}, {ID: 3},
+		}, nil)
+		auditRepo := new(mocks.AuditRepo)
+		entries := audited(auditRepo)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo))
+
+		_, err := s.Batch(ctx, domain.MessageBatch{BestEffort: true, Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 2, Author: "Arthur Morgan", Message: "Hey, John!"}},
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 2}},
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 9}},
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Sadie Adler", Message: "Hello"}},
+		}})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+
+		got := *entries
+		if len(got) != 3 || got[0].Action != domain.AuditActionUpdate || got[1].Action != domain.AuditActionDelete || got[2].Action != domain.AuditActionCreate {
+			t.Fatalf("audited %+v, want the update, first deletion and creation", got)
+		}
+		// The deletion follows the update.
+		if got[1].Before.Message != "Hey, John!" || got[2].MessageID != 3 || got[2].After.Author != "Sadie Adler" {
+			t.Errorf("audited %+v, %+v, want the updated message deleted and the new one created", got[1], got[2])
+		}
+	})
+
+	t.Run("batch changing a message it created", func(t *testing.T) {
+		parentID := int64(1)
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Get", mock.Anything, int64(7)).Return(nil, domain.ErrNotFound)
+		messageRepo.On("Batch", mock.Anything, mock.Anything).Return([]domain.MessageOperationResult{
+			{ID: 7}, {ID: 7}, {ID: 7},
+		}, nil)
+		auditRepo := new(mocks.AuditRepo)
+		entries := audited(auditRepo)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo))
+
+		_, err := s.Batch(ctx, domain.MessageBatch{Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{ParentID: &parentID, Author: "Sadie Adler", Message: "Hello"}},
+			{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 7, Author: "Sadie Adler", Message: "Hello, Dutch"}},
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 7}},
+		}})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+
+		got := *entries
+		if len(got) != 3 || got[0].Action != domain.AuditActionCreate || got[1].Action != domain.AuditActionUpdate || got[2].Action != domain.AuditActionDelete {
+			t.Fatalf("audited %+v, want the creation, update and deletion", got)
+		}
+		// The update and deletion follow the creation.
+		if got[1].Before.Message != "Hello" || got[1].After.ParentID == nil || *got[1].After.ParentID != parentID {
+			t.Errorf("audited update %+v, want the created reply updated", got[1])
+		}
+		if got[2].Before.Message != "Hello, Dutch" {
+			t.Errorf("audited deletion %+v, want the updated message deleted", got[2])
+		}
+	})
+
+	t.Run("batch deleting a message it created", func(t *testing.T) {
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("Get", mock.Anything, int64(7)).Return(nil, domain.ErrNotFound)
+		messageRepo.On("Batch", mock.Anything, mock.Anything).Return([]domain.MessageOperationResult{{ID: 7}, {ID: 7}}, nil)
+		auditRepo := new(mocks.AuditRepo)
+		entries := audited(auditRepo)
+		s := NewMessageService(logger, messageRepo, WithAuditRepo(auditRepo))
+
+		_, err := s.Batch(ctx, domain.MessageBatch{Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Sadie Adler", Message: "Hello"}},
+			{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 7}},
+		}})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+
+		got := *entries
+		if len(got) != 2 || got[1].Action != domain.AuditActionDelete || got[1].Before == nil || got[1].Before.Message != "Hello" {
+			t.Errorf("audited %+v, want the created message deleted", got)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"regexp"
+)
+
+// AvatarGenerator generates the avatar of a hash as a PNG image.
+type AvatarGenerator interface {
+	PNG(hash []byte, size int) ([]byte, error)
+}
+
+// avatarIDPattern matches the IDs of avatars: hex encoded HMAC-SHA256, see
+// domain.NewAvatarID.
+var avatarIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// AvatarService generates the avatars of message authors. Avatars are
+// generated from their ID alone, so they do not need any network access.
+type AvatarService struct {
+	logger    *slog.Logger
+	generator AvatarGenerator
+}
+
+// NewAvatarService returns a new AvatarService instance.
+func NewAvatarService(logger *slog.Logger, generator AvatarGenerator) *AvatarService {
+	return &AvatarService{
+		logger:    logger,
+		generator: generator,
+	}
+}
+
+// Get returns the avatar identified by id as a PNG image of size×size
+// pixels, or of domain.DefaultAvatarSize pixels if size is 0.
+func (s *AvatarService) Get(ctx context.Context, id string, size int) ([]byte, error) {
+	if !avatarIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("failed to get avatar: %w: invalid ID %q", domain.ErrNotFound, id)
+	}
+	if size == 0 {
+		size = domain.DefaultAvatarSize
+	}
+	if size < domain.MinAvatarSize || size > domain.MaxAvatarSize {
+		return nil, fmt.Errorf("failed to get avatar: %w: size %d is not between %d and %d", domain.ErrInvalidArgument, size, domain.MinAvatarSize, domain.MaxAvatarSize)
+	}
+
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar: %w", err)
+	}
+	png, err := s.generator.PNG(b, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar: %w", err)
+	}
+
+	return png, nil
+}
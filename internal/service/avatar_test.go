@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAvatarService_Get(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	id := domain.NewAvatarID([]byte("key"), &domain.Message{EmailHash: domain.HashEmail("arthur@example.com")})
+
+	tests := []struct {
+		name     string
+		id       string
+		size     int
+		wantSize int
+		wantErr  error
+	}{
+		{name: "default size", id: id, wantSize: domain.DefaultAvatarSize},
+		{name: "requested size", id: id, size: 200, wantSize: 200},
+		{name: "too small", id: id, size: 8, wantErr: domain.ErrInvalidArgument},
+		{name: "too large", id: id, size: 4096, wantErr: domain.ErrInvalidArgument},
+		{name: "uppercase ID", id: strings.ToUpper(id), wantErr: domain.ErrNotFound},
+		{name: "short ID", id: id[:40], wantErr: domain.ErrNotFound},
+		{name: "MD5 hash", id: strings.Repeat("0a", 16), wantErr: domain.ErrNotFound},
+		{name: "email", id: "arthur@example.com", wantErr: domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := new(mocks.AvatarGenerator)
+			generator.On("PNG", mock.Anything, tt.wantSize).Return([]byte("png"), nil)
+			s := NewAvatarService(logger, generator)
+
+			got, err := s.Get(context.Background(), tt.id, tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AvatarService.Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				generator.AssertNotCalled(t, "PNG", mock.Anything, mock.Anything)
+				return
+			}
+			if string(got) != "png" {
+				t.Errorf("AvatarService.Get() = %q, want png", got)
+			}
+		})
+	}
+}
+
+func TestMessageService_AvatarIDs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	emailHash := domain.HashEmail("arthur@example.com")
+
+	messageRepo := func() MessageRepo {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([]*domain.Message{
+			{ID: 1, Author: "Arthur Morgan", EmailHash: emailHash},
+			{ID: 2, Author: "Arthur Morgan"},
+		}, nil)
+		return mockRepo
+	}
+
+	msgs, err := NewMessageService(logger, messageRepo(), WithAvatarKey([]byte("key"))).GetAll(context.Background(), domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("MessageService.GetAll() error = %v", err)
+	}
+	if want := domain.NewAvatarID([]byte("key"), msgs[0]); msgs[0].AvatarID != want {
+		t.Errorf("AvatarID = %q, want %q", msgs[0].AvatarID, want)
+	}
+	if msgs[0].AvatarID == emailHash || msgs[0].AvatarID == msgs[1].AvatarID {
+		t.Errorf("AvatarID = %q, want a keyed hash of the email", msgs[0].AvatarID)
+	}
+	if other := domain.NewAvatarID([]byte("other key"), msgs[0]); msgs[0].AvatarID == other {
+		t.Error("AvatarID does not depend on the key")
+	}
+
+	msgs, err = NewMessageService(logger, messageRepo()).GetAll(context.Background(), domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("MessageService.GetAll() error = %v", err)
+	}
+	if msgs[0].AvatarID != "" {
+		t.Errorf("AvatarID without key = %q, want empty", msgs[0].AvatarID)
+	}
+}
+
+func TestStreamService_AvatarIDs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ch := make(chan domain.MessageEvent, 1)
+	shared := &domain.Message{ID: 1, Author: "Arthur Morgan"}
+	ch <- domain.MessageEvent{ID: 1, Type: domain.MessageCreated, MessageID: 1, Message: shared}
+	close(ch)
+	eventSubscriber := new(mocks.EventSubscriber)
+	eventSubscriber.On("Subscribe", mock.Anything, int64(2), int64(0)).Return((<-chan domain.MessageEvent)(ch), true)
+
+	s := NewStreamService(logger, eventSubscriber, WithStreamAvatarKey([]byte("key")))
+
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 2, Slug: "wedding"})
+	events, _, err := s.Subscribe(ctx, 0)
+	if err != nil {
+		t.Fatalf("StreamService.Subscribe() error = %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if want := domain.NewAvatarID([]byte("key"), shared); ev.Message.AvatarID != want {
+			t.Errorf("event message AvatarID = %q, want %q", ev.Message.AvatarID, want)
+		}
+		if ev.Message.HTML != "" {
+			t.Errorf("event message HTML = %q without renderer", ev.Message.HTML)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event was streamed")
+	}
+	if shared.AvatarID != "" {
+		t.Error("the shared message was modified")
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+)
+
+// Batch applies a batch of operations on messages with the rules of Create,
+// Update and Delete, and returns their results in order. Operations failing
+// validation are not applied, and abort the other operations unless the
+// batch is best effort. The error is only set when the batch as a whole is
+// invalid or could not be applied.
+func (s *MessageService) Batch(ctx context.Context, batch domain.MessageBatch) ([]domain.MessageOperationResult, error) {
+	if n := len(batch.Operations); n == 0 || n > domain.MaxBatchOperations {
+		return nil, fmt.Errorf("failed to apply batch: %w: %d operations, want 1 to %d", domain.ErrInvalidArgument, n, domain.MaxBatchOperations)
+	}
+
+	results := make([]domain.MessageOperationResult, len(batch.Operations))
+	valid := domain.MessageBatch{BestEffort: batch.BestEffort}
+	var positions []int
+	for i, op := range batch.Operations {
+		m, err := s.validateOperation(ctx, op)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to %s message: %w", op.Type, err)
+			continue
+		}
+		valid.Operations = append(valid.Operations, domain.MessageOperation{Type: op.Type, Message: m})
+		positions = append(positions, i)
+	}
+
+	if len(positions) < len(results) && !batch.BestEffort {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = domain.ErrAborted
+			}
+		}
+		return results, nil
+	}
+	if len(positions) == 0 {
+		return results, nil
+	}
+
+	var applied []domain.MessageOperationResult
+	err := s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+		befores, err := s.auditedOperations(ctx, valid.Operations)
+		if err != nil {
+			return err
+		}
+
+		applied, err = s.messageRepo.Batch(ctx, valid)
+		if err != nil {
+			return err
+		}
+
+		if s.auditRepo == nil {
+			return nil
+		}
+		var entries []*domain.AuditEntry
+		// Messages changed earlier in the batch, as they were left.
+		changed := make(map[int64]*domain.Message)
+		for j, res := range applied {
+			if res.Err != nil {
+				continue
+			}
+			op := valid.Operations[j]
+			before, ok := changed[res.ID]
+			if !ok {
+				before = befores[j]
+			}
+			switch op.Type {
+			case domain.MessageOperationCreate:
+				created := *op.Message
+				created.ID = res.ID
+				changed[res.ID] = &created
+				entries = append(entries, newAuditEntry(domain.AuditActionCreate, res.ID, nil, &created))
+			case domain.MessageOperationUpdate:
+				after := *op.Message
+				if before != nil {
+					after.ParentID = before.ParentID
+				}
+				changed[res.ID] = &after
+				entries = append(entries, newAuditEntry(domain.AuditActionUpdate, res.ID, before, &after))
+			case domain.MessageOperationDelete:
+				entries = append(entries, newAuditEntry(domain.AuditActionDelete, res.ID, before, nil))
+			}
+		}
+		return s.audit(ctx, entries...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply batch: %w", err)
+	}
+	var changed bool
+	for j, res := range applied {
+		results[positions[j]] = res
+		changed = changed || res.Err == nil
+	}
+
+	if changed {
+		s.notify()
+	}
+
+	return results, nil
+}
+
+// validateOperation validates an operation of a batch, and returns the
+// message to apply it with.
+func (s *MessageService) validateOperation(ctx context.Context, op domain.MessageOperation) (*domain.Message, error) {
+	if op.Message == nil {
+		return nil, fmt.Errorf("%w: no message", domain.ErrInvalidArgument)
+	}
+
+	switch op.Type {
+	case domain.MessageOperationCreate:
+		if g, ok := domain.GuestbookFromContext(ctx); ok && !g.Settings.AllowPosting {
+			return nil, fmt.Errorf("guestbook %q does not allow posting: %w", g.Slug, domain.ErrForbidden)
+		}
+		return s.sanitize(op.Message)
+	case domain.MessageOperationUpdate:
+		return s.sanitize(op.Message)
+	case domain.MessageOperationDelete:
+		return op.Message, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", domain.ErrInvalidArgument, op.Type)
+	}
+}
+
+// auditedOperations returns the messages the update and delete operations of
+// a batch are about to change, for the audit log, or nil if changes are not
+// audited. Operations on missing messages, which are going to fail, have no
+// message.
+func (s *MessageService) auditedOperations(ctx context.Context, ops []domain.MessageOperation) ([]*domain.Message, error) {
+	if s.auditRepo == nil {
+		return nil, nil
+	}
+
+	befores := make([]*domain.Message, len(ops))
+	for i, op := range ops {
+		if op.Type == domain.MessageOperationCreate {
+			continue
+		}
+		m, err := s.messageRepo.Get(ctx, op.Message.ID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		befores[i] = m
+	}
+
+	return befores, nil
+}
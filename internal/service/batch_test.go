@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_Batch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ops := []domain.MessageOperation{
+		{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "<b></b>", Message: "Spam"}},
+		{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 1}},
+		{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 2, Author: "Arthur Morgan", Message: "<i>Hey</i>, Dutch!"}},
+	}
+
+	t.Run("best effort", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("Batch", mock.Anything, domain.MessageBatch{
+			BestEffort: true,
+			Operations: []domain.MessageOperation{
+				{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: 1}},
+				{Type: domain.MessageOperationUpdate, Message: &domain.Message{ID: 2, Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+			},
+		}).Return([]domain.MessageOperationResult{{Err: domain.ErrNotFound}, {ID: 2}}, nil)
+		eventNotifier := new(mocks.EventNotifier)
+		eventNotifier.On("Notify").Return()
+		s := NewMessageService(logger, mockRepo, WithEventNotifier(eventNotifier))
+
+		got, err := s.Batch(context.Background(), domain.MessageBatch{Operations: ops, BestEffort: true})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+		if len(got) != 3 || !errors.Is(got[0].Err, domain.ErrInvalidArgument) || !errors.Is(got[1].Err, domain.ErrNotFound) || got[2] != (domain.MessageOperationResult{ID: 2}) {
+			t.Errorf("MessageService.Batch() = %+v, want the creation invalid, the deletion not found and the update applied", got)
+		}
+		eventNotifier.AssertNumberOfCalls(t, "Notify", 1)
+	})
+
+	t.Run("invalid operation aborts the batch", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		s := NewMessageService(logger, mockRepo)
+
+		got, err := s.Batch(context.Background(), domain.MessageBatch{Operations: ops})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+		if len(got) != 3 || !errors.Is(got[0].Err, domain.ErrInvalidArgument) || !errors.Is(got[1].Err, domain.ErrAborted) || !errors.Is(got[2].Err, domain.ErrAborted) {
+			t.Errorf("MessageService.Batch() = %+v, want the batch aborted by the creation", got)
+		}
+		mockRepo.AssertNotCalled(t, "Batch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("aborted batch notifies nothing", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("Batch", mock.Anything, mock.Anything).
+			Return([]domain.MessageOperationResult{{Err: domain.ErrNotFound}, {Err: domain.ErrAborted}}, nil)
+		eventNotifier := new(mocks.EventNotifier)
+		s := NewMessageService(logger, mockRepo, WithEventNotifier(eventNotifier))
+
+		got, err := s.Batch(context.Background(), domain.MessageBatch{Operations: ops[1:]})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+		if len(got) != 2 || !errors.Is(got[1].Err, domain.ErrAborted) {
+			t.Errorf("MessageService.Batch() = %+v, want the batch aborted", got)
+		}
+		eventNotifier.AssertNotCalled(t, "Notify")
+	})
+
+	t.Run("posting disabled", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		s := NewMessageService(logger, mockRepo)
+		ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{Slug: "wedding"})
+
+		got, err := s.Batch(ctx, domain.MessageBatch{Operations: []domain.MessageOperation{
+			{Type: domain.MessageOperationCreate, Message: &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}},
+		}})
+		if err != nil {
+			t.Fatalf("MessageService.Batch() error = %v", err)
+		}
+		if len(got) != 1 || !errors.Is(got[0].Err, domain.ErrForbidden) {
+			t.Errorf("MessageService.Batch() = %+v, want the creation forbidden", got)
+		}
+	})
+
+	t.Run("invalid batch", func(t *testing.T) {
+		s := NewMessageService(logger, new(mocks.MessageRepo))
+
+		for _, n := range []int{0, domain.MaxBatchOperations + 1} {
+			batch := domain.MessageBatch{Operations: make([]domain.MessageOperation, n)}
+			if _, err := s.Batch(context.Background(), batch); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("MessageService.Batch() with %d operations error = %v, want %v", n, err, domain.ErrInvalidArgument)
+			}
+		}
+	})
+
+	t.Run("failed to apply batch", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("Batch", mock.Anything, mock.Anything).Return(nil, domain.ErrNoGuestbook)
+		s := NewMessageService(logger, mockRepo)
+
+		if _, err := s.Batch(context.Background(), domain.MessageBatch{Operations: ops[1:]}); !errors.Is(err, domain.ErrNoGuestbook) {
+			t.Errorf("MessageService.Batch() error = %v, want %v", err, domain.ErrNoGuestbook)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// corsOriginCacheTTL is how long the allowed origins are cached.
+const corsOriginCacheTTL = time.Minute
+
+type CORSOriginRepo interface {
+	GetAll(context.Context) ([]*domain.CORSOrigin, error)
+	Create(context.Context, *domain.CORSOrigin) (int64, error)
+	Delete(context.Context, int64) error
+}
+
+// CORSOriginService manages the origins allowed to call the API from the
+// browser.
+type CORSOriginService struct {
+	logger         *slog.Logger
+	corsOriginRepo CORSOriginRepo
+
+	mu        sync.Mutex
+	origins   map[string]bool
+	expiresAt time.Time
+}
+
+// NewCORSOriginService returns a new CORSOriginService instance.
+func NewCORSOriginService(logger *slog.Logger, corsOriginRepo CORSOriginRepo) *CORSOriginService {
+	return &CORSOriginService{
+		logger:         logger,
+		corsOriginRepo: corsOriginRepo,
+	}
+}
+
+// GetAll returns all allowed origins.
+func (s *CORSOriginService) GetAll(ctx context.Context) ([]*domain.CORSOrigin, error) {
+	origins, err := s.corsOriginRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all cors origins: %w", err)
+	}
+
+	return origins, nil
+}
+
+// Create normalizes and allows an origin.
+func (s *CORSOriginService) Create(ctx context.Context, origin *domain.CORSOrigin) (int64, error) {
+	normalized, err := NormalizeOrigin(origin.Origin)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create cors origin: %w", err)
+	}
+
+	allowed, err := s.IsAllowed(ctx, normalized)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create cors origin: %w", err)
+	}
+	if allowed {
+		return 0, fmt.Errorf("failed to create cors origin: %w", domain.ErrAlreadyExists)
+	}
+
+	id, err := s.corsOriginRepo.Create(ctx, &domain.CORSOrigin{Origin: normalized})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create cors origin: %w", err)
+	}
+	s.invalidate()
+
+	return id, nil
+}
+
+// Delete removes an allowed origin.
+func (s *CORSOriginService) Delete(ctx context.Context, id int64) error {
+	if err := s.corsOriginRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete cors origin: %w", err)
+	}
+	s.invalidate()
+
+	return nil
+}
+
+// IsAllowed reports whether the origin is allowed. The allowed origins are
+// cached for a short time, as this is called for every cross-origin request.
+func (s *CORSOriginService) IsAllowed(ctx context.Context, origin string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.origins == nil || time.Now().After(s.expiresAt) {
+		all, err := s.corsOriginRepo.GetAll(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to load cors origins: %w", err)
+		}
+		s.origins = make(map[string]bool, len(all))
+		for _, o := range all {
+			s.origins[o.Origin] = true
+		}
+		s.expiresAt = time.Now().Add(corsOriginCacheTTL)
+	}
+
+	return s.origins[origin], nil
+}
+
+func (s *CORSOriginService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.origins = nil
+}
+
+// NormalizeOrigin validates an origin and returns it in the serialized form
+// browsers send in the Origin header: lower-case scheme and host, no path.
+func NormalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid origin: %s", domain.ErrInvalidArgument, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: origin scheme must be http or https", domain.ErrInvalidArgument)
+	}
+	if u.Host == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%w: origin must only consist of scheme, host and port", domain.ErrInvalidArgument)
+	}
+
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCORSOriginService_Create(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		corsOriginRepo func() *mocks.CORSOriginRepo
+		origin         string
+		want           int64
+		wantErr        bool
+		wantErrIs      error
+	}{
+		{
+			name: "success",
+			corsOriginRepo: func() *mocks.CORSOriginRepo {
+				mockRepo := new(mocks.CORSOriginRepo)
+				mockRepo.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{}, nil)
+				mockRepo.On("Create", mock.Anything, &domain.CORSOrigin{Origin: "https://partner.example:8443"}).Return(int64(1), nil)
+				return mockRepo
+			},
+			origin: " HTTPS://Partner.Example:8443/ ",
+			want:   1,
+		},
+		{
+			name: "invalid origin",
+			corsOriginRepo: func() *mocks.CORSOriginRepo {
+				return new(mocks.CORSOriginRepo)
+			},
+			origin:    "https://partner.example/path",
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "origin already allowed",
+			corsOriginRepo: func() *mocks.CORSOriginRepo {
+				mockRepo := new(mocks.CORSOriginRepo)
+				mockRepo.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{
+					{ID: 1, Origin: "https://partner.example"},
+				}, nil)
+				return mockRepo
+			},
+			origin:    "https://partner.example",
+			wantErr:   true,
+			wantErrIs: domain.ErrAlreadyExists,
+		},
+		{
+			name: "failed to create cors origin",
+			corsOriginRepo: func() *mocks.CORSOriginRepo {
+				mockRepo := new(mocks.CORSOriginRepo)
+				mockRepo.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{}, nil)
+				mockRepo.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create cors origin"))
+				return mockRepo
+			},
+			origin:  "https://partner.example",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.corsOriginRepo()
+			s := NewCORSOriginService(logger, repo)
+			got, err := s.Create(context.Background(), &domain.CORSOrigin{Origin: tt.origin})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CORSOriginService.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("CORSOriginService.Create() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if got != tt.want {
+				t.Errorf("CORSOriginService.Create() = %v, want %v", got, tt.want)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCORSOriginService_IsAllowed(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockRepo := new(mocks.CORSOriginRepo)
+	mockRepo.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{
+		{ID: 1, Origin: "https://partner.example"},
+	}, nil).Once()
+	mockRepo.On("Delete", mock.Anything, int64(1)).Return(nil)
+	mockRepo.On("GetAll", mock.Anything).Return([]*domain.CORSOrigin{}, nil).Once()
+
+	s := NewCORSOriginService(logger, mockRepo)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		{"https://partner.example", true},
+		{"https://evil.example", false},
+	} {
+		got, err := s.IsAllowed(ctx, tc.origin)
+		if err != nil {
+			t.Fatalf("CORSOriginService.IsAllowed() error = %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("CORSOriginService.IsAllowed(%q) = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+	// Both lookups are served by a single query.
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 1)
+
+	// Deleting an origin invalidates the cache.
+	if err := s.Delete(ctx, 1); err != nil {
+		t.Fatalf("CORSOriginService.Delete() error = %v", err)
+	}
+	got, err := s.IsAllowed(ctx, "https://partner.example")
+	if err != nil {
+		t.Fatalf("CORSOriginService.IsAllowed() error = %v", err)
+	}
+	if got {
+		t.Errorf("CORSOriginService.IsAllowed() = true after delete")
+	}
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 2)
+}
+
+func TestCORSOriginService_IsAllowed_Error(t *testing.T) {
+	mockRepo := new(mocks.CORSOriginRepo)
+	mockRepo.On("GetAll", mock.Anything).Return(nil, fmt.Errorf("failed to get all cors origins"))
+
+	s := NewCORSOriginService(slog.New(slog.NewTextHandler(io.Discard, nil)), mockRepo)
+	if _, err := s.IsAllowed(context.Background(), "https://partner.example"); err == nil {
+		t.Errorf("CORSOriginService.IsAllowed() error = nil, want error")
+	}
+}
+
+func TestNormalizeOrigin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		want    string
+		wantErr bool
+	}{
+		{origin: "https://partner.example", want: "https://partner.example"},
+		{origin: "http://LOCALHOST:3000/", want: "http://localhost:3000"},
+		{origin: "ftp://partner.example", wantErr: true},
+		{origin: "javascript:alert(1)", wantErr: true},
+		{origin: "https://user@partner.example", wantErr: true},
+		{origin: "https://partner.example?x=1", wantErr: true},
+		{origin: "partner.example", wantErr: true},
+		{origin: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			got, err := NormalizeOrigin(tt.origin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeOrigin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeOrigin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+)
+
+// EmbedService provides the configuration of embedded guestbook widgets.
+type EmbedService struct {
+	logger        *slog.Logger
+	guestbookRepo GuestbookRepo
+}
+
+// NewEmbedService returns a new EmbedService instance.
+func NewEmbedService(logger *slog.Logger, guestbookRepo GuestbookRepo) *EmbedService {
+	return &EmbedService{
+		logger:        logger,
+		guestbookRepo: guestbookRepo,
+	}
+}
+
+// GetConfig returns the widget configuration of a guestbook.
+func (s *EmbedService) GetConfig(ctx context.Context, slug string) (*domain.EmbedConfig, error) {
+	g, err := s.guestbookRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embed config: %w", err)
+	}
+
+	return &domain.EmbedConfig{
+		GuestbookSlug: g.Slug,
+		Title:         g.Title,
+		AllowPosting:  g.Settings.AllowPosting,
+	}, nil
+}
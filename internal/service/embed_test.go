@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEmbedService_GetConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      func() GuestbookRepo
+		slug      string
+		want      *domain.EmbedConfig
+		wantErrIs error
+	}{
+		{
+			name: "success",
+			repo: func() GuestbookRepo {
+				repo := new(mocks.GuestbookRepo)
+				repo.On("GetBySlug", mock.Anything, "wedding").Return(&domain.Guestbook{
+					ID:       2,
+					Slug:     "wedding",
+					Title:    "Wedding guestbook",
+					Settings: domain.GuestbookSettings{AllowPosting: false},
+				}, nil)
+				return repo
+			},
+			slug: "wedding",
+			want: &domain.EmbedConfig{
+				GuestbookSlug: "wedding",
+				Title:         "Wedding guestbook",
+				AllowPosting:  false,
+			},
+		},
+		{
+			name: "unknown guestbook",
+			repo: func() GuestbookRepo {
+				repo := new(mocks.GuestbookRepo)
+				repo.On("GetBySlug", mock.Anything, "unknown").Return(nil, domain.ErrNotFound)
+				return repo
+			},
+			slug:      "unknown",
+			wantErrIs: domain.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEmbedService(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.repo())
+			got, err := s.GetConfig(context.Background(), tt.slug)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("EmbedService.GetConfig() error = %v, want %v", err, tt.wantErrIs)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EmbedService.GetConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
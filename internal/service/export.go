@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+)
+
+// Export calls fn with every message of the guestbook, in the order they were
+// posted, without loading them all in memory. An error returned by fn stops
+// the export and is returned.
+func (s *MessageService) Export(ctx context.Context, q domain.MessageExportQuery, fn func(*domain.ExportedMessage) error) error {
+	if err := s.messageRepo.Export(ctx, q, fn); err != nil {
+		return fmt.Errorf("failed to export messages: %w", err)
+	}
+
+	return nil
+}
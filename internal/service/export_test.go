@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_Export(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("Export", mock.Anything, domain.MessageExportQuery{IncludeDeleted: true}, mock.Anything).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*domain.ExportedMessage) error)
+				_ = fn(&domain.ExportedMessage{ID: 1, Author: "Arthur Morgan"})
+				_ = fn(&domain.ExportedMessage{ID: 2, Author: "Dutch van der Linde"})
+			}).
+			Return(nil)
+		s := NewMessageService(logger, mockRepo)
+
+		var got []int64
+		err := s.Export(context.Background(), domain.MessageExportQuery{IncludeDeleted: true}, func(m *domain.ExportedMessage) error {
+			got = append(got, m.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("MessageService.Export() error = %v", err)
+		}
+		if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("MessageService.Export() exported %v, want [1 2]", got)
+		}
+	})
+
+	t.Run("failed to export messages", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrNoGuestbook)
+		s := NewMessageService(logger, mockRepo)
+
+		err := s.Export(context.Background(), domain.MessageExportQuery{}, func(*domain.ExportedMessage) error { return nil })
+		if !errors.Is(err, domain.ErrNoGuestbook) {
+			t.Errorf("MessageService.Export() error = %v, want %v", err, domain.ErrNoGuestbook)
+		}
+	})
+}
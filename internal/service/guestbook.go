@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"regexp"
+)
+
+// slugPattern is the format of guestbook slugs, which appear in URLs.
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,62}[a-z0-9])?$`)
+
+type GuestbookRepo interface {
+	GetBySlug(context.Context, string) (*domain.Guestbook, error)
+	GetAll(context.Context) ([]*domain.Guestbook, error)
+	Create(context.Context, *domain.Guestbook) (int64, error)
+	Update(context.Context, *domain.Guestbook) error
+}
+
+// GuestbookService manages the guestbooks messages belong to.
+type GuestbookService struct {
+	logger        *slog.Logger
+	guestbookRepo GuestbookRepo
+}
+
+// NewGuestbookService returns a new GuestbookService instance.
+func NewGuestbookService(logger *slog.Logger, guestbookRepo GuestbookRepo) *GuestbookService {
+	return &GuestbookService{
+		logger:        logger,
+		guestbookRepo: guestbookRepo,
+	}
+}
+
+// GetBySlug returns a guestbook.
+func (s *GuestbookService) GetBySlug(ctx context.Context, slug string) (*domain.Guestbook, error) {
+	g, err := s.guestbookRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guestbook: %w", err)
+	}
+
+	return g, nil
+}
+
+// GetAll returns all guestbooks.
+func (s *GuestbookService) GetAll(ctx context.Context) ([]*domain.Guestbook, error) {
+	gs, err := s.guestbookRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all guestbooks: %w", err)
+	}
+
+	return gs, nil
+}
+
+// Create validates and creates a guestbook.
+func (s *GuestbookService) Create(ctx context.Context, g *domain.Guestbook) (int64, error) {
+	if !slugPattern.MatchString(g.Slug) {
+		return 0, fmt.Errorf("failed to create guestbook: %w: slug must be 1-64 lower-case letters, digits or dashes", domain.ErrInvalidArgument)
+	}
+	title := sanitizeText(g.Title)
+	if title == "" {
+		return 0, fmt.Errorf("failed to create guestbook: %w: title is empty", domain.ErrInvalidArgument)
+	}
+
+	_, err := s.guestbookRepo.GetBySlug(ctx, g.Slug)
+	switch {
+	case err == nil:
+		return 0, fmt.Errorf("failed to create guestbook: %w", domain.ErrAlreadyExists)
+	case !errors.Is(err, domain.ErrNotFound):
+		return 0, fmt.Errorf("failed to create guestbook: %w", err)
+	}
+
+	id, err := s.guestbookRepo.Create(ctx, &domain.Guestbook{
+		Slug:     g.Slug,
+		Title:    title,
+		Settings: g.Settings,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook: %w", err)
+	}
+
+	return id, nil
+}
+
+// Update updates the title and settings of a guestbook. The slug cannot be
+// changed, as it is part of the URLs of embedded widgets.
+func (s *GuestbookService) Update(ctx context.Context, g *domain.Guestbook) error {
+	title := sanitizeText(g.Title)
+	if title == "" {
+		return fmt.Errorf("failed to update guestbook: %w: title is empty", domain.ErrInvalidArgument)
+	}
+
+	existing, err := s.guestbookRepo.GetBySlug(ctx, g.Slug)
+	if err != nil {
+		return fmt.Errorf("failed to update guestbook: %w", err)
+	}
+
+	if err := s.guestbookRepo.Update(ctx, &domain.Guestbook{
+		ID:       existing.ID,
+		Slug:     existing.Slug,
+		Title:    title,
+		Settings: g.Settings,
+	}); err != nil {
+		return fmt.Errorf("failed to update guestbook: %w", err)
+	}
+
+	return nil
+}
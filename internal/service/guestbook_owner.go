@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+type GuestbookOwnerRepo interface {
+	GetAll(context.Context) ([]*domain.GuestbookOwner, error)
+	Create(context.Context, *domain.GuestbookOwner) (int64, error)
+	Update(context.Context, *domain.GuestbookOwner) error
+	Delete(context.Context, int64) error
+	GetSubscribed(context.Context) ([]*domain.GuestbookOwner, error)
+	MarkNotified(ctx context.Context, id, lastMessageID int64, at time.Time) error
+}
+
+// GuestbookOwnerService manages the owners of the guestbook the context is
+// scoped to, who are emailed about its new messages.
+type GuestbookOwnerService struct {
+	logger    *slog.Logger
+	ownerRepo GuestbookOwnerRepo
+}
+
+// NewGuestbookOwnerService returns a new GuestbookOwnerService instance.
+func NewGuestbookOwnerService(logger *slog.Logger, ownerRepo GuestbookOwnerRepo) *GuestbookOwnerService {
+	return &GuestbookOwnerService{
+		logger:    logger,
+		ownerRepo: ownerRepo,
+	}
+}
+
+// GetAll returns the owners of the guestbook.
+func (s *GuestbookOwnerService) GetAll(ctx context.Context) ([]*domain.GuestbookOwner, error) {
+	os, err := s.ownerRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all guestbook owners: %w", err)
+	}
+
+	return os, nil
+}
+
+// Create validates and adds an owner to the guestbook. The owner is notified
+// of the messages posted from now on.
+func (s *GuestbookOwnerService) Create(ctx context.Context, o *domain.GuestbookOwner) (int64, error) {
+	owner, err := validateGuestbookOwner(o)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook owner: %w", err)
+	}
+
+	existing, err := s.ownerRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook owner: %w", err)
+	}
+	if slices.ContainsFunc(existing, func(e *domain.GuestbookOwner) bool { return strings.EqualFold(e.Email, owner.Email) }) {
+		return 0, fmt.Errorf("failed to create guestbook owner: %w", domain.ErrAlreadyExists)
+	}
+
+	id, err := s.ownerRepo.Create(ctx, owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guestbook owner: %w", err)
+	}
+
+	return id, nil
+}
+
+// Update validates and updates the name, email and notification frequency
+// of an owner.
+func (s *GuestbookOwnerService) Update(ctx context.Context, o *domain.GuestbookOwner) error {
+	owner, err := validateGuestbookOwner(o)
+	if err != nil {
+		return fmt.Errorf("failed to update guestbook owner: %w", err)
+	}
+
+	if err := s.ownerRepo.Update(ctx, owner); err != nil {
+		return fmt.Errorf("failed to update guestbook owner: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an owner from the guestbook.
+func (s *GuestbookOwnerService) Delete(ctx context.Context, id int64) error {
+	if err := s.ownerRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete guestbook owner: %w", err)
+	}
+
+	return nil
+}
+
+// validateGuestbookOwner returns the owner with its email address and name
+// normalized, or an error if they or its frequency are invalid.
+func validateGuestbookOwner(o *domain.GuestbookOwner) (*domain.GuestbookOwner, error) {
+	addr, err := mail.ParseAddress(o.Email)
+	if err != nil || addr.Name != "" {
+		return nil, fmt.Errorf("%w: invalid email address", domain.ErrInvalidArgument)
+	}
+	if !slices.Contains(domain.NotificationFrequencies, o.Frequency) {
+		return nil, fmt.Errorf("%w: unknown frequency %q", domain.ErrInvalidArgument, o.Frequency)
+	}
+
+	return &domain.GuestbookOwner{
+		ID:        o.ID,
+		Name:      sanitizeText(o.Name),
+		Email:     addr.Address,
+		Frequency: o.Frequency,
+	}, nil
+}
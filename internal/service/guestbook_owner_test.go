@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGuestbookOwnerService_Create(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	existing := []*domain.GuestbookOwner{{ID: 1, Email: "Hosea@example.com", Frequency: domain.NotifyDaily}}
+
+	tests := []struct {
+		name      string
+		owner     *domain.GuestbookOwner
+		repoErr   error
+		want      int64
+		wantEmail string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:      "success",
+			owner:     &domain.GuestbookOwner{Name: "Dutch", Email: " dutch@example.com ", Frequency: domain.NotifyInstant},
+			want:      2,
+			wantEmail: "dutch@example.com",
+		},
+		{
+			name:      "invalid email",
+			owner:     &domain.GuestbookOwner{Email: "dutch", Frequency: domain.NotifyInstant},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name:      "email with a display name",
+			owner:     &domain.GuestbookOwner{Email: "Dutch <dutch@example.com>", Frequency: domain.NotifyInstant},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name:      "unknown frequency",
+			owner:     &domain.GuestbookOwner{Email: "dutch@example.com", Frequency: "weekly"},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name:      "duplicate email",
+			owner:     &domain.GuestbookOwner{Email: "hosea@example.com", Frequency: domain.NotifyOff},
+			wantErr:   true,
+			wantErrIs: domain.ErrAlreadyExists,
+		},
+		{
+			name:    "failed to create guestbook owner",
+			owner:   &domain.GuestbookOwner{Email: "dutch@example.com", Frequency: domain.NotifyInstant},
+			repoErr: errors.New("db down"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.GuestbookOwnerRepo)
+			repo.On("GetAll", mock.Anything).Return(existing, nil).Maybe()
+			repo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.GuestbookOwner) bool {
+				return tt.wantEmail == "" || o.Email == tt.wantEmail
+			})).Return(tt.want, tt.repoErr).Maybe()
+			s := NewGuestbookOwnerService(logger, repo)
+
+			got, err := s.Create(context.Background(), tt.owner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GuestbookOwnerService.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("GuestbookOwnerService.Create() error = %v, wantErrIs %v", err, tt.wantErrIs)
+			}
+			if got != tt.want {
+				t.Errorf("GuestbookOwnerService.Create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestbookOwnerService_Update(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(mocks.GuestbookOwnerRepo)
+		repo.On("Update", mock.Anything, &domain.GuestbookOwner{ID: 1, Name: "Dutch", Email: "dutch@example.com", Frequency: domain.NotifyOff}).Return(nil)
+		s := NewGuestbookOwnerService(logger, repo)
+
+		err := s.Update(context.Background(), &domain.GuestbookOwner{ID: 1, Name: "Dutch", Email: "dutch@example.com", Frequency: domain.NotifyOff})
+		if err != nil {
+			t.Errorf("GuestbookOwnerService.Update() error = %v", err)
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid frequency", func(t *testing.T) {
+		s := NewGuestbookOwnerService(logger, new(mocks.GuestbookOwnerRepo))
+
+		err := s.Update(context.Background(), &domain.GuestbookOwner{ID: 1, Email: "dutch@example.com"})
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("GuestbookOwnerService.Update() error = %v, want %v", err, domain.ErrInvalidArgument)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := new(mocks.GuestbookOwnerRepo)
+		repo.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+		s := NewGuestbookOwnerService(logger, repo)
+
+		err := s.Update(context.Background(), &domain.GuestbookOwner{ID: 42, Email: "dutch@example.com", Frequency: domain.NotifyDaily})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GuestbookOwnerService.Update() error = %v, want %v", err, domain.ErrNotFound)
+		}
+	})
+}
+
+func TestGuestbookOwnerService_Delete(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := new(mocks.GuestbookOwnerRepo)
+	repo.On("Delete", mock.Anything, int64(42)).Return(domain.ErrNotFound)
+	s := NewGuestbookOwnerService(logger, repo)
+
+	if err := s.Delete(context.Background(), 42); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GuestbookOwnerService.Delete() error = %v, want %v", err, domain.ErrNotFound)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGuestbookService_Create(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name          string
+		guestbookRepo func() *mocks.GuestbookRepo
+		guestbook     *domain.Guestbook
+		want          int64
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name: "success",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				mockRepo := new(mocks.GuestbookRepo)
+				mockRepo.On("GetBySlug", mock.Anything, "wedding").Return(nil, domain.ErrNotFound)
+				mockRepo.On("Create", mock.Anything, &domain.Guestbook{
+					Slug:     "wedding",
+					Title:    "Wedding guestbook",
+					Settings: domain.GuestbookSettings{AllowPosting: true},
+				}).Return(int64(2), nil)
+				return mockRepo
+			},
+			guestbook: &domain.Guestbook{
+				Slug:     "wedding",
+				Title:    "<b>Wedding guestbook</b>",
+				Settings: domain.GuestbookSettings{AllowPosting: true},
+			},
+			want: 2,
+		},
+		{
+			name: "invalid slug",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				return new(mocks.GuestbookRepo)
+			},
+			guestbook: &domain.Guestbook{Slug: "Wedding/2024", Title: "Wedding guestbook"},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "empty title",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				return new(mocks.GuestbookRepo)
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: "<script>alert(1)</script>"},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "slug already taken",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				mockRepo := new(mocks.GuestbookRepo)
+				mockRepo.On("GetBySlug", mock.Anything, "wedding").Return(&domain.Guestbook{ID: 2, Slug: "wedding"}, nil)
+				return mockRepo
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: "Wedding guestbook"},
+			wantErr:   true,
+			wantErrIs: domain.ErrAlreadyExists,
+		},
+		{
+			name: "failed to create guestbook",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				mockRepo := new(mocks.GuestbookRepo)
+				mockRepo.On("GetBySlug", mock.Anything, "wedding").Return(nil, domain.ErrNotFound)
+				mockRepo.On("Create", mock.Anything, mock.Anything).Return(int64(0), fmt.Errorf("failed to create guestbook"))
+				return mockRepo
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: "Wedding guestbook"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.guestbookRepo()
+			s := NewGuestbookService(logger, repo)
+			got, err := s.Create(context.Background(), tt.guestbook)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GuestbookService.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("GuestbookService.Create() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if got != tt.want {
+				t.Errorf("GuestbookService.Create() = %v, want %v", got, tt.want)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGuestbookService_Update(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name          string
+		guestbookRepo func() *mocks.GuestbookRepo
+		guestbook     *domain.Guestbook
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name: "success",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				mockRepo := new(mocks.GuestbookRepo)
+				mockRepo.On("GetBySlug", mock.Anything, "wedding").Return(&domain.Guestbook{
+					ID:    2,
+					Slug:  "wedding",
+					Title: "Wedding guestbook",
+				}, nil)
+				mockRepo.On("Update", mock.Anything, &domain.Guestbook{
+					ID:       2,
+					Slug:     "wedding",
+					Title:    "Our wedding",
+					Settings: domain.GuestbookSettings{AllowPosting: false},
+				}).Return(nil)
+				return mockRepo
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: "Our wedding"},
+		},
+		{
+			name: "empty title",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				return new(mocks.GuestbookRepo)
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: " "},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "guestbook not found",
+			guestbookRepo: func() *mocks.GuestbookRepo {
+				mockRepo := new(mocks.GuestbookRepo)
+				mockRepo.On("GetBySlug", mock.Anything, "wedding").Return(nil, domain.ErrNotFound)
+				return mockRepo
+			},
+			guestbook: &domain.Guestbook{Slug: "wedding", Title: "Our wedding"},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.guestbookRepo()
+			s := NewGuestbookService(logger, repo)
+			err := s.Update(context.Background(), tt.guestbook)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GuestbookService.Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("GuestbookService.Update() error = %v, want %v", err, tt.wantErrIs)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// The processing applied to every image attached to a message:
+//
+//   - the type is sniffed from the content, whatever the client claims, and
+//     only JPEG, PNG and GIF images are accepted
+//   - images larger than domain.MaxAttachmentSize bytes or
+//     domain.MaxAttachmentPixels pixels are rejected before being decoded;
+//     the frames of animated GIFs count together
+//   - the image is decoded and encoded again, which drops EXIF and all other
+//     metadata, such as the location a photo was taken at; the orientation
+//     recorded in the EXIF data of JPEG images is applied to the pixels first
+//   - a thumbnail fitting in thumbnailSize pixels is generated, as a JPEG
+//     for JPEG images and a PNG otherwise
+
+// thumbnailSize is the largest width and height of thumbnails.
+const thumbnailSize = 320
+
+// jpegQuality is the quality JPEG images and thumbnails are encoded with.
+const jpegQuality = 85
+
+// imageTypes maps the accepted content types to the extension of their keys.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// processedImage is an image ready to be stored, with its thumbnail.
+type processedImage struct {
+	contentType string
+	data        []byte
+	width       int
+	height      int
+
+	thumbnailType string
+	thumbnail     []byte
+}
+
+// processImage applies the processing policy to the image read from r.
+func processImage(r io.Reader) (*processedImage, error) {
+	data, err := io.ReadAll(io.LimitReader(r, domain.MaxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > domain.MaxAttachmentSize {
+		return nil, fmt.Errorf("%w: image is larger than %d bytes", domain.ErrTooLarge, domain.MaxAttachmentSize)
+	}
+
+	contentType := mimetype.Detect(data).String()
+	if _, ok := imageTypes[contentType]; !ok {
+		return nil, fmt.Errorf("%w: %s is not a JPEG, PNG or GIF image", domain.ErrUnsupportedMediaType, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnsupportedMediaType, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > domain.MaxAttachmentPixels/cfg.Height {
+		return nil, fmt.Errorf("%w: image of %dx%d pixels is larger than %d pixels", domain.ErrTooLarge, cfg.Width, cfg.Height, domain.MaxAttachmentPixels)
+	}
+	if contentType == "image/gif" {
+		pixels, ok := gifPixels(data)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed GIF", domain.ErrUnsupportedMediaType)
+		}
+		if pixels > domain.MaxAttachmentPixels {
+			return nil, fmt.Errorf("%w: GIF frames have more than %d pixels", domain.ErrTooLarge, domain.MaxAttachmentPixels)
+		}
+	}
+
+	p := &processedImage{contentType: contentType}
+	var (
+		out   bytes.Buffer
+		frame image.Image
+	)
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUnsupportedMediaType, err)
+		}
+		frame = orient(img, jpegOrientation(data))
+		err = jpeg.Encode(&out, frame, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return nil, err
+		}
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUnsupportedMediaType, err)
+		}
+		frame = img
+		if err := png.Encode(&out, img); err != nil {
+			return nil, err
+		}
+	case "image/gif":
+		// Animations are kept, without comments and application extensions
+		// other than the loop count.
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUnsupportedMediaType, err)
+		}
+		if len(g.Image) == 0 {
+			return nil, fmt.Errorf("%w: GIF without frames", domain.ErrUnsupportedMediaType)
+		}
+		frame = g.Image[0]
+		if err := gif.EncodeAll(&out, g); err != nil {
+			return nil, err
+		}
+	}
+	p.data = out.Bytes()
+	p.width, p.height = frame.Bounds().Dx(), frame.Bounds().Dy()
+	if contentType == "image/gif" {
+		p.width, p.height = cfg.Width, cfg.Height
+	}
+
+	var thumb bytes.Buffer
+	small := resize(frame, thumbnailSize)
+	if contentType == "image/jpeg" {
+		p.thumbnailType = "image/jpeg"
+		err = jpeg.Encode(&thumb, small, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		p.thumbnailType = "image/png"
+		err = png.Encode(&thumb, small)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.thumbnail = thumb.Bytes()
+
+	return p, nil
+}
+
+// toRGBA returns img as an *image.RGBA with bounds starting at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// resize scales img down to fit in a square of size pixels, keeping its
+// aspect ratio, by averaging the pixels each pixel of the result covers.
+// Images that already fit are returned unscaled.
+func resize(img image.Image, size int) *image.RGBA {
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, max(1, h*size/w)
+	if h > w {
+		dw, dh = max(1, w*size/h), size
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*h/dh, max((dy+1)*h/dh, dy*h/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*w/dw, max((dx+1)*w/dw, dx*w/dw+1)
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride+x0*4 : y*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			o := dy*dst.Stride + dx*4
+			for i := range sum {
+				dst.Pix[o+i] = uint8((sum[i] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// orient returns img transformed as the EXIF orientation tag o asks for
+// the image to be displayed upright. Unknown orientations leave img as it
+// is.
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch o {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-dx, dy
+			case 3: // rotated 180°
+				sx, sy = w-1-dx, h-1-dy
+			case 4: // mirrored vertically
+				sx, sy = dx, h-1-dy
+			case 5: // transposed
+				sx, sy = dy, dx
+			case 6: // rotated 90° clockwise to display
+				sx, sy = dy, h-1-dx
+			case 7: // transversed
+				sx, sy = w-1-dy, h-1-dx
+			case 8: // rotated 90° counterclockwise to display
+				sx, sy = w-1-dy, dx
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the orientation tag of the EXIF data of a JPEG
+// image, or 0 if it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 0
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// The image data follows the start of scan, there is no EXIF
+		// segment after it.
+		if marker == 0xDA || marker == 0xD9 {
+			return 0
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 0
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 0
+}
+
+// exifOrientation returns the orientation tag of the first IFD of TIFF
+// encoded EXIF data, or 0 if it has none.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		// The orientation is a single SHORT, stored in the value field.
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// gifPixels returns the number of pixels of all the frames of a GIF image,
+// reading the block structure without decoding the frames. ok is false if
+// the structure is malformed.
+func gifPixels(data []byte) (pixels int, ok bool) {
+	// skipSubBlocks returns the index following the data sub-blocks at i.
+	skipSubBlocks := func(i int) int {
+		for i < len(data) && data[i] != 0 {
+			i += 1 + int(data[i])
+		}
+		return i + 1
+	}
+	colorTable := func(flags byte) int {
+		if flags&0x80 == 0 {
+			return 0
+		}
+		return 3 << (flags&0x07 + 1)
+	}
+
+	if len(data) < 13 {
+		return 0, false
+	}
+	i := 13 + colorTable(data[10])
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return 0, false
+			}
+			i = skipSubBlocks(i + 2)
+		case 0x2C: // image descriptor
+			if i+10 > len(data) {
+				return 0, false
+			}
+			w := int(binary.LittleEndian.Uint16(data[i+5:]))
+			h := int(binary.LittleEndian.Uint16(data[i+7:]))
+			pixels += w * h
+			if pixels > domain.MaxAttachmentPixels {
+				return pixels, true
+			}
+			// The table is followed by the LZW minimum code size.
+			i = skipSubBlocks(i + 10 + colorTable(data[i+9]) + 1)
+		case 0x3B: // trailer
+			return pixels, true
+		default:
+			return 0, false
+		}
+	}
+	// Truncated GIFs are decoded as far as they go.
+	return pixels, true
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"guestbook-example/internal/domain"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// testImage returns an image of w×h pixels, red in its top half and blue in
+// its bottom half.
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if y >= h/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withEXIF inserts EXIF data with the orientation o after the start of image
+// marker of a JPEG image.
+func withEXIF(data []byte, o uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{o, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func TestProcessImage_PNG(t *testing.T) {
+	got, err := processImage(bytes.NewReader(encodePNG(t, testImage(640, 480))))
+	if err != nil {
+		t.Fatalf("processImage() error = %v", err)
+	}
+	if got.contentType != "image/png" || got.width != 640 || got.height != 480 {
+		t.Errorf("processImage() = %s %dx%d, want image/png 640x480", got.contentType, got.width, got.height)
+	}
+
+	if got.thumbnailType != "image/png" {
+		t.Errorf("processImage() thumbnail type = %s, want image/png", got.thumbnailType)
+	}
+	thumb, err := png.Decode(bytes.NewReader(got.thumbnail))
+	if err != nil {
+		t.Fatalf("png.Decode() thumbnail error = %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != thumbnailSize || b.Dy() != 240 {
+		t.Errorf("thumbnail is %dx%d, want %dx240", b.Dx(), b.Dy(), thumbnailSize)
+	}
+}
+
+func TestProcessImage_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	// Rotated 90° clockwise to display: the bottom left corner goes to the
+	// top left.
+	data := withEXIF(buf.Bytes(), 6)
+	if o := jpegOrientation(data); o != 6 {
+		t.Fatalf("jpegOrientation() = %d, want 6", o)
+	}
+
+	got, err := processImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("processImage() error = %v", err)
+	}
+	if got.contentType != "image/jpeg" || got.width != 20 || got.height != 40 {
+		t.Errorf("processImage() = %s %dx%d, want image/jpeg 20x40", got.contentType, got.width, got.height)
+	}
+	if bytes.Contains(got.data, []byte("Exif")) {
+		t.Error("processImage() kept the EXIF data")
+	}
+	if o := jpegOrientation(got.data); o != 0 {
+		t.Errorf("jpegOrientation() of the processed image = %d, want 0", o)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("jpeg.Decode() error = %v", err)
+	}
+	if r, _, b, _ := img.At(2, 2).RGBA(); b < r {
+		t.Errorf("top left pixel is not blue: %v", img.At(2, 2))
+	}
+	if r, _, b, _ := img.At(17, 2).RGBA(); r < b {
+		t.Errorf("top right pixel is not red: %v", img.At(17, 2))
+	}
+	if got.thumbnailType != "image/jpeg" {
+		t.Errorf("processImage() thumbnail type = %s, want image/jpeg", got.thumbnailType)
+	}
+}
+
+func TestProcessImage_GIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 16, 8), palette),
+			image.NewPaletted(image.Rect(0, 0, 16, 8), palette),
+		},
+		Delay: []int{10, 10},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("gif.EncodeAll() error = %v", err)
+	}
+
+	got, err := processImage(&buf)
+	if err != nil {
+		t.Fatalf("processImage() error = %v", err)
+	}
+	if got.contentType != "image/gif" || got.width != 16 || got.height != 8 {
+		t.Errorf("processImage() = %s %dx%d, want image/gif 16x8", got.contentType, got.width, got.height)
+	}
+	out, err := gif.DecodeAll(bytes.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error = %v", err)
+	}
+	if len(out.Image) != 2 {
+		t.Errorf("processed GIF has %d frames, want 2", len(out.Image))
+	}
+	if got.thumbnailType != "image/png" {
+		t.Errorf("processImage() thumbnail type = %s, want image/png", got.thumbnailType)
+	}
+}
+
+// gifHeader returns the header of a GIF image of w×h pixels without global
+// color table.
+func gifHeader(w, h uint16) []byte {
+	b := []byte("GIF89a")
+	b = binary.LittleEndian.AppendUint16(b, w)
+	b = binary.LittleEndian.AppendUint16(b, h)
+	return append(b, 0, 0, 0)
+}
+
+func TestProcessImage_Rejected(t *testing.T) {
+	// Frames of 1000×1000 pixels, each with an empty image data block.
+	frames := gifHeader(1000, 1000)
+	for i := 0; i < 30; i++ {
+		frames = append(frames, 0x2C, 0, 0, 0, 0, 0xE8, 0x03, 0xE8, 0x03, 0, 2, 0)
+	}
+	frames = append(frames, 0x3B)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "too many bytes",
+			data:    append(encodePNG(t, testImage(8, 8)), make([]byte, domain.MaxAttachmentSize)...),
+			wantErr: domain.ErrTooLarge,
+		},
+		{
+			name:    "too many pixels",
+			data:    append(gifHeader(6000, 5000), 0x3B),
+			wantErr: domain.ErrTooLarge,
+		},
+		{
+			name:    "too many pixels in frames",
+			data:    frames,
+			wantErr: domain.ErrTooLarge,
+		},
+		{
+			name:    "text",
+			data:    []byte("hello, world"),
+			wantErr: domain.ErrUnsupportedMediaType,
+		},
+		{
+			name:    "SVG",
+			data:    []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+			wantErr: domain.ErrUnsupportedMediaType,
+		},
+		{
+			name:    "corrupted PNG",
+			data:    append([]byte("\x89PNG\r\n\x1a\n"), strings.Repeat("x", 64)...),
+			wantErr: domain.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := processImage(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("processImage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		wantW, wantH int
+	}{
+		{w: 640, h: 480, wantW: 320, wantH: 240},
+		{w: 480, h: 640, wantW: 240, wantH: 320},
+		{w: 10000, h: 10, wantW: 320, wantH: 1},
+		{w: 100, h: 50, wantW: 100, wantH: 50},
+	}
+	for _, tt := range tests {
+		got := resize(testImage(tt.w, tt.h), thumbnailSize)
+		if b := got.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("resize(%dx%d) = %dx%d, want %dx%d", tt.w, tt.h, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
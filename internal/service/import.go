@@ -0,0 +1,370 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importBatchSize is the number of messages created per transaction by an
+// import.
+const importBatchSize = 500
+
+// maxImportLineSize is the longest line of a JSON Lines import, in bytes.
+const maxImportLineSize = 1 << 20
+
+// importRecord is a row of an import file. CSV columns and JSON keys are
+// named like the fields of a message creation request, plus an optional
+// RFC 3339 created_at keeping the original posting time and an optional id
+// identifying the row within the file. Other columns and keys are ignored.
+//
+// Without ids, parent_id is the ID of an existing message. Once rows have
+// ids, as those of an export do, parent_id is the id of an earlier row of
+// the file instead, and the reply is attached to the message created from
+// that row.
+type importRecord struct {
+	ID        *int64 `json:"id"`
+	ParentID  *int64 `json:"parent_id"`
+	Author    string `json:"author"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// importRowError is an error of a row of an import file, which rejects the
+// row but lets the import go on.
+type importRowError struct {
+	msg string
+}
+
+func (e *importRowError) Error() string { return e.msg }
+
+// importReader reads the rows of an import file. Next returns the next row
+// and the line it starts on, an *importRowError for a row that cannot be
+// parsed, or io.EOF at the end of the file.
+type importReader interface {
+	Next() (int, *importRecord, error)
+}
+
+// Import reads messages from r, validates each row with the rules of Create
+// and creates the valid ones in batches of importBatchSize, each in its own
+// transaction, without recording events but auditing the creations. Imports
+// are meant for admins migrating messages, so they are accepted even where
+// the guestbook does not allow posting. Invalid rows are rejected in the
+// report, while a file that cannot be read is an error. A storage error stops
+// the import and is returned with the report of the rows before the failed
+// batch, which were created. Replies to rows of the file are resolved as
+// described for importRecord.
+func (s *MessageService) Import(ctx context.Context, r io.Reader, opts domain.MessageImportOptions) (*domain.MessageImportReport, error) {
+	rows, err := newImportReader(r, opts.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import messages: %w", err)
+	}
+
+	report := &domain.MessageImportReport{DryRun: opts.DryRun, Rows: make([]domain.MessageImportRow, 0)}
+	var (
+		batch   []*domain.Message
+		pending []int
+		parents = make(map[int64]bool)
+		// fileRows maps the ids of the rows of the file, once rows have
+		// ids, to their index in the report.
+		fileRows map[int64]int
+	)
+	flush := func() error {
+		if len(batch) == 0 || opts.DryRun {
+			batch, pending = batch[:0], pending[:0]
+			return nil
+		}
+		var ids []int64
+		err := s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			ids, err = s.messageRepo.CreateBatch(ctx, batch)
+			if err != nil {
+				return err
+			}
+
+			entries := make([]*domain.AuditEntry, len(ids))
+			for i, id := range ids {
+				created := *batch[i]
+				created.ID = id
+				entries[i] = newAuditEntry(domain.AuditActionCreate, id, nil, &created)
+			}
+			return s.audit(ctx, entries...)
+		})
+		if err != nil {
+			// The report ends before the failed batch.
+			for _, row := range report.Rows[pending[0]:] {
+				if row.Error != "" {
+					report.Rejected--
+				}
+			}
+			report.Rows = report.Rows[:pending[0]]
+			report.Accepted -= len(batch)
+			return err
+		}
+		for i, id := range ids {
+			report.Rows[pending[i]].ID = id
+		}
+		batch, pending = batch[:0], pending[:0]
+		return nil
+	}
+
+	// resolveParent replaces the parent_id of a row, which is the id of an
+	// earlier row of the file, with the ID of the message created from that
+	// row, creating the pending batch first if the row is part of it. In a
+	// dry run, where no message is created, it only checks the parent row.
+	resolveParent := func(rec *importRecord) error {
+		if rec.ParentID == nil {
+			return nil
+		}
+		i, ok := fileRows[*rec.ParentID]
+		if !ok {
+			return &importRowError{"parent row not found"}
+		}
+		if report.Rows[i].Error != "" {
+			return &importRowError{"parent row was rejected"}
+		}
+		if opts.DryRun {
+			rec.ParentID = nil
+			return nil
+		}
+		if report.Rows[i].ID == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		id := report.Rows[i].ID
+		rec.ParentID = &id
+		parents[id] = true
+		return nil
+	}
+
+	for {
+		line, rec, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importRowError
+		if err != nil && !errors.As(err, &rowErr) {
+			return report, fmt.Errorf("failed to import messages: %w", err)
+		}
+
+		// fileID is the id the row is known by to the rows after it.
+		var fileID *int64
+		if err == nil && rec.ID != nil {
+			if fileRows == nil {
+				fileRows = make(map[int64]int)
+			}
+			if _, ok := fileRows[*rec.ID]; ok {
+				err = &importRowError{"duplicate id"}
+			} else {
+				fileID = rec.ID
+			}
+		}
+		if err == nil && fileRows != nil {
+			if err = resolveParent(rec); err != nil && !errors.As(err, &rowErr) {
+				return report, fmt.Errorf("failed to import messages: %w", err)
+			}
+		}
+
+		var m *domain.Message
+		if err == nil {
+			m, err = s.validateImportRecord(ctx, rec, parents)
+			if err != nil && !errors.As(err, &rowErr) {
+				return report, fmt.Errorf("failed to import messages: %w", err)
+			}
+		}
+		if fileID != nil {
+			fileRows[*fileID] = len(report.Rows)
+		}
+		if err != nil {
+			report.Rows = append(report.Rows, domain.MessageImportRow{Line: line, Error: err.Error()})
+			report.Rejected++
+			continue
+		}
+
+		report.Rows = append(report.Rows, domain.MessageImportRow{Line: line})
+		report.Accepted++
+		batch = append(batch, m)
+		pending = append(pending, len(report.Rows)-1)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return report, fmt.Errorf("failed to import messages: %w", err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, fmt.Errorf("failed to import messages: %w", err)
+	}
+
+	return report, nil
+}
+
+// validateImportRecord returns the message of a row, or an *importRowError
+// if the row breaks the rules of Create. parents caches the parent messages
+// already looked up.
+func (s *MessageService) validateImportRecord(ctx context.Context, rec *importRecord, parents map[int64]bool) (*domain.Message, error) {
+	if rec.Author == "" {
+		return nil, &importRowError{"author is empty"}
+	}
+	if rec.Content == "" {
+		return nil, &importRowError{"content is empty"}
+	}
+	m, err := s.sanitize(&domain.Message{ParentID: rec.ParentID, Author: rec.Author, Message: rec.Content})
+	if err != nil {
+		return nil, &importRowError{"author or content is empty after sanitization"}
+	}
+	if rec.CreatedAt != "" {
+		if m.CreatedAt, err = time.Parse(time.RFC3339, rec.CreatedAt); err != nil {
+			return nil, &importRowError{"invalid created_at"}
+		}
+	}
+
+	if m.ParentID != nil {
+		found, ok := parents[*m.ParentID]
+		if !ok {
+			_, err := s.messageRepo.Get(ctx, *m.ParentID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return nil, err
+			}
+			found = err == nil
+			parents[*m.ParentID] = found
+		}
+		if !found {
+			return nil, &importRowError{"parent message not found"}
+		}
+	}
+
+	return m, nil
+}
+
+func newImportReader(r io.Reader, format domain.MessageImportFormat) (importReader, error) {
+	switch format {
+	case domain.MessageImportCSV:
+		return newCSVImportReader(r)
+	case domain.MessageImportJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineSize)
+		return &jsonlImportReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", domain.ErrInvalidArgument, format)
+	}
+}
+
+// csvImportReader reads a CSV file whose header row names the columns.
+type csvImportReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty file", domain.ErrInvalidArgument)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %w", domain.ErrInvalidArgument, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	for _, name := range []string{"author", "content"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: no %s column", domain.ErrInvalidArgument, name)
+		}
+	}
+
+	return &csvImportReader{r: cr, columns: columns}, nil
+}
+
+func (r *csvImportReader) Next() (int, *importRecord, error) {
+	record, err := r.r.Read()
+	line, _ := r.r.FieldPos(0)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, nil, &importRowError{"invalid CSV: " + parseErr.Err.Error()}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	rec := &importRecord{
+		Author:    unescapeCSVFormula(field("author")),
+		Content:   unescapeCSVFormula(field("content")),
+		CreatedAt: field("created_at"),
+	}
+	if v := field("id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return line, nil, &importRowError{"invalid id"}
+		}
+		rec.ID = &id
+	}
+	if v := field("parent_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return line, nil, &importRowError{"invalid parent_id"}
+		}
+		rec.ParentID = &id
+	}
+
+	return line, rec, nil
+}
+
+// unescapeCSVFormula removes the quote CSV exports prefix formulas and cells
+// starting with a quote with, so an exported message is imported as it was
+// posted. Other cells starting with a quote were not escaped, and are kept.
+func unescapeCSVFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r'", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// jsonlImportReader reads a JSON Lines file, skipping blank lines.
+type jsonlImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlImportReader) Next() (int, *importRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		b := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+		var rec importRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return r.line, nil, &importRowError{"invalid JSON"}
+		}
+		return r.line, &rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return 0, nil, fmt.Errorf("%w: line %d is too long", domain.ErrInvalidArgument, r.line+1)
+		}
+		return 0, nil, err
+	}
+	return 0, nil, io.EOF
+}
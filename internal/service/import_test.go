@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_Import(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	at := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	parentID := int64(7)
+
+	// createBatch returns a repo numbering created messages from 100.
+	createBatch := func(mockRepo *mocks.MessageRepo) {
+		next := int64(100)
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(func(_ context.Context, ms []*domain.Message) ([]int64, error) {
+			ids := make([]int64, len(ms))
+			for i := range ms {
+				ids[i] = next
+				next++
+			}
+			return ids, nil
+		})
+	}
+
+	tests := []struct {
+		name        string
+		messageRepo func() *mocks.MessageRepo
+		input       string
+		opts        domain.MessageImportOptions
+		want        *domain.MessageImportReport
+		wantCreated []*domain.Message
+	}{
+		{
+			name: "csv",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(7)).Return(&domain.Message{ID: 7}, nil).Once()
+				mockRepo.On("Get", mock.Anything, int64(8)).Return(nil, domain.ErrNotFound).Once()
+				createBatch(mockRepo)
+				return mockRepo
+			},
+			input: "\ufeffguestbook_id,Author,content,created_at,parent_id\n" +
+				"1,Arthur Morgan,\"Hey,\nDutch!\",1899-04-01T12:00:00Z,\n" +
+				"1,,I have a plan!,,\n" +
+				"1,Dutch van der Linde,<script>x</script>,,\n" +
+				"1,Dutch van der Linde,I have a plan!,yesterday,\n" +
+				"1,Dutch van der Linde,<b>I have a plan!</b>,,7\n" +
+				"1,Micah Bell,Cowards!,,8\n" +
+				"1,Micah Bell,Cowards!,,seven\n" +
+				"1,Sadie Adler,Rest easy.,,7\n",
+			opts: domain.MessageImportOptions{Format: domain.MessageImportCSV},
+			want: &domain.MessageImportReport{
+				Accepted: 3,
+				Rejected: 5,
+				Rows: []domain.MessageImportRow{
+					{Line: 2, ID: 100},
+					{Line: 4, Error: "author is empty"},
+					{Line: 5, Error: "author or content is empty after sanitization"},
+					{Line: 6, Error: "invalid created_at"},
+					{Line: 7, ID: 101},
+					{Line: 8, Error: "parent message not found"},
+					{Line: 9, Error: "invalid parent_id"},
+					{Line: 10, ID: 102},
+				},
+			},
+			wantCreated: []*domain.Message{
+				{Author: "Arthur Morgan", Message: "Hey,\nDutch!", CreatedAt: at},
+				{Author: "Dutch van der Linde", Message: "I have a plan!", ParentID: &parentID},
+				{Author: "Sadie Adler", Message: "Rest easy.", ParentID: &parentID},
+			},
+		},
+		{
+			name: "jsonl dry run",
+			messageRepo: func() *mocks.MessageRepo {
+				return new(mocks.MessageRepo)
+			},
+			input: `{"author":"Arthur Morgan","content":"Hey, Dutch!","id":12}` + "\n\n" +
+				`{"author":"Dutch van der Linde"` + "\n" +
+				`{"author":"Dutch van der Linde","content":""}` + "\n" +
+				`{"author":"Dutch van der Linde","content":"I have a plan!","created_at":"1899-04-01T12:00:00Z"}`,
+			opts: domain.MessageImportOptions{Format: domain.MessageImportJSONL, DryRun: true},
+			want: &domain.MessageImportReport{
+				DryRun:   true,
+				Accepted: 2,
+				Rejected: 2,
+				Rows: []domain.MessageImportRow{
+					{Line: 1},
+					{Line: 3, Error: "invalid JSON"},
+					{Line: 4, Error: "content is empty"},
+					{Line: 5},
+				},
+			},
+		},
+		{
+			name: "csv with a row of the wrong length",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				createBatch(mockRepo)
+				return mockRepo
+			},
+			input: "author,content\nArthur Morgan\nArthur Morgan,\"Hey\" Dutch\nArthur Morgan,Hey\n",
+			opts:  domain.MessageImportOptions{Format: domain.MessageImportCSV},
+			want: &domain.MessageImportReport{
+				Accepted: 1,
+				Rejected: 2,
+				Rows: []domain.MessageImportRow{
+					{Line: 2, Error: "content is empty"},
+					{Line: 3, Error: "invalid CSV: extraneous or missing \" in quoted-field"},
+					{Line: 4, ID: 100},
+				},
+			},
+		},
+		{
+			name: "empty jsonl",
+			messageRepo: func() *mocks.MessageRepo {
+				return new(mocks.MessageRepo)
+			},
+			opts: domain.MessageImportOptions{Format: domain.MessageImportJSONL},
+			want: &domain.MessageImportReport{Rows: []domain.MessageImportRow{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := tt.messageRepo()
+			s := NewMessageService(logger, mockRepo)
+
+			got, err := s.Import(context.Background(), strings.NewReader(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("MessageService.Import() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageService.Import() = %+v, want %+v", got, tt.want)
+			}
+			if tt.wantCreated != nil {
+				mockRepo.AssertCalled(t, "CreateBatch", mock.Anything, tt.wantCreated)
+			}
+			if tt.opts.DryRun {
+				mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMessageService_Import_fileIDs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	input := "id,author,content,parent_id\n" +
+		"10,Arthur Morgan,'=Hey Dutch!,\n" +
+		"11,Dutch van der Linde,I have a plan!,10\n" +
+		"12,Micah Bell,Cowards!,99\n" +
+		"13,,Rest easy.,\n" +
+		"14,Sadie Adler,Rest easy.,13\n" +
+		"10,John Marston,Duplicate,\n" +
+		"ten,John Marston,Invalid,\n" +
+		"15,Sadie Adler,Another plan?,11\n"
+
+	t.Run("replies are attached to the created messages", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		var created []domain.Message
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(func(_ context.Context, ms []*domain.Message) ([]int64, error) {
+			ids := make([]int64, len(ms))
+			for i, m := range ms {
+				created = append(created, *m)
+				ids[i] = int64(99 + len(created))
+			}
+			return ids, nil
+		})
+		s := NewMessageService(logger, mockRepo)
+
+		got, err := s.Import(context.Background(), strings.NewReader(input), domain.MessageImportOptions{Format: domain.MessageImportCSV})
+		if err != nil {
+			t.Fatalf("MessageService.Import() error = %v", err)
+		}
+		want := &domain.MessageImportReport{
+			Accepted: 3,
+			Rejected: 5,
+			Rows: []domain.MessageImportRow{
+				{Line: 2, ID: 100},
+				{Line: 3, ID: 101},
+				{Line: 4, Error: "parent row not found"},
+				{Line: 5, Error: "author is empty"},
+				{Line: 6, Error: "parent row was rejected"},
+				{Line: 7, Error: "duplicate id"},
+				{Line: 8, Error: "invalid id"},
+				{Line: 9, ID: 102},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("MessageService.Import() = %+v, want %+v", got, want)
+		}
+		arthurID, dutchID := int64(100), int64(101)
+		wantCreated := []domain.Message{
+			{Author: "Arthur Morgan", Message: "=Hey Dutch!"},
+			{Author: "Dutch van der Linde", Message: "I have a plan!", ParentID: &arthurID},
+			{Author: "Sadie Adler", Message: "Another plan?", ParentID: &dutchID},
+		}
+		if !reflect.DeepEqual(created, wantCreated) {
+			t.Errorf("created messages = %+v, want %+v", created, wantCreated)
+		}
+		// Parents in the file are not looked up among the existing messages.
+		mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		s := NewMessageService(logger, mockRepo)
+
+		got, err := s.Import(context.Background(), strings.NewReader(input), domain.MessageImportOptions{Format: domain.MessageImportCSV, DryRun: true})
+		if err != nil {
+			t.Fatalf("MessageService.Import() error = %v", err)
+		}
+		if got.Accepted != 3 || got.Rejected != 5 {
+			t.Errorf("MessageService.Import() accepted %d and rejected %d, want 3 and 5", got.Accepted, got.Rejected)
+		}
+		mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMessageService_Import_batches(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var input strings.Builder
+	input.WriteString("author,content\n")
+	for i := range 2*importBatchSize + 1 {
+		fmt.Fprintf(&input, "Arthur Morgan,Message %d\n", i)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		var sizes []int
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(func(_ context.Context, ms []*domain.Message) ([]int64, error) {
+			sizes = append(sizes, len(ms))
+			return make([]int64, len(ms)), nil
+		})
+		s := NewMessageService(logger, mockRepo)
+
+		got, err := s.Import(context.Background(), strings.NewReader(input.String()), domain.MessageImportOptions{Format: domain.MessageImportCSV})
+		if err != nil {
+			t.Fatalf("MessageService.Import() error = %v", err)
+		}
+		if got.Accepted != 2*importBatchSize+1 || !reflect.DeepEqual(sizes, []int{importBatchSize, importBatchSize, 1}) {
+			t.Errorf("MessageService.Import() accepted %d in batches %v", got.Accepted, sizes)
+		}
+	})
+
+	t.Run("failed to create messages", func(t *testing.T) {
+		mockRepo := new(mocks.MessageRepo)
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(make([]int64, importBatchSize), nil).Once()
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(nil, errors.New("db down")).Once()
+		s := NewMessageService(logger, mockRepo)
+
+		got, err := s.Import(context.Background(), strings.NewReader(input.String()), domain.MessageImportOptions{Format: domain.MessageImportCSV})
+		if err == nil {
+			t.Fatal("MessageService.Import() error = nil, want an error")
+		}
+		// The report covers the rows that were created.
+		if got.Accepted != importBatchSize || len(got.Rows) != importBatchSize {
+			t.Errorf("MessageService.Import() = %d accepted in %d rows, want %d", got.Accepted, len(got.Rows), importBatchSize)
+		}
+	})
+}
+
+func TestMessageService_Import_invalidFile(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name  string
+		input string
+		opts  domain.MessageImportOptions
+	}{
+		{
+			name: "unknown format",
+			opts: domain.MessageImportOptions{Format: "xml"},
+		},
+		{
+			name: "empty csv",
+			opts: domain.MessageImportOptions{Format: domain.MessageImportCSV},
+		},
+		{
+			name:  "csv without content column",
+			input: "author,message\nArthur Morgan,Hey\n",
+			opts:  domain.MessageImportOptions{Format: domain.MessageImportCSV},
+		},
+		{
+			name:  "jsonl line too long",
+			input: `{"author":"Arthur Morgan","content":"` + strings.Repeat("x", maxImportLineSize) + `"}`,
+			opts:  domain.MessageImportOptions{Format: domain.MessageImportJSONL},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMessageService(logger, new(mocks.MessageRepo))
+
+			_, err := s.Import(context.Background(), strings.NewReader(tt.input), tt.opts)
+			if !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("MessageService.Import() error = %v, want %v", err, domain.ErrInvalidArgument)
+			}
+		})
+	}
+}
+
+func TestUnescapeCSVFormula(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "'=1+1", want: "=1+1"},
+		{in: "'\t=1+1", want: "\t=1+1"},
+		{in: "''=x", want: "'=x"},
+		{in: "''tis", want: "'tis"},
+		{in: "'tis", want: "'tis"},
+		{in: "'", want: "'"},
+		{in: "Hey", want: "Hey"},
+	}
+	for _, tt := range tests {
+		if got := unescapeCSVFormula(tt.in); got != tt.want {
+			t.Errorf("unescapeCSVFormula(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
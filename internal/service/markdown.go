@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"unicode/utf8"
+)
+
+// MarkdownRenderer renders the Markdown content of messages to sanitized
+// HTML.
+type MarkdownRenderer interface {
+	Render(string) string
+}
+
+// Preview returns the HTML content would be rendered to once posted. The
+// content is sanitized as when creating a message, so the preview matches
+// the rendering of the stored message. Without a renderer, the HTML is
+// empty.
+func (s *MessageService) Preview(_ context.Context, content string) (string, error) {
+	content = sanitizeText(content)
+	if content == "" {
+		return "", fmt.Errorf("failed to preview message: %w: content is empty after sanitization", domain.ErrInvalidArgument)
+	}
+	if utf8.RuneCountInString(content) > domain.MaxContentLength {
+		return "", fmt.Errorf("failed to preview message: %w: content is longer than %d characters", domain.ErrInvalidArgument, domain.MaxContentLength)
+	}
+	if s.renderer == nil {
+		return "", nil
+	}
+	return s.renderer.Render(content), nil
+}
+
+// render sets the HTML of msgs, if a renderer is configured, and the IDs of
+// their avatars, if an avatar key is.
+func (s *MessageService) render(msgs ...*domain.Message) {
+	for _, m := range msgs {
+		if s.renderer != nil {
+			m.HTML = s.renderer.Render(m.Message)
+		}
+		if s.avatarKey != nil {
+			m.AvatarID = domain.NewAvatarID(s.avatarKey, m)
+		}
+	}
+}
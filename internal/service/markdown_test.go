@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_RendersMarkdown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1, Message: "**a**"}, nil)
+	messageRepo.On("GetAll", mock.Anything, mock.Anything).Return([]*domain.Message{
+		{ID: 1, Message: "**a**"},
+		{ID: 2, ParentID: ptr(int64(1)), Message: "*b*"},
+	}, nil)
+	renderer := new(mocks.MarkdownRenderer)
+	renderer.On("Render", "**a**").Return("<p><strong>a</strong></p>\n")
+	renderer.On("Render", "*b*").Return("<p><em>b</em></p>\n")
+
+	s := NewMessageService(logger, messageRepo, WithMarkdownRenderer(renderer))
+
+	msg, err := s.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("MessageService.Get() error = %v", err)
+	}
+	if msg.HTML != "<p><strong>a</strong></p>\n" {
+		t.Errorf("MessageService.Get() HTML = %q", msg.HTML)
+	}
+
+	threads, err := s.GetThreads(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("MessageService.GetThreads() error = %v", err)
+	}
+	if len(threads) != 1 || len(threads[0].Replies) != 1 || threads[0].Replies[0].HTML != "<p><em>b</em></p>\n" {
+		t.Errorf("MessageService.GetThreads() replies are not rendered: %+v", threads)
+	}
+}
+
+func TestMessageService_Preview(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	renderer := new(mocks.MarkdownRenderer)
+	renderer.On("Render", "**a** b").Return("<p><strong>a</strong> b</p>\n")
+	s := NewMessageService(logger, new(mocks.MessageRepo), WithMarkdownRenderer(renderer))
+
+	// The content is sanitized as when it is posted.
+	got, err := s.Preview(context.Background(), "  **a** <script>x</script>b\r\n")
+	if err != nil {
+		t.Fatalf("MessageService.Preview() error = %v", err)
+	}
+	if got != "<p><strong>a</strong> b</p>\n" {
+		t.Errorf("MessageService.Preview() = %q", got)
+	}
+
+	if _, err := s.Preview(context.Background(), "<b></b>"); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("MessageService.Preview() error = %v, want %v", err, domain.ErrInvalidArgument)
+	}
+	if _, err := s.Preview(context.Background(), strings.Repeat("a", domain.MaxContentLength+1)); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("MessageService.Preview() error = %v, want %v", err, domain.ErrInvalidArgument)
+	}
+	renderer.AssertNumberOfCalls(t, "Render", 1)
+
+	got, err = NewMessageService(logger, new(mocks.MessageRepo)).Preview(context.Background(), "**a**")
+	if err != nil || got != "" {
+		t.Errorf("MessageService.Preview() without renderer = %q, %v, want empty", got, err)
+	}
+}
+
+func TestStreamService_RendersMarkdown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ch := make(chan domain.MessageEvent, 2)
+	shared := &domain.Message{ID: 1, Message: "**a**"}
+	ch <- domain.MessageEvent{ID: 1, Type: domain.MessageCreated, MessageID: 1, Message: shared}
+	ch <- domain.MessageEvent{ID: 2, Type: domain.MessageDeleted, MessageID: 1}
+	close(ch)
+	eventSubscriber := new(mocks.EventSubscriber)
+	eventSubscriber.On("Subscribe", mock.Anything, int64(2), int64(0)).Return((<-chan domain.MessageEvent)(ch), true)
+	renderer := new(mocks.MarkdownRenderer)
+	renderer.On("Render", "**a**").Return("<p><strong>a</strong></p>\n")
+
+	s := NewStreamService(logger, eventSubscriber, WithStreamRenderer(renderer))
+
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 2, Slug: "wedding"})
+	events, _, err := s.Subscribe(ctx, 0)
+	if err != nil {
+		t.Fatalf("StreamService.Subscribe() error = %v", err)
+	}
+
+	var got []domain.MessageEvent
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatal("the events channel was not closed")
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].Message.HTML != "<p><strong>a</strong></p>\n" {
+		t.Errorf("event message HTML = %q", got[0].Message.HTML)
+	}
+	if shared.HTML != "" {
+		t.Error("the shared message was modified")
+	}
+	if got[1].Message != nil {
+		t.Errorf("deletion event message = %+v, want nil", got[1].Message)
+	}
+}
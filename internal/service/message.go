@@ -5,28 +5,130 @@ import (
 	"fmt"
 	"guestbook-example/internal/domain"
 	"log/slog"
+	"slices"
+	"unicode/utf8"
 )
 
 type MessageRepo interface {
 	Get(context.Context, int64) (*domain.Message, error)
-	GetAll(context.Context) ([]*domain.Message, error)
+	GetForUpdate(context.Context, int64) (*domain.Message, error)
+	GetAll(context.Context, domain.MessageQuery) ([]*domain.Message, error)
+	GetReplies(context.Context, int64) ([]*domain.Message, error)
+	GetRevisions(context.Context, int64) ([]*domain.MessageRevision, error)
+	GetRevision(context.Context, int64, int) (*domain.MessageRevision, error)
+	Search(context.Context, domain.SearchQuery) ([]*domain.Message, error)
+	Export(context.Context, domain.MessageExportQuery, func(*domain.ExportedMessage) error) error
 	Create(context.Context, *domain.Message) (int64, error)
+	CreateBatch(context.Context, []*domain.Message) ([]int64, error)
+	Batch(context.Context, domain.MessageBatch) ([]domain.MessageOperationResult, error)
 	Update(context.Context, *domain.Message) error
 	Delete(context.Context, int64) error
 }
 
+// EventNotifier is told when the repository has recorded events for the
+// changes made to messages.
+type EventNotifier interface {
+	Notify()
+}
+
 // MessageService is the interface that provides message methods.
 type MessageService struct {
-	logger      *slog.Logger
-	messageRepo MessageRepo
+	logger        *slog.Logger
+	messageRepo   MessageRepo
+	reactionRepo  ReactionRepo
+	eventNotifier EventNotifier
+	unitOfWork    UnitOfWork
+	auditRepo     AuditRepo
+	renderer      MarkdownRenderer
+	avatarKey     []byte
+
+	attachmentRepo AttachmentRepo
+	blobStore      BlobStore
+
+	linkPreviewRepo LinkPreviewRepo
+}
+
+// MessageServiceOption configures optional dependencies of MessageService.
+type MessageServiceOption func(*MessageService)
+
+// WithReactionRepo makes MessageService return messages with their reaction
+// counts.
+func WithReactionRepo(reactionRepo ReactionRepo) MessageServiceOption {
+	return func(s *MessageService) {
+		s.reactionRepo = reactionRepo
+	}
+}
+
+// WithEventNotifier makes MessageService notify eventNotifier whenever it
+// creates, updates or deletes messages, which the repository records events
+// for.
+func WithEventNotifier(eventNotifier EventNotifier) MessageServiceOption {
+	return func(s *MessageService) {
+		s.eventNotifier = eventNotifier
+	}
+}
+
+// WithUnitOfWork makes MessageService run the repository calls of multi-step
+// operations, such as checking the parent of a reply and creating it, in a
+// single transaction.
+func WithUnitOfWork(unitOfWork UnitOfWork) MessageServiceOption {
+	return func(s *MessageService) {
+		s.unitOfWork = unitOfWork
+	}
+}
+
+// WithAuditRepo makes MessageService record who created, updated or deleted
+// messages in the audit log, in the unit of work making the change.
+func WithAuditRepo(auditRepo AuditRepo) MessageServiceOption {
+	return func(s *MessageService) {
+		s.auditRepo = auditRepo
+	}
+}
+
+// WithMarkdownRenderer makes MessageService return messages with their
+// content rendered to HTML, and preview the rendering of content.
+func WithMarkdownRenderer(renderer MarkdownRenderer) MessageServiceOption {
+	return func(s *MessageService) {
+		s.renderer = renderer
+	}
+}
+
+// WithAvatarKey makes MessageService return messages with the IDs of the
+// avatars of their authors, derived with key, see domain.NewAvatarID.
+func WithAvatarKey(key []byte) MessageServiceOption {
+	return func(s *MessageService) {
+		s.avatarKey = key
+	}
+}
+
+// WithAttachments makes MessageService accept images attached to messages,
+// stored in blobStore, and return messages with their attachments.
+func WithAttachments(attachmentRepo AttachmentRepo, blobStore BlobStore) MessageServiceOption {
+	return func(s *MessageService) {
+		s.attachmentRepo = attachmentRepo
+		s.blobStore = blobStore
+	}
+}
+
+// WithLinkPreviews makes MessageService return messages with the previews
+// of their links.
+func WithLinkPreviews(linkPreviewRepo LinkPreviewRepo) MessageServiceOption {
+	return func(s *MessageService) {
+		s.linkPreviewRepo = linkPreviewRepo
+	}
 }
 
 // NewMessageService returns a new MessageService instance.
-func NewMessageService(logger *slog.Logger, messageRepo MessageRepo) *MessageService {
-	return &MessageService{
+func NewMessageService(logger *slog.Logger, messageRepo MessageRepo, opts ...MessageServiceOption) *MessageService {
+	s := &MessageService{
 		logger:      logger,
 		messageRepo: messageRepo,
+		unitOfWork:  noUnitOfWork{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Get returns a message.
@@ -36,43 +138,299 @@ func (s *MessageService) Get(ctx context.Context, id int64) (*domain.Message, er
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
+	if err := s.countReactions(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if err := s.loadAttachments(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if err := s.loadLinkPreviews(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	s.render(msg)
+
 	return msg, nil
 }
 
-// GetAll returns all messages.
-func (s *MessageService) GetAll(ctx context.Context) ([]*domain.Message, error) {
-	msgs, err := s.messageRepo.GetAll(ctx)
+// GetAll returns the messages matching q.
+func (s *MessageService) GetAll(ctx context.Context, q domain.MessageQuery) ([]*domain.Message, error) {
+	if err := validateMessageQuery(q); err != nil {
+		return nil, fmt.Errorf("failed to get all messages: %w", err)
+	}
+
+	msgs, err := s.messageRepo.GetAll(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all messages: %w", err)
 	}
 
+	if err := s.countReactions(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get all messages: %w", err)
+	}
+	if err := s.loadAttachments(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get all messages: %w", err)
+	}
+	if err := s.loadLinkPreviews(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get all messages: %w", err)
+	}
+	s.render(msgs...)
+
 	return msgs, nil
 }
 
-// Create creates a message.
+// GetThreads returns the top-level messages with their replies nested below
+// them, down to depth reply levels.
+func (s *MessageService) GetThreads(ctx context.Context, depth int) ([]*domain.Message, error) {
+	if depth < 0 || depth > domain.MaxThreadDepth {
+		return nil, fmt.Errorf("failed to get threads: %w: depth must be between 0 and %d", domain.ErrInvalidArgument, domain.MaxThreadDepth)
+	}
+
+	msgs, err := s.messageRepo.GetAll(ctx, domain.MessageQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+
+	if err := s.countReactions(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+	if err := s.loadAttachments(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+	if err := s.loadLinkPreviews(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get threads: %w", err)
+	}
+	s.render(msgs...)
+
+	return buildThreads(msgs, depth), nil
+}
+
+// GetReplies returns the direct replies to a message.
+func (s *MessageService) GetReplies(ctx context.Context, id int64) ([]*domain.Message, error) {
+	if _, err := s.messageRepo.Get(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+
+	msgs, err := s.messageRepo.GetReplies(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+
+	if err := s.countReactions(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+	if err := s.loadAttachments(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+	if err := s.loadLinkPreviews(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+	s.render(msgs...)
+
+	return msgs, nil
+}
+
+// Create sanitizes and creates a message in the guestbook of the context,
+// unless the guestbook does not allow posting. A reply must refer to a
+// message of the same guestbook.
 func (s *MessageService) Create(ctx context.Context, message *domain.Message) (int64, error) {
-	id, err := s.messageRepo.Create(ctx, message)
+	if g, ok := domain.GuestbookFromContext(ctx); ok && !g.Settings.AllowPosting {
+		return 0, fmt.Errorf("failed to create message: guestbook %q does not allow posting: %w", g.Slug, domain.ErrForbidden)
+	}
+
+	var id int64
+	err := s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+		// The parent stays locked until the reply is created, so a
+		// concurrent deletion of the parent either happens first, and the
+		// parent is not found, or waits and deletes the reply with it. This
+		// only holds within a unit of work.
+		if message.ParentID != nil {
+			if _, err := s.messageRepo.GetForUpdate(ctx, *message.ParentID); err != nil {
+				return fmt.Errorf("parent %d: %w", *message.ParentID, err)
+			}
+		}
+
+		message, err := s.sanitize(message)
+		if err != nil {
+			return err
+		}
+
+		id, err = s.messageRepo.Create(ctx, message)
+		if err != nil {
+			return err
+		}
+
+		created := *message
+		created.ID = id
+		return s.audit(ctx, newAuditEntry(domain.AuditActionCreate, id, nil, &created))
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to create message: %w", err)
 	}
 
+	s.notify()
+
 	return id, nil
 }
 
-// Update updates a message.
+// Update sanitizes and updates a message, whose previous version is kept as
+// a revision.
 func (s *MessageService) Update(ctx context.Context, message *domain.Message) error {
-	if err := s.messageRepo.Update(ctx, message); err != nil {
+	message, err := s.sanitize(message)
+	if err != nil {
 		return fmt.Errorf("failed to update message: %w", err)
 	}
 
+	if err := s.update(ctx, message); err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	s.notify()
+
 	return nil
 }
 
-// Delete deletes a message.
+// update updates a sanitized message and audits the update, in a unit of
+// work.
+func (s *MessageService) update(ctx context.Context, message *domain.Message) error {
+	return s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.auditedMessage(ctx, message.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.messageRepo.Update(ctx, message); err != nil {
+			return err
+		}
+
+		if before == nil {
+			return nil
+		}
+		after := *message
+		after.ParentID = before.ParentID
+		return s.audit(ctx, newAuditEntry(domain.AuditActionUpdate, message.ID, before, &after))
+	})
+}
+
+// Delete deletes a message and all replies to it. The deletion is audited
+// as the deletion of the message, its replies going with it.
 func (s *MessageService) Delete(ctx context.Context, id int64) error {
-	if err := s.messageRepo.Delete(ctx, id); err != nil {
+	err := s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.auditedMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.messageRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		if before == nil {
+			return nil
+		}
+		return s.audit(ctx, newAuditEntry(domain.AuditActionDelete, id, before, nil))
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete message: %w", err)
 	}
 
+	s.notify()
+
 	return nil
 }
+
+// auditedMessage returns the message about to be changed, for the audit
+// log, or nil if changes are not audited.
+func (s *MessageService) auditedMessage(ctx context.Context, id int64) (*domain.Message, error) {
+	if s.auditRepo == nil {
+		return nil, nil
+	}
+	return s.messageRepo.Get(ctx, id)
+}
+
+// notify tells the event notifier that the repository has recorded an event.
+func (s *MessageService) notify() {
+	if s.eventNotifier != nil {
+		s.eventNotifier.Notify()
+	}
+}
+
+// countReactions sets the reaction counts of msgs, as seen by the reactor of
+// the context.
+func (s *MessageService) countReactions(ctx context.Context, msgs ...*domain.Message) error {
+	if s.reactionRepo == nil || len(msgs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	reactor, _ := domain.ReactorFromContext(ctx)
+
+	counts, err := s.reactionRepo.CountByMessages(ctx, ids, reactor)
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		m.Reactions = counts[m.ID]
+	}
+
+	return nil
+}
+
+// sanitize applies the sanitization policy to a message and rejects messages
+// that are left without an author or content.
+func (s *MessageService) sanitize(message *domain.Message) (*domain.Message, error) {
+	sanitized := sanitizeMessage(message)
+	if sanitized.Author == "" {
+		return nil, fmt.Errorf("%w: author is empty after sanitization", domain.ErrInvalidArgument)
+	}
+	if sanitized.Message == "" {
+		return nil, fmt.Errorf("%w: content is empty after sanitization", domain.ErrInvalidArgument)
+	}
+	if utf8.RuneCountInString(sanitized.Message) > domain.MaxContentLength {
+		return nil, fmt.Errorf("%w: content is longer than %d characters", domain.ErrInvalidArgument, domain.MaxContentLength)
+	}
+	return sanitized, nil
+}
+
+// validateMessageQuery rejects unknown sort orders and empty time ranges.
+func validateMessageQuery(q domain.MessageQuery) error {
+	if q.Sort != "" && !slices.Contains(domain.MessageSorts, q.Sort) {
+		return fmt.Errorf("%w: unknown sort %q", domain.ErrInvalidArgument, q.Sort)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return fmt.Errorf("%w: since must be before until", domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// buildThreads arranges msgs into threads, returning the top-level messages.
+// Replies below depth levels are counted but left out.
+func buildThreads(msgs []*domain.Message, depth int) []*domain.Message {
+	roots := make([]*domain.Message, 0)
+	replies := make(map[int64][]*domain.Message)
+	for _, m := range msgs {
+		if m.ParentID == nil {
+			roots = append(roots, m)
+			continue
+		}
+		replies[*m.ParentID] = append(replies[*m.ParentID], m)
+	}
+
+	var attach func(m *domain.Message, level int)
+	attach = func(m *domain.Message, level int) {
+		m.ReplyCount = len(replies[m.ID])
+		if level >= depth {
+			return
+		}
+		m.Replies = replies[m.ID]
+		for _, reply := range m.Replies {
+			attach(reply, level+1)
+		}
+	}
+	for _, root := range roots {
+		attach(root, 0)
+	}
+
+	return roots
+}
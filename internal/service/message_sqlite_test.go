@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/infra/repository"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite returns a SQLite database set up like the server's.
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	// A file, as every connection to :memory: opens a database of its own.
+	gormdb, err := gorm.Open(sqlite.Open(repository.SQLiteDSN(filepath.Join(t.TempDir(), "guestbook.db"))), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db, got error: %v", err)
+	}
+	err = gormdb.AutoMigrate(&repository.Message{}, &repository.MessageRevision{}, &repository.MessageAttachment{},
+		&repository.Reaction{}, &repository.OutboxEvent{}, &repository.AuditEntry{})
+	if err != nil {
+		t.Fatalf("failed to migrate sqlite db, got error: %v", err)
+	}
+	return gormdb
+}
+
+// newSQLiteMessageService returns a MessageService running its units of work
+// and audit log on a SQLite database, set up like the server's.
+func newSQLiteMessageService(t *testing.T) (*MessageService, *repository.AuditRepo) {
+	t.Helper()
+
+	gormdb := openSQLite(t)
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auditRepo := repository.NewAuditRepo(l, gormdb)
+	s := NewMessageService(l, repository.NewMessageRepo(l, gormdb),
+		WithUnitOfWork(repository.NewUnitOfWork(l, gormdb)),
+		WithAuditRepo(auditRepo))
+	return s, auditRepo
+}
+
+func TestMessageService_SQLite_ReplyToDeletedParent(t *testing.T) {
+	s, _ := newSQLiteMessageService(t)
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 1, Settings: domain.GuestbookSettings{AllowPosting: true}})
+
+	parentID, err := s.Create(ctx, &domain.Message{Author: "Dutch van der Linde", Message: "I have a plan!"})
+	if err != nil {
+		t.Fatalf("MessageService.Create() error = %v", err)
+	}
+	if err := s.Delete(ctx, parentID); err != nil {
+		t.Fatalf("MessageService.Delete() error = %v", err)
+	}
+
+	_, err = s.Create(ctx, &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Of course you do."})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("MessageService.Create() error = %v, want %v", err, domain.ErrNotFound)
+	}
+	msgs, err := s.GetAll(ctx, domain.MessageQuery{})
+	if err != nil || len(msgs) != 0 {
+		t.Errorf("MessageService.GetAll() = %d messages, %v, want none", len(msgs), err)
+	}
+}
+
+func TestMessageService_SQLite_ConcurrentRepliesAndDeletion(t *testing.T) {
+	s, auditRepo := newSQLiteMessageService(t)
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 1, Settings: domain.GuestbookSettings{AllowPosting: true}})
+
+	parentID, err := s.Create(ctx, &domain.Message{Author: "Dutch van der Linde", Message: "I have a plan!"})
+	if err != nil {
+		t.Fatalf("MessageService.Create() error = %v", err)
+	}
+
+	const replies = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, replies+1)
+	for i := range replies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Create(ctx, &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Of course you do."})
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				errs <- err
+			}
+		}()
+		if i == replies/2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- s.Delete(ctx, parentID)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent call error = %v", err)
+		}
+	}
+
+	// Replies were either created before the deletion, which deleted them
+	// too, or rejected after it.
+	msgs, err := s.GetAll(ctx, domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("MessageService.GetAll() error = %v", err)
+	}
+	for _, m := range msgs {
+		t.Errorf("message %d replying to %v was left behind", m.ID, m.ParentID)
+	}
+
+	// Every change was audited in its unit of work, on a single chain.
+	var entries int
+	prev := ""
+	err = auditRepo.Scan(context.Background(), func(e *domain.AuditEntry) error {
+		entries++
+		if e.PrevHash != prev {
+			t.Errorf("audit entry %d is chained to %q, want %q", e.ID, e.PrevHash, prev)
+		}
+		prev = e.Hash
+		return nil
+	})
+	if err != nil || entries == 0 {
+		t.Errorf("auditRepo.Scan() = %d entries, %v, want the audited changes", entries, err)
+	}
+}
+
+func TestMessageService_SQLite_BatchDeletesOrphanedAttachments(t *testing.T) {
+	gormdb := openSQLite(t)
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	messageRepo := repository.NewMessageRepo(l, gormdb)
+	attachmentRepo := repository.NewAttachmentRepo(l, gormdb)
+	blobStore := new(mocks.BlobStore)
+	s := NewMessageService(l, messageRepo,
+		WithUnitOfWork(repository.NewUnitOfWork(l, gormdb)),
+		WithAttachments(attachmentRepo, blobStore))
+	dispatcher := NewOutboxDispatcher(l, repository.NewOutboxRepo(l, gormdb), DefaultOutboxConfig())
+	dispatcher.Subscribe("attachments", EventHandlerFunc(s.DeleteOrphanedAttachments))
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 1, Settings: domain.GuestbookSettings{AllowPosting: true}})
+
+	create := func(parentID *int64, key string) int64 {
+		t.Helper()
+		id, err := messageRepo.Create(ctx, &domain.Message{ParentID: parentID, Author: "Arthur Morgan", Message: "Look!",
+			Attachment: &domain.Attachment{ContentType: "image/png", Key: key + ".png", ThumbnailKey: key + "-thumb.png", ThumbnailContentType: "image/png"}})
+		if err != nil {
+			t.Fatalf("messageRepo.Create() error = %v", err)
+		}
+		return id
+	}
+	// The deleted message and its reply have images, and so does a message
+	// left alone.
+	deleted := create(nil, "0123456789abcdef0123456789abcdef")
+	reply := create(&deleted, "fedcba9876543210fedcba9876543210")
+	kept := create(nil, "00000000000000000000000000000000")
+	ids := []int64{deleted, reply, kept}
+	for _, key := range []string{"0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"} {
+		blobStore.On("Delete", mock.Anything, key+".png").Return(nil).Once()
+		blobStore.On("Delete", mock.Anything, key+"-thumb.png").Return(nil).Once()
+	}
+
+	results, err := s.Batch(ctx, domain.MessageBatch{Operations: []domain.MessageOperation{
+		{Type: domain.MessageOperationDelete, Message: &domain.Message{ID: deleted}},
+	}})
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("MessageService.Batch() = %+v, %v, want the deletion applied", results, err)
+	}
+	if err := drain(DefaultOutboxConfig().BatchSize, func() (int, error) { return dispatcher.Dispatch(context.Background()) }); err != nil {
+		t.Fatalf("OutboxDispatcher.Dispatch() error = %v", err)
+	}
+
+	got, err := attachmentRepo.GetByMessages(ctx, ids)
+	if err != nil {
+		t.Fatalf("attachmentRepo.GetByMessages() error = %v", err)
+	}
+	if len(got) != 1 || got[kept] == nil {
+		t.Errorf("attachmentRepo.GetByMessages() = %v, want only the attachment of message %d", got, kept)
+	}
+	var left int64
+	if err := gormdb.Unscoped().Model(&repository.MessageAttachment{}).Count(&left).Error; err != nil || left != 1 {
+		t.Errorf("attachments left = %d, %v, want 1", left, err)
+	}
+	blobStore.AssertExpectations(t)
+}
@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"guestbook-example/internal/domain"
 	"guestbook-example/internal/service/mocks"
+	"io"
 	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -95,6 +99,7 @@ func TestMessageService_GetAll(t *testing.T) {
 	}
 	type args struct {
 		ctx context.Context
+		q   domain.MessageQuery
 	}
 	tests := []struct {
 		name    string
@@ -109,7 +114,7 @@ func TestMessageService_GetAll(t *testing.T) {
 				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 				messageRepo: func() MessageRepo {
 					mockRepo := new(mocks.MessageRepo)
-					mockRepo.On("GetAll", mock.Anything).Return(
+					mockRepo.On("GetAll", mock.Anything, domain.MessageQuery{Author: "Arthur Morgan", Sort: domain.MessageSortCreatedAtDesc}).Return(
 						[]*domain.Message{
 							{
 								ID:      1,
@@ -132,6 +137,7 @@ func TestMessageService_GetAll(t *testing.T) {
 			},
 			args: args{
 				ctx: context.Background(),
+				q:   domain.MessageQuery{Author: "Arthur Morgan", Sort: domain.MessageSortCreatedAtDesc},
 			},
 			want: []*domain.Message{
 				{
@@ -157,7 +163,7 @@ func TestMessageService_GetAll(t *testing.T) {
 				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 				messageRepo: func() MessageRepo {
 					mockRepo := new(mocks.MessageRepo)
-					mockRepo.On("GetAll", mock.Anything).Return(
+					mockRepo.On("GetAll", mock.Anything, mock.Anything).Return(
 						nil,
 						fmt.Errorf("failed to get all messages"))
 					return mockRepo
@@ -168,11 +174,38 @@ func TestMessageService_GetAll(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown sort",
+			fields: fields{
+				logger:      slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: new(mocks.MessageRepo),
+			},
+			args: args{
+				ctx: context.Background(),
+				q:   domain.MessageQuery{Sort: "author; DROP TABLE messages"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "since not before until",
+			fields: fields{
+				logger:      slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: new(mocks.MessageRepo),
+			},
+			args: args{
+				ctx: context.Background(),
+				q: domain.MessageQuery{
+					Since: time.Date(1899, 4, 2, 0, 0, 0, 0, time.UTC),
+					Until: time.Date(1899, 4, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewMessageService(tt.fields.logger, tt.fields.messageRepo)
-			got, err := s.GetAll(tt.args.ctx)
+			got, err := s.GetAll(tt.args.ctx, tt.args.q)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MessageService.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -184,6 +217,162 @@ func TestMessageService_GetAll(t *testing.T) {
 	}
 }
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestMessageService_GetThreads(t *testing.T) {
+	// 1
+	// ├── 2
+	// │   └── 4
+	// │       └── 5
+	// └── 3
+	// 6
+	messages := func() []*domain.Message {
+		return []*domain.Message{
+			{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+			{ID: 2, ParentID: ptr(int64(1)), Author: "Dutch van der Linde", Message: "I have a plan!"},
+			{ID: 3, ParentID: ptr(int64(1)), Author: "John Marston", Message: "Not again."},
+			{ID: 4, ParentID: ptr(int64(2)), Author: "Arthur Morgan", Message: "Of course you do."},
+			{ID: 5, ParentID: ptr(int64(4)), Author: "Dutch van der Linde", Message: "Have faith."},
+			{ID: 6, Author: "Sadie Adler", Message: "Hello!"},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		depth     int
+		want      []*domain.Message
+		wantErrIs error
+	}{
+		{
+			name:  "top-level messages only",
+			depth: 0,
+			want: []*domain.Message{
+				{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!", ReplyCount: 2},
+				{ID: 6, Author: "Sadie Adler", Message: "Hello!"},
+			},
+		},
+		{
+			name:  "cut off below depth",
+			depth: 2,
+			want: []*domain.Message{
+				{
+					ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!", ReplyCount: 2,
+					Replies: []*domain.Message{
+						{
+							ID: 2, ParentID: ptr(int64(1)), Author: "Dutch van der Linde", Message: "I have a plan!", ReplyCount: 1,
+							Replies: []*domain.Message{
+								{ID: 4, ParentID: ptr(int64(2)), Author: "Arthur Morgan", Message: "Of course you do.", ReplyCount: 1},
+							},
+						},
+						{ID: 3, ParentID: ptr(int64(1)), Author: "John Marston", Message: "Not again."},
+					},
+				},
+				{ID: 6, Author: "Sadie Adler", Message: "Hello!"},
+			},
+		},
+		{
+			name:      "depth too large",
+			depth:     domain.MaxThreadDepth + 1,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name:      "negative depth",
+			depth:     -1,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MessageRepo)
+			mockRepo.On("GetAll", mock.Anything, domain.MessageQuery{}).Return(messages(), nil)
+
+			s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), mockRepo)
+			got, err := s.GetThreads(context.Background(), tt.depth)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("MessageService.GetThreads() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageService.GetThreads() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageService_GetAll_WithReactions(t *testing.T) {
+	ctx := domain.ContextWithReactor(context.Background(), "c:visitor")
+
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("GetAll", mock.Anything, domain.MessageQuery{}).Return([]*domain.Message{
+		{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+		{ID: 2, Author: "Dutch van der Linde", Message: "I have a plan!"},
+	}, nil)
+	reactionRepo := new(mocks.ReactionRepo)
+	reactionRepo.On("CountByMessages", mock.Anything, []int64{1, 2}, "c:visitor").Return(map[int64][]domain.ReactionCount{
+		2: {{Emoji: "😂", Count: 3, Reacted: true}},
+	}, nil)
+
+	s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), messageRepo, WithReactionRepo(reactionRepo))
+	got, err := s.GetAll(ctx, domain.MessageQuery{})
+	if err != nil {
+		t.Fatalf("MessageService.GetAll() error = %v", err)
+	}
+
+	want := []*domain.Message{
+		{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!"},
+		{ID: 2, Author: "Dutch van der Linde", Message: "I have a plan!", Reactions: []domain.ReactionCount{{Emoji: "😂", Count: 3, Reacted: true}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MessageService.GetAll() = %v, want %v", got, want)
+	}
+}
+
+func TestMessageService_GetReplies(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageRepo func() MessageRepo
+		want        []*domain.Message
+		wantErrIs   error
+	}{
+		{
+			name: "success",
+			messageRepo: func() MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+				mockRepo.On("GetReplies", mock.Anything, int64(1)).Return([]*domain.Message{
+					{ID: 2, ParentID: ptr(int64(1)), Author: "Dutch van der Linde", Message: "I have a plan!"},
+				}, nil)
+				return mockRepo
+			},
+			want: []*domain.Message{
+				{ID: 2, ParentID: ptr(int64(1)), Author: "Dutch van der Linde", Message: "I have a plan!"},
+			},
+		},
+		{
+			name: "message not found",
+			messageRepo: func() MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				return mockRepo
+			},
+			wantErrIs: domain.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.messageRepo())
+			got, err := s.GetReplies(context.Background(), 1)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("MessageService.GetReplies() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageService.GetReplies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestMessageService_Create(t *testing.T) {
 	type fields struct {
 		logger      *slog.Logger
@@ -221,6 +410,131 @@ func TestMessageService_Create(t *testing.T) {
 			},
 			want: int64(1),
 		},
+		{
+			name: "success with sanitized message",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					mockRepo.On("Create", mock.Anything, &domain.Message{
+						Author:  "Arthur Morgan",
+						Message: "Hey, Dutch!",
+					}).Return(int64(1), nil)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					Author:  "<b>Arthur Morgan</b>",
+					Message: "Hey, Dutch!<script>alert(1)</script>",
+				},
+			},
+			want: int64(1),
+		},
+		{
+			name: "failed to create message with markup only content",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					Author:  "Arthur Morgan",
+					Message: "<img src=x onerror=alert(1)>",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failed to create message with too long content",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					Author:  "Arthur Morgan",
+					Message: strings.Repeat("a", domain.MaxContentLength+1),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success with reply",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					mockRepo.On("GetForUpdate", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+					mockRepo.On("Create", mock.Anything, &domain.Message{
+						ParentID: ptr(int64(1)),
+						Author:   "Dutch van der Linde",
+						Message:  "I have a plan!",
+					}).Return(int64(2), nil)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					ParentID: ptr(int64(1)),
+					Author:   "Dutch van der Linde",
+					Message:  "I have a plan!",
+				},
+			},
+			want: int64(2),
+		},
+		{
+			name: "failed to create reply to unknown message",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					mockRepo.On("GetForUpdate", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					ParentID: ptr(int64(1)),
+					Author:   "Dutch van der Linde",
+					Message:  "I have a plan!",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failed to create message in guestbook not allowing posting",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{
+					ID:       2,
+					Slug:     "archive",
+					Settings: domain.GuestbookSettings{AllowPosting: false},
+				}),
+				message: &domain.Message{
+					Author:  "Arthur Morgan",
+					Message: "Hey, Dutch!",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "failed to create message",
 			fields: fields{
@@ -294,6 +608,25 @@ func TestMessageService_Update(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "failed to update message with markup only author",
+			fields: fields{
+				logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+				messageRepo: func() MessageRepo {
+					mockRepo := new(mocks.MessageRepo)
+					return mockRepo
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				message: &domain.Message{
+					ID:      1,
+					Author:  "<script>alert(1)</script>",
+					Message: "Hey, Dutch!",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "failed to update message",
 			fields: fields{
@@ -411,6 +744,7 @@ func TestNewMessageService(t *testing.T) {
 					mockRepo := new(mocks.MessageRepo)
 					return mockRepo
 				}(),
+				unitOfWork: noUnitOfWork{},
 			},
 		},
 	}
@@ -423,3 +757,76 @@ func TestNewMessageService(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageService_NotifiesEvents(t *testing.T) {
+	g := &domain.Guestbook{ID: 2, Slug: "wedding", Settings: domain.GuestbookSettings{AllowPosting: true}}
+	ctx := domain.ContextWithGuestbook(context.Background(), g)
+
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("Create", mock.Anything, mock.Anything).Return(int64(1), nil)
+	messageRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	messageRepo.On("Delete", mock.Anything, int64(1)).Return(nil)
+	eventNotifier := new(mocks.EventNotifier)
+	eventNotifier.On("Notify").Return()
+
+	s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), messageRepo, WithEventNotifier(eventNotifier))
+	if _, err := s.Create(ctx, &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}); err != nil {
+		t.Fatalf("MessageService.Create() error = %v", err)
+	}
+	if err := s.Update(ctx, &domain.Message{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!"}); err != nil {
+		t.Fatalf("MessageService.Update() error = %v", err)
+	}
+	if err := s.Delete(ctx, 1); err != nil {
+		t.Fatalf("MessageService.Delete() error = %v", err)
+	}
+
+	eventNotifier.AssertNumberOfCalls(t, "Notify", 3)
+}
+
+func TestMessageService_NotifiesNothingOnError(t *testing.T) {
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("Delete", mock.Anything, int64(1)).Return(domain.ErrNotFound)
+	eventNotifier := new(mocks.EventNotifier)
+
+	s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), messageRepo, WithEventNotifier(eventNotifier))
+	if err := s.Delete(context.Background(), 1); err == nil {
+		t.Fatalf("MessageService.Delete() error = nil, want error")
+	}
+	eventNotifier.AssertNotCalled(t, "Notify")
+}
+
+func TestMessageService_CreateWithinUnitOfWork(t *testing.T) {
+	type txKey struct{}
+	inTx := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(txKey{}) != nil })
+	parentID := int64(1)
+
+	t.Run("reply", func(t *testing.T) {
+		unitOfWork := new(mocks.UnitOfWork)
+		unitOfWork.On("WithinTx", mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(context.WithValue(ctx, txKey{}, true))
+			})
+		messageRepo := new(mocks.MessageRepo)
+		messageRepo.On("GetForUpdate", inTx, parentID).Return(&domain.Message{ID: parentID}, nil)
+		messageRepo.On("Create", inTx, mock.Anything).Return(int64(2), nil)
+
+		s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), messageRepo, WithUnitOfWork(unitOfWork))
+		id, err := s.Create(context.Background(), &domain.Message{ParentID: &parentID, Author: "Arthur Morgan", Message: "Hey, Dutch!"})
+		if err != nil || id != 2 {
+			t.Fatalf("MessageService.Create() = %d, %v, want 2", id, err)
+		}
+		messageRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed transaction", func(t *testing.T) {
+		unitOfWork := new(mocks.UnitOfWork)
+		unitOfWork.On("WithinTx", mock.Anything, mock.Anything).Return(errors.New("database is locked"))
+		eventNotifier := new(mocks.EventNotifier)
+
+		s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), new(mocks.MessageRepo), WithUnitOfWork(unitOfWork), WithEventNotifier(eventNotifier))
+		if _, err := s.Create(context.Background(), &domain.Message{Author: "Arthur Morgan", Message: "Hey, Dutch!"}); err == nil {
+			t.Fatalf("MessageService.Create() error = nil, want error")
+		}
+		eventNotifier.AssertNotCalled(t, "Notify")
+	})
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"guestbook-example/internal/domain"
+	htmltemplate "html/template"
+	"log/slog"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/notification.*.tmpl
+var notificationTemplates embed.FS
+
+var (
+	notificationText = template.Must(template.ParseFS(notificationTemplates, "templates/notification.txt.tmpl"))
+	notificationHTML = htmltemplate.Must(htmltemplate.ParseFS(notificationTemplates, "templates/notification.html.tmpl"))
+)
+
+// EmailSender sends emails.
+type EmailSender interface {
+	Send(context.Context, *domain.Email) error
+}
+
+// NotificationConfig configures a NotificationScheduler.
+type NotificationConfig struct {
+	// PollInterval is how often owners are checked for due notifications
+	// when the scheduler is not notified of new messages. Failed emails are
+	// sent again at the next poll.
+	PollInterval time.Duration
+	// DigestHour is the hour of the day, in local time, daily digests are
+	// sent at.
+	DigestHour int
+	// MaxMessages is the maximum number of messages in an email. Later
+	// messages are left for the next email.
+	MaxMessages int
+}
+
+// DefaultNotificationConfig returns the default notification configuration.
+func DefaultNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		PollInterval: time.Minute,
+		DigestHour:   8,
+		MaxMessages:  50,
+	}
+}
+
+// NotificationScheduler emails guestbook owners about the messages posted
+// since they were last notified: right away for instant notifications, and
+// once a day for digests.
+//
+// Each owner keeps the last message it was notified of, so messages are
+// neither lost nor sent twice when the server restarts, whereas an email is
+// sent again if the server stops between sending it and recording it.
+type NotificationScheduler struct {
+	logger        *slog.Logger
+	ownerRepo     GuestbookOwnerRepo
+	guestbookRepo GuestbookRepo
+	messageRepo   MessageRepo
+	sender        EmailSender
+	config        NotificationConfig
+	poller        *poller
+}
+
+// NewNotificationScheduler returns a new NotificationScheduler instance.
+func NewNotificationScheduler(logger *slog.Logger, ownerRepo GuestbookOwnerRepo, guestbookRepo GuestbookRepo, messageRepo MessageRepo, sender EmailSender, config NotificationConfig) *NotificationScheduler {
+	return &NotificationScheduler{
+		logger:        logger,
+		ownerRepo:     ownerRepo,
+		guestbookRepo: guestbookRepo,
+		messageRepo:   messageRepo,
+		sender:        sender,
+		config:        config,
+		poller:        newPoller(config.PollInterval),
+	}
+}
+
+// Notify tells the scheduler that messages have been posted, so instant
+// notifications are sent without waiting for the next poll. It never
+// blocks.
+func (s *NotificationScheduler) Notify() {
+	s.poller.notify()
+}
+
+// HandleEvent notifies the scheduler of created messages. It is called by
+// the OutboxDispatcher.
+func (s *NotificationScheduler) HandleEvent(_ context.Context, ev domain.MessageEvent) error {
+	if ev.Type == domain.MessageCreated {
+		s.Notify()
+	}
+	return nil
+}
+
+// Run sends notifications until ctx is done.
+func (s *NotificationScheduler) Run(ctx context.Context) {
+	s.poller.run(ctx, func(ctx context.Context) {
+		if _, err := s.SendDue(ctx, time.Now()); err != nil {
+			s.logger.Error("failed to send notifications", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// SendDue emails the owners due for a notification at now about their new
+// messages, and returns the number of emails sent. An email that fails to be
+// sent is logged and left for the next call.
+func (s *NotificationScheduler) SendDue(ctx context.Context, now time.Time) (int, error) {
+	owners, err := s.ownerRepo.GetSubscribed(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send notifications: %w", err)
+	}
+
+	var guestbooks map[int64]*domain.Guestbook
+	sent := 0
+	for _, o := range owners {
+		if o.Frequency == domain.NotifyDaily && now.Before(nextDigest(o.LastNotifiedAt, s.config.DigestHour)) {
+			continue
+		}
+
+		if guestbooks == nil {
+			gs, err := s.guestbookRepo.GetAll(ctx)
+			if err != nil {
+				return sent, fmt.Errorf("failed to send notifications: %w", err)
+			}
+			guestbooks = make(map[int64]*domain.Guestbook, len(gs))
+			for _, g := range gs {
+				guestbooks[g.ID] = g
+			}
+		}
+		g, ok := guestbooks[o.GuestbookID]
+		if !ok {
+			continue
+		}
+
+		ms, err := s.messageRepo.GetAll(domain.ContextWithGuestbook(ctx, g), domain.MessageQuery{
+			AfterID: o.LastMessageID,
+			Limit:   s.config.MaxMessages,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to send notifications: %w", err)
+		}
+		if len(ms) == 0 {
+			// An empty digest is skipped, but counts as sent so the next one
+			// is due tomorrow.
+			if o.Frequency == domain.NotifyDaily {
+				if err := s.ownerRepo.MarkNotified(ctx, o.ID, o.LastMessageID, now); err != nil {
+					return sent, fmt.Errorf("failed to send notifications: %w", err)
+				}
+			}
+			continue
+		}
+
+		email, err := newNotificationEmail(g, o, ms)
+		if err != nil {
+			return sent, fmt.Errorf("failed to send notifications: %w", err)
+		}
+		if err := s.sender.Send(ctx, email); err != nil {
+			s.logger.Error("failed to send notification",
+				slog.Int64("owner_id", o.ID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if err := s.ownerRepo.MarkNotified(ctx, o.ID, ms[len(ms)-1].ID, now); err != nil {
+			return sent, fmt.Errorf("failed to send notifications: %w", err)
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// nextDigest returns the first time a daily digest is due after last.
+func nextDigest(last time.Time, hour int) time.Time {
+	last = last.Local()
+	next := time.Date(last.Year(), last.Month(), last.Day(), hour, 0, 0, 0, time.Local)
+	if !next.After(last) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+type notificationData struct {
+	Guestbook *domain.Guestbook
+	Owner     *domain.GuestbookOwner
+	Digest    bool
+	Messages  []*domain.Message
+}
+
+// newNotificationEmail renders the email notifying an owner of messages.
+func newNotificationEmail(g *domain.Guestbook, o *domain.GuestbookOwner, ms []*domain.Message) (*domain.Email, error) {
+	data := notificationData{
+		Guestbook: g,
+		Owner:     o,
+		Digest:    o.Frequency == domain.NotifyDaily,
+		Messages:  ms,
+	}
+
+	var text, html bytes.Buffer
+	if err := notificationText.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render notification: %w", err)
+	}
+	if err := notificationHTML.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	var subject string
+	switch {
+	case data.Digest && len(ms) == 1:
+		subject = fmt.Sprintf("Daily digest: 1 new message in %s", g.Title)
+	case data.Digest:
+		subject = fmt.Sprintf("Daily digest: %d new messages in %s", len(ms), g.Title)
+	case len(ms) == 1:
+		subject = fmt.Sprintf("New message from %s in %s", ms[0].Author, g.Title)
+	default:
+		subject = fmt.Sprintf("%d new messages in %s", len(ms), g.Title)
+	}
+
+	return &domain.Email{
+		To:      []string{o.Email},
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
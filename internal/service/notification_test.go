@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotificationScheduler_SendDue(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := NotificationConfig{PollInterval: time.Minute, DigestHour: 8, MaxMessages: 50}
+	now := time.Date(1899, 4, 2, 9, 0, 0, 0, time.Local)
+	wedding := &domain.Guestbook{ID: 2, Slug: "wedding", Title: "Wedding"}
+	messages := []*domain.Message{
+		{ID: 3, GuestbookID: 2, Author: "Arthur Morgan", Message: "Hey, Dutch!", CreatedAt: now},
+		{ID: 4, GuestbookID: 2, Author: "Dutch van der Linde", Message: "I have a plan!", CreatedAt: now},
+	}
+	inGuestbook := func(ctx context.Context) bool {
+		g, ok := domain.GuestbookFromContext(ctx)
+		return ok && g.ID == wedding.ID
+	}
+
+	tests := []struct {
+		name       string
+		owner      *domain.GuestbookOwner
+		messages   []*domain.Message
+		sendErr    error
+		want       int
+		wantMarked int64
+		wantEmail  func(t *testing.T, e *domain.Email)
+	}{
+		{
+			name:       "instant",
+			owner:      &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Name: "Dutch", Email: "dutch@example.com", Frequency: domain.NotifyInstant, LastMessageID: 2, LastNotifiedAt: now.Add(-time.Minute)},
+			messages:   messages[:1],
+			want:       1,
+			wantMarked: 3,
+			wantEmail: func(t *testing.T, e *domain.Email) {
+				if e.Subject != "New message from Arthur Morgan in Wedding" {
+					t.Errorf("Subject = %q", e.Subject)
+				}
+				if len(e.To) != 1 || e.To[0] != "dutch@example.com" {
+					t.Errorf("To = %v", e.To)
+				}
+				if !strings.Contains(e.Text, "Hello Dutch,") || !strings.Contains(e.Text, "Arthur Morgan wrote on Apr 2, 1899 at 09:00:\nHey, Dutch!") {
+					t.Errorf("Text = %q", e.Text)
+				}
+				if !strings.Contains(e.HTML, "<strong>Arthur Morgan</strong> wrote") {
+					t.Errorf("HTML = %q", e.HTML)
+				}
+			},
+		},
+		{
+			name:       "instant with several messages",
+			owner:      &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyInstant, LastMessageID: 2},
+			messages:   messages,
+			want:       1,
+			wantMarked: 4,
+			wantEmail: func(t *testing.T, e *domain.Email) {
+				if e.Subject != "2 new messages in Wedding" {
+					t.Errorf("Subject = %q", e.Subject)
+				}
+			},
+		},
+		{
+			name:       "digest due",
+			owner:      &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyDaily, LastMessageID: 2, LastNotifiedAt: now.AddDate(0, 0, -1)},
+			messages:   messages,
+			want:       1,
+			wantMarked: 4,
+			wantEmail: func(t *testing.T, e *domain.Email) {
+				if e.Subject != "Daily digest: 2 new messages in Wedding" {
+					t.Errorf("Subject = %q", e.Subject)
+				}
+				if !strings.Contains(e.Text, "since your last digest") {
+					t.Errorf("Text = %q", e.Text)
+				}
+			},
+		},
+		{
+			name:  "digest not due",
+			owner: &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyDaily, LastMessageID: 2, LastNotifiedAt: now.Add(-30 * time.Minute)},
+		},
+		{
+			name:       "empty digest",
+			owner:      &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyDaily, LastMessageID: 2, LastNotifiedAt: now.AddDate(0, 0, -1)},
+			wantMarked: 2,
+		},
+		{
+			name:  "no new messages",
+			owner: &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyInstant, LastMessageID: 4},
+		},
+		{
+			name:     "failed to send",
+			owner:    &domain.GuestbookOwner{ID: 1, GuestbookID: 2, Email: "dutch@example.com", Frequency: domain.NotifyInstant, LastMessageID: 2},
+			messages: messages,
+			sendErr:  errors.New("550 no such user"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ownerRepo := new(mocks.GuestbookOwnerRepo)
+			ownerRepo.On("GetSubscribed", mock.Anything).Return([]*domain.GuestbookOwner{tt.owner}, nil)
+			ownerRepo.On("MarkNotified", mock.Anything, tt.owner.ID, tt.wantMarked, now).Return(nil).Maybe()
+			guestbookRepo := new(mocks.GuestbookRepo)
+			guestbookRepo.On("GetAll", mock.Anything).Return([]*domain.Guestbook{wedding}, nil).Maybe()
+			messageRepo := new(mocks.MessageRepo)
+			messageRepo.On("GetAll", mock.MatchedBy(inGuestbook), domain.MessageQuery{AfterID: tt.owner.LastMessageID, Limit: 50}).
+				Return(tt.messages, nil).Maybe()
+			sender := new(mocks.EmailSender)
+			var sent *domain.Email
+			sender.On("Send", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { sent = args.Get(1).(*domain.Email) }).
+				Return(tt.sendErr).Maybe()
+			s := NewNotificationScheduler(logger, ownerRepo, guestbookRepo, messageRepo, sender, config)
+
+			got, err := s.SendDue(context.Background(), now)
+			if err != nil {
+				t.Fatalf("NotificationScheduler.SendDue() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NotificationScheduler.SendDue() = %v, want %v", got, tt.want)
+			}
+			if tt.wantMarked != 0 {
+				ownerRepo.AssertCalled(t, "MarkNotified", mock.Anything, tt.owner.ID, tt.wantMarked, now)
+			} else {
+				ownerRepo.AssertNotCalled(t, "MarkNotified", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.wantEmail != nil {
+				if sent == nil {
+					t.Fatal("no email sent")
+				}
+				tt.wantEmail(t, sent)
+			}
+		})
+	}
+
+	t.Run("failed to get owners", func(t *testing.T) {
+		ownerRepo := new(mocks.GuestbookOwnerRepo)
+		ownerRepo.On("GetSubscribed", mock.Anything).Return(nil, errors.New("db down"))
+		s := NewNotificationScheduler(logger, ownerRepo, new(mocks.GuestbookRepo), new(mocks.MessageRepo), new(mocks.EmailSender), config)
+
+		if _, err := s.SendDue(context.Background(), now); err == nil {
+			t.Error("NotificationScheduler.SendDue() error = nil, want an error")
+		}
+	})
+}
+
+func TestNotificationScheduler_HandleEvent(t *testing.T) {
+	s := NewNotificationScheduler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, nil, nil, DefaultNotificationConfig())
+
+	_ = s.HandleEvent(context.Background(), domain.MessageEvent{Type: domain.MessageDeleted})
+	if s.poller.notified() {
+		t.Error("HandleEvent() woke the scheduler for a deleted message")
+	}
+	_ = s.HandleEvent(context.Background(), domain.MessageEvent{Type: domain.MessageCreated})
+	if !s.poller.notified() {
+		t.Error("HandleEvent() did not wake the scheduler for a created message")
+	}
+}
+
+func Test_nextDigest(t *testing.T) {
+	tests := []struct {
+		name string
+		last time.Time
+		want time.Time
+	}{
+		{
+			name: "before the digest hour",
+			last: time.Date(1899, 4, 2, 7, 59, 0, 0, time.Local),
+			want: time.Date(1899, 4, 2, 8, 0, 0, 0, time.Local),
+		},
+		{
+			name: "at the digest hour",
+			last: time.Date(1899, 4, 2, 8, 0, 0, 0, time.Local),
+			want: time.Date(1899, 4, 3, 8, 0, 0, 0, time.Local),
+		},
+		{
+			name: "after the digest hour",
+			last: time.Date(1899, 4, 2, 20, 0, 0, 0, time.Local),
+			want: time.Date(1899, 4, 3, 8, 0, 0, 0, time.Local),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigest(tt.last, 8); !got.Equal(tt.want) {
+				t.Errorf("nextDigest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
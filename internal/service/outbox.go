@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+type OutboxRepo interface {
+	GetPending(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxEvent, error)
+	MarkDispatched(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, handled []string, next time.Time) error
+	MarkDead(ctx context.Context, id int64, handled []string, at time.Time) error
+	DeleteDispatched(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventHandler handles the message events dispatched from the outbox.
+type EventHandler interface {
+	HandleEvent(context.Context, domain.MessageEvent) error
+}
+
+// EventHandlerFunc adapts a function to an EventHandler.
+type EventHandlerFunc func(context.Context, domain.MessageEvent) error
+
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, ev domain.MessageEvent) error {
+	return f(ctx, ev)
+}
+
+// OutboxConfig configures an OutboxDispatcher.
+type OutboxConfig struct {
+	// PollInterval is how often the outbox is checked for events when the
+	// dispatcher is not notified of new ones.
+	PollInterval time.Duration
+	// BatchSize is the number of events read from the outbox at once.
+	BatchSize int
+	// MaxAttempts is the number of attempts after which an event is
+	// dead-lettered.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the delay before an event that failed
+	// to be dispatched is attempted again, which doubles with every attempt.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long dispatched and dead-lettered events are kept.
+	Retention time.Duration
+}
+
+// DefaultOutboxConfig returns the default outbox configuration, which retries
+// an event for about twenty minutes.
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval: 5 * time.Second,
+		BatchSize:    100,
+		MaxAttempts:  12,
+		MinBackoff:   time.Second,
+		MaxBackoff:   5 * time.Minute,
+		Retention:    24 * time.Hour,
+	}
+}
+
+type namedEventHandler struct {
+	name    string
+	handler EventHandler
+}
+
+// OutboxDispatcher delivers the events of the outbox to the handlers
+// subscribed to them, at least once: an event is marked as dispatched only
+// once every handler has handled it, and is otherwise delivered again later
+// to the handlers that failed, until it runs out of attempts and is
+// dead-lettered. Handlers must tolerate duplicates, since an event they
+// handled may be delivered again if recording it fails, and should be quick,
+// queueing slow work rather than doing it.
+//
+// Events are delivered in order, except that an event being retried is
+// overtaken by later ones.
+type OutboxDispatcher struct {
+	logger     *slog.Logger
+	outboxRepo OutboxRepo
+	config     OutboxConfig
+	handlers   []namedEventHandler
+	poller     *poller
+}
+
+// NewOutboxDispatcher returns a new OutboxDispatcher instance.
+func NewOutboxDispatcher(logger *slog.Logger, outboxRepo OutboxRepo, config OutboxConfig) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		logger:     logger,
+		outboxRepo: outboxRepo,
+		config:     config,
+		poller:     newPoller(config.PollInterval),
+	}
+}
+
+// Subscribe registers a handler of all events. It must be called before Run.
+// The outbox records which handlers have handled an event by name, so names
+// must be unique and stay the same across restarts.
+func (d *OutboxDispatcher) Subscribe(name string, handler EventHandler) {
+	d.handlers = append(d.handlers, namedEventHandler{name: name, handler: handler})
+}
+
+// Notify tells the dispatcher that events have been written to the outbox,
+// so it dispatches them without waiting for the next poll. It never blocks.
+func (d *OutboxDispatcher) Notify() {
+	d.poller.notify()
+}
+
+// Run dispatches events until ctx is done.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	var lastCleanup time.Time
+	d.poller.run(ctx, func(ctx context.Context) {
+		err := drain(d.config.BatchSize, func() (int, error) { return d.Dispatch(ctx) })
+		if err != nil {
+			d.logger.Error("failed to dispatch outbox events", slog.String("error", err.Error()))
+		}
+
+		if now := time.Now(); now.Sub(lastCleanup) >= d.config.Retention/24 {
+			lastCleanup = now
+			if _, err := d.outboxRepo.DeleteDispatched(ctx, now.Add(-d.config.Retention)); err != nil {
+				d.logger.Error("failed to delete dispatched outbox events", slog.String("error", err.Error()))
+			}
+		}
+	})
+}
+
+// Dispatch delivers a batch of pending events, and returns how many were
+// read from the outbox.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.outboxRepo.GetPending(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dispatch outbox events: %w", err)
+	}
+
+	for _, ev := range events {
+		if handled, err := d.deliver(ctx, ev); err != nil {
+			if err := d.fail(ctx, ev, handled, err); err != nil {
+				return 0, fmt.Errorf("failed to dispatch outbox events: %w", err)
+			}
+			continue
+		}
+
+		if err := d.outboxRepo.MarkDispatched(ctx, ev.ID, time.Now()); err != nil {
+			return 0, fmt.Errorf("failed to dispatch outbox events: %w", err)
+		}
+	}
+
+	return len(events), nil
+}
+
+// deliver hands an event to every handler that has not handled it yet, and
+// returns the names of the handlers that have now handled it along with the
+// first error.
+func (d *OutboxDispatcher) deliver(ctx context.Context, ev *domain.OutboxEvent) (handled []string, err error) {
+	handled = slices.Clone(ev.Handled)
+	for _, h := range d.handlers {
+		if slices.Contains(ev.Handled, h.name) {
+			continue
+		}
+		if herr := d.handle(ctx, h, ev.Event); herr != nil {
+			if err == nil {
+				err = herr
+			}
+			continue
+		}
+		handled = append(handled, h.name)
+	}
+	return handled, err
+}
+
+// fail records a failed attempt to dispatch an event, which is attempted
+// again after a backoff or, out of attempts, dead-lettered.
+func (d *OutboxDispatcher) fail(ctx context.Context, ev *domain.OutboxEvent, handled []string, err error) error {
+	attempts := ev.Attempts + 1
+	if attempts >= d.config.MaxAttempts {
+		d.logger.Warn("outbox event dead-lettered",
+			slog.Int64("id", ev.ID),
+			slog.Int("attempts", attempts),
+			slog.String("error", err.Error()))
+		return d.outboxRepo.MarkDead(ctx, ev.ID, handled, time.Now())
+	}
+
+	next := time.Now().Add(backoff(attempts, d.config.MinBackoff, d.config.MaxBackoff))
+	d.logger.Error("failed to dispatch outbox event",
+		slog.Int64("id", ev.ID),
+		slog.Int("attempts", attempts),
+		slog.Time("next_attempt_at", next),
+		slog.String("error", err.Error()))
+	return d.outboxRepo.MarkFailed(ctx, ev.ID, handled, next)
+}
+
+// handle hands an event to a handler, turning a panic into an error so one
+// faulty handler does not stop the dispatcher.
+func (d *OutboxDispatcher) handle(ctx context.Context, h namedEventHandler, ev domain.MessageEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler %s panicked: %v", h.name, r)
+		}
+	}()
+
+	if err := h.handler.HandleEvent(ctx, ev); err != nil {
+		return fmt.Errorf("handler %s: %w", h.name, err)
+	}
+	return nil
+}
+
+// backoff returns the delay before retrying after the given failed attempt,
+// which doubles from minDelay with every attempt up to maxDelay.
+func backoff(attempt int, minDelay, maxDelay time.Duration) time.Duration {
+	delay := minDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOutboxDispatcher_Dispatch(t *testing.T) {
+	config := DefaultOutboxConfig()
+	created := &domain.OutboxEvent{ID: 1, Event: domain.MessageEvent{Type: domain.MessageCreated, GuestbookID: 2, MessageID: 7}}
+	deleted := &domain.OutboxEvent{ID: 2, Attempts: 2, Event: domain.MessageEvent{Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 7}}
+	retried := &domain.OutboxEvent{ID: 3, Attempts: 1, Handled: []string{"first"}, Event: domain.MessageEvent{Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 8}}
+	exhausted := &domain.OutboxEvent{ID: 4, Attempts: config.MaxAttempts - 1, Event: domain.MessageEvent{Type: domain.MessageDeleted, GuestbookID: 2, MessageID: 9}}
+
+	// within matches a time between now+min and now+max, as seen when the
+	// test case runs.
+	within := func(min, max time.Duration) any {
+		start := time.Now()
+		return mock.MatchedBy(func(next time.Time) bool {
+			return !next.Before(start.Add(min)) && !next.After(time.Now().Add(max))
+		})
+	}
+
+	tests := []struct {
+		name       string
+		outboxRepo func() *mocks.OutboxRepo
+		handlers   []EventHandler
+		want       int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{
+			name: "success",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{created, deleted}, nil)
+				outboxRepo.On("MarkDispatched", mock.Anything, int64(1), mock.Anything).Return(nil)
+				outboxRepo.On("MarkDispatched", mock.Anything, int64(2), mock.Anything).Return(nil)
+				return outboxRepo
+			},
+			want:      2,
+			wantCalls: 4,
+		},
+		{
+			name: "handler failed",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{deleted}, nil)
+				// The third attempt is delayed by 4 * MinBackoff.
+				outboxRepo.On("MarkFailed", mock.Anything, int64(2), []string{"first"}, within(4*time.Second, 4*time.Second)).Return(nil)
+				return outboxRepo
+			},
+			handlers: []EventHandler{EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+				return errors.New("webhook down")
+			})},
+			want:      1,
+			wantCalls: 2,
+		},
+		{
+			name: "handler panicked",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{created}, nil)
+				outboxRepo.On("MarkFailed", mock.Anything, int64(1), []string{"first"}, within(time.Second, time.Second)).Return(nil)
+				return outboxRepo
+			},
+			handlers: []EventHandler{EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+				panic("oops")
+			})},
+			want:      1,
+			wantCalls: 2,
+		},
+		{
+			name: "retry of the failed handlers only",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{retried}, nil)
+				outboxRepo.On("MarkDispatched", mock.Anything, int64(3), mock.Anything).Return(nil)
+				return outboxRepo
+			},
+			want:      1,
+			wantCalls: 1,
+		},
+		{
+			name: "handler failed again",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{retried}, nil)
+				outboxRepo.On("MarkFailed", mock.Anything, int64(3), []string{"first"}, within(2*time.Second, 2*time.Second)).Return(nil)
+				return outboxRepo
+			},
+			handlers: []EventHandler{EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+				return errors.New("webhook down")
+			})},
+			want:      1,
+			wantCalls: 1,
+		},
+		{
+			name: "dead-lettered",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{exhausted}, nil)
+				outboxRepo.On("MarkDead", mock.Anything, int64(4), []string{"first"}, mock.Anything).Return(nil)
+				return outboxRepo
+			},
+			handlers: []EventHandler{EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+				return errors.New("webhook down")
+			})},
+			want:      1,
+			wantCalls: 2,
+		},
+		{
+			name: "failed to get pending events",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return(nil, errors.New("database is locked"))
+				return outboxRepo
+			},
+			wantErr: true,
+		},
+		{
+			name: "failed to mark event as dispatched",
+			outboxRepo: func() *mocks.OutboxRepo {
+				outboxRepo := new(mocks.OutboxRepo)
+				outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{created, deleted}, nil)
+				outboxRepo.On("MarkDispatched", mock.Anything, int64(1), mock.Anything).Return(errors.New("database is locked"))
+				return outboxRepo
+			},
+			wantErr:   true,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxRepo := tt.outboxRepo()
+			d := NewOutboxDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), outboxRepo, config)
+
+			// Every event reaches every handler that has not handled it,
+			// even after one failed.
+			calls := 0
+			d.Subscribe("first", EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+				calls++
+				return nil
+			}))
+			for _, h := range tt.handlers {
+				d.Subscribe("failing", EventHandlerFunc(func(ctx context.Context, ev domain.MessageEvent) error {
+					calls++
+					return h.HandleEvent(ctx, ev)
+				}))
+			}
+			if len(tt.handlers) == 0 {
+				d.Subscribe("second", EventHandlerFunc(func(context.Context, domain.MessageEvent) error {
+					calls++
+					return nil
+				}))
+			}
+
+			got, err := d.Dispatch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OutboxDispatcher.Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("OutboxDispatcher.Dispatch() = %d, want %d", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handlers called %d times, want %d", calls, tt.wantCalls)
+			}
+			outboxRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOutboxDispatcher_Run(t *testing.T) {
+	config := DefaultOutboxConfig()
+	config.PollInterval = time.Hour
+
+	outboxRepo := new(mocks.OutboxRepo)
+	outboxRepo.On("DeleteDispatched", mock.Anything, mock.Anything).Return(int64(0), nil).Once()
+	// Nothing is pending until the dispatcher is notified.
+	outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{}, nil).Once()
+	outboxRepo.On("GetPending", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.OutboxEvent{
+		{ID: 1, Event: domain.MessageEvent{Type: domain.MessageCreated, GuestbookID: 2, MessageID: 7}},
+	}, nil).Once()
+	outboxRepo.On("MarkDispatched", mock.Anything, int64(1), mock.Anything).Return(nil)
+
+	d := NewOutboxDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), outboxRepo, config)
+	handled := make(chan domain.MessageEvent, 1)
+	d.Subscribe("test", EventHandlerFunc(func(_ context.Context, ev domain.MessageEvent) error {
+		handled <- ev
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+
+	d.Notify()
+	select {
+	case ev := <-handled:
+		if ev.MessageID != 7 {
+			t.Errorf("handled event for message %d, want 7", ev.MessageID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event handled after Notify")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("OutboxDispatcher.Run() did not return after cancel")
+	}
+	outboxRepo.AssertExpectations(t)
+}
+
+func Test_backoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 9, want: 256 * time.Second},
+		{attempt: 10, want: 5 * time.Minute},
+		{attempt: 1000, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt, time.Second, 5*time.Minute); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// poller runs the loop of a background worker: it calls a function, then
+// waits to be notified or for the poll interval to pass before calling it
+// again.
+type poller struct {
+	interval time.Duration
+	wake     chan struct{}
+}
+
+func newPoller(interval time.Duration) *poller {
+	return &poller{
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// notify wakes the poller up, or makes it run again right away if it is
+// running. It never blocks.
+func (p *poller) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// notified reports whether the poller has been notified since it last woke
+// up.
+func (p *poller) notified() bool {
+	return len(p.wake) != 0
+}
+
+// run calls fn, and again whenever the poller is notified or the interval
+// passes, until ctx is done.
+func (p *poller) run(ctx context.Context, fn func(context.Context)) {
+	poll := time.NewTicker(p.interval)
+	defer poll.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-poll.C:
+		}
+	}
+}
+
+// drain calls batch until it returns fewer than size items or fails, and
+// returns its error.
+func drain(size int, batch func() (int, error)) error {
+	for {
+		n, err := batch()
+		if err != nil {
+			return err
+		}
+		if n < size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoller_run(t *testing.T) {
+	p := newPoller(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.run(ctx, func(context.Context) { calls <- struct{}{} })
+	}()
+
+	// The function runs right away, then whenever the poller is notified.
+	for i := range 3 {
+		select {
+		case <-calls:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("call %d did not happen", i+1)
+		}
+		p.notify()
+		// Notifications do not pile up.
+		p.notify()
+	}
+
+	<-calls
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller.run() did not return once ctx was done")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	var sizes []int
+	batches := []int{10, 10, 3, 10}
+	err := drain(10, func() (int, error) {
+		n := batches[len(sizes)]
+		sizes = append(sizes, n)
+		return n, nil
+	})
+	if err != nil || len(sizes) != 3 {
+		t.Errorf("drain() = %v after %d batches, want nil after 3", err, len(sizes))
+	}
+
+	errBoom := errors.New("boom")
+	calls := 0
+	err = drain(10, func() (int, error) {
+		calls++
+		return 0, errBoom
+	})
+	if !errors.Is(err, errBoom) || calls != 1 {
+		t.Errorf("drain() = %v after %d calls, want %v after 1", err, calls, errBoom)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+)
+
+type ReactionRepo interface {
+	Add(context.Context, *domain.Reaction) error
+	Remove(context.Context, *domain.Reaction) error
+	CountByMessages(ctx context.Context, messageIDs []int64, reactor string) (map[int64][]domain.ReactionCount, error)
+}
+
+// ReactionService manages the reactions of visitors to messages.
+type ReactionService struct {
+	logger       *slog.Logger
+	reactionRepo ReactionRepo
+	messageRepo  MessageRepo
+}
+
+// NewReactionService returns a new ReactionService instance.
+func NewReactionService(logger *slog.Logger, reactionRepo ReactionRepo, messageRepo MessageRepo) *ReactionService {
+	return &ReactionService{
+		logger:       logger,
+		reactionRepo: reactionRepo,
+		messageRepo:  messageRepo,
+	}
+}
+
+// SetReaction adds the reaction of the reactor of the context to a message if
+// on is true, or removes it otherwise, and returns the reaction counts of the
+// message. Setting a reaction to the state it is in already does nothing, so
+// repeated requests are safe.
+func (s *ReactionService) SetReaction(ctx context.Context, messageID int64, emoji string, on bool) ([]domain.ReactionCount, error) {
+	reactor, ok := domain.ReactorFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to set reaction: %w: no reactor", domain.ErrInvalidArgument)
+	}
+	emoji, ok = domain.NormalizeReaction(emoji)
+	if !ok {
+		return nil, fmt.Errorf("failed to set reaction: %w: unsupported emoji", domain.ErrInvalidArgument)
+	}
+
+	// Looked up in the guestbook of the context, so visitors cannot react
+	// to messages of other guestbooks.
+	if _, err := s.messageRepo.Get(ctx, messageID); err != nil {
+		return nil, fmt.Errorf("failed to set reaction: %w", err)
+	}
+
+	reaction := &domain.Reaction{
+		MessageID: messageID,
+		Reactor:   reactor,
+		Emoji:     emoji,
+	}
+	var err error
+	if on {
+		err = s.reactionRepo.Add(ctx, reaction)
+	} else {
+		err = s.reactionRepo.Remove(ctx, reaction)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set reaction: %w", err)
+	}
+
+	counts, err := s.reactionRepo.CountByMessages(ctx, []int64{messageID}, reactor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set reaction: %w", err)
+	}
+
+	return counts[messageID], nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReactionService_SetReaction(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := domain.ContextWithReactor(context.Background(), "c:visitor")
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		reactionRepo func() *mocks.ReactionRepo
+		messageRepo  func() *mocks.MessageRepo
+		emoji        string
+		on           bool
+		want         []domain.ReactionCount
+		wantErr      bool
+		wantErrIs    error
+	}{
+		{
+			name: "add reaction",
+			ctx:  ctx,
+			reactionRepo: func() *mocks.ReactionRepo {
+				mockRepo := new(mocks.ReactionRepo)
+				mockRepo.On("Add", mock.Anything, &domain.Reaction{MessageID: 1, Reactor: "c:visitor", Emoji: "👍"}).Return(nil)
+				mockRepo.On("CountByMessages", mock.Anything, []int64{1}, "c:visitor").Return(map[int64][]domain.ReactionCount{
+					1: {{Emoji: "👍", Count: 2, Reacted: true}},
+				}, nil)
+				return mockRepo
+			},
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+				return mockRepo
+			},
+			emoji: "👍",
+			on:    true,
+			want:  []domain.ReactionCount{{Emoji: "👍", Count: 2, Reacted: true}},
+		},
+		{
+			name: "remove reaction written without variation selector",
+			ctx:  ctx,
+			reactionRepo: func() *mocks.ReactionRepo {
+				mockRepo := new(mocks.ReactionRepo)
+				mockRepo.On("Remove", mock.Anything, &domain.Reaction{MessageID: 1, Reactor: "c:visitor", Emoji: "❤️"}).Return(nil)
+				mockRepo.On("CountByMessages", mock.Anything, []int64{1}, "c:visitor").Return(map[int64][]domain.ReactionCount{}, nil)
+				return mockRepo
+			},
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+				return mockRepo
+			},
+			emoji: "❤",
+			on:    false,
+		},
+		{
+			name:         "unsupported emoji",
+			ctx:          ctx,
+			reactionRepo: func() *mocks.ReactionRepo { return new(mocks.ReactionRepo) },
+			messageRepo:  func() *mocks.MessageRepo { return new(mocks.MessageRepo) },
+			emoji:        "<script>",
+			on:           true,
+			wantErr:      true,
+			wantErrIs:    domain.ErrInvalidArgument,
+		},
+		{
+			name:         "no reactor",
+			ctx:          context.Background(),
+			reactionRepo: func() *mocks.ReactionRepo { return new(mocks.ReactionRepo) },
+			messageRepo:  func() *mocks.MessageRepo { return new(mocks.MessageRepo) },
+			emoji:        "👍",
+			on:           true,
+			wantErr:      true,
+			wantErrIs:    domain.ErrInvalidArgument,
+		},
+		{
+			name:         "message not found",
+			ctx:          ctx,
+			reactionRepo: func() *mocks.ReactionRepo { return new(mocks.ReactionRepo) },
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				return mockRepo
+			},
+			emoji:     "👍",
+			on:        true,
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to add reaction",
+			ctx:  ctx,
+			reactionRepo: func() *mocks.ReactionRepo {
+				mockRepo := new(mocks.ReactionRepo)
+				mockRepo.On("Add", mock.Anything, mock.Anything).Return(fmt.Errorf("failed to add reaction"))
+				return mockRepo
+			},
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+				return mockRepo
+			},
+			emoji:   "👍",
+			on:      true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reactionRepo := tt.reactionRepo()
+			s := NewReactionService(logger, reactionRepo, tt.messageRepo())
+			got, err := s.SetReaction(tt.ctx, 1, tt.emoji, tt.on)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReactionService.SetReaction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("ReactionService.SetReaction() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReactionService.SetReaction() = %v, want %v", got, tt.want)
+			}
+			reactionRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDiffCells bounds the work of a diff, the product of the numbers of
+// tokens of the two versions that differ. Larger diffs replace the whole
+// content. Diffs are computed on every read of the revisions, which anyone
+// can request.
+const maxDiffCells = 1 << 16
+
+// GetRevisions returns the versions of a message, oldest first: the stored
+// revisions, then the current version. Each version carries the diff of its
+// content from the previous one.
+func (s *MessageService) GetRevisions(ctx context.Context, id int64) ([]*domain.MessageRevision, error) {
+	msg, err := s.messageRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message revisions: %w", err)
+	}
+
+	revisions, err := s.messageRepo.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message revisions: %w", err)
+	}
+
+	current := &domain.MessageRevision{
+		MessageID: id,
+		Revision:  msg.EditCount + 1,
+		Author:    msg.Author,
+		Message:   msg.Message,
+		CreatedAt: msg.CreatedAt,
+		Current:   true,
+	}
+	if msg.EditCount > 0 {
+		current.CreatedAt = msg.EditedAt
+	}
+	revisions = append(revisions, current)
+
+	prev := ""
+	for _, r := range revisions {
+		r.Diff = diffWords(prev, r.Message)
+		prev = r.Message
+	}
+
+	return revisions, nil
+}
+
+// Revert updates a message back to a stored revision. Like any update, it
+// keeps the version it replaces as a new revision, so it can be undone.
+func (s *MessageService) Revert(ctx context.Context, id int64, revision int) error {
+	err := s.unitOfWork.WithinTx(ctx, func(ctx context.Context) error {
+		r, err := s.messageRepo.GetRevision(ctx, id, revision)
+		if err != nil {
+			return err
+		}
+
+		// Revisions were sanitized when they were written.
+		return s.update(ctx, &domain.Message{ID: id, Author: r.Author, Message: r.Message})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert message to revision %d: %w", revision, err)
+	}
+
+	s.notify()
+
+	return nil
+}
+
+// diffWords returns the segments turning a into b, word by word. Runs of
+// whitespace are tokens of their own, so the segments join back into a and
+// b exactly.
+func diffWords(a, b string) []domain.DiffSegment {
+	x, y := splitTokens(a), splitTokens(b)
+
+	// Common prefixes and suffixes are cheap to find and shrink the diff.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	var segments []domain.DiffSegment
+	add := func(op domain.DiffOp, text string) {
+		if text == "" {
+			return
+		}
+		if n := len(segments); n > 0 && segments[n-1].Op == op {
+			segments[n-1].Text += text
+			return
+		}
+		segments = append(segments, domain.DiffSegment{Op: op, Text: text})
+	}
+
+	for _, t := range x[:pre] {
+		add(domain.DiffEqual, t)
+	}
+	mx, my := x[pre:len(x)-suf], y[pre:len(y)-suf]
+	if len(mx)*len(my) > maxDiffCells {
+		for _, t := range mx {
+			add(domain.DiffDelete, t)
+		}
+		for _, t := range my {
+			add(domain.DiffInsert, t)
+		}
+	} else {
+		diffTokens(mx, my, add)
+	}
+	for _, t := range x[len(x)-suf:] {
+		add(domain.DiffEqual, t)
+	}
+
+	return segments
+}
+
+// diffTokens adds the segments turning x into y with Hirschberg's
+// algorithm, which finds a longest common subsequence in space linear in the
+// numbers of tokens: it splits x in halves, finds where y splits so that the
+// common subsequences of the two pairs of halves are longest, and diffs each
+// pair on its own.
+func diffTokens(x, y []string, add func(domain.DiffOp, string)) {
+	switch {
+	case len(x) == 0:
+		for _, t := range y {
+			add(domain.DiffInsert, t)
+		}
+		return
+	case len(y) == 0:
+		for _, t := range x {
+			add(domain.DiffDelete, t)
+		}
+		return
+	case len(x) == 1:
+		k := slices.Index(y, x[0])
+		if k < 0 {
+			add(domain.DiffDelete, x[0])
+			diffTokens(nil, y, add)
+			return
+		}
+		diffTokens(nil, y[:k], add)
+		add(domain.DiffEqual, x[0])
+		diffTokens(nil, y[k+1:], add)
+		return
+	}
+
+	mid := len(x) / 2
+	prefix := lcsPrefixLengths(x[:mid], y)
+	suffix := lcsSuffixLengths(x[mid:], y)
+	k := 0
+	for j := range prefix {
+		if prefix[j]+suffix[j] > prefix[k]+suffix[k] {
+			k = j
+		}
+	}
+	diffTokens(x[:mid], y[:k], add)
+	diffTokens(x[mid:], y[k:], add)
+}
+
+// lcsPrefixLengths returns the lengths of the longest common subsequences of
+// x and every prefix of y: the j-th length is the one of x and y[:j].
+func lcsPrefixLengths(x, y []string) []int {
+	prev, cur := make([]int, len(y)+1), make([]int, len(y)+1)
+	for _, t := range x {
+		for j := range y {
+			if t == y[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// lcsSuffixLengths returns the lengths of the longest common subsequences of
+// x and every suffix of y: the j-th length is the one of x and y[j:].
+func lcsSuffixLengths(x, y []string) []int {
+	prev, cur := make([]int, len(y)+1), make([]int, len(y)+1)
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// splitTokens splits s into alternating runs of whitespace and of other
+// characters.
+func splitTokens(s string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range s {
+		if i > start {
+			prev, _ := utf8.DecodeLastRuneInString(s[:i])
+			if unicode.IsSpace(prev) != unicode.IsSpace(r) {
+				tokens = append(tokens, s[start:i])
+				start = i
+			}
+		}
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_GetRevisions(t *testing.T) {
+	createdAt := time.Date(1899, 4, 1, 12, 0, 0, 0, time.UTC)
+	editedAt := time.Date(1899, 4, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		messageRepo func() *mocks.MessageRepo
+		want        []*domain.MessageRevision
+		wantErrIs   error
+		wantErr     bool
+	}{
+		{
+			name: "edited",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).
+					Return(&domain.Message{ID: 1, Author: "Arthur Morgan", Message: "Hey, Dutch!", CreatedAt: createdAt, EditCount: 1, EditedAt: editedAt}, nil)
+				mockRepo.On("GetRevisions", mock.Anything, int64(1)).
+					Return([]*domain.MessageRevision{{MessageID: 1, Revision: 1, Author: "Arthur Morgan", Message: "Hey, John!", CreatedAt: createdAt}}, nil)
+				return mockRepo
+			},
+			want: []*domain.MessageRevision{
+				{
+					MessageID: 1,
+					Revision:  1,
+					Author:    "Arthur Morgan",
+					Message:   "Hey, John!",
+					CreatedAt: createdAt,
+					Diff:      []domain.DiffSegment{{Op: domain.DiffInsert, Text: "Hey, John!"}},
+				},
+				{
+					MessageID: 1,
+					Revision:  2,
+					Author:    "Arthur Morgan",
+					Message:   "Hey, Dutch!",
+					CreatedAt: editedAt,
+					Current:   true,
+					Diff: []domain.DiffSegment{
+						{Op: domain.DiffEqual, Text: "Hey, "},
+						{Op: domain.DiffDelete, Text: "John!"},
+						{Op: domain.DiffInsert, Text: "Dutch!"},
+					},
+				},
+			},
+		},
+		{
+			name: "never edited",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).
+					Return(&domain.Message{ID: 1, Author: "Arthur Morgan", Message: "Hey", CreatedAt: createdAt}, nil)
+				mockRepo.On("GetRevisions", mock.Anything, int64(1)).Return([]*domain.MessageRevision{}, nil)
+				return mockRepo
+			},
+			want: []*domain.MessageRevision{{
+				MessageID: 1,
+				Revision:  1,
+				Author:    "Arthur Morgan",
+				Message:   "Hey",
+				CreatedAt: createdAt,
+				Current:   true,
+				Diff:      []domain.DiffSegment{{Op: domain.DiffInsert, Text: "Hey"}},
+			}},
+		},
+		{
+			name: "message not found",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				return mockRepo
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to get revisions",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Get", mock.Anything, int64(1)).Return(&domain.Message{ID: 1}, nil)
+				mockRepo.On("GetRevisions", mock.Anything, int64(1)).Return(nil, fmt.Errorf("failed to get revisions"))
+				return mockRepo
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.messageRepo()
+			s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+			got, err := s.GetRevisions(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MessageService.GetRevisions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("MessageService.GetRevisions() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageService.GetRevisions() = %+v, want %+v", got, tt.want)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_Revert(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageRepo func() *mocks.MessageRepo
+		wantErrIs   error
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&domain.MessageRevision{MessageID: 1, Revision: 1, Author: "Arthur Morgan", Message: "Hey, John!"}, nil)
+				mockRepo.On("Update", mock.Anything, &domain.Message{ID: 1, Author: "Arthur Morgan", Message: "Hey, John!"}).Return(nil)
+				return mockRepo
+			},
+		},
+		{
+			name: "revision not found",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("GetRevision", mock.Anything, int64(1), 1).Return(nil, domain.ErrNotFound)
+				return mockRepo
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrNotFound,
+		},
+		{
+			name: "failed to update message",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&domain.MessageRevision{MessageID: 1, Revision: 1, Author: "Arthur Morgan", Message: "Hey, John!"}, nil)
+				mockRepo.On("Update", mock.Anything, mock.Anything).Return(fmt.Errorf("failed to update message"))
+				return mockRepo
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.messageRepo()
+			s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+			err := s.Revert(context.Background(), 1, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MessageService.Revert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("MessageService.Revert() error = %v, want %v", err, tt.wantErrIs)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.DiffSegment
+	}{
+		{name: "unchanged", a: "Hey, Dutch!", b: "Hey, Dutch!", want: []domain.DiffSegment{{Op: domain.DiffEqual, Text: "Hey, Dutch!"}}},
+		{name: "both empty", want: nil},
+		{name: "cleared", a: "Hey", want: []domain.DiffSegment{{Op: domain.DiffDelete, Text: "Hey"}}},
+		{
+			name: "word replaced",
+			a:    "I have a plan",
+			b:    "I have a good plan",
+			want: []domain.DiffSegment{
+				{Op: domain.DiffEqual, Text: "I have a "},
+				{Op: domain.DiffInsert, Text: "good "},
+				{Op: domain.DiffEqual, Text: "plan"},
+			},
+		},
+		{
+			name: "words moved",
+			a:    "we need money",
+			b:    "money we need",
+			want: []domain.DiffSegment{
+				{Op: domain.DiffInsert, Text: "money "},
+				{Op: domain.DiffEqual, Text: "we need"},
+				{Op: domain.DiffDelete, Text: " money"},
+			},
+		},
+		{
+			name: "whitespace",
+			a:    "Hey  Dutch",
+			b:    "Hey Dutch\n",
+			want: []domain.DiffSegment{
+				{Op: domain.DiffEqual, Text: "Hey"},
+				{Op: domain.DiffDelete, Text: "  "},
+				{Op: domain.DiffInsert, Text: " "},
+				{Op: domain.DiffEqual, Text: "Dutch"},
+				{Op: domain.DiffInsert, Text: "\n"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffWords(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffWords_Large(t *testing.T) {
+	a := strings.Repeat("a ", 1500)
+	b := strings.Repeat("b ", 1500)
+
+	got := diffWords("x "+a, "y "+b)
+	var before, after strings.Builder
+	for _, s := range got {
+		if s.Op != domain.DiffInsert {
+			before.WriteString(s.Text)
+		}
+		if s.Op != domain.DiffDelete {
+			after.WriteString(s.Text)
+		}
+	}
+	if before.String() != "x "+a || after.String() != "y "+b {
+		t.Errorf("diffWords() does not join back into both versions")
+	}
+}
+
+func TestDiffWords_LongestCommonSubsequence(t *testing.T) {
+	// lcs is the length of the longest common subsequence of x and y,
+	// computed with the full table.
+	lcs := func(x, y []string) int {
+		table := make([][]int, len(x)+1)
+		for i := range table {
+			table[i] = make([]int, len(y)+1)
+		}
+		for i := len(x) - 1; i >= 0; i-- {
+			for j := len(y) - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					table[i][j] = table[i+1][j+1] + 1
+				} else {
+					table[i][j] = max(table[i+1][j], table[i][j+1])
+				}
+			}
+		}
+		return table[0][0]
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	words := []string{"Arthur", "Dutch", "John", "Sadie", "plan"}
+	sentence := func() string {
+		n := rnd.IntN(60)
+		ws := make([]string, n)
+		for i := range ws {
+			ws[i] = words[rnd.IntN(len(words))]
+		}
+		return strings.Join(ws, " ")
+	}
+
+	for range 200 {
+		a, b := sentence(), sentence()
+		got := diffWords(a, b)
+
+		var before, after strings.Builder
+		equal := 0
+		for _, s := range got {
+			if s.Op != domain.DiffInsert {
+				before.WriteString(s.Text)
+			}
+			if s.Op != domain.DiffDelete {
+				after.WriteString(s.Text)
+			}
+			if s.Op == domain.DiffEqual {
+				equal += len(splitTokens(s.Text))
+			}
+		}
+		if before.String() != a || after.String() != b {
+			t.Fatalf("diffWords(%q, %q) does not join back into both versions", a, b)
+		}
+		if want := lcs(splitTokens(a), splitTokens(b)); equal != want {
+			t.Fatalf("diffWords(%q, %q) keeps %d tokens, want %d", a, b, equal, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"guestbook-example/internal/domain"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// The sanitization policy applied to every message before it is persisted:
+//
+//   - invalid UTF-8 sequences are dropped
+//   - HTML markup (tags, comments, doctypes) is removed and character
+//     references are decoded, in a single pass, so text that only reads like
+//     markup once decoded, e.g. "&lt;b&gt;", is kept as text; Markdown
+//     autolinks, e.g. "<https://example.com>", are kept too
+//   - the content of elements that never carry readable text (script, style,
+//     iframe, ...) is removed together with the element
+//   - control and invisible formatting characters are removed, except for
+//     newlines and tabs; CRLF is normalized to LF
+//   - leading and trailing whitespace is trimmed
+//
+// The result is still untrusted text: it must be encoded for the context it
+// is rendered in, see model.GetMessageResponse.
+
+// droppedElements lists the elements whose content is removed entirely.
+var droppedElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"noembed":  true,
+	"noframes": true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+	"xmp":      true,
+}
+
+// autolinkPattern matches the Markdown autolinks kept by stripMarkup, which
+// the HTML tokenizer reads as start tags.
+var autolinkPattern = regexp.MustCompile(`(?i)^<(https?://|mailto:)[^\s<>"']*>$`)
+
+// sanitizeMessage returns a sanitized copy of the message.
+func sanitizeMessage(m *domain.Message) *domain.Message {
+	sanitized := *m
+	sanitized.Author = sanitizeText(m.Author)
+	sanitized.Message = sanitizeText(m.Message)
+	return &sanitized
+}
+
+// Zero width joiners and non-joiners are format characters that text
+// needs: they combine emoji sequences, e.g. 👨‍👩‍👧, and shape the words of
+// scripts such as Persian and Hindi.
+const (
+	zeroWidthNonJoiner = '\u200c'
+	zeroWidthJoiner    = '\u200d'
+)
+
+// sanitizeText applies the sanitization policy to s. Control characters and
+// invisible format characters, such as the bidi overrides and isolates that
+// can disguise text, are removed, except zero width joiners and non-joiners.
+func sanitizeText(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = stripMarkup(s)
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return '\n'
+		case r == zeroWidthNonJoiner || r == zeroWidthJoiner:
+			return r
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// stripMarkup removes HTML markup from s, except Markdown autolinks, and
+// returns the decoded text.
+func stripMarkup(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken:
+			if raw := z.Raw(); autolinkPattern.Match(raw) {
+				if skip == 0 {
+					b.Write(raw)
+				}
+				continue
+			}
+			name, _ := z.TagName()
+			if droppedElements[string(name)] {
+				skip++
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if droppedElements[string(name)] && skip > 0 {
+				skip--
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"guestbook-example/internal/domain"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// markupPattern matches anything a browser could parse as the start of a tag,
+// comment or doctype.
+var markupPattern = regexp.MustCompile(`<[a-zA-Z/!?]`)
+
+// xssPayloads is a corpus of common XSS vectors. None of them may survive
+// sanitization with markup or an executable URL intact. Escaped markup, e.g.
+// "&lt;script&gt;", is text rather than markup, and is kept as such, see
+// TestSanitizeText.
+var xssPayloads = []string{
+	`<script>alert(1)</script>`,
+	`<SCRIPT SRC=//xss.example/x.js></SCRIPT>`,
+	`<img src=x onerror=alert(1)>`,
+	`<svg onload=alert(1)>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<body onload=alert(1)>`,
+	`<iframe src="javascript:alert(1)"></iframe>`,
+	`<a href="javascript:alert(1)">click</a>`,
+	`<div style="background:url(javascript:alert(1))">`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+	`"><script>alert(1)</script>`,
+	`'><img src=x onerror=alert(1)>`,
+	`<scr<script>ipt>alert(1)</scr</script>ipt>`,
+	`<!--<img src=x onerror=alert(1)>-->`,
+	`<object data="javascript:alert(1)"></object>`,
+	`<embed src="javascript:alert(1)">`,
+	`<input autofocus onfocus=alert(1)>`,
+	`<javascript:alert(1)>`,
+	`<details open ontoggle=alert(1)>`,
+	`<template><img src=x onerror=alert(1)></template>`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	"<img\x00 src=x onerror=alert(1)>",
+	"<scr\u200bipt>alert(1)</scr\u200bipt>",
+}
+
+func TestSanitizeText_XSSPayloads(t *testing.T) {
+	for _, payload := range xssPayloads {
+		t.Run(payload, func(t *testing.T) {
+			got := sanitizeText(payload)
+			if markupPattern.MatchString(got) {
+				t.Errorf("sanitizeText(%q) = %q, still contains markup", payload, got)
+			}
+			if again := sanitizeText(got); again != got {
+				t.Errorf("sanitizeText is not idempotent: %q -> %q", got, again)
+			}
+		})
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "plain text is kept",
+			s:    "Hey, Dutch!",
+			want: "Hey, Dutch!",
+		},
+		{
+			name: "comparison operators are kept",
+			s:    "1 < 2 and 3 > 2",
+			want: "1 < 2 and 3 > 2",
+		},
+		{
+			name: "character references are decoded",
+			s:    "Tom &amp; Jerry",
+			want: "Tom & Jerry",
+		},
+		{
+			name: "character references are decoded once",
+			s:    "Type &lt;b&gt; for bold, or &amp;lt;b&amp;gt; in HTML source",
+			want: "Type <b> for bold, or &lt;b&gt; in HTML source",
+		},
+		{
+			name: "autolinks are kept",
+			s:    "See <https://example.com/a?b=c> or <mailto:arthur@example.com>",
+			want: "See <https://example.com/a?b=c> or <mailto:arthur@example.com>",
+		},
+		{
+			name: "autolinks in dropped elements are removed",
+			s:    "<script><https://example.com></script>",
+			want: "",
+		},
+		{
+			name: "tags are removed and text is kept",
+			s:    "<b>bold</b> <a href=\"javascript:alert(1)\">link</a>",
+			want: "bold link",
+		},
+		{
+			name: "script content is removed",
+			s:    "Hello<script>alert(1)</script> world",
+			want: "Hello world",
+		},
+		{
+			name: "newlines and tabs are kept",
+			s:    "line 1\r\nline 2\n\tindented",
+			want: "line 1\nline 2\n\tindented",
+		},
+		{
+			name: "control and formatting characters are removed",
+			s:    "a\x00b\x1bc\u202ed\u200be",
+			want: "abcde",
+		},
+		{
+			name: "bidi overrides and isolates are removed",
+			s:    "\u202aa\u202bb\u202cc\u202dd\u202ee\u2066f\u2067g\u2068h\u2069",
+			want: "abcdefgh",
+		},
+		{
+			name: "zero width joiners are kept in emoji",
+			s:    "family: \U0001F468\u200d\U0001F469\u200d\U0001F467",
+			want: "family: \U0001F468\u200d\U0001F469\u200d\U0001F467",
+		},
+		{
+			name: "zero width non-joiners are kept",
+			s:    "\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645",
+			want: "\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645",
+		},
+		{
+			name: "invalid utf-8 is removed",
+			s:    "caf\xc3\xa9\xff",
+			want: "café",
+		},
+		{
+			name: "surrounding whitespace is trimmed",
+			s:    "  \n Arthur Morgan \t ",
+			want: "Arthur Morgan",
+		},
+		{
+			name: "markup only becomes empty",
+			s:    "<script>alert(1)</script>",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeText(tt.s); got != tt.want {
+				t.Errorf("sanitizeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeMessage(t *testing.T) {
+	m := &domain.Message{
+		ID:      1,
+		Author:  "<b>Arthur Morgan</b>",
+		Message: "Hey, Dutch!<img src=x onerror=alert(1)>",
+	}
+	want := &domain.Message{
+		ID:      1,
+		Author:  "Arthur Morgan",
+		Message: "Hey, Dutch!",
+	}
+
+	got := sanitizeMessage(m)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeMessage() = %v, want %v", got, want)
+	}
+	if m.Author != "<b>Arthur Morgan</b>" {
+		t.Errorf("sanitizeMessage() modified its argument")
+	}
+}
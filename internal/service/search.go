@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSearchQueryLength is the longest search query accepted, in bytes.
+const maxSearchQueryLength = 256
+
+// snippetLength is the largest number of characters of a search snippet,
+// and snippetLead the number of characters shown before the first match.
+const (
+	snippetLength = 160
+	snippetLead   = 40
+)
+
+// Search returns the messages matching a full-text query, best matches first,
+// each with a snippet of its content highlighting the matching words.
+func (s *MessageService) Search(ctx context.Context, query string) ([]*domain.Message, error) {
+	q, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	msgs, err := s.messageRepo.Search(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	if err := s.countReactions(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	if err := s.loadAttachments(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	if err := s.loadLinkPreviews(ctx, msgs...); err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	s.render(msgs...)
+
+	for _, m := range msgs {
+		m.Snippet = buildSnippet(m.Message, q.Terms)
+	}
+
+	return msgs, nil
+}
+
+// parseSearchQuery splits a query into lower-cased terms of letters and
+// digits. Everything else separates terms, so no query syntax of the
+// underlying index can be injected.
+func parseSearchQuery(query string) (domain.SearchQuery, error) {
+	if len(query) > maxSearchQueryLength {
+		return domain.SearchQuery{}, fmt.Errorf("%w: query is longer than %d bytes", domain.ErrInvalidArgument, maxSearchQueryLength)
+	}
+
+	var terms []string
+	for _, w := range splitWords(strings.ToLower(sanitizeText(query))) {
+		if !slices.Contains(terms, w.text) {
+			terms = append(terms, w.text)
+		}
+	}
+	if len(terms) == 0 {
+		return domain.SearchQuery{}, fmt.Errorf("%w: query has no words", domain.ErrInvalidArgument)
+	}
+	if len(terms) > domain.MaxSearchTerms {
+		return domain.SearchQuery{}, fmt.Errorf("%w: query has more than %d words", domain.ErrInvalidArgument, domain.MaxSearchTerms)
+	}
+
+	return domain.SearchQuery{Terms: terms, Limit: domain.MaxSearchResults}, nil
+}
+
+// word is a run of letters and digits, with the combining marks following
+// them, in a text. start and end are its byte offsets.
+type word struct {
+	text       string
+	start, end int
+}
+
+func splitWords(s string) []word {
+	var words []word
+	start := -1
+	for i, r := range s {
+		wordStart := unicode.IsLetter(r) || unicode.IsDigit(r)
+		inWord := wordStart || unicode.Is(unicode.Mn, r)
+		switch {
+		case wordStart && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			words = append(words, word{text: s[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{text: s[start:], start: start, end: len(s)})
+	}
+	return words
+}
+
+// foldWord lower-cases w and removes its diacritics, the way the full-text
+// indexes compare words, so "Café" matches the term "cafe".
+func foldWord(w string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(strings.ToLower(w)))
+}
+
+// buildSnippet returns an excerpt of text of up to snippetLength characters,
+// starting shortly before the first word matching one of terms, split into
+// segments so matching words can be highlighted. Elided text is marked with
+// an ellipsis.
+func buildSnippet(text string, terms []string) []domain.TextSegment {
+	words := splitWords(text)
+
+	folded := make([]string, len(terms))
+	for i, t := range terms {
+		folded[i] = foldWord(t)
+	}
+	var matches []word
+	for _, w := range words {
+		f := foldWord(w.text)
+		if slices.ContainsFunc(folded, func(t string) bool { return strings.HasPrefix(f, t) }) {
+			matches = append(matches, w)
+		}
+	}
+
+	// Choose the window, in bytes, on rune boundaries.
+	start := 0
+	if len(matches) > 0 {
+		start = matches[0].start
+		for n := 0; n < snippetLead && start > 0; n++ {
+			_, size := utf8.DecodeLastRuneInString(text[:start])
+			start -= size
+		}
+		// Do not start in the middle of a word.
+		for _, w := range words {
+			if w.start < start && start < w.end {
+				start = w.end
+				break
+			}
+		}
+		start += len(text[start:]) - len(strings.TrimLeftFunc(text[start:], unicode.IsSpace))
+	}
+	end := start
+	for n := 0; n < snippetLength && end < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	var segments []domain.TextSegment
+	add := func(s string, match bool) {
+		if s == "" {
+			return
+		}
+		if n := len(segments); n > 0 && segments[n-1].Match == match {
+			segments[n-1].Text += s
+			return
+		}
+		segments = append(segments, domain.TextSegment{Text: s, Match: match})
+	}
+
+	if start > 0 {
+		add("…", false)
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		add(text[pos:m.start], false)
+		add(m.text, true)
+		pos = m.end
+	}
+	add(text[pos:end], false)
+	if end < len(text) {
+		add("…", false)
+	}
+
+	return segments
+}
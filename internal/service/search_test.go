@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageService_Search(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageRepo func() *mocks.MessageRepo
+		query       string
+		want        []*domain.Message
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name: "success",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Search", mock.Anything, domain.SearchQuery{Terms: []string{"dutch", "plan"}, Limit: domain.MaxSearchResults}).
+					Return([]*domain.Message{{ID: 1, Author: "Dutch van der Linde", Message: "I have a plan!"}}, nil)
+				return mockRepo
+			},
+			query: " Dutch, <b>PLAN</b> dutch ",
+			want: []*domain.Message{{
+				ID:      1,
+				Author:  "Dutch van der Linde",
+				Message: "I have a plan!",
+				Snippet: []domain.TextSegment{{Text: "I have a "}, {Text: "plan", Match: true}, {Text: "!"}},
+			}},
+		},
+		{
+			name: "query without words",
+			messageRepo: func() *mocks.MessageRepo {
+				return new(mocks.MessageRepo)
+			},
+			query:     `"*()`,
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "query too long",
+			messageRepo: func() *mocks.MessageRepo {
+				return new(mocks.MessageRepo)
+			},
+			query:     strings.Repeat("a", maxSearchQueryLength+1),
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidArgument,
+		},
+		{
+			name: "failed to search messages",
+			messageRepo: func() *mocks.MessageRepo {
+				mockRepo := new(mocks.MessageRepo)
+				mockRepo.On("Search", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to search messages"))
+				return mockRepo
+			},
+			query:   "dutch",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.messageRepo()
+			s := NewMessageService(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+			got, err := s.Search(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MessageService.Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("MessageService.Search() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MessageService.Search() = %v, want %v", got, tt.want)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{query: "fox", want: []string{"fox"}},
+		{query: "Café  au-lait", want: []string{"café", "au", "lait"}},
+		{query: `"fox" OR NEAR(dog*)`, want: []string{"fox", "or", "near", "dog"}},
+		{query: "+fox -dog", want: []string{"fox", "dog"}},
+		{query: "a b c d e f g h", want: []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+		{query: "a b c d e f g h i", wantErr: true},
+		{query: "   ", wantErr: true},
+		{query: "\u0301", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := parseSearchQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got.Terms, tt.want) {
+				t.Errorf("parseSearchQuery() = %q, want %q", got.Terms, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSnippet(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 10) + "the quick brown fox " + strings.Repeat("dolor sit amet ", 15)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  []domain.TextSegment
+	}{
+		{
+			name:  "prefix and case insensitive",
+			text:  "Foxes and a fox",
+			terms: []string{"fox"},
+			want:  []domain.TextSegment{{Text: "Foxes", Match: true}, {Text: " and a "}, {Text: "fox", Match: true}},
+		},
+		{
+			name:  "diacritics",
+			text:  "Café de Flore",
+			terms: []string{"cafe", "flôre"},
+			want:  []domain.TextSegment{{Text: "Café", Match: true}, {Text: " de "}, {Text: "Flore", Match: true}},
+		},
+		{
+			name:  "no match in content",
+			text:  "I have a plan!",
+			terms: []string{"dutch"},
+			want:  []domain.TextSegment{{Text: "I have a plan!"}},
+		},
+		{
+			name:  "long text",
+			text:  long,
+			terms: []string{"fox"},
+			want: []domain.TextSegment{
+				{Text: "…lorem ipsum lorem ipsum the quick brown "},
+				{Text: "fox", Match: true},
+				{Text: " " + strings.Repeat("dolor sit amet ", 7) + "dolor sit a…"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSnippet(tt.text, tt.terms)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildSnippet() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+)
+
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, guestbookID, lastEventID int64) (<-chan domain.MessageEvent, bool)
+}
+
+// StreamService streams the changes to the messages of a guestbook.
+type StreamService struct {
+	logger          *slog.Logger
+	eventSubscriber EventSubscriber
+	renderer        MarkdownRenderer
+	avatarKey       []byte
+}
+
+// StreamServiceOption configures optional dependencies of StreamService.
+type StreamServiceOption func(*StreamService)
+
+// WithStreamRenderer makes StreamService stream messages with their content
+// rendered to HTML.
+func WithStreamRenderer(renderer MarkdownRenderer) StreamServiceOption {
+	return func(s *StreamService) {
+		s.renderer = renderer
+	}
+}
+
+// WithStreamAvatarKey makes StreamService stream messages with the IDs of the
+// avatars of their authors, derived with key, see domain.NewAvatarID.
+func WithStreamAvatarKey(key []byte) StreamServiceOption {
+	return func(s *StreamService) {
+		s.avatarKey = key
+	}
+}
+
+// NewStreamService returns a new StreamService instance.
+func NewStreamService(logger *slog.Logger, eventSubscriber EventSubscriber, opts ...StreamServiceOption) *StreamService {
+	s := &StreamService{
+		logger:          logger,
+		eventSubscriber: eventSubscriber,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Subscribe returns the events of the guestbook of the context published
+// after lastEventID, until ctx is done. resumed is false if some of them are
+// no longer available, in which case the subscriber should reload the
+// messages.
+func (s *StreamService) Subscribe(ctx context.Context, lastEventID int64) (events <-chan domain.MessageEvent, resumed bool, err error) {
+	g, ok := domain.GuestbookFromContext(ctx)
+	if !ok {
+		return nil, false, fmt.Errorf("failed to subscribe to messages: %w", domain.ErrNoGuestbook)
+	}
+
+	events, resumed = s.eventSubscriber.Subscribe(ctx, g.ID, lastEventID)
+	if s.renderer != nil || s.avatarKey != nil {
+		events = s.renderEvents(ctx, events)
+	}
+	return events, resumed, nil
+}
+
+// renderEvents returns a channel relaying events with the content of their
+// messages rendered to HTML and the IDs of their avatars, closed when events is or ctx is done. The
+// messages are copied, since the events are shared with the other
+// subscribers.
+func (s *StreamService) renderEvents(ctx context.Context, events <-chan domain.MessageEvent) <-chan domain.MessageEvent {
+	rendered := make(chan domain.MessageEvent, cap(events))
+	go func() {
+		defer close(rendered)
+		for ev := range events {
+			if ev.Message != nil {
+				m := *ev.Message
+				if s.renderer != nil {
+					m.HTML = s.renderer.Render(m.Message)
+				}
+				if s.avatarKey != nil {
+					m.AvatarID = domain.NewAvatarID(s.avatarKey, &m)
+				}
+				ev.Message = &m
+			}
+			select {
+			case rendered <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return rendered
+}
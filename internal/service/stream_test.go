@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStreamService_Subscribe(t *testing.T) {
+	ch := make(<-chan domain.MessageEvent)
+	eventSubscriber := new(mocks.EventSubscriber)
+	eventSubscriber.On("Subscribe", mock.Anything, int64(2), int64(41)).Return(ch, true)
+
+	s := NewStreamService(slog.New(slog.NewTextHandler(io.Discard, nil)), eventSubscriber)
+
+	ctx := domain.ContextWithGuestbook(context.Background(), &domain.Guestbook{ID: 2, Slug: "wedding"})
+	got, resumed, err := s.Subscribe(ctx, 41)
+	if err != nil {
+		t.Fatalf("StreamService.Subscribe() error = %v", err)
+	}
+	if got != ch || !resumed {
+		t.Errorf("StreamService.Subscribe() = %v, %v, want %v, true", got, resumed, ch)
+	}
+
+	if _, _, err := s.Subscribe(context.Background(), 0); !errors.Is(err, domain.ErrNoGuestbook) {
+		t.Errorf("StreamService.Subscribe() error = %v, want %v", err, domain.ErrNoGuestbook)
+	}
+	eventSubscriber.AssertNumberOfCalls(t, "Subscribe", 1)
+}
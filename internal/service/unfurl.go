@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type LinkPreviewRepo interface {
+	GetByMessages(context.Context, []int64) (map[int64][]*domain.LinkPreview, error)
+	Replace(context.Context, int64, []string) ([]*domain.LinkPreview, error)
+	GetPending(context.Context, int) ([]*domain.LinkPreview, error)
+	Update(context.Context, *domain.LinkPreview) error
+	DeleteOrphans(context.Context, int64) ([]*domain.LinkPreview, error)
+}
+
+// LinkFetcher fetches the pages linked to in messages. It must refuse to
+// connect to anything but public web servers, since the links are chosen by
+// users.
+type LinkFetcher interface {
+	FetchMetadata(context.Context, string) (*domain.LinkMetadata, error)
+	FetchImage(context.Context, string) ([]byte, error)
+}
+
+// UnfurlConfig configures an Unfurler.
+type UnfurlConfig struct {
+	// PollInterval is how often pending previews are checked for when the
+	// unfurler is not notified of new links.
+	PollInterval time.Duration
+	// BatchSize is the number of previews fetched at once.
+	BatchSize int
+}
+
+// DefaultUnfurlConfig returns the default unfurl configuration.
+func DefaultUnfurlConfig() UnfurlConfig {
+	return UnfurlConfig{
+		PollInterval: time.Minute,
+		BatchSize:    10,
+	}
+}
+
+// The length limits of link previews, in characters. Longer text is cut off.
+const (
+	maxLinkTitleLength       = 200
+	maxLinkDescriptionLength = 300
+	maxLinkSiteNameLength    = 100
+)
+
+// maxLinkLength is the longest URL previewed.
+const maxLinkLength = 2048
+
+// linkPattern matches the http and https URLs in the content of messages.
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'` + "`" + `]+`)
+
+// Unfurler builds the previews of the links in messages in the background:
+// it records the links of messages as they are created or updated, then
+// fetches the metadata of the pages they link to. Previews that cannot be
+// fetched are marked failed and never shown.
+//
+// The title, description and site name of previews are sanitized like
+// messages, and their image is stored as a thumbnail, processed like the
+// images attached to messages, so nothing from the linked page reaches
+// clients as is.
+type Unfurler struct {
+	logger    *slog.Logger
+	repo      LinkPreviewRepo
+	fetcher   LinkFetcher
+	blobStore BlobStore
+	config    UnfurlConfig
+	poller    *poller
+}
+
+// NewUnfurler returns a new Unfurler instance. Previews are built without
+// images when blobStore is nil.
+func NewUnfurler(logger *slog.Logger, repo LinkPreviewRepo, fetcher LinkFetcher, blobStore BlobStore, config UnfurlConfig) *Unfurler {
+	return &Unfurler{
+		logger:    logger,
+		repo:      repo,
+		fetcher:   fetcher,
+		blobStore: blobStore,
+		config:    config,
+		poller:    newPoller(config.PollInterval),
+	}
+}
+
+// Notify tells the unfurler that links are waiting to be fetched, so they
+// are without waiting for the next poll. It never blocks.
+func (u *Unfurler) Notify() {
+	u.poller.notify()
+}
+
+// HandleEvent records the links of created and updated messages to be
+// fetched, and deletes the previews of deleted messages. It is called by the
+// OutboxDispatcher.
+func (u *Unfurler) HandleEvent(ctx context.Context, ev domain.MessageEvent) error {
+	switch ev.Type {
+	case domain.MessageCreated, domain.MessageUpdated:
+		if ev.Message == nil {
+			return nil
+		}
+		links := extractLinks(ev.Message.Message)
+		replaced, err := u.repo.Replace(ctx, ev.MessageID, links)
+		if err != nil {
+			return fmt.Errorf("failed to record links: %w", err)
+		}
+		u.deleteImages(ctx, replaced)
+		if len(links) > 0 {
+			u.Notify()
+		}
+	case domain.MessageDeleted:
+		orphans, err := u.repo.DeleteOrphans(ctx, ev.MessageID)
+		if err != nil {
+			return fmt.Errorf("failed to delete link previews: %w", err)
+		}
+		u.deleteImages(ctx, orphans)
+	}
+	return nil
+}
+
+// Run fetches pending previews until ctx is done.
+func (u *Unfurler) Run(ctx context.Context) {
+	u.poller.run(ctx, func(ctx context.Context) {
+		if err := drain(u.config.BatchSize, func() (int, error) { return u.UnfurlPending(ctx) }); err != nil {
+			u.logger.Error("failed to unfurl links", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// UnfurlPending fetches a batch of pending previews, and returns the number
+// of previews fetched, whether they succeeded or failed.
+func (u *Unfurler) UnfurlPending(ctx context.Context) (int, error) {
+	pending, err := u.repo.GetPending(ctx, u.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unfurl links: %w", err)
+	}
+
+	for i, p := range pending {
+		u.unfurl(ctx, p)
+		if ctx.Err() != nil {
+			// Fetching was interrupted, not failed: the preview is left
+			// pending.
+			u.deleteImages(context.WithoutCancel(ctx), []*domain.LinkPreview{p})
+			return i, ctx.Err()
+		}
+		if err := u.repo.Update(ctx, p); err != nil {
+			u.deleteImages(ctx, []*domain.LinkPreview{p})
+			if errors.Is(err, domain.ErrNotFound) {
+				// The message was updated or deleted meanwhile, along with
+				// the previews of its links.
+				continue
+			}
+			return i, fmt.Errorf("failed to unfurl links: %w", err)
+		}
+	}
+
+	return len(pending), nil
+}
+
+// unfurl fetches the metadata of the page p links to, and sets the preview
+// from it.
+func (u *Unfurler) unfurl(ctx context.Context, p *domain.LinkPreview) {
+	p.FetchedAt = time.Now()
+	p.Status = domain.LinkPreviewFailed
+
+	meta, err := u.fetcher.FetchMetadata(ctx, p.URL)
+	if err != nil {
+		u.logger.Info("failed to fetch link preview",
+			slog.Int64("preview_id", p.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	p.Title = previewText(meta.Title, maxLinkTitleLength)
+	if p.Title == "" {
+		// There is nothing to show.
+		return
+	}
+	p.Description = previewText(meta.Description, maxLinkDescriptionLength)
+	p.SiteName = previewText(meta.SiteName, maxLinkSiteNameLength)
+	if p.SiteName == "" {
+		if pu, err := url.Parse(meta.URL); err == nil {
+			p.SiteName = previewText(pu.Hostname(), maxLinkSiteNameLength)
+		}
+	}
+	if meta.ImageURL != "" && u.blobStore != nil {
+		p.ImageKey = u.storeImage(ctx, p, meta.ImageURL)
+	}
+	p.Status = domain.LinkPreviewReady
+}
+
+// storeImage fetches the image of a preview and stores its thumbnail, and
+// returns its key, or an empty key when the image cannot be used.
+func (u *Unfurler) storeImage(ctx context.Context, p *domain.LinkPreview, imageURL string) string {
+	data, err := u.fetcher.FetchImage(ctx, imageURL)
+	if err != nil {
+		u.logger.Info("failed to fetch link preview image",
+			slog.Int64("preview_id", p.ID),
+			slog.String("error", err.Error()))
+		return ""
+	}
+	img, err := processImage(bytes.NewReader(data))
+	if err != nil {
+		u.logger.Info("failed to process link preview image",
+			slog.Int64("preview_id", p.ID),
+			slog.String("error", err.Error()))
+		return ""
+	}
+
+	name, err := newBlobName()
+	if err != nil {
+		u.logger.Error("failed to store link preview image", slog.String("error", err.Error()))
+		return ""
+	}
+	// Only the thumbnail is kept, under a thumbnail key, so it is served
+	// like the thumbnails of attachments.
+	key := name + "-thumb" + imageTypes[img.thumbnailType]
+	if err := u.blobStore.Put(ctx, key, bytes.NewReader(img.thumbnail)); err != nil {
+		u.logger.Error("failed to store link preview image",
+			slog.String("key", key),
+			slog.String("error", err.Error()))
+		return ""
+	}
+
+	return key
+}
+
+// deleteImages deletes the images of previews, logging failures.
+func (u *Unfurler) deleteImages(ctx context.Context, previews []*domain.LinkPreview) {
+	for _, p := range previews {
+		if p.ImageKey == "" || u.blobStore == nil {
+			continue
+		}
+		if err := u.blobStore.Delete(ctx, p.ImageKey); err != nil {
+			u.logger.Error("failed to delete link preview image",
+				slog.String("key", p.ImageKey),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// extractLinks returns the distinct http and https URLs in text, in order of
+// appearance, up to domain.MaxLinkPreviews. Punctuation ending a sentence or
+// closing parentheses around a URL are not part of it.
+func extractLinks(text string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		link = trimLink(link)
+		if len(link) > maxLinkLength || seen[link] {
+			continue
+		}
+		if u, err := url.Parse(link); err != nil || u.Hostname() == "" {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+		if len(links) == domain.MaxLinkPreviews {
+			break
+		}
+	}
+	return links
+}
+
+// trimLink removes the trailing punctuation and unbalanced closing
+// parentheses matched with a link.
+func trimLink(link string) string {
+	for {
+		trimmed := strings.TrimRight(link, ".,;:!?*_~")
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, "(") < strings.Count(trimmed, ")") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == link {
+			return link
+		}
+		link = trimmed
+	}
+}
+
+// previewText sanitizes text from a linked page, collapses its whitespace
+// and cuts it to max characters.
+func previewText(s string, max int) string {
+	s = strings.Join(strings.Fields(sanitizeText(s)), " ")
+	if r := []rune(s); len(r) > max {
+		s = strings.TrimSpace(string(r[:max-1])) + "…"
+	}
+	return s
+}
+
+// loadLinkPreviews sets the ready link previews of msgs.
+func (s *MessageService) loadLinkPreviews(ctx context.Context, msgs ...*domain.Message) error {
+	if s.linkPreviewRepo == nil || len(msgs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+
+	previews, err := s.linkPreviewRepo.GetByMessages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		m.Links = previews[m.ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "none",
+			text: "Hey, Dutch! See you at example.com",
+		},
+		{
+			name: "sentence",
+			text: "Look at https://example.com/a?b=c. And http://example.org!",
+			want: []string{"https://example.com/a?b=c", "http://example.org"},
+		},
+		{
+			name: "parentheses",
+			text: "The wiki (https://en.wikipedia.org/wiki/Go_(programming_language)) and (https://example.com)",
+			want: []string{"https://en.wikipedia.org/wiki/Go_(programming_language)", "https://example.com"},
+		},
+		{
+			name: "markdown",
+			text: "[our photos](https://example.com/photos) and <https://example.com/map>",
+			want: []string{"https://example.com/photos", "https://example.com/map"},
+		},
+		{
+			name: "duplicates and limit",
+			text: "https://a.example https://a.example HTTPS://b.example https://c.example https://d.example",
+			want: []string{"https://a.example", "HTTPS://b.example", "https://c.example"},
+		},
+		{
+			name: "other schemes and too long",
+			text: "javascript:alert(1) ftp://example.com https://" + strings.Repeat("a", maxLinkLength) + " https://",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractLinks(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnfurler_HandleEvent(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	t.Run("created", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("Replace", mock.Anything, int64(7), []string{"https://example.com"}).Return([]*domain.LinkPreview{}, nil)
+		u := NewUnfurler(logger, repo, new(mocks.LinkFetcher), new(mocks.BlobStore), DefaultUnfurlConfig())
+
+		err := u.HandleEvent(ctx, domain.MessageEvent{Type: domain.MessageCreated, MessageID: 7,
+			Message: &domain.Message{ID: 7, Message: "Look: https://example.com"}})
+		if err != nil {
+			t.Fatalf("Unfurler.HandleEvent() error = %v", err)
+		}
+		if !u.poller.notified() {
+			t.Error("Unfurler.HandleEvent() did not notify the unfurler")
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("Replace", mock.Anything, int64(7), []string(nil)).Return([]*domain.LinkPreview{
+			{ID: 1, ImageKey: "0123456789abcdef0123456789abcdef-thumb.png"},
+			{ID: 2},
+		}, nil)
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Delete", mock.Anything, "0123456789abcdef0123456789abcdef-thumb.png").Return(nil)
+		u := NewUnfurler(logger, repo, new(mocks.LinkFetcher), blobStore, DefaultUnfurlConfig())
+
+		err := u.HandleEvent(ctx, domain.MessageEvent{Type: domain.MessageUpdated, MessageID: 7,
+			Message: &domain.Message{ID: 7, Message: "No more links"}})
+		if err != nil {
+			t.Fatalf("Unfurler.HandleEvent() error = %v", err)
+		}
+		if u.poller.notified() {
+			t.Error("Unfurler.HandleEvent() notified the unfurler without links")
+		}
+		repo.AssertExpectations(t)
+		blobStore.AssertExpectations(t)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("DeleteOrphans", mock.Anything, int64(7)).Return([]*domain.LinkPreview{
+			{ID: 1, ImageKey: "0123456789abcdef0123456789abcdef-thumb.jpg"},
+		}, nil)
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Delete", mock.Anything, "0123456789abcdef0123456789abcdef-thumb.jpg").Return(nil)
+		u := NewUnfurler(logger, repo, new(mocks.LinkFetcher), blobStore, DefaultUnfurlConfig())
+
+		if err := u.HandleEvent(ctx, domain.MessageEvent{Type: domain.MessageDeleted, MessageID: 7}); err != nil {
+			t.Fatalf("Unfurler.HandleEvent() error = %v", err)
+		}
+		repo.AssertExpectations(t)
+		blobStore.AssertExpectations(t)
+	})
+
+	t.Run("failed to record links", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("Replace", mock.Anything, int64(7), mock.Anything).Return(nil, errors.New("boom"))
+		u := NewUnfurler(logger, repo, new(mocks.LinkFetcher), new(mocks.BlobStore), DefaultUnfurlConfig())
+
+		err := u.HandleEvent(ctx, domain.MessageEvent{Type: domain.MessageCreated, MessageID: 7,
+			Message: &domain.Message{ID: 7, Message: "https://example.com"}})
+		if err == nil {
+			t.Error("Unfurler.HandleEvent() error = nil, want error")
+		}
+	})
+}
+
+func TestUnfurler_UnfurlPending(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("GetPending", mock.Anything, 10).Return([]*domain.LinkPreview{
+			{ID: 1, URL: "https://example.com/a"},
+			{ID: 2, URL: "https://example.com/b"},
+			{ID: 3, URL: "https://example.com/c"},
+		}, nil)
+		var updated []domain.LinkPreview
+		repo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			updated = append(updated, *args.Get(1).(*domain.LinkPreview))
+		}).Return(nil)
+
+		fetcher := new(mocks.LinkFetcher)
+		fetcher.On("FetchMetadata", mock.Anything, "https://example.com/a").Return(&domain.LinkMetadata{
+			URL:         "https://www.example.com/a",
+			Title:       "<b>Blackwater</b>\n  & beyond",
+			Description: strings.Repeat("long ", 100),
+			ImageURL:    "https://example.com/cover.png",
+		}, nil)
+		fetcher.On("FetchImage", mock.Anything, "https://example.com/cover.png").Return(encodePNG(t, testImage(640, 480)), nil)
+		fetcher.On("FetchMetadata", mock.Anything, "https://example.com/b").Return(nil, domain.ErrForbidden)
+		fetcher.On("FetchMetadata", mock.Anything, "https://example.com/c").Return(&domain.LinkMetadata{
+			URL:      "https://example.com/c",
+			SiteName: "No title",
+		}, nil)
+
+		var stored []string
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Put", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = append(stored, args.String(1))
+		}).Return(nil)
+
+		u := NewUnfurler(logger, repo, fetcher, blobStore, DefaultUnfurlConfig())
+		n, err := u.UnfurlPending(ctx)
+		if err != nil {
+			t.Fatalf("Unfurler.UnfurlPending() error = %v", err)
+		}
+		if n != 3 || len(updated) != 3 {
+			t.Fatalf("Unfurler.UnfurlPending() = %d, updated %d, want 3", n, len(updated))
+		}
+
+		ready := updated[0]
+		if ready.Status != domain.LinkPreviewReady || ready.Title != "Blackwater & beyond" || ready.SiteName != "www.example.com" {
+			t.Errorf("preview = %+v, want a ready preview of Blackwater & beyond on www.example.com", ready)
+		}
+		if n := len([]rune(ready.Description)); n != maxLinkDescriptionLength || !strings.HasSuffix(ready.Description, "…") {
+			t.Errorf("description of %d characters, want %d ending with an ellipsis", n, maxLinkDescriptionLength)
+		}
+		if len(stored) != 1 || stored[0] != ready.ImageKey || !strings.HasSuffix(ready.ImageKey, "-thumb.png") || !attachmentKeyPattern.MatchString(ready.ImageKey) {
+			t.Errorf("stored %v with image key %q, want a thumbnail key", stored, ready.ImageKey)
+		}
+		if ready.FetchedAt.IsZero() {
+			t.Error("preview without fetch time")
+		}
+		for _, failed := range updated[1:] {
+			if failed.Status != domain.LinkPreviewFailed || failed.ImageKey != "" {
+				t.Errorf("preview = %+v, want a failed preview", failed)
+			}
+		}
+	})
+
+	t.Run("image not usable", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("GetPending", mock.Anything, 10).Return([]*domain.LinkPreview{{ID: 1, URL: "https://example.com"}}, nil)
+		var updated *domain.LinkPreview
+		repo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			updated = args.Get(1).(*domain.LinkPreview)
+		}).Return(nil)
+		fetcher := new(mocks.LinkFetcher)
+		fetcher.On("FetchMetadata", mock.Anything, "https://example.com").Return(&domain.LinkMetadata{
+			URL:      "https://example.com",
+			Title:    "Example",
+			ImageURL: "https://example.com/cover.svg",
+		}, nil)
+		fetcher.On("FetchImage", mock.Anything, "https://example.com/cover.svg").Return([]byte("<svg></svg>"), nil)
+		blobStore := new(mocks.BlobStore)
+
+		u := NewUnfurler(logger, repo, fetcher, blobStore, DefaultUnfurlConfig())
+		if _, err := u.UnfurlPending(ctx); err != nil {
+			t.Fatalf("Unfurler.UnfurlPending() error = %v", err)
+		}
+		if updated.Status != domain.LinkPreviewReady || updated.ImageKey != "" {
+			t.Errorf("preview = %+v, want a ready preview without image", updated)
+		}
+		blobStore.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("replaced meanwhile", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("GetPending", mock.Anything, 10).Return([]*domain.LinkPreview{{ID: 1, URL: "https://example.com"}}, nil)
+		repo.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+		fetcher := new(mocks.LinkFetcher)
+		fetcher.On("FetchMetadata", mock.Anything, "https://example.com").Return(&domain.LinkMetadata{
+			URL:      "https://example.com",
+			Title:    "Example",
+			ImageURL: "https://example.com/cover.png",
+		}, nil)
+		fetcher.On("FetchImage", mock.Anything, "https://example.com/cover.png").Return(encodePNG(t, testImage(64, 32)), nil)
+		var stored string
+		blobStore := new(mocks.BlobStore)
+		blobStore.On("Put", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.String(1)
+		}).Return(nil)
+		blobStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+		u := NewUnfurler(logger, repo, fetcher, blobStore, DefaultUnfurlConfig())
+		if _, err := u.UnfurlPending(ctx); err != nil {
+			t.Fatalf("Unfurler.UnfurlPending() error = %v", err)
+		}
+		blobStore.AssertCalled(t, "Delete", mock.Anything, stored)
+	})
+
+	t.Run("failed to get pending", func(t *testing.T) {
+		repo := new(mocks.LinkPreviewRepo)
+		repo.On("GetPending", mock.Anything, 10).Return(nil, errors.New("boom"))
+
+		u := NewUnfurler(logger, repo, new(mocks.LinkFetcher), new(mocks.BlobStore), DefaultUnfurlConfig())
+		if _, err := u.UnfurlPending(ctx); err == nil {
+			t.Error("Unfurler.UnfurlPending() error = nil, want error")
+		}
+	})
+}
+
+func TestMessageService_Get_LinkPreviews(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	messageRepo := new(mocks.MessageRepo)
+	messageRepo.On("Get", mock.Anything, int64(7)).Return(&domain.Message{ID: 7, Message: "https://example.com"}, nil)
+	preview := &domain.LinkPreview{ID: 1, MessageID: 7, URL: "https://example.com", Title: "Example", Status: domain.LinkPreviewReady}
+	linkPreviewRepo := new(mocks.LinkPreviewRepo)
+	linkPreviewRepo.On("GetByMessages", mock.Anything, []int64{7}).Return(map[int64][]*domain.LinkPreview{7: {preview}}, nil)
+
+	s := NewMessageService(logger, messageRepo, WithLinkPreviews(linkPreviewRepo))
+	got, err := s.Get(ctx, 7)
+	if err != nil {
+		t.Fatalf("MessageService.Get() error = %v", err)
+	}
+	if len(got.Links) != 1 || got.Links[0] != preview {
+		t.Errorf("MessageService.Get() links = %v, want %v", got.Links, []*domain.LinkPreview{preview})
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+// UnitOfWork runs functions atomically: the repository calls made with the
+// context passed to fn are committed together if fn returns nil, and rolled
+// back together otherwise.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(context.Context) error) error
+}
+
+// noUnitOfWork runs functions without a transaction, for services
+// configured without a UnitOfWork.
+type noUnitOfWork struct{}
+
+func (noUnitOfWork) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// minWebhookSecretLength is the minimum length of the secret of a webhook,
+// so signatures cannot be forged by guessing it.
+const minWebhookSecretLength = 16
+
+// webhookDeliveryLogSize is the number of deliveries kept in the log of a
+// webhook returned to admins.
+const webhookDeliveryLogSize = 100
+
+// webhookEventTypes are the event types webhooks can subscribe to.
+var webhookEventTypes = []domain.MessageEventType{domain.MessageCreated, domain.MessageUpdated, domain.MessageDeleted}
+
+type WebhookRepo interface {
+	GetAll(context.Context) ([]*domain.Webhook, error)
+	Get(context.Context, int64) (*domain.Webhook, error)
+	Create(context.Context, *domain.Webhook) (int64, error)
+	Update(context.Context, *domain.Webhook) error
+	Delete(context.Context, int64) error
+	CreateDeliveries(context.Context, []*domain.WebhookDelivery) error
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]*domain.WebhookDelivery, error)
+	GetDelivery(context.Context, int64) (*domain.WebhookDelivery, error)
+	UpdateDelivery(context.Context, *domain.WebhookDelivery) error
+}
+
+// HostValidator checks that a host may be connected to.
+type HostValidator interface {
+	ValidateHost(ctx context.Context, host string) error
+}
+
+// WebhookService manages the webhooks message events are delivered to, and
+// queues the deliveries of the events dispatched from the outbox.
+type WebhookService struct {
+	logger      *slog.Logger
+	webhookRepo WebhookRepo
+	// deliverer is notified of queued deliveries.
+	deliverer     EventNotifier
+	hostValidator HostValidator
+}
+
+// WebhookServiceOption configures optional dependencies of WebhookService.
+type WebhookServiceOption func(*WebhookService)
+
+// WithHostValidator makes WebhookService reject webhooks whose host
+// hostValidator rejects, such as hosts on the internal network.
+func WithHostValidator(hostValidator HostValidator) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.hostValidator = hostValidator
+	}
+}
+
+// NewWebhookService returns a new WebhookService instance.
+func NewWebhookService(logger *slog.Logger, webhookRepo WebhookRepo, deliverer EventNotifier, opts ...WebhookServiceOption) *WebhookService {
+	s := &WebhookService{
+		logger:      logger,
+		webhookRepo: webhookRepo,
+		deliverer:   deliverer,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetAll returns all webhooks.
+func (s *WebhookService) GetAll(ctx context.Context) ([]*domain.Webhook, error) {
+	ws, err := s.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all webhooks: %w", err)
+	}
+
+	return ws, nil
+}
+
+// Create validates and creates a webhook.
+func (s *WebhookService) Create(ctx context.Context, w *domain.Webhook) (int64, error) {
+	if err := s.validate(ctx, w); err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := s.webhookRepo.Create(ctx, w)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return id, nil
+}
+
+// Update validates and updates a webhook. An empty secret keeps the current
+// one.
+func (s *WebhookService) Update(ctx context.Context, w *domain.Webhook) error {
+	if w.Secret == "" {
+		current, err := s.webhookRepo.Get(ctx, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update webhook: %w", err)
+		}
+		updated := *w
+		updated.Secret = current.Secret
+		w = &updated
+	}
+	if err := s.validate(ctx, w); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	if err := s.webhookRepo.Update(ctx, w); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a webhook and its deliveries.
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the last deliveries to a webhook, newest first.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID int64) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.Get(ctx, webhookID); err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	ds, err := s.webhookRepo.GetDeliveries(ctx, webhookID, webhookDeliveryLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return ds, nil
+}
+
+// Redeliver queues a delivery to a webhook again, with a fresh set of
+// attempts, typically after it was dead-lettered.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID int64) error {
+	d, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+	if d.WebhookID != webhookID {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", domain.ErrNotFound)
+	}
+	if d.Status == domain.WebhookDeliveryPending {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w: delivery is pending", domain.ErrInvalidArgument)
+	}
+
+	d.Status = domain.WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+	if err := s.webhookRepo.UpdateDelivery(ctx, d); err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+	s.deliverer.Notify()
+
+	return nil
+}
+
+// HandleEvent queues the deliveries of an event to the webhooks subscribed
+// to it. It is called by the OutboxDispatcher.
+func (s *WebhookService) HandleEvent(ctx context.Context, ev domain.MessageEvent) error {
+	ws, err := s.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	var ds []*domain.WebhookDelivery
+	var payload []byte
+	now := time.Now()
+	for _, w := range ws {
+		if !w.Subscribes(ev.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(newWebhookPayload(ev)); err != nil {
+				return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+			}
+		}
+		ds = append(ds, &domain.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       ev.ID,
+			EventType:     ev.Type,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+		})
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, ds); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	s.deliverer.Notify()
+
+	return nil
+}
+
+// validate validates a webhook and, if a host validator is configured, the
+// host of its URL. The host is only checked when the webhook is saved: the
+// deliverer checks the addresses it connects to on every delivery.
+func (s *WebhookService) validate(ctx context.Context, w *domain.Webhook) error {
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+	if s.hostValidator == nil {
+		return nil
+	}
+	u, _ := url.Parse(w.URL)
+	if err := s.hostValidator.ValidateHost(ctx, u.Hostname()); err != nil {
+		return fmt.Errorf("%w: url host is not allowed: %s", domain.ErrInvalidArgument, err)
+	}
+	return nil
+}
+
+// validateWebhook checks the URL, secret and event types of a webhook.
+func validateWebhook(w *domain.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid url: %s", domain.ErrInvalidArgument, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: url must be an absolute http or https url without credentials", domain.ErrInvalidArgument)
+	}
+	if len(w.Secret) < minWebhookSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d characters", domain.ErrInvalidArgument, minWebhookSecretLength)
+	}
+	if len(w.EventTypes) == 0 {
+		return fmt.Errorf("%w: no event types", domain.ErrInvalidArgument)
+	}
+	for _, t := range w.EventTypes {
+		if !slices.Contains(webhookEventTypes, t) {
+			return fmt.Errorf("%w: unknown event type %q", domain.ErrInvalidArgument, t)
+		}
+	}
+
+	return nil
+}
+
+// webhookPayload is the JSON body delivered to webhooks. Text summarizes
+// the event, so the payload can be posted to Slack incoming webhooks as is.
+type webhookPayload struct {
+	ID          int64                   `json:"id"`
+	Type        domain.MessageEventType `json:"type"`
+	GuestbookID int64                   `json:"guestbook_id"`
+	MessageID   int64                   `json:"message_id"`
+	Message     *webhookMessage         `json:"message,omitempty"`
+	Time        time.Time               `json:"time"`
+	Text        string                  `json:"text"`
+}
+
+type webhookMessage struct {
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWebhookPayload(ev domain.MessageEvent) *webhookPayload {
+	p := &webhookPayload{
+		ID:          ev.ID,
+		Type:        ev.Type,
+		GuestbookID: ev.GuestbookID,
+		MessageID:   ev.MessageID,
+		Time:        ev.Time,
+	}
+	if m := ev.Message; m != nil {
+		p.Message = &webhookMessage{
+			ID:        m.ID,
+			ParentID:  m.ParentID,
+			Author:    m.Author,
+			Content:   m.Message,
+			CreatedAt: m.CreatedAt,
+		}
+	}
+
+	switch {
+	case ev.Type == domain.MessageCreated && p.Message != nil && p.Message.ParentID != nil:
+		p.Text = fmt.Sprintf("%s replied: %s", p.Message.Author, p.Message.Content)
+	case ev.Type == domain.MessageCreated && p.Message != nil:
+		p.Text = fmt.Sprintf("%s signed the guestbook: %s", p.Message.Author, p.Message.Content)
+	case ev.Type == domain.MessageUpdated && p.Message != nil:
+		p.Text = fmt.Sprintf("%s edited their message: %s", p.Message.Author, p.Message.Content)
+	default:
+		p.Text = fmt.Sprintf("Message %d was deleted", ev.MessageID)
+	}
+
+	return p
+}
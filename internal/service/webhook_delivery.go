@@ -0,0 +1,207 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"guestbook-example/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxWebhookErrorLength bounds the error of the last attempt recorded on a
+// delivery, which may quote the response of the webhook.
+const maxWebhookErrorLength = 1024
+
+// WebhookConfig configures a WebhookDeliverer.
+type WebhookConfig struct {
+	// PollInterval is how often deliveries are checked for when the
+	// deliverer is not notified of new ones.
+	PollInterval time.Duration
+	// BatchSize is the number of deliveries attempted at once.
+	BatchSize int
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts after which a delivery is
+	// dead-lettered.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the delay before a failed delivery is
+	// attempted again, which doubles with every attempt.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultWebhookConfig returns the default webhook configuration, which
+// retries a delivery for about two hours.
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		PollInterval: 5 * time.Second,
+		BatchSize:    50,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  8,
+		MinBackoff:   30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// WebhookDeliverer posts the queued deliveries to their webhooks, retrying
+// the failed ones with exponential backoff until they run out of attempts.
+type WebhookDeliverer struct {
+	logger      *slog.Logger
+	webhookRepo WebhookRepo
+	client      *http.Client
+	config      WebhookConfig
+	poller      *poller
+}
+
+// NewWebhookDeliverer returns a new WebhookDeliverer instance.
+func NewWebhookDeliverer(logger *slog.Logger, webhookRepo WebhookRepo, client *http.Client, config WebhookConfig) *WebhookDeliverer {
+	return &WebhookDeliverer{
+		logger:      logger,
+		webhookRepo: webhookRepo,
+		client:      client,
+		config:      config,
+		poller:      newPoller(config.PollInterval),
+	}
+}
+
+// Notify tells the deliverer that deliveries have been queued, so it
+// attempts them without waiting for the next poll. It never blocks.
+func (d *WebhookDeliverer) Notify() {
+	d.poller.notify()
+}
+
+// Run delivers until ctx is done.
+func (d *WebhookDeliverer) Run(ctx context.Context) {
+	d.poller.run(ctx, func(ctx context.Context) {
+		if err := drain(d.config.BatchSize, func() (int, error) { return d.Deliver(ctx) }); err != nil {
+			d.logger.Error("failed to deliver webhooks", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// Deliver attempts a batch of due deliveries, and returns how many were
+// attempted.
+func (d *WebhookDeliverer) Deliver(ctx context.Context) (int, error) {
+	ds, err := d.webhookRepo.GetDueDeliveries(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deliver webhooks: %w", err)
+	}
+
+	webhooks := make(map[int64]*domain.Webhook)
+	for _, delivery := range ds {
+		w, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			w, err = d.webhookRepo.Get(ctx, delivery.WebhookID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return 0, fmt.Errorf("failed to deliver webhooks: %w", err)
+			}
+			webhooks[delivery.WebhookID] = w
+		}
+
+		d.attempt(ctx, w, delivery)
+		if err := d.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return 0, fmt.Errorf("failed to deliver webhooks: %w", err)
+		}
+	}
+
+	return len(ds), nil
+}
+
+// attempt posts a delivery to its webhook, and records the outcome on the
+// delivery. Deliveries to deleted or inactive webhooks are dead-lettered.
+func (d *WebhookDeliverer) attempt(ctx context.Context, w *domain.Webhook, delivery *domain.WebhookDelivery) {
+	delivery.Attempts++
+	if w == nil || !w.Active {
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.LastStatusCode = 0
+		delivery.LastError = "webhook is deleted or inactive"
+		return
+	}
+
+	code, err := d.post(ctx, w, delivery)
+	delivery.LastStatusCode = code
+	if err == nil {
+		now := time.Now()
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), maxWebhookErrorLength)
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		d.logger.Warn("webhook delivery dead-lettered",
+			slog.Int64("id", delivery.ID),
+			slog.Int64("webhook_id", w.ID),
+			slog.Int("attempts", delivery.Attempts),
+			slog.String("error", err.Error()))
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts, d.config.MinBackoff, d.config.MaxBackoff))
+}
+
+// post sends a delivery to a webhook, and returns the status code of the
+// response, if any. Any status but 2xx is an error.
+func (d *WebhookDeliverer) post(ctx context.Context, w *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "guestbook-webhooks/1.0")
+	req.Header.Set("X-Guestbook-Event", string(delivery.EventType))
+	req.Header.Set("X-Guestbook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Guestbook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Guestbook-Signature", SignWebhookPayload(w.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		if snippet = bytes.TrimSpace(snippet); len(snippet) == 0 {
+			return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, snippet)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Guestbook-Signature header of a payload
+// sent at timestamp: the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the secret of the webhook, prefixed with "sha256=". Receivers recompute it
+// to authenticate deliveries, and check the timestamp to reject replays.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// truncate cuts s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/service/mocks"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// webhookReceiver is a local webhook which checks the signature of the
+// deliveries it receives, and replies with the next status code.
+type webhookReceiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []*http.Request
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	timestamp, err := strconv.ParseInt(req.Header.Get("X-Guestbook-Timestamp"), 10, 64)
+	if err != nil {
+		r.t.Errorf("invalid X-Guestbook-Timestamp: %v", err)
+	}
+	if got, want := req.Header.Get("X-Guestbook-Signature"), SignWebhookPayload(r.secret, timestamp, body); got != want {
+		r.t.Errorf("X-Guestbook-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		r.t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if string(body) != `{"id":5}` {
+		r.t.Errorf("body = %s, want the payload", body)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, req)
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("busy"))
+}
+
+func TestWebhookDeliverer_Deliver(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	const secret = "0123456789abcdef"
+	config := DefaultWebhookConfig()
+
+	tests := []struct {
+		name      string
+		status    int
+		attempts  int
+		inactive  bool
+		deleted   bool
+		check     func(t *testing.T, d *domain.WebhookDelivery)
+		wantCalls int
+	}{
+		{
+			name:      "delivered",
+			status:    http.StatusNoContent,
+			wantCalls: 1,
+			check: func(t *testing.T, d *domain.WebhookDelivery) {
+				if d.Status != domain.WebhookDeliveryDelivered || d.Attempts != 1 || d.LastStatusCode != http.StatusNoContent || d.DeliveredAt == nil || d.LastError != "" {
+					t.Errorf("delivery = %+v, want it delivered", d)
+				}
+			},
+		},
+		{
+			name:      "retried with backoff",
+			status:    http.StatusServiceUnavailable,
+			attempts:  2,
+			wantCalls: 1,
+			check: func(t *testing.T, d *domain.WebhookDelivery) {
+				if d.Status != domain.WebhookDeliveryPending || d.Attempts != 3 || d.LastStatusCode != http.StatusServiceUnavailable {
+					t.Errorf("delivery = %+v, want it pending", d)
+				}
+				if d.LastError != "unexpected status 503 Service Unavailable: busy" {
+					t.Errorf("delivery.LastError = %q", d.LastError)
+				}
+				// The third attempt is followed by 4 * MinBackoff.
+				if delay := time.Until(d.NextAttemptAt); delay < 4*config.MinBackoff-time.Second || delay > 4*config.MinBackoff {
+					t.Errorf("delivery.NextAttemptAt is in %v, want %v", delay, 4*config.MinBackoff)
+				}
+			},
+		},
+		{
+			name:      "dead-lettered after the last attempt",
+			status:    http.StatusInternalServerError,
+			attempts:  config.MaxAttempts - 1,
+			wantCalls: 1,
+			check: func(t *testing.T, d *domain.WebhookDelivery) {
+				if d.Status != domain.WebhookDeliveryDead || d.Attempts != config.MaxAttempts || d.LastStatusCode != http.StatusInternalServerError {
+					t.Errorf("delivery = %+v, want it dead", d)
+				}
+			},
+		},
+		{
+			name:     "inactive webhook",
+			inactive: true,
+			check: func(t *testing.T, d *domain.WebhookDelivery) {
+				if d.Status != domain.WebhookDeliveryDead {
+					t.Errorf("delivery = %+v, want it dead", d)
+				}
+			},
+		},
+		{
+			name:    "deleted webhook",
+			deleted: true,
+			check: func(t *testing.T, d *domain.WebhookDelivery) {
+				if d.Status != domain.WebhookDeliveryDead {
+					t.Errorf("delivery = %+v, want it dead", d)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{t: t, secret: secret, statuses: []int{tt.status}}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			delivery := &domain.WebhookDelivery{
+				ID:        3,
+				WebhookID: 1,
+				EventID:   5,
+				EventType: domain.MessageCreated,
+				Payload:   `{"id":5}`,
+				Status:    domain.WebhookDeliveryPending,
+				Attempts:  tt.attempts,
+			}
+			repo := new(mocks.WebhookRepo)
+			repo.On("GetDueDeliveries", mock.Anything, mock.Anything, config.BatchSize).Return([]*domain.WebhookDelivery{delivery}, nil)
+			if tt.deleted {
+				repo.On("Get", mock.Anything, int64(1)).Return(nil, errors.Join(domain.ErrNotFound, errors.New("record not found")))
+			} else {
+				repo.On("Get", mock.Anything, int64(1)).Return(&domain.Webhook{ID: 1, URL: server.URL, Secret: secret, Active: !tt.inactive}, nil)
+			}
+			repo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+			d := NewWebhookDeliverer(logger, repo, server.Client(), config)
+			n, err := d.Deliver(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("WebhookDeliverer.Deliver() = %v, %v, want 1, nil", n, err)
+			}
+			tt.check(t, delivery)
+			if len(receiver.received) != tt.wantCalls {
+				t.Fatalf("webhook received %d requests, want %d", len(receiver.received), tt.wantCalls)
+			}
+			if tt.wantCalls > 0 {
+				req := receiver.received[0]
+				if req.Header.Get("X-Guestbook-Event") != "message.created" || req.Header.Get("X-Guestbook-Delivery") != "3" {
+					t.Errorf("request headers = %v", req.Header)
+				}
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookDeliverer_Deliver_unreachable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	delivery := &domain.WebhookDelivery{ID: 3, WebhookID: 1, Payload: `{}`, Status: domain.WebhookDeliveryPending}
+	repo := new(mocks.WebhookRepo)
+	repo.On("GetDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	repo.On("Get", mock.Anything, int64(1)).Return(&domain.Webhook{ID: 1, URL: url, Secret: "0123456789abcdef", Active: true}, nil)
+	repo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	d := NewWebhookDeliverer(logger, repo, http.DefaultClient, DefaultWebhookConfig())
+	if _, err := d.Deliver(context.Background()); err != nil {
+		t.Fatalf("WebhookDeliverer.Deliver() error = %v", err)
+	}
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.LastStatusCode != 0 || delivery.LastError == "" {
+		t.Errorf("delivery = %+v, want it pending with the connection error", delivery)
+	}
+}
+
+// TestWebhookDeliverer_Run delivers again a delivery which failed once,
+// against a local webhook.
+func TestWebhookDeliverer_Run(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	const secret = "0123456789abcdef"
+	receiver := &webhookReceiver{t: t, secret: secret, statuses: []int{http.StatusBadGateway, http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	var mu sync.Mutex
+	delivery := &domain.WebhookDelivery{ID: 3, WebhookID: 1, EventID: 5, EventType: domain.MessageCreated, Payload: `{"id":5}`, Status: domain.WebhookDeliveryPending}
+	delivered := make(chan struct{})
+	repo := new(mocks.WebhookRepo)
+	repo.On("GetDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, now time.Time, _ int) []*domain.WebhookDelivery {
+		mu.Lock()
+		defer mu.Unlock()
+		if delivery.Status != domain.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			return nil
+		}
+		d := *delivery
+		return []*domain.WebhookDelivery{&d}
+	}, nil)
+	repo.On("Get", mock.Anything, int64(1)).Return(&domain.Webhook{ID: 1, URL: server.URL, Secret: secret, Active: true}, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		*delivery = *args.Get(1).(*domain.WebhookDelivery)
+		if delivery.Status == domain.WebhookDeliveryDelivered {
+			close(delivered)
+		}
+	}).Return(nil)
+
+	config := DefaultWebhookConfig()
+	config.PollInterval = 10 * time.Millisecond
+	config.MinBackoff = 20 * time.Millisecond
+	d := NewWebhookDeliverer(logger, repo, server.Client(), config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery was not delivered")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivery.Attempts != 2 || len(receiver.received) != 2 {
+		t.Errorf("delivery took %d attempts and %d requests, want 2", delivery.Attempts, len(receiver.received))
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// echo -n '1700000000.{"id":5}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	got := SignWebhookPayload("0123456789abcdef", 1700000000, []byte(`{"id":5}`))
+	want := "sha256=03c151093e80eae764ab180b59180f985c2e703a36f7e67ec62a2f66a88f685e"
+	if got != want {
+		t.Errorf("SignWebhookPayload() = %v, want %v", got, want)
+	}
+}
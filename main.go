@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"embed"
 	"fmt"
 	"guestbook-example/internal/api"
 	"guestbook-example/internal/api/handler"
+	"guestbook-example/internal/api/middleware"
+	"guestbook-example/internal/config"
+	"guestbook-example/internal/domain"
+	"guestbook-example/internal/infra/blob"
+	"guestbook-example/internal/infra/identicon"
+	"guestbook-example/internal/infra/mail"
+	"guestbook-example/internal/infra/markdown"
+	"guestbook-example/internal/infra/netguard"
+	"guestbook-example/internal/infra/pubsub"
 	"guestbook-example/internal/infra/repository"
+	"guestbook-example/internal/infra/unfurl"
 	"guestbook-example/internal/service"
 	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -22,7 +35,7 @@ var embeddedFiles embed.FS
 
 // GORM with glebarez/sqlite
 func initDB() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open("sqlite.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(repository.SQLiteDSN("sqlite.db")), &gorm.Config{})
 	if err != nil {
 		panic("failed to connect database")
 	}
@@ -34,17 +47,39 @@ func initDB() (*gorm.DB, error) {
 }
 
 func migrateDB(db *gorm.DB) error {
-	err := db.AutoMigrate(&repository.Message{})
+	err := db.AutoMigrate(&repository.Guestbook{}, &repository.Message{}, &repository.MessageRevision{}, &repository.Reaction{}, &repository.CORSOrigin{}, &repository.OutboxEvent{}, &repository.Webhook{}, &repository.WebhookDelivery{}, &repository.GuestbookOwner{}, &repository.AuditEntry{}, &repository.MessageAttachment{}, &repository.LinkPreview{})
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := repository.MigrateMessageSearch(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if err := repository.MigrateAuditLog(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
 	return nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:], os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
+	cfg, err := config.Load()
+	if err != nil {
+		// TODO: handle error
+		panic(err)
+	}
+
 	db, err := initDB()
 	if err != nil {
 		// TODO: handle error
@@ -63,12 +98,161 @@ func main() {
 		panic(err)
 	}
 
+	guestbookRepo := repository.NewGuestbookRepo(logger, db)
+	_, err = guestbookRepo.EnsureDefault(context.Background(), cfg.Title)
+	if err != nil {
+		// TODO: handle error
+		panic(err)
+	}
+
+	guestbookService := service.NewGuestbookService(logger, guestbookRepo)
+	guestbookHandler := handler.NewGuestbookHandler(logger, guestbookService)
 	messageRepo := repository.NewMessageRepo(logger, db)
-	messageService := service.NewMessageService(logger, messageRepo)
+	reactionRepo := repository.NewReactionRepo(logger, db)
+	broker := pubsub.NewBroker(logger, pubsub.DefaultHistorySize)
+	outboxRepo := repository.NewOutboxRepo(logger, db)
+	dispatcher := service.NewOutboxDispatcher(logger, outboxRepo, service.DefaultOutboxConfig())
+	dispatcher.Subscribe("stream", service.EventHandlerFunc(func(ctx context.Context, ev domain.MessageEvent) error {
+		broker.Publish(ctx, ev)
+		return nil
+	}))
+	webhookRepo := repository.NewWebhookRepo(logger, db)
+	webhookConfig := service.DefaultWebhookConfig()
+	webhookDeliverer := service.NewWebhookDeliverer(logger, webhookRepo, netguard.NewClient(webhookConfig.Timeout), webhookConfig)
+	webhookService := service.NewWebhookService(logger, webhookRepo, webhookDeliverer, service.WithHostValidator(netguard.NewHostValidator()))
+	webhookHandler := handler.NewWebhookHandler(logger, webhookService)
+	dispatcher.Subscribe("webhooks", webhookService)
+	guestbookOwnerRepo := repository.NewGuestbookOwnerRepo(logger, db)
+	guestbookOwnerService := service.NewGuestbookOwnerService(logger, guestbookOwnerRepo)
+	guestbookOwnerHandler := handler.NewGuestbookOwnerHandler(logger, guestbookOwnerService)
+	if cfg.SMTPAddr != "" {
+		sender := mail.NewSMTPSender(logger, mail.SMTPConfig{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  30 * time.Second,
+		})
+		notification := service.DefaultNotificationConfig()
+		notification.DigestHour = cfg.DigestHour
+		scheduler := service.NewNotificationScheduler(logger, guestbookOwnerRepo, guestbookRepo, messageRepo, sender, notification)
+		dispatcher.Subscribe("notifications", scheduler)
+		go scheduler.Run(context.Background())
+	}
+	go webhookDeliverer.Run(context.Background())
+	unitOfWork := repository.NewUnitOfWork(logger, db)
+	auditRepo := repository.NewAuditRepo(logger, db)
+	markdownRenderer := markdown.NewRenderer(markdown.DefaultCacheSize)
+	blobStore, err := blob.NewFileSystem(logger, cfg.AttachmentDir)
+	if err != nil {
+		// TODO: handle error
+		panic(err)
+	}
+	attachmentRepo := repository.NewAttachmentRepo(logger, db)
+	avatarKey := []byte(cfg.AvatarKey)
+	if len(avatarKey) == 0 {
+		avatarKey = make([]byte, 32)
+		if _, err := rand.Read(avatarKey); err != nil {
+			// TODO: handle error
+			panic(err)
+		}
+		logger.Warn("GUESTBOOK_AVATAR_KEY is not set, avatars will change on restart")
+	}
+	messageOptions := []service.MessageServiceOption{
+		service.WithReactionRepo(reactionRepo),
+		service.WithEventNotifier(dispatcher),
+		service.WithUnitOfWork(unitOfWork),
+		service.WithAuditRepo(auditRepo),
+		service.WithMarkdownRenderer(markdownRenderer),
+		service.WithAvatarKey(avatarKey),
+		service.WithAttachments(attachmentRepo, blobStore),
+	}
+	if cfg.LinkPreviews {
+		linkPreviewRepo := repository.NewLinkPreviewRepo(logger, db)
+		fetcher := unfurl.NewFetcher(logger, unfurl.DefaultConfig())
+		unfurler := service.NewUnfurler(logger, linkPreviewRepo, fetcher, blobStore, service.DefaultUnfurlConfig())
+		dispatcher.Subscribe("unfurl", unfurler)
+		go unfurler.Run(context.Background())
+		messageOptions = append(messageOptions, service.WithLinkPreviews(linkPreviewRepo))
+	}
+	messageService := service.NewMessageService(logger, messageRepo, messageOptions...)
+	dispatcher.Subscribe("attachments", service.EventHandlerFunc(messageService.DeleteOrphanedAttachments))
+	go dispatcher.Run(context.Background())
 	messageHandler := handler.NewMessageHandler(logger, messageService)
+	exportHandler := handler.NewExportHandler(logger, messageService)
+	importHandler := handler.NewImportHandler(logger, messageService)
+	batchHandler := handler.NewBatchHandler(logger, messageService)
+	previewHandler := handler.NewPreviewHandler(logger, messageService)
+	revisionHandler := handler.NewRevisionHandler(logger, messageService)
+	attachmentService := service.NewAttachmentService(logger, blobStore)
+	attachmentHandler := handler.NewAttachmentHandler(logger, attachmentService)
+	avatarService := service.NewAvatarService(logger, identicon.NewGenerator())
+	avatarHandler := handler.NewAvatarHandler(logger, avatarService)
+	auditService := service.NewAuditService(logger, auditRepo)
+	auditHandler := handler.NewAuditHandler(logger, auditService)
+	streamService := service.NewStreamService(logger, broker, service.WithStreamRenderer(markdownRenderer), service.WithStreamAvatarKey(avatarKey))
+	streamHandler := handler.NewStreamHandler(logger, streamService, handler.DefaultHeartbeatInterval)
+	webSocket := handler.DefaultWebSocketConfig()
+	webSocket.AllowedOrigins = cfg.CSRFTrustedOrigins
+	corsOriginRepo := repository.NewCORSOriginRepo(logger, db)
+	corsOriginService := service.NewCORSOriginService(logger, corsOriginRepo)
+	webSocketHandler := handler.NewWebSocketHandler(logger, guestbookService, messageService, streamService, webSocket, corsOriginService)
+	reactionService := service.NewReactionService(logger, reactionRepo, messageRepo)
+	reactionHandler := handler.NewReactionHandler(logger, reactionService)
+	corsOriginHandler := handler.NewCORSOriginHandler(logger, corsOriginService)
+	embedService := service.NewEmbedService(logger, guestbookRepo)
+	embedHandler := handler.NewEmbedHandler(logger, embedService)
 	staticFileHandler := handler.NewStaticFileHandler(logger, http.FS(staticFiles))
+	cspReportHandler := handler.NewCSPReportHandler(logger)
+	csrfHandler := handler.NewCSRFHandler(logger)
+
+	securityHeaders := middleware.DefaultSecurityHeadersConfig()
+	securityHeaders.CSPReportOnly = cfg.CSPReportOnly
+	securityHeaders.HSTSMaxAge = cfg.HSTSMaxAge
+
+	csrf := middleware.DefaultCSRFConfig()
+	csrf.SecureCookie = cfg.SecureCookies
+	csrf.TrustedOrigins = cfg.CSRFTrustedOrigins
+
+	reactor := middleware.DefaultReactorConfig()
+	reactor.SecureCookie = cfg.SecureCookies
+
+	cors := middleware.DefaultCORSConfig()
+	cors.AllowedOrigins = cfg.CORSAllowedOrigins
+	cors.AllowedMethods = cfg.CORSAllowedMethods
+	cors.AllowedHeaders = cfg.CORSAllowedHeaders
+	cors.AllowCredentials = cfg.CORSAllowCredentials
+	cors.MaxAge = cfg.CORSMaxAge
 
-	router := api.SetupRouter(messageHandler, staticFileHandler)
+	router := api.SetupRouter(messageHandler, staticFileHandler,
+		api.WithMiddlewares(
+			middleware.RequestID(),
+			middleware.SecurityHeaders(securityHeaders),
+			middleware.CORS(logger, cors, corsOriginService),
+			middleware.CSRF(logger, csrf, corsOriginService),
+			middleware.Reactor(reactor),
+		),
+		api.WithTrustedProxies(cfg.TrustedProxies...),
+		api.WithAdminMiddlewares(middleware.AdminAuth(cfg.AdminToken)),
+		api.WithCSPReportHandler(cspReportHandler),
+		api.WithCSRFHandler(csrfHandler),
+		api.WithCORSOriginHandler(corsOriginHandler),
+		api.WithEmbedHandler(embedHandler),
+		api.WithGuestbookHandler(guestbookHandler),
+		api.WithReactionHandler(reactionHandler),
+		api.WithStreamHandler(streamHandler),
+		api.WithExportHandler(exportHandler),
+		api.WithImportHandler(importHandler),
+		api.WithBatchHandler(batchHandler),
+		api.WithPreviewHandler(previewHandler),
+		api.WithAttachmentHandler(attachmentHandler),
+		api.WithAvatarHandler(avatarHandler),
+		api.WithRevisionHandler(revisionHandler),
+		api.WithWebSocketHandler(webSocketHandler),
+		api.WithWebhookHandler(webhookHandler),
+		api.WithGuestbookOwnerHandler(guestbookOwnerHandler),
+		api.WithAuditHandler(auditHandler),
+	)
 
 	router.Run(":8080")
 
@@ -6,23 +6,102 @@
 // API Service - Handles all communication with the backend
 const APIService = {
     baseUrl: '/api/v1/messages',
+    threadDepth: 3,
+    csrfTokenUrl: '/api/v1/csrf-token',
+    csrfToken: null,
 
+    async fetchCSRFToken() {
+        const response = await fetch(this.csrfTokenUrl, { credentials: 'same-origin' });
+        if (!response.ok) throw new Error('Failed to fetch CSRF token');
+        const data = await response.json();
+        this.csrfToken = data.csrf_token;
+        return this.csrfToken;
+    },
+
+    // Sends a state-changing request with the CSRF token, refreshing the
+    // token once if the server rejects it.
+    async send(url, options, retried = false) {
+        const token = this.csrfToken || await this.fetchCSRFToken();
+        const response = await fetch(url, {
+            ...options,
+            credentials: 'same-origin',
+            headers: { ...options.headers, 'X-CSRF-Token': token }
+        });
+        if (response.status === 403 && !retried) {
+            this.csrfToken = null;
+            return this.send(url, options, true);
+        }
+        return response;
+    },
+
+    // Fetches the top-level messages with their replies nested below them.
     async fetchMessages() {
-        const response = await fetch(this.baseUrl);
+        const response = await fetch(`${this.baseUrl}?view=tree&depth=${this.threadDepth}`);
         if (!response.ok) throw new Error('Failed to fetch messages');
         return response.json();
     },
 
-    async addMessage(author, content) {
-        return fetch(this.baseUrl, {
+    // Fetches the messages matching a full-text query, best matches first.
+    // Queries without any words are rejected by the server and match nothing.
+    async searchMessages(query) {
+        const response = await fetch(`${this.baseUrl}?q=${encodeURIComponent(query)}`);
+        if (response.status === 400) return { messages: [] };
+        if (!response.ok) throw new Error('Failed to search messages');
+        return response.json();
+    },
+
+    async fetchReplies(id) {
+        const response = await fetch(`${this.baseUrl}/${encodeURIComponent(id)}/replies`);
+        if (!response.ok) throw new Error('Failed to fetch replies');
+        return response.json();
+    },
+
+    // Posts a message, as a multipart form when an image is attached to it.
+    // The email is optional and only used for the author's avatar.
+    async addMessage({ author, content, email = '', parentId = null, image = null }) {
+        if (image) {
+            const form = new FormData();
+            form.append('author', author);
+            form.append('content', content);
+            if (email) form.append('email', email);
+            if (parentId) form.append('parent_id', parentId);
+            form.append('image', image);
+            return this.send(this.baseUrl, { method: 'POST', body: form });
+        }
+
+        const body = { author, content };
+        if (email) body.email = email;
+        if (parentId) body.parent_id = parentId;
+        return this.send(this.baseUrl, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+    },
+
+    // Adds or removes the visitor's reaction and returns the updated counts.
+    async setReaction(id, emoji, on) {
+        const url = `${this.baseUrl}/${encodeURIComponent(id)}/reactions/${encodeURIComponent(emoji)}`;
+        const response = await this.send(url, { method: on ? 'POST' : 'DELETE' });
+        if (!response.ok) throw new Error('Failed to update reaction');
+        return response.json();
+    },
+
+    // Returns the HTML the server renders the Markdown content to, which is
+    // empty when the server does not render Markdown.
+    async previewMessage(content) {
+        const response = await this.send(`${this.baseUrl}/preview`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ author, content })
+            body: JSON.stringify({ content })
         });
+        if (response.status === 400) return { content_html: '' };
+        if (!response.ok) throw new Error('Failed to preview message');
+        return response.json();
     },
 
     async deleteMessage(id) {
-        return fetch(`${this.baseUrl}/${id}`, { method: 'DELETE' });
+        return this.send(`${this.baseUrl}/${id}`, { method: 'DELETE' });
     }
 };
 
@@ -50,12 +129,20 @@ const FormValidator = {
     }
 };
 
+// Emoji visitors can react with, in the order the server accepts them
+const REACTIONS = ['\u{1F44D}', '\u2764\uFE0F', '\u{1F602}', '\u{1F389}', '\u{1F62E}', '\u{1F622}'];
+
 // UI Manager - Handles all UI-related operations
 const UIManager = {
     elements: {
         form: document.getElementById('messageForm'),
         nameInput: document.getElementById('name'),
         messageInput: document.getElementById('message'),
+        preview: document.getElementById('preview'),
+        emailInput: document.getElementById('email'),
+        imageInput: document.getElementById('image'),
+        searchForm: document.getElementById('searchForm'),
+        searchInput: document.getElementById('search'),
         messagesContainer: document.getElementById('messages')
     },
 
@@ -74,37 +161,301 @@ const UIManager = {
         this.toggleError(this.elements.messageInput, null);
     },
 
-    displayMessages(messages) {
+    // Messages are plain text (see content_type in the API response), so they
+    // are only ever inserted through textContent. The one exception is
+    // content_html, the Markdown content rendered and sanitized by the server,
+    // which is meant to be inserted as HTML.
+    createMessageElement(msg) {
+        const div = document.createElement('div');
+        div.className = 'message';
+        div.dataset.id = msg.id;
+
+        const deleteBtn = document.createElement('span');
+        deleteBtn.className = 'delete-btn';
+        deleteBtn.dataset.id = msg.id;
+        deleteBtn.title = 'Delete message and replies';
+        deleteBtn.textContent = '\u00d7';
+
+        const avatar = document.createElement('img');
+        avatar.className = 'avatar';
+        avatar.alt = '';
+        avatar.width = 32;
+        avatar.height = 32;
+        avatar.loading = 'lazy';
+        if (msg.avatar_url) avatar.src = `${msg.avatar_url}?s=64`;
+
+        const author = document.createElement('strong');
+        author.textContent = msg.author || 'Anonymous';
+        if (msg.edited) {
+            const edited = document.createElement('small');
+            edited.className = 'edited';
+            edited.title = msg.edit_count === 1 ? 'Edited once' : `Edited ${msg.edit_count} times`;
+            edited.textContent = ' (edited)';
+            author.appendChild(edited);
+        }
+
+        const content = document.createElement('div');
+        content.className = 'content';
+        if (msg.snippet) {
+            content.append(this.createSnippet(msg.snippet));
+        } else {
+            this.setContent(content, msg);
+        }
+
+        const replyBtn = document.createElement('button');
+        replyBtn.type = 'button';
+        replyBtn.className = 'reply-btn';
+        replyBtn.dataset.id = msg.id;
+        replyBtn.textContent = 'Reply';
+
+        const replies = document.createElement('div');
+        replies.className = 'replies';
+        this.appendReplies(replies, msg);
+
+        div.append(deleteBtn, avatar, author, content);
+        if (msg.attachment) div.append(this.createAttachment(msg.attachment));
+        if (msg.links) div.append(this.createLinks(msg.links));
+        div.append(this.createReactionBar(msg.id, msg.reactions), replyBtn, replies);
+        return div;
+    },
+
+    // Renders the content of a message, as HTML if the server rendered it.
+    setContent(element, msg) {
+        if (msg.content_html) {
+            element.innerHTML = msg.content_html;
+        } else {
+            element.textContent = msg.content || 'No content provided';
+        }
+    },
+
+    // Renders the thumbnail of an attached image, linking to the full image.
+    createAttachment(attachment) {
+        const link = document.createElement('a');
+        link.className = 'attachment';
+        link.href = attachment.url;
+        link.target = '_blank';
+        link.rel = 'noopener';
+
+        const img = document.createElement('img');
+        img.src = attachment.thumbnail_url;
+        img.alt = 'Attached image';
+        img.loading = 'lazy';
+        link.appendChild(img);
+        return link;
+    },
+
+    // Renders a card per link preview. The title, description and site name
+    // come from the linked pages, so they are inserted as text only.
+    createLinks(links) {
+        const container = document.createElement('div');
+        container.className = 'links';
+        links.forEach(link => {
+            const card = document.createElement('a');
+            card.className = 'link-preview';
+            card.href = link.url;
+            card.target = '_blank';
+            card.rel = 'nofollow ugc noopener noreferrer';
+
+            if (link.image_url) {
+                const img = document.createElement('img');
+                img.src = link.image_url;
+                img.alt = '';
+                img.loading = 'lazy';
+                card.appendChild(img);
+            }
+
+            const text = document.createElement('div');
+            const title = document.createElement('strong');
+            title.textContent = link.title;
+            text.appendChild(title);
+            if (link.description) {
+                const description = document.createElement('p');
+                description.textContent = link.description;
+                text.appendChild(description);
+            }
+            if (link.site_name) {
+                const site = document.createElement('small');
+                site.textContent = link.site_name;
+                text.appendChild(site);
+            }
+            card.appendChild(text);
+            container.appendChild(card);
+        });
+        return container;
+    },
+
+    // Renders a search snippet, wrapping the matching segments in <mark>. The
+    // segments are plain text like the rest of the message.
+    createSnippet(segments) {
+        const fragment = document.createDocumentFragment();
+        segments.forEach(segment => {
+            if (segment.match) {
+                const mark = document.createElement('mark');
+                mark.textContent = segment.text;
+                fragment.appendChild(mark);
+            } else {
+                fragment.appendChild(document.createTextNode(segment.text));
+            }
+        });
+        return fragment;
+    },
+
+    // Renders a toggle button per reaction emoji with its count.
+    createReactionBar(id, reactions) {
+        const counts = new Map((reactions || []).map(r => [r.emoji, r]));
+        const bar = document.createElement('div');
+        bar.className = 'reactions';
+
+        REACTIONS.forEach(emoji => {
+            const reaction = counts.get(emoji);
+            const btn = document.createElement('button');
+            btn.type = 'button';
+            btn.className = reaction?.reacted ? 'reaction-btn reacted' : 'reaction-btn';
+            btn.dataset.id = id;
+            btn.dataset.emoji = emoji;
+            btn.setAttribute('aria-pressed', String(Boolean(reaction?.reacted)));
+            btn.textContent = reaction ? `${emoji} ${reaction.count}` : emoji;
+            bar.appendChild(btn);
+        });
+        return bar;
+    },
+
+    displayReactions(id, reactions) {
+        const bar = this.findMessage(id)?.querySelector(':scope > .reactions');
+        if (bar) bar.replaceWith(this.createReactionBar(id, reactions));
+    },
+
+    // Appends the replies of msg, followed by a button loading the replies
+    // the server left out below the thread depth.
+    appendReplies(container, msg) {
+        const replies = Array.isArray(msg.replies) ? msg.replies : [];
+        replies.forEach(reply => container.appendChild(this.createMessageElement(reply)));
+
+        const hidden = (msg.reply_count || 0) - replies.length;
+        if (hidden > 0) {
+            const moreBtn = document.createElement('button');
+            moreBtn.type = 'button';
+            moreBtn.className = 'more-replies-btn';
+            moreBtn.dataset.id = msg.id;
+            moreBtn.textContent = hidden === 1 ? 'Show 1 reply' : `Show ${hidden} replies`;
+            container.appendChild(moreBtn);
+        }
+    },
+
+    // Replaces the replies of a message with the ones loaded from the server.
+    displayReplies(id, replies) {
+        const container = this.findMessage(id)?.querySelector(':scope > .replies');
+        if (!container) return;
+        container.replaceChildren();
+        replies.forEach(reply => container.appendChild(this.createMessageElement(reply)));
+    },
+
+    findMessage(id) {
+        return this.elements.messagesContainer.querySelector(`.message[data-id="${CSS.escape(String(id))}"]`);
+    },
+
+    // Shows a reply form below a message, or hides it if already shown.
+    toggleReplyForm(id) {
+        const message = this.findMessage(id);
+        if (!message) return;
+
+        const existing = message.querySelector(':scope > .reply-form');
+        if (existing) {
+            existing.remove();
+            return;
+        }
+
+        const form = document.createElement('form');
+        form.className = 'reply-form';
+        form.dataset.parentId = id;
+        form.noValidate = true;
+
+        const name = document.createElement('input');
+        name.type = 'text';
+        name.name = 'author';
+        name.placeholder = 'Your name';
+        name.value = this.elements.nameInput.value.trim();
+
+        const content = document.createElement('textarea');
+        content.name = 'content';
+        content.rows = 2;
+        content.placeholder = 'Write your reply here';
+
+        const submit = document.createElement('button');
+        submit.type = 'submit';
+        submit.textContent = 'Post Reply';
+
+        form.append(name, content, submit);
+        message.querySelector(':scope > .replies').before(form);
+        (name.value ? content : name).focus();
+    },
+
+    displayMessages(messages, searching = false) {
         const container = this.elements.messagesContainer;
-        container.innerHTML = '';
+        container.replaceChildren();
 
         if (!messages || messages.length === 0) {
-            container.innerHTML = '<p>No messages to display.</p>';
+            const empty = document.createElement('p');
+            empty.textContent = searching ? 'No messages match your search.' : 'No messages to display.';
+            container.appendChild(empty);
             return;
         }
 
         const fragment = document.createDocumentFragment();
         messages.forEach(msg => {
-            const div = document.createElement('div');
-            div.className = 'message';
-            div.innerHTML = `
-                <span class="delete-btn" data-id="${msg.id}">&times;</span>
-                <strong>${msg.author || 'Anonymous'}</strong>
-                <p>${msg.content || 'No content provided'}</p>
-            `;
-            fragment.appendChild(div);
+            fragment.appendChild(this.createMessageElement(msg));
         });
         container.appendChild(fragment);
     },
 
+    // Adds a message received from the stream below its parent, or as a new
+    // thread, unless it is already shown.
+    addMessage(msg) {
+        if (this.findMessage(msg.id)) return;
+
+        if (msg.parent_id) {
+            const container = this.findMessage(msg.parent_id)?.querySelector(':scope > .replies');
+            if (container) container.appendChild(this.createMessageElement(msg));
+            return;
+        }
+
+        const container = this.elements.messagesContainer;
+        if (!container.querySelector(':scope > .message')) container.replaceChildren();
+        container.appendChild(this.createMessageElement(msg));
+    },
+
+    updateMessage(msg) {
+        const message = this.findMessage(msg.id);
+        if (!message) return;
+        message.querySelector(':scope > strong').textContent = msg.author || 'Anonymous';
+        if (msg.avatar_url) message.querySelector(':scope > .avatar').src = `${msg.avatar_url}?s=64`;
+        this.setContent(message.querySelector(':scope > .content'), msg);
+        // The previews of the new links are fetched again by the server.
+        message.querySelector(':scope > .links')?.remove();
+    },
+
+    removeMessage(id) {
+        this.findMessage(id)?.remove();
+    },
+
     clearMessageInput() {
         this.elements.messageInput.value = '';
+        this.elements.imageInput.value = '';
+        this.showPreview('');
+    },
+
+    // Shows the rendering of the message being written, hidden while empty.
+    showPreview(html) {
+        this.elements.preview.innerHTML = html;
+        this.elements.preview.hidden = html === '';
     },
 
     showError(message) {
         console.error(message);
-        this.elements.messagesContainer.innerHTML =
-            `<p class="error-message">Error: ${message}. Please try again later.</p>`;
+        const error = document.createElement('p');
+        error.className = 'error-message';
+        error.textContent = `Error: ${message}. Please try again later.`;
+        this.elements.messagesContainer.replaceChildren(error);
     }
 };
 
@@ -116,6 +467,9 @@ const EventHandler = {
         this.handleMessageKeyPress = this.handleMessageKeyPress.bind(this);
         this.handleInput = this.handleInput.bind(this);
         this.handleMessageClick = this.handleMessageClick.bind(this);
+        this.handleReplySubmit = this.handleReplySubmit.bind(this);
+        this.handleSearchInput = this.handleSearchInput.bind(this);
+        this.handlePreviewInput = this.handlePreviewInput.bind(this);
 
         // Form submission
         UIManager.elements.form.addEventListener('submit', this.handleSubmit);
@@ -125,14 +479,55 @@ const EventHandler = {
         UIManager.elements.messageInput.addEventListener('input', this.handleInput);
         UIManager.elements.nameInput.addEventListener('input', this.handleInput);
 
+        // Preview the Markdown as you type, once typing pauses
+        UIManager.elements.messageInput.addEventListener('input', this.handlePreviewInput);
+
         // Focus events
         UIManager.elements.messageInput.addEventListener('focus',
             () => UIManager.toggleError(UIManager.elements.messageInput, null));
         UIManager.elements.nameInput.addEventListener('focus',
             () => UIManager.toggleError(UIManager.elements.nameInput, null));
 
-        // Message container for delete buttons
+        // Message container for delete, reply and show replies buttons
         UIManager.elements.messagesContainer.addEventListener('click', this.handleMessageClick);
+        UIManager.elements.messagesContainer.addEventListener('submit', this.handleReplySubmit);
+
+        // Search as you type, once typing pauses
+        UIManager.elements.searchInput.addEventListener('input', this.handleSearchInput);
+        UIManager.elements.searchForm.addEventListener('submit', e => {
+            e.preventDefault();
+            clearTimeout(this.searchTimer);
+            GuestbookController.loadMessages();
+        });
+    },
+
+    searchTimer: null,
+
+    handleSearchInput() {
+        clearTimeout(this.searchTimer);
+        this.searchTimer = setTimeout(() => GuestbookController.loadMessages(), 300);
+    },
+
+    previewTimer: null,
+
+    handlePreviewInput() {
+        clearTimeout(this.previewTimer);
+        this.previewTimer = setTimeout(async () => {
+            const content = UIManager.elements.messageInput.value.trim();
+            if (content === '') {
+                UIManager.showPreview('');
+                return;
+            }
+            try {
+                const preview = await APIService.previewMessage(content);
+                // Typing may have gone on while the preview was requested.
+                if (UIManager.elements.messageInput.value.trim() === content) {
+                    UIManager.showPreview(preview.content_html);
+                }
+            } catch (error) {
+                console.error(error);
+            }
+        }, 300);
     },
 
     async handleSubmit(e) {
@@ -148,10 +543,15 @@ const EventHandler = {
         }
 
         try {
-            const response = await APIService.addMessage(author, content);
+            const image = UIManager.elements.imageInput.files[0] || null;
+            const email = UIManager.elements.emailInput.value.trim();
+            const response = await APIService.addMessage({ author, content, email, image });
             if (response.ok) {
                 UIManager.clearMessageInput();
                 GuestbookController.loadMessages();
+            } else if ([400, 413, 415].includes(response.status)) {
+                const data = await response.json();
+                UIManager.showError(data.error);
             } else {
                 UIManager.showError('Failed to add message');
             }
@@ -174,8 +574,35 @@ const EventHandler = {
     },
 
     async handleMessageClick(e) {
-        if (e.target.classList.contains('delete-btn')) {
-            const messageId = e.target.dataset.id;
+        const target = e.target;
+        if (target.classList.contains('reaction-btn')) {
+            const { id, emoji } = target.dataset;
+            try {
+                const data = await APIService.setReaction(id, emoji, target.getAttribute('aria-pressed') !== 'true');
+                UIManager.displayReactions(id, data.reactions);
+            } catch (error) {
+                UIManager.showError(error.message);
+            }
+            return;
+        }
+
+        if (target.classList.contains('reply-btn')) {
+            UIManager.toggleReplyForm(target.dataset.id);
+            return;
+        }
+
+        if (target.classList.contains('more-replies-btn')) {
+            try {
+                const data = await APIService.fetchReplies(target.dataset.id);
+                UIManager.displayReplies(target.dataset.id, data.messages || []);
+            } catch (error) {
+                UIManager.showError(error.message);
+            }
+            return;
+        }
+
+        if (target.classList.contains('delete-btn')) {
+            const messageId = target.dataset.id;
             try {
                 const response = await APIService.deleteMessage(messageId);
                 if (response.ok) {
@@ -187,28 +614,97 @@ const EventHandler = {
                 UIManager.showError(error.message);
             }
         }
+    },
+
+    async handleReplySubmit(e) {
+        const form = e.target.closest('.reply-form');
+        if (!form) return;
+        e.preventDefault();
+
+        const author = form.elements.author.value.trim();
+        const content = form.elements.content.value.trim();
+
+        const validation = FormValidator.validateForm(author, content);
+        if (!validation.valid) {
+            if (validation.nameError) UIManager.toggleError(form.elements.author, validation.nameError);
+            if (validation.messageError) UIManager.toggleError(form.elements.content, validation.messageError);
+            return;
+        }
+
+        try {
+            const email = UIManager.elements.emailInput.value.trim();
+            const response = await APIService.addMessage({ author, content, email, parentId: Number(form.dataset.parentId) });
+            if (response.ok) {
+                GuestbookController.loadMessages();
+            } else {
+                UIManager.showError('Failed to add reply');
+            }
+        } catch (error) {
+            UIManager.showError(error.message);
+        }
+    }
+};
+
+// Live updates pushed by the server. The browser reconnects on its own and
+// resumes from the last event it received.
+const MessageStream = {
+    source: null,
+
+    connect() {
+        if (!window.EventSource) return;
+
+        this.source = new EventSource(`${APIService.baseUrl}/stream`);
+        this.on('message.created', data => UIManager.addMessage(data.message));
+        this.on('message.updated', data => UIManager.updateMessage(data.message));
+        this.on('message.deleted', data => UIManager.removeMessage(data.message_id));
+        // Sent when events were missed while disconnected.
+        this.source.addEventListener('reset', () => GuestbookController.loadMessages());
+    },
+
+    // Applies an event to the messages shown, unless they are search results,
+    // which are refreshed by the next search instead.
+    on(type, apply) {
+        this.source.addEventListener(type, e => {
+            if (UIManager.elements.searchInput.value.trim() !== '') return;
+            try {
+                apply(JSON.parse(e.data));
+            } catch (error) {
+                UIManager.showError(error.message);
+            }
+        });
     }
 };
 
 // Main Application Controller
 const GuestbookController = {
+    // Incremented on every load, so a slow response does not overwrite the
+    // result of a later search.
+    loadSeq: 0,
+
+    // Loads the messages matching the search box, or all threads if it is empty.
     async loadMessages() {
+        const seq = ++this.loadSeq;
+        const query = UIManager.elements.searchInput.value.trim();
         try {
-            const data = await APIService.fetchMessages();
+            const data = query
+                ? await APIService.searchMessages(query)
+                : await APIService.fetchMessages();
+            if (seq !== this.loadSeq) return;
 
             if (!data.messages || !Array.isArray(data.messages)) {
                 throw new Error('Unexpected response format');
             }
 
-            UIManager.displayMessages(data.messages);
+            UIManager.displayMessages(data.messages, query !== '');
         } catch (error) {
-            UIManager.showError(error.message);
+            if (seq === this.loadSeq) UIManager.showError(error.message);
         }
     },
 
     init() {
         EventHandler.init();
         this.loadMessages();
+        MessageStream.connect();
     }
 };
 
@@ -0,0 +1,241 @@
+/**
+ * Guestbook Widget
+ * Embeds a guestbook into any website with a single script tag:
+ *
+ *   <script src="https://guestbook.example/widget.js"
+ *           data-guestbook="default"
+ *           data-theme="dark"
+ *           data-accent-color="#e91e63"
+ *           async></script>
+ *
+ * Supported data attributes:
+ *   data-guestbook     guestbook slug, defaults to "default"
+ *   data-target        CSS selector of the element to render into, defaults to
+ *                      a new element inserted after the script tag
+ *   data-theme         "light" (default) or "dark"
+ *   data-accent-color  color of buttons and links
+ *   data-font-family   font of the widget
+ *   data-radius        corner radius, e.g. "8px"
+ *   data-max-messages  number of messages shown, defaults to 20
+ *
+ * The widget renders into a shadow root, so the host page's styles do not
+ * leak in and the widget's styles do not leak out.
+ */
+(function () {
+    'use strict';
+
+    const script = document.currentScript;
+    if (!script) return;
+
+    const serverOrigin = new URL(script.src, document.baseURI).origin;
+
+    const THEMES = {
+        light: { bg: '#ffffff', fg: '#333333', muted: '#888888', border: '#cccccc', card: '#f9f9f9' },
+        dark: { bg: '#1e1e1e', fg: '#eeeeee', muted: '#aaaaaa', border: '#444444', card: '#2a2a2a' }
+    };
+
+    const STYLES = `
+        :host {
+            all: initial;
+            display: block;
+            font-family: var(--gb-font-family);
+            color: var(--gb-fg);
+        }
+        .widget {
+            background: var(--gb-bg);
+            border: 1px solid var(--gb-border);
+            border-radius: var(--gb-radius);
+            padding: 16px;
+            line-height: 1.5;
+        }
+        h2 { margin: 0 0 12px; font-size: 1.2em; }
+        form { display: flex; flex-direction: column; gap: 8px; margin-bottom: 16px; }
+        input, textarea {
+            font: inherit;
+            color: var(--gb-fg);
+            background: var(--gb-card);
+            border: 1px solid var(--gb-border);
+            border-radius: var(--gb-radius);
+            padding: 8px;
+        }
+        button {
+            align-self: flex-start;
+            font: inherit;
+            color: #fff;
+            background: var(--gb-accent);
+            border: none;
+            border-radius: var(--gb-radius);
+            padding: 8px 16px;
+            cursor: pointer;
+        }
+        button:disabled { opacity: 0.6; cursor: default; }
+        .message {
+            background: var(--gb-card);
+            border: 1px solid var(--gb-border);
+            border-radius: var(--gb-radius);
+            padding: 8px 12px;
+            margin-bottom: 8px;
+        }
+        .message p { margin: 4px 0 0; white-space: pre-wrap; overflow-wrap: anywhere; }
+        .status { color: var(--gb-muted); font-size: 0.9em; }
+        .error { color: #d32f2f; }
+    `;
+
+    // Reads the theme from the data attributes. Values end up in CSS custom
+    // properties set through setProperty, so they cannot inject rules.
+    function readTheme(dataset) {
+        const base = THEMES[dataset.theme] || THEMES.light;
+        return {
+            '--gb-bg': base.bg,
+            '--gb-fg': base.fg,
+            '--gb-muted': base.muted,
+            '--gb-border': base.border,
+            '--gb-card': base.card,
+            '--gb-accent': dataset.accentColor || '#007bff',
+            '--gb-font-family': dataset.fontFamily || 'Arial, sans-serif',
+            '--gb-radius': dataset.radius || '5px'
+        };
+    }
+
+    function el(tag, props, children) {
+        const node = document.createElement(tag);
+        Object.assign(node, props);
+        (children || []).forEach(child => node.append(child));
+        return node;
+    }
+
+    function adoptStyles(root) {
+        if ('adoptedStyleSheets' in root && typeof CSSStyleSheet === 'function') {
+            const sheet = new CSSStyleSheet();
+            sheet.replaceSync(STYLES);
+            root.adoptedStyleSheets = [sheet];
+        } else {
+            root.append(el('style', { textContent: STYLES }));
+        }
+    }
+
+    // API client scoped to the guestbook. Requests never carry cookies, so
+    // the host page cannot act on behalf of users of the guestbook site.
+    function createClient(guestbook) {
+        const request = async (url, options) => {
+            const response = await fetch(new URL(url, serverOrigin), {
+                ...options,
+                credentials: 'omit',
+                mode: 'cors'
+            });
+            if (!response.ok) throw new Error(`Request failed with status ${response.status}`);
+            return response.json();
+        };
+
+        return {
+            config: null,
+
+            async loadConfig() {
+                this.config = await request(`/api/v1/embed/${encodeURIComponent(guestbook)}/config`);
+                return this.config;
+            },
+
+            fetchMessages() {
+                return request(this.config.messages_url);
+            },
+
+            addMessage(author, content) {
+                return request(this.config.messages_url, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ author, content })
+                });
+            }
+        };
+    }
+
+    function mount() {
+        const dataset = script.dataset;
+        const maxMessages = parseInt(dataset.maxMessages, 10) || 20;
+
+        let host = dataset.target ? document.querySelector(dataset.target) : null;
+        if (!host) {
+            host = document.createElement('div');
+            script.insertAdjacentElement('afterend', host);
+        }
+        host.classList.add('guestbook-widget');
+
+        const theme = readTheme(dataset);
+        Object.keys(theme).forEach(name => host.style.setProperty(name, theme[name]));
+
+        const root = host.attachShadow({ mode: 'open' });
+        adoptStyles(root);
+
+        const title = el('h2', { textContent: 'Guestbook' });
+        const status = el('p', { className: 'status', textContent: 'Loading…' });
+        const list = el('div', { className: 'messages' });
+        const nameInput = el('input', { type: 'text', placeholder: 'Your name', required: true, maxLength: 100 });
+        const messageInput = el('textarea', { placeholder: 'Your message', rows: 3, maxLength: 10000, required: true });
+        const submit = el('button', { type: 'submit', textContent: 'Sign the guestbook' });
+        const form = el('form', { hidden: true }, [nameInput, messageInput, submit]);
+        root.append(el('div', { className: 'widget', part: 'widget' }, [title, form, status, list]));
+
+        const client = createClient(dataset.guestbook || 'default');
+
+        const showStatus = (text, isError) => {
+            status.textContent = text;
+            status.className = isError ? 'status error' : 'status';
+            status.hidden = !text;
+        };
+
+        // Messages are plain text and only ever inserted through textContent.
+        const render = messages => {
+            list.replaceChildren(...messages.slice(-maxMessages).reverse().map(msg =>
+                el('div', { className: 'message' }, [
+                    el('strong', { textContent: msg.author || 'Anonymous' }),
+                    el('p', { textContent: msg.content || '' })
+                ])
+            ));
+            showStatus(messages.length === 0 ? 'No messages yet.' : '', false);
+        };
+
+        const load = async () => {
+            try {
+                const data = await client.fetchMessages();
+                render(Array.isArray(data.messages) ? data.messages : []);
+            } catch (error) {
+                showStatus('Could not load messages.', true);
+            }
+        };
+
+        form.addEventListener('submit', async e => {
+            e.preventDefault();
+            const author = nameInput.value.trim();
+            const content = messageInput.value.trim();
+            if (!author || !content) {
+                showStatus('Please enter your name and a message.', true);
+                return;
+            }
+
+            submit.disabled = true;
+            try {
+                await client.addMessage(author, content);
+                messageInput.value = '';
+                await load();
+            } catch (error) {
+                showStatus('Could not add your message.', true);
+            } finally {
+                submit.disabled = false;
+            }
+        });
+
+        client.loadConfig()
+            .then(config => {
+                title.textContent = config.title || 'Guestbook';
+                form.hidden = !config.allow_posting;
+                return load();
+            })
+            .catch(() => showStatus('Could not load the guestbook.', true));
+    }
+
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', mount);
+    } else {
+        mount();
+    }
+})();